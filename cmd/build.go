@@ -1,8 +1,13 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/sebrandon1/go-quay/lib"
 	"github.com/spf13/cobra"
@@ -14,10 +19,14 @@ var (
 	buildUUID          string
 	buildLimit         int
 	buildArchiveURL    string
+	buildContextDir    string
 	buildDockerfile    string
 	buildSubdirectory  string
 	buildTags          []string
 	confirmBuildCancel bool
+	buildLogsFollow    bool
+	buildWait          bool
+	buildWaitTimeout   time.Duration
 )
 
 // buildCmd represents the build command group
@@ -83,13 +92,25 @@ var buildInfoCmd = &cobra.Command{
 var buildLogsCmd = &cobra.Command{
 	Use:   "logs",
 	Short: "Get build logs",
-	Long:  `Get the logs for a specific build.`,
+	Long: `Get the logs for a specific build.
+
+With --follow, log entries are printed as they are written until the build
+completes, fails or is cancelled. Phases and Dockerfile commands are
+highlighted, and the command exits non-zero unless the build completes.
+
+Examples:
+  go-quay get build logs -n myorg -r myrepo -u BUILD_UUID
+  go-quay get build logs -n myorg -r myrepo -u BUILD_UUID --follow`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		client, err := getClient()
 		if err != nil {
 			return fmt.Errorf("creating client: %w", err)
 		}
 
+		if buildLogsFollow {
+			return followBuildLogs(cmd, client)
+		}
+
 		logs, err := client.GetBuildLogs(cmd.Context(), buildNamespace, buildRepository, buildUUID)
 		if err != nil {
 			return fmt.Errorf("getting build logs: %w", err)
@@ -100,13 +121,70 @@ var buildLogsCmd = &cobra.Command{
 	},
 }
 
+// followBuildLogs renders a build's logs as they are written and fails
+// unless the build completes.
+func followBuildLogs(cmd *cobra.Command, client *lib.Client) error {
+	phase := ""
+	opts := &lib.FollowBuildLogsOptions{
+		OnStatus: func(status *lib.BuildStatus) { phase = status.Phase },
+	}
+	for entry, err := range client.FollowBuildLogs(cmd.Context(), buildNamespace, buildRepository, buildUUID, opts) {
+		if err != nil {
+			return fmt.Errorf("following build logs: %w", err)
+		}
+		printBuildLogEntry(os.Stdout, entry)
+	}
+
+	fmt.Fprintf(os.Stderr, "Build %s finished in phase %q\n", buildUUID, phase)
+	if phase != lib.BuildPhaseComplete {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("build %s did not complete: %s", buildUUID, phase)
+	}
+	return nil
+}
+
+// printBuildLogEntry writes one build log entry, marking phase changes,
+// Dockerfile commands and errors so they stand out from build output.
+func printBuildLogEntry(out io.Writer, entry lib.BuildLogEntry) {
+	message := strings.TrimRight(entry.Message, "\r\n")
+	switch entry.Type {
+	case lib.BuildLogTypePhase:
+		fmt.Fprintf(out, "==> %s\n", strings.ToUpper(message))
+	case lib.BuildLogTypeCommand:
+		fmt.Fprintf(out, "--> %s\n", message)
+	case lib.BuildLogTypeError:
+		fmt.Fprintf(out, "ERROR: %s\n", message)
+	default:
+		fmt.Fprintf(out, "    %s\n", message)
+	}
+}
+
 // Build Request
 var buildRequestCmd = &cobra.Command{
 	Use:   "request",
 	Short: "Request a new build",
-	Long: `Request a new build from an archive URL.
-
-The archive should be a tar.gz file containing a Dockerfile and any necessary build context.`,
+	Long: `Request a new build from an archive URL or a local directory.
+
+The archive should be a tar.gz file containing a Dockerfile and any necessary build context.
+With --context, the directory is archived, leaving out paths matched by its
+.dockerignore, and uploaded to Quay before the build is requested.
+
+With --wait, the command polls the build until it is complete, failed,
+cancelled or expired, reporting phase changes on stderr, then prints the final
+build and the manifest digest of each built tag. If --wait-timeout expires or
+the command is interrupted, the build is cancelled. Exit codes:
+
+  0    build complete
+  1    request or API error
+  2    build failed (error, internalerror)
+  3    build cancelled or expired
+  4    --wait-timeout expired
+  130  interrupted
+
+Examples:
+  go-quay create build -n myorg -r myrepo -a https://example.com/ctx.tar.gz --tag v1.2.0 --wait
+  go-quay create build -n myorg -r myrepo -a https://example.com/ctx.tar.gz --wait --wait-timeout 20m
+  go-quay create build -n myorg -r myrepo --context . --tag dev --wait`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		client, err := getClient()
 		if err != nil {
@@ -120,16 +198,67 @@ The archive should be a tar.gz file containing a Dockerfile and any necessary bu
 			Tags:           buildTags,
 		}
 
-		build, err := client.RequestBuild(cmd.Context(), buildNamespace, buildRepository, buildReq)
+		var build *lib.Build
+		if buildContextDir != "" {
+			fmt.Fprintf(os.Stderr, "Uploading build context %s\n", buildContextDir)
+			build, err = client.RequestBuildFromDirectory(cmd.Context(), buildNamespace, buildRepository, buildContextDir, buildReq)
+		} else {
+			build, err = client.RequestBuild(cmd.Context(), buildNamespace, buildRepository, buildReq)
+		}
 		if err != nil {
 			return fmt.Errorf("requesting build: %w", err)
 		}
 
 		fmt.Fprintf(os.Stderr, "Build requested successfully!\n")
+		if buildWait {
+			return waitForBuild(cmd, client, build)
+		}
 		return printJSON(build)
 	},
 }
 
+// waitForBuild waits for a requested build, reporting phase changes, and maps
+// how it ended to an exit code.
+func waitForBuild(cmd *cobra.Command, client *lib.Client, build *lib.Build) error {
+	started := time.Now()
+	phase := build.Phase
+	fmt.Fprintf(os.Stderr, "Waiting for build %s (phase %s)\n", build.ID, phase)
+	result, err := client.WaitForBuild(cmd.Context(), buildNamespace, buildRepository, build.ID, &lib.WaitForBuildOptions{
+		Timeout:       buildWaitTimeout,
+		CancelOnAbort: true,
+		OnPoll: func(b *lib.Build) {
+			if b.Phase != phase {
+				phase = b.Phase
+				fmt.Fprintf(os.Stderr, "[%s] %s\n", time.Since(started).Round(time.Second), phase)
+			}
+		},
+	})
+	cmd.SilenceUsage = true
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return withExitCode(exitCodeTimeout, fmt.Errorf("waiting for build: timed out after %s: %w", buildWaitTimeout, err))
+	case errors.Is(err, context.Canceled):
+		return withExitCode(exitCodeInterrupted, fmt.Errorf("waiting for build: interrupted: %w", err))
+	case result == nil:
+		return fmt.Errorf("waiting for build: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "[%s] %s\n", time.Since(started).Round(time.Second), result.Build.Phase)
+	if printErr := printJSON(result); printErr != nil {
+		return printErr
+	}
+	if err != nil {
+		return fmt.Errorf("waiting for build: %w", err)
+	}
+	switch result.Build.Phase {
+	case lib.BuildPhaseComplete:
+		return nil
+	case lib.BuildPhaseCancelled, lib.BuildPhaseExpired:
+		return withExitCode(exitCodeBuildCancelled, fmt.Errorf("build %s was %s", build.ID, result.Build.Phase))
+	}
+	return withExitCode(exitCodeBuildFailed, fmt.Errorf("build %s failed: %s", build.ID, firstNonEmpty(result.Build.Error, result.Build.Phase)))
+}
+
 // Build Cancel
 var buildCancelCmd = &cobra.Command{
 	Use:   "cancel",
@@ -192,6 +321,7 @@ func init() {
 
 	initBuildListFlags()
 	initBuildInfoFlags()
+	initBuildLogsFlags()
 	initBuildRequestFlags()
 	initBuildCancelFlags()
 	initBuildStatusFlags()
@@ -211,12 +341,22 @@ func initBuildInfoFlags() {
 	_ = buildInfoCmd.MarkFlagRequired("uuid")
 }
 
+func initBuildLogsFlags() {
+	buildLogsCmd.Flags().StringVarP(&buildUUID, "uuid", "u", "", "Build UUID")
+	buildLogsCmd.Flags().BoolVarP(&buildLogsFollow, "follow", "f", false, "Stream new log entries until the build finishes")
+	_ = buildLogsCmd.MarkFlagRequired("uuid")
+}
+
 func initBuildRequestFlags() {
 	buildRequestCmd.Flags().StringVarP(&buildArchiveURL, "archive-url", "a", "", "URL to archive containing Dockerfile")
+	buildRequestCmd.Flags().StringVar(&buildContextDir, "context", "", "Local directory to upload as the build context (honors .dockerignore)")
 	buildRequestCmd.Flags().StringVarP(&buildDockerfile, "dockerfile", "d", "", "Path to Dockerfile within archive")
 	buildRequestCmd.Flags().StringVarP(&buildSubdirectory, "subdirectory", "s", "", "Subdirectory containing build context")
 	buildRequestCmd.Flags().StringSliceVar(&buildTags, "tag", []string{"latest"}, "Tags for the built image")
-	_ = buildRequestCmd.MarkFlagRequired("archive-url")
+	buildRequestCmd.Flags().BoolVar(&buildWait, "wait", false, "Wait for the build to finish and exit non-zero unless it completes")
+	buildRequestCmd.Flags().DurationVar(&buildWaitTimeout, "wait-timeout", time.Hour, "Maximum time to wait with --wait before cancelling the build")
+	buildRequestCmd.MarkFlagsOneRequired("archive-url", "context")
+	buildRequestCmd.MarkFlagsMutuallyExclusive("archive-url", "context")
 }
 
 func initBuildCancelFlags() {
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestBuildLogsFollowCmd(t *testing.T) {
+	t.Cleanup(func() {
+		token = ""
+		quayURL = ""
+		buildUUID = ""
+		buildLogsFollow = false
+		rootCmd.SetArgs([]string{})
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/build/abc/status"):
+			_, _ = w.Write([]byte(`{"id": "abc", "phase": "error"}`))
+		case strings.HasSuffix(r.URL.Path, "/build/abc/logs"):
+			_, _ = w.Write([]byte(`{"start": 0, "total": 4, "logs": [
+				{"type": "phase", "message": "building"},
+				{"type": "command", "message": "Step 1/2 : RUN make"},
+				{"message": "make: *** [all] Error 2\n"},
+				{"type": "error", "message": "The command returned a non-zero code: 2"}
+			]}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	rootCmd.SetArgs([]string{
+		"get", "build", "logs", testTokenFlag, testTokenValue, testQuayURLFlag, server.URL,
+		"-n", testNamespace, "-r", testRepository, "-u", "abc", "--follow",
+	})
+	err := rootCmd.Execute()
+
+	w.Close()
+	os.Stdout = oldStdout
+	if err == nil || !strings.Contains(err.Error(), "did not complete: error") {
+		t.Errorf("expected failed build error, got: %v", err)
+	}
+
+	var buf bytes.Buffer
+	_, _ = io.Copy(&buf, r)
+	want := "==> BUILDING\n--> Step 1/2 : RUN make\n    make: *** [all] Error 2\nERROR: The command returned a non-zero code: 2\n"
+	if buf.String() != want {
+		t.Errorf("unexpected output:\n%s", buf.String())
+	}
+}
+
+func TestCreateBuildWaitExitCode(t *testing.T) {
+	t.Cleanup(func() {
+		token = ""
+		quayURL = ""
+		buildArchiveURL = ""
+		buildWait = false
+		rootCmd.SetArgs([]string{})
+	})
+
+	for phase, want := range map[string]int{"complete": 0, "error": exitCodeBuildFailed, "cancelled": exitCodeBuildCancelled} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			switch {
+			case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/build/"):
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte(`{"id": "abc", "phase": "waiting", "tags": ["latest"]}`))
+			case strings.HasSuffix(r.URL.Path, "/build/abc"):
+				_, _ = w.Write([]byte(`{"id": "abc", "phase": "` + phase + `", "tags": ["latest"]}`))
+			case strings.HasSuffix(r.URL.Path, "/tag/latest"):
+				_, _ = w.Write([]byte(`{"name": "latest", "manifest_digest": "sha256:built"}`))
+			default:
+				t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+
+		oldStdout := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+
+		rootCmd.SetArgs([]string{
+			"create", "build", testTokenFlag, testTokenValue, testQuayURLFlag, server.URL,
+			"-n", testNamespace, "-r", testRepository, "--archive-url", "https://example.com/ctx.tar.gz", "--wait",
+		})
+		err := rootCmd.Execute()
+
+		w.Close()
+		os.Stdout = oldStdout
+		server.Close()
+		var buf bytes.Buffer
+		_, _ = io.Copy(&buf, r)
+
+		if got := ExitCode(err); got != want {
+			t.Errorf("phase %s: expected exit code %d, got %d (%v)", phase, want, got, err)
+		}
+		if phase == "complete" && !strings.Contains(buf.String(), `"latest": "sha256:built"`) {
+			t.Errorf("expected built digest in output, got: %s", buf.String())
+		}
+	}
+}
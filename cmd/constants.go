@@ -42,6 +42,17 @@ const (
 	cmdTeamPermission = "team-permission"
 	cmdMarketplace    = "marketplace"
 
+	// Process exit codes. Errors without a specific code exit with exitCodeError.
+	exitCodeError          = 1
+	exitCodeBuildFailed    = 2
+	exitCodeBuildCancelled = 3
+	exitCodeTimeout        = 4
+	exitCodeDrift          = 5
+	exitCodeInterrupted    = 130
+
+	// annotationOffline marks commands that run without an API token.
+	annotationOffline = "go-quay/offline"
+
 	// Output format constants
 	outputJSON  = "json"
 	outputYAML  = "yaml"
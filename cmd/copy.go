@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sebrandon1/go-quay/lib"
+	"github.com/spf13/cobra"
+)
+
+var (
+	copyDestURL     string
+	copyDestToken   string
+	copyConcurrency int
+	copyQuiet       bool
+)
+
+// copyCmd copies an image between repositories
+var copyCmd = &cobra.Command{
+	Use:   "copy SOURCE DESTINATION",
+	Short: "Copy an image or manifest list between repositories",
+	Long: `Copy an image, or a manifest list with every platform, between repositories
+over the registry v2 API.
+
+Images are written as namespace/repository[:tag|@digest]. The source tag
+defaults to "latest" and the destination tag defaults to the source tag.
+
+On the same Quay instance, blobs are linked with cross-repository mounts
+instead of being transferred, and a copy within one repository only moves the
+tag. Use --dest-url and --dest-token to copy to another instance; the source
+token is only reused for the destination on the same instance. The destination
+digest is verified against the source before the command succeeds.
+
+Examples:
+  go-quay copy myorg/app:rc myorg/app-prod:1.4.0
+  go-quay copy myorg/app:rc myorg/app:stable
+  go-quay copy myorg/app@sha256:... backup/app --dest-url https://quay.example.com/api/v1 --dest-token $DEST_TOKEN`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		src, err := lib.ParseImageRef(args[0])
+		if err != nil {
+			return err
+		}
+		dst, err := lib.ParseImageRef(args[1])
+		if err != nil {
+			return err
+		}
+
+		client, err := getClient()
+		if err != nil {
+			return fmt.Errorf("creating client: %w", err)
+		}
+
+		dest, err := getDestinationClient(client, copyDestURL, copyDestToken)
+		if err != nil {
+			return err
+		}
+		opts := &lib.CopyImageOptions{Concurrency: copyConcurrency, Destination: dest}
+		if !copyQuiet {
+			opts.Progress = func(blob lib.RegistryDescriptor, action lib.BlobCopyAction) {
+				fmt.Fprintf(os.Stderr, "  %-8s %s (%d bytes)\n", action, blob.Digest, blob.Size)
+			}
+		}
+
+		result, err := client.CopyImage(cmd.Context(), src, dst, opts)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "Copied %s to %s (%s)\n", result.Source, result.Destination, result.Digest)
+		return printJSON(result)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(copyCmd)
+
+	copyCmd.Flags().StringVar(&copyDestURL, "dest-url", "", "Destination Quay API base URL (default: same as --quay-url)")
+	copyCmd.Flags().StringVar(&copyDestToken, "dest-token", "", "Destination API token ($QUAY_DEST_TOKEN; required when --dest-url differs, otherwise defaults to --token)")
+	copyCmd.Flags().IntVar(&copyConcurrency, "concurrency", 4, "Maximum parallel blob transfers")
+	copyCmd.Flags().BoolVarP(&copyQuiet, "quiet", "q", false, "Do not print per-blob progress")
+}
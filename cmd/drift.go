@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sebrandon1/go-quay/lib"
+	"github.com/spf13/cobra"
+)
+
+var driftFailOn string
+
+// driftCmd groups commands that compare live configuration with a spec
+var driftCmd = &cobra.Command{
+	Use:   "drift",
+	Short: "Detect configuration drift",
+	Long: `Commands that compare the live configuration with a spec.
+
+Available commands:
+  check - Compare an organization with its spec`,
+}
+
+// driftCheckCmd reports how an organization differs from its spec
+var driftCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Compare an organization with its spec",
+	Long: `Compare the organization named in a spec with the live configuration and
+print one finding per difference, most severe first. Nothing is modified.
+
+Each finding has a kind (missing, unexpected, changed or disabled), the
+resource and name as in "plan", the differing fields and a severity rated by
+the access it grants beyond the spec:
+
+  Critical  unexpected member of the owners team or another admin team
+  High      other unexpected team members, admin roles beyond the spec,
+            unexpected robots and federation
+  Medium    other extra access, notifications (including ones Quay disabled
+            after failed deliveries), auto-prune, mirrors, quota, proxy cache
+  Low       missing access, descriptions
+
+The report is JSON by default; use -O yaml, or -O table for a table. A summary
+goes to stderr. The command exits with status 5 when any finding is at or
+above --fail-on (default Low), 1 on errors and 0 otherwise, so it can run as
+a nightly job.
+` + orgSpecHelp + `
+
+Examples:
+  go-quay drift check -f myorg.yaml
+  go-quay drift check -f myorg.yaml --fail-on High -O table`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		failOn := lib.NormalizeSeverity(driftFailOn)
+		if !strings.EqualFold(failOn, driftFailOn) {
+			return fmt.Errorf("invalid --fail-on %q: use Low, Medium, High or Critical", driftFailOn)
+		}
+		spec, err := readOrgSpec()
+		if err != nil {
+			return err
+		}
+		client, err := getClient()
+		if err != nil {
+			return fmt.Errorf("creating client: %w", err)
+		}
+		cmd.SilenceUsage = true
+		report, err := client.CheckOrgDrift(cmd.Context(), spec)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(os.Stderr, driftSummary(report))
+		if outputFormat == outputTable {
+			printDriftTable(os.Stdout, report)
+		} else if err := printJSON(report); err != nil {
+			return err
+		}
+		if failing := report.AtOrAbove(failOn); len(failing) > 0 {
+			return withExitCode(exitCodeDrift, fmt.Errorf("%s has drifted: %d finding(s) at or above %s", report.Organization, len(failing), failOn))
+		}
+		return nil
+	},
+}
+
+// driftSummary counts the findings of a report by severity.
+func driftSummary(report *lib.DriftReport) string {
+	if len(report.Findings) == 0 {
+		return fmt.Sprintf("No drift. %s matches the spec.", report.Organization)
+	}
+	var counts []string
+	for i := len(lib.Severities) - 1; i >= 0; i-- {
+		if n := report.Count(lib.Severities[i]); n > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", n, lib.Severities[i]))
+		}
+	}
+	return fmt.Sprintf("%d drift finding(s) in %s: %s", len(report.Findings), report.Organization, strings.Join(counts, ", "))
+}
+
+func printDriftTable(out io.Writer, report *lib.DriftReport) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SEVERITY\tKIND\tRESOURCE\tMESSAGE")
+	for _, finding := range report.Findings {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", finding.Severity, finding.Kind, finding.Resource, finding.Message)
+	}
+	_ = w.Flush()
+}
+
+func init() {
+	rootCmd.AddCommand(driftCmd)
+	driftCmd.AddCommand(driftCheckCmd)
+
+	driftCheckCmd.Flags().StringVarP(&orgSpecFile, "file", "f", "", "Organization spec (YAML)")
+	_ = driftCheckCmd.MarkFlagRequired("file")
+	driftCheckCmd.Flags().StringVar(&driftFailOn, "fail-on", lib.SeverityLow, "Lowest severity that fails the check: Low, Medium, High or Critical")
+}
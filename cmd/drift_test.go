@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sebrandon1/go-quay/lib"
+	"github.com/sebrandon1/go-quay/lib/quaytest"
+)
+
+func TestDriftCheck(t *testing.T) {
+	t.Cleanup(func() {
+		token = ""
+		quayURL = ""
+		orgSpecFile = ""
+		driftFailOn = lib.SeverityLow
+		rootCmd.SetArgs([]string{})
+	})
+
+	srv := quaytest.New()
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+	if _, err := client.CreateOrganization(ctx, testOrgName, "ops@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CreateTeam(ctx, testOrgName, "developers", "", "member"); err != nil {
+		t.Fatal(err)
+	}
+	for _, member := range []string{"alice", "mallory"} {
+		if err := client.AddTeamMember(ctx, testOrgName, "developers", member); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	spec := filepath.Join(t.TempDir(), "org.yaml")
+	if err := os.WriteFile(spec, []byte("organization: "+testOrgName+"\nteams:\n  - name: developers\n    members: [alice]\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	common := []string{"drift", "check", "-f", spec, testTokenFlag, testTokenValue, testQuayURLFlag, srv.URL}
+
+	out, err := runCapturingStdout(t, common...)
+	if code := ExitCode(err); code != exitCodeDrift {
+		t.Fatalf("Expected exit code %d, got %d (%v)", exitCodeDrift, code, err)
+	}
+	var report lib.DriftReport
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("Report is not JSON: %v\n%s", err, out)
+	}
+	if len(report.Findings) != 1 || report.Findings[0].Kind != lib.DriftKindUnexpected ||
+		report.Findings[0].Name != "developers/mallory" || report.Findings[0].Severity != lib.SeverityHigh {
+		t.Errorf("Unexpected findings: %+v", report.Findings)
+	}
+
+	if _, err := runCapturingStdout(t, append(common, "--fail-on", "critical")...); err != nil {
+		t.Errorf("Expected a High finding to pass --fail-on critical, got %v", err)
+	}
+	if _, err := runCapturingStdout(t, append(common, "--fail-on", "severe")...); err == nil {
+		t.Error("Expected an invalid --fail-on to fail")
+	}
+}
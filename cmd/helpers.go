@@ -3,6 +3,8 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 
 	"github.com/sebrandon1/go-quay/lib"
 	"gopkg.in/yaml.v3"
@@ -22,6 +24,29 @@ func getClient() (*lib.Client, error) {
 	return client, nil
 }
 
+// getDestinationClient creates the client of a copy's destination from its
+// --dest-url and --dest-token flags, or returns nil when neither they nor
+// $QUAY_DEST_TOKEN are set. The source token is only reused on the source
+// instance, so a destination elsewhere needs its own. The client shares the
+// source's HTTP client, so blob transfers to it stream through the same
+// transport without an overall timeout.
+func getDestinationClient(source *lib.Client, destURL, destToken string) (*lib.Client, error) {
+	destToken = firstNonEmpty(destToken, os.Getenv("QUAY_DEST_TOKEN"))
+	if destURL == "" && destToken == "" {
+		return nil, nil
+	}
+	if destToken == "" && destURL != quayURL {
+		return nil, fmt.Errorf("--dest-token or $QUAY_DEST_TOKEN is required when --dest-url differs from the source URL")
+	}
+	client, err := lib.NewClientWithURL(firstNonEmpty(destToken, token), firstNonEmpty(destURL, quayURL))
+	if err != nil {
+		return nil, fmt.Errorf("creating destination client: %w", err)
+	}
+	client.Version = rootCmd.Version
+	client.HTTPClient = source.HTTPClient
+	return client, nil
+}
+
 // printJSON marshals and prints data in the format selected by --output.
 // Supported formats: json (default), yaml, table (falls back to json).
 func printJSON(data interface{}) error {
@@ -53,3 +78,27 @@ func printAsJSON(data interface{}) error {
 	fmt.Println(string(output))
 	return nil
 }
+
+// writeFile creates path with perm and passes it to write. An existing file
+// loses any permission bits perm does not grant, since opening it keeps its
+// mode. If writing or closing fails, the partial file is removed.
+func writeFile(path string, perm os.FileMode, write func(w io.Writer) error) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm) // #nosec G304 -- path is an explicit CLI argument
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err == nil && info.Mode().Perm()&^perm != 0 {
+		err = file.Chmod(info.Mode().Perm() & perm)
+	}
+	if err == nil {
+		err = write(file)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(path)
+	}
+	return err
+}
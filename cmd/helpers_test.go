@@ -2,8 +2,10 @@ package cmd
 
 import (
 	"bytes"
+	"errors"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -53,3 +55,67 @@ func TestPrintJSON(t *testing.T) {
 		t.Error("Expected indented JSON output")
 	}
 }
+
+func TestWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.txt")
+	if err := writeFile(path, 0o600, func(w io.Writer) error {
+		_, err := io.WriteString(w, "ok")
+		return err
+	}); err != nil {
+		t.Fatalf("writeFile returned error: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected a 0600 file, got %v, %v", info, err)
+	}
+
+	// Rewriting a file others can read makes it private.
+	if err := os.Chmod(path, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFile(path, 0o600, func(w io.Writer) error { return nil }); err != nil {
+		t.Fatalf("writeFile returned error: %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected the existing file to become 0600, got %v, %v", info, err)
+	}
+
+	err = writeFile(path, 0o600, func(w io.Writer) error {
+		_, _ = io.WriteString(w, "partial")
+		return errors.New("disk full")
+	})
+	if err == nil || err.Error() != "disk full" {
+		t.Errorf("Expected the write error, got %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected the partial file to be removed, got %v", err)
+	}
+}
+
+func TestGetDestinationClient(t *testing.T) {
+	oldToken, oldURL := token, quayURL
+	t.Cleanup(func() { token, quayURL = oldToken, oldURL })
+	token, quayURL = "source-token", "https://quay.example.com/api/v1"
+	t.Setenv("QUAY_DEST_TOKEN", "")
+	source, err := getClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if client, err := getDestinationClient(source, "", ""); client != nil || err != nil {
+		t.Errorf("Expected no destination client, got %v, %v", client, err)
+	}
+	if _, err := getDestinationClient(source, "https://dr.example.com/api/v1", ""); err == nil {
+		t.Error("Expected another instance without a destination token to fail")
+	}
+
+	client, err := getDestinationClient(source, quayURL, "")
+	if err != nil || client.BearerToken != "source-token" || client.HTTPClient != source.HTTPClient {
+		t.Errorf("Expected the source token on the source instance, got %v, %v", client, err)
+	}
+	t.Setenv("QUAY_DEST_TOKEN", "dest-token")
+	client, err = getDestinationClient(source, "https://dr.example.com/api/v1", "")
+	if err != nil || client.BearerToken != "dest-token" || client.BaseURL != "https://dr.example.com/api/v1" {
+		t.Errorf("Expected the destination token from the environment, got %v, %v", client, err)
+	}
+}
@@ -1,8 +1,13 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"iter"
+	"net/url"
 	"os"
+	"time"
 
 	"github.com/sebrandon1/go-quay/lib"
 	"github.com/spf13/cobra"
@@ -19,8 +24,27 @@ var (
 	endtime       string
 	callbackURL   string
 	callbackEmail string
+
+	logsFollow       bool
+	logsKinds        []string
+	logsPerformers   []string
+	logsSince        time.Duration
+	logsPollInterval time.Duration
+	logsFormat       string
 )
 
+// logEntriesHelp is appended to the help of log commands that list entries.
+const logEntriesHelp = `
+
+--format prints one entry per line for SIEM collectors: ndjson (LogEntry JSON),
+ecs (Elastic Common Schema), cef (ArcSight) or leef (QRadar). The default, json,
+prints the page as returned by Quay in the --output format.
+
+With --follow, new entries are printed as they are logged, one per line (ndjson
+unless --format says otherwise), until interrupted. --since starts that far in
+the past, and --kind and --performer (globs, repeatable) keep only matching
+entries.`
+
 // logsCmd is the parent command group for log management
 var logsCmd = &cobra.Command{
 	Use:   "logs",
@@ -30,19 +54,23 @@ var logsCmd = &cobra.Command{
 var repoLogsCmd = &cobra.Command{
 	Use:   "repo-logs",
 	Short: "Get repository logs",
-	Long:  `Get action logs for a specific repository.`,
+	Long:  `Get action logs for a specific repository.` + logEntriesHelp,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		client, err := getClient()
 		if err != nil {
 			return fmt.Errorf("creating client: %w", err)
 		}
 
+		if logsFollow {
+			return printLogStream(client, client.FollowRepositoryLogs(cmd.Context(), namespace, repository, followLogsOptions()))
+		}
+
 		logs, err := client.GetLogs(cmd.Context(), namespace, repository, nextPage, startdate, enddate)
 		if err != nil {
 			return fmt.Errorf("getting repository logs: %w", err)
 		}
 
-		return printJSON(logs)
+		return printLogs(client, logs)
 	},
 }
 
@@ -68,19 +96,28 @@ var repoAggregatedLogsCmd = &cobra.Command{
 var orgLogsCmd = &cobra.Command{
 	Use:   "org-logs",
 	Short: "Get organization logs",
-	Long:  `Get action logs for an organization.`,
+	Long: `Get action logs for an organization.` + logEntriesHelp + `
+
+Examples:
+  go-quay get logs org-logs -o myorg --follow
+  go-quay get logs org-logs -o myorg --follow --since 1h --kind 'push_*' --performer 'ci+*'
+  go-quay get logs org-logs -o myorg --follow --format cef | nc siem.example.com 514`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		client, err := getClient()
 		if err != nil {
 			return fmt.Errorf("creating client: %w", err)
 		}
 
+		if logsFollow {
+			return printLogStream(client, client.FollowOrganizationLogs(cmd.Context(), orgName, followLogsOptions()))
+		}
+
 		logs, err := client.GetOrganizationLogs(cmd.Context(), orgName, nextPage, startdate, enddate)
 		if err != nil {
 			return fmt.Errorf("getting organization logs: %w", err)
 		}
 
-		return printJSON(logs)
+		return printLogs(client, logs)
 	},
 }
 
@@ -106,19 +143,23 @@ var orgAggregatedLogsCmd = &cobra.Command{
 var userLogsCmd = &cobra.Command{
 	Use:   "user-logs",
 	Short: "Get user logs",
-	Long:  `Get action logs for the current user.`,
+	Long:  `Get action logs for the current user.` + logEntriesHelp,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		client, err := getClient()
 		if err != nil {
 			return fmt.Errorf("creating client: %w", err)
 		}
 
+		if logsFollow {
+			return printLogStream(client, client.FollowUserLogs(cmd.Context(), followLogsOptions()))
+		}
+
 		logs, err := client.GetUserLogs(cmd.Context(), nextPage, startdate, enddate)
 		if err != nil {
 			return fmt.Errorf("getting user logs: %w", err)
 		}
 
-		return printJSON(logs)
+		return printLogs(client, logs)
 	},
 }
 
@@ -216,6 +257,88 @@ var exportRepoLogsCmd = &cobra.Command{
 	},
 }
 
+// followLogsOptions builds follower options from the --follow flags.
+func followLogsOptions() *lib.FollowLogsOptions {
+	opts := &lib.FollowLogsOptions{
+		PollInterval: logsPollInterval,
+		Kinds:        logsKinds,
+		Performers:   logsPerformers,
+	}
+	if logsSince > 0 {
+		opts.Since = time.Now().Add(-logsSince)
+	}
+	return opts
+}
+
+// logFormatter returns the formatter selected by --format, or nil for json.
+// fallback is used when --format is json or unset.
+func logFormatter(client *lib.Client, fallback string) (*lib.LogFormatter, error) {
+	format := logsFormat
+	if format == "" || format == outputJSON {
+		if fallback == "" {
+			return nil, nil
+		}
+		format = fallback
+	}
+	formatter, err := lib.NewLogFormatter(format)
+	if err != nil {
+		return nil, err
+	}
+	if u, err := url.Parse(client.BaseURL); err == nil {
+		formatter.Host = u.Hostname()
+	}
+	return formatter, nil
+}
+
+// printLogs prints a page of log entries in the --format format. The next
+// page token, if any, goes to stderr when entries are printed one per line.
+func printLogs(client *lib.Client, logs *lib.Logs) error {
+	formatter, err := logFormatter(client, "")
+	if err != nil {
+		return err
+	}
+	if formatter == nil {
+		return printJSON(logs)
+	}
+	if err := formatter.WriteEntries(os.Stdout, logs.Logs); err != nil {
+		return fmt.Errorf("writing logs: %w", err)
+	}
+	if logs.NextPage != "" {
+		fmt.Fprintf(os.Stderr, "More entries: --next-page %s\n", logs.NextPage)
+	}
+	return nil
+}
+
+// printLogStream prints followed log entries one per line until the stream
+// fails or the command is interrupted.
+func printLogStream(client *lib.Client, entries iter.Seq2[lib.LogEntry, error]) error {
+	formatter, err := logFormatter(client, lib.LogFormatNDJSON)
+	if err != nil {
+		return err
+	}
+	for entry, err := range entries {
+		if errors.Is(err, context.Canceled) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("following logs: %w", err)
+		}
+		if err := formatter.WriteEntries(os.Stdout, []lib.LogEntry{entry}); err != nil {
+			return fmt.Errorf("writing logs: %w", err)
+		}
+	}
+	return nil
+}
+
+func addLogEntryFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "Print new entries as they are logged until interrupted")
+	cmd.Flags().StringSliceVar(&logsKinds, "kind", nil, "With --follow, only entries whose kind matches these globs (repeatable)")
+	cmd.Flags().StringSliceVar(&logsPerformers, "performer", nil, "With --follow, only entries whose performer matches these globs (repeatable)")
+	cmd.Flags().DurationVar(&logsSince, "since", 0, "With --follow, start this far in the past (default: now)")
+	cmd.Flags().DurationVar(&logsPollInterval, "poll-interval", lib.DefaultLogPollInterval, "With --follow, time between polls")
+	cmd.Flags().StringVar(&logsFormat, "format", outputJSON, "Entry format: json, ndjson, ecs, cef or leef")
+}
+
 func init() {
 	logsCmd.AddCommand(repoLogsCmd)
 	logsCmd.AddCommand(repoAggregatedLogsCmd)
@@ -233,6 +356,7 @@ func init() {
 	repoLogsCmd.Flags().StringVar(&nextPage, "next-page", "", "Next page token for pagination")
 	repoLogsCmd.Flags().StringVarP(&startdate, "startdate", "s", "", "Start date for the logs")
 	repoLogsCmd.Flags().StringVarP(&enddate, "enddate", "e", "", "End date for the logs")
+	addLogEntryFlags(repoLogsCmd)
 
 	// repo-aggregated-logs flags
 	repoAggregatedLogsCmd.Flags().StringVarP(&namespace, "namespace", "n", appCfg.Namespace, "Repository namespace (default: config file)")
@@ -245,6 +369,7 @@ func init() {
 	orgLogsCmd.Flags().StringVar(&nextPage, "next-page", "", "Next page token for pagination")
 	orgLogsCmd.Flags().StringVarP(&startdate, "startdate", "s", "", "Start date for the logs")
 	orgLogsCmd.Flags().StringVarP(&enddate, "enddate", "e", "", "End date for the logs")
+	addLogEntryFlags(orgLogsCmd)
 
 	// org-aggregated-logs flags
 	orgAggregatedLogsCmd.Flags().StringVarP(&orgName, "organization", "o", "", "Organization name")
@@ -255,6 +380,7 @@ func init() {
 	userLogsCmd.Flags().StringVar(&nextPage, "next-page", "", "Next page token for pagination")
 	userLogsCmd.Flags().StringVarP(&startdate, "startdate", "s", "", "Start date for the logs")
 	userLogsCmd.Flags().StringVarP(&enddate, "enddate", "e", "", "End date for the logs")
+	addLogEntryFlags(userLogsCmd)
 
 	// user-aggregated-logs flags
 	userAggregatedLogsCmd.Flags().StringVarP(&startdate, "startdate", "s", "", "Start date")
@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sebrandon1/go-quay/lib"
+	"github.com/spf13/cobra"
+)
+
+var (
+	logArchiveDir   string
+	logArchiveSince time.Duration
+
+	logQueryStart        string
+	logQueryEnd          string
+	logQueryKinds        []string
+	logQueryRepositories []string
+	logQueryPerformers   []string
+	logQueryRobots       []string
+	logQueryIPs          []string
+	logQueryLimit        int
+	logQueryFormat       string
+)
+
+// auditLogsCmd groups commands that work on a local archive of audit logs
+var auditLogsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Archive and query audit logs offline",
+	Long: `Commands that keep a local archive of an organization's audit logs, beyond
+Quay's retention window, and query it without the API.
+
+Available commands:
+  archive - Append new organization log entries to a local archive
+  query   - Filter archived entries by time, kind, repository, performer, robot and IP`,
+}
+
+// logsArchiveCmd syncs an organization's logs into a local archive
+var logsArchiveCmd = &cobra.Command{
+	Use:   "archive [ORGANIZATION]",
+	Short: "Append new organization log entries to a local archive",
+	Long: `Walk the organization's audit logs day by day and append the entries not
+yet archived to --dir: one JSONL segment per UTC day plus cursor.json, which
+records where the last sync stopped. Run it on a schedule to keep an archive
+beyond Quay's retention; an interrupted run resumes where it stopped.
+
+The first sync starts --since ago (default 30 days). ORGANIZATION defaults to
+--organization, and an archive holds a single organization.
+
+Examples:
+  go-quay logs archive myorg --dir /var/lib/quay-audit/myorg
+  go-quay logs archive myorg --dir ./audit --since 2160h`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		org := orgName
+		if len(args) == 1 {
+			org = args[0]
+		}
+		if org == "" {
+			return fmt.Errorf("organization is required")
+		}
+		archive, err := lib.OpenLogArchive(logArchiveDir)
+		if err != nil {
+			return err
+		}
+
+		client, err := getClient()
+		if err != nil {
+			return fmt.Errorf("creating client: %w", err)
+		}
+		summary, err := client.ArchiveOrganizationLogs(cmd.Context(), archive, org, &lib.LogArchiveOptions{
+			Since: time.Now().Add(-logArchiveSince),
+			Progress: func(day time.Time, appended int) {
+				fmt.Fprintf(os.Stderr, "%s: %d new entries\n", day.Format(time.DateOnly), appended)
+			},
+		})
+		if err != nil {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("archiving logs: %w", err)
+		}
+		return printJSON(summary)
+	},
+}
+
+// logsQueryCmd filters the entries of a local archive
+var logsQueryCmd = &cobra.Command{
+	Use:   "query",
+	Short: "Filter archived audit log entries",
+	Long: `Print the entries of a local archive written by "logs archive" that match
+every given filter, oldest first, one per line. Only the segments of days in
+the time range are read.
+
+--start and --end take a date (YYYY-MM-DD, UTC) or an RFC 3339 time; --end is
+exclusive, and a date includes that whole day. --kind, --repo, --performer and
+--robot take globs; --robot keeps robot performers only. --ip takes addresses
+or CIDR prefixes. Each filter may be repeated and matches when any value does.
+
+--format prints ndjson (default), ecs, cef or leef, as the log commands do.
+
+Examples:
+  go-quay logs query --dir ./audit --kind 'delete_*' --start 2026-05-01
+  go-quay logs query --dir ./audit --robot 'myorg+*' --ip 10.0.0.0/8 --format cef
+  go-quay logs query --dir ./audit --repo api --performer alice --end 2026-05-31`,
+	Args:        cobra.NoArgs,
+	Annotations: map[string]string{annotationOffline: "true"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		query, err := logQuery()
+		if err != nil {
+			return err
+		}
+		formatter, err := lib.NewLogFormatter(logQueryFormat)
+		if err != nil {
+			return err
+		}
+		archive, err := lib.OpenLogArchive(logArchiveDir)
+		if err != nil {
+			return err
+		}
+
+		printed := 0
+		for entry, err := range archive.Query(query) {
+			if err != nil {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("querying archive: %w", err)
+			}
+			if err := formatter.WriteEntries(os.Stdout, []lib.LogEntry{entry}); err != nil {
+				return fmt.Errorf("writing logs: %w", err)
+			}
+			if printed++; printed == logQueryLimit {
+				break
+			}
+		}
+		fmt.Fprintf(os.Stderr, "%d entries\n", printed)
+		return nil
+	},
+}
+
+// logQuery builds the archive query from the query flags.
+func logQuery() (lib.LogQuery, error) {
+	query := lib.LogQuery{
+		Kinds:        logQueryKinds,
+		Repositories: logQueryRepositories,
+		Performers:   logQueryPerformers,
+		Robots:       logQueryRobots,
+		IPs:          logQueryIPs,
+	}
+	var err error
+	if query.Start, err = parseQueryTime(logQueryStart, false); err != nil {
+		return query, fmt.Errorf("invalid --start: %w", err)
+	}
+	if query.End, err = parseQueryTime(logQueryEnd, true); err != nil {
+		return query, fmt.Errorf("invalid --end: %w", err)
+	}
+	return query, nil
+}
+
+// parseQueryTime parses a YYYY-MM-DD date (UTC) or an RFC 3339 time. For an
+// end bound, a date means the end of that day.
+func parseQueryTime(value string, end bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if day, err := time.Parse(time.DateOnly, value); err == nil {
+		if end {
+			day = day.AddDate(0, 0, 1)
+		}
+		return day, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func init() {
+	rootCmd.AddCommand(auditLogsCmd)
+	auditLogsCmd.AddCommand(logsArchiveCmd)
+	auditLogsCmd.AddCommand(logsQueryCmd)
+
+	logsArchiveCmd.Flags().StringVarP(&orgName, "organization", "o", "", "Organization to archive")
+	logsArchiveCmd.Flags().StringVar(&logArchiveDir, "dir", "", "Archive directory")
+	logsArchiveCmd.Flags().DurationVar(&logArchiveSince, "since", lib.DefaultLogArchiveLookback, "How far back the first sync starts")
+	_ = logsArchiveCmd.MarkFlagRequired("dir")
+
+	logsQueryCmd.Flags().StringVar(&logArchiveDir, "dir", "", "Archive directory")
+	logsQueryCmd.Flags().StringVar(&logQueryStart, "start", "", "Only entries at or after this date or time")
+	logsQueryCmd.Flags().StringVar(&logQueryEnd, "end", "", "Only entries before this time, or through this date")
+	logsQueryCmd.Flags().StringSliceVar(&logQueryKinds, "kind", nil, "Only entries whose kind matches these globs (repeatable)")
+	logsQueryCmd.Flags().StringSliceVar(&logQueryRepositories, "repo", nil, "Only entries for repositories matching these globs (repeatable)")
+	logsQueryCmd.Flags().StringSliceVar(&logQueryPerformers, "performer", nil, "Only entries whose performer matches these globs (repeatable)")
+	logsQueryCmd.Flags().StringSliceVar(&logQueryRobots, "robot", nil, "Only entries by robots matching these globs (repeatable)")
+	logsQueryCmd.Flags().StringSliceVar(&logQueryIPs, "ip", nil, "Only entries from these IPs or CIDR prefixes (repeatable)")
+	logsQueryCmd.Flags().IntVar(&logQueryLimit, "limit", 0, "Stop after N entries (0 = all)")
+	logsQueryCmd.Flags().StringVar(&logQueryFormat, "format", lib.LogFormatNDJSON, "Entry format: ndjson, ecs, cef or leef")
+	_ = logsQueryCmd.MarkFlagRequired("dir")
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestOrgLogsFormatCEF(t *testing.T) {
+	t.Cleanup(func() {
+		orgName = ""
+		token = ""
+		quayURL = ""
+		logsFormat = outputJSON
+		rootCmd.SetArgs([]string{})
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/organization/"+testOrgName+"/logs") {
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"logs": [
+			{"kind": "push_repo", "ip": "203.0.113.7", "datetime": "Tue, 19 May 2026 00:00:00 -0000",
+			 "performer": {"kind": "user", "name": "alice"}, "metadata": {"namespace": "` + testOrgName + `", "repo": "app"}},
+			{"kind": "delete_tag", "datetime": "Tue, 19 May 2026 00:01:00 -0000", "performer": {"kind": "user", "name": "bob"}}
+		]}`))
+	}))
+	defer server.Close()
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	rootCmd.SetArgs([]string{
+		"get", "logs", "org-logs", testTokenFlag, testTokenValue, testQuayURLFlag, server.URL,
+		"-o", testOrgName, "--format", "cef",
+	})
+	err := rootCmd.Execute()
+
+	w.Close()
+	os.Stdout = oldStdout
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	_, _ = io.Copy(&buf, r)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected one line per entry, got:\n%s", buf.String())
+	}
+	if !strings.HasPrefix(lines[0], "CEF:0|Red Hat|Quay||push_repo|push repo|3|") || !strings.Contains(lines[0], "suser=alice") {
+		t.Errorf("unexpected first line: %s", lines[0])
+	}
+	if !strings.Contains(lines[1], "|delete_tag|delete tag|5|") {
+		t.Errorf("unexpected second line: %s", lines[1])
+	}
+}
+
+func TestLogsQueryWithoutToken(t *testing.T) {
+	t.Cleanup(func() {
+		token = ""
+		logArchiveDir = ""
+		logQueryKinds = nil
+		rootCmd.SetArgs([]string{})
+	})
+	t.Setenv("QUAY_TOKEN", "")
+
+	dir := t.TempDir()
+	segment := `{"kind": "push_repo", "datetime": "Tue, 19 May 2026 02:00:00 -0000", "performer": {"name": "alice"}}
+{"kind": "delete_tag", "datetime": "Tue, 19 May 2026 01:00:00 -0000", "performer": {"name": "bob"}}
+`
+	if err := os.WriteFile(dir+"/2026-05-19.jsonl", []byte(segment), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	rootCmd.SetArgs([]string{"logs", "query", "--dir", dir, "--kind", "delete_*"})
+	err := rootCmd.Execute()
+
+	w.Close()
+	os.Stdout = oldStdout
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	_, _ = io.Copy(&buf, r)
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 1 || !strings.Contains(lines[0], `"bob"`) {
+		t.Errorf("expected only bob's entry, got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+
+	"github.com/sebrandon1/go-quay/lib"
+	"github.com/spf13/cobra"
+)
+
+var (
+	migrateDestURL     string
+	migrateDestToken   string
+	migrateHistory     bool
+	migrateConcurrency int
+	migrateJournal     string
+	migrateQuiet       bool
+)
+
+// migrateCmd groups commands that move content between registries
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Migrate content between registries",
+	Long: `Commands that move content, with its metadata, between repositories on the
+same or another Quay instance.
+
+Available commands:
+  repo - Migrate a repository`,
+}
+
+// migrateRepoCmd copies a repository with its tags, labels and settings
+var migrateRepoCmd = &cobra.Command{
+	Use:     "repo SOURCE DESTINATION",
+	Aliases: []string{cmdRepository},
+	Short:   "Migrate a repository with its tags, labels and permissions",
+	Long: `Copy a repository, written as namespace/repository, to another repository on
+the same instance or, with --dest-url and --dest-token, on another one. The
+source token is only reused for the destination when it is the same instance.
+
+The destination is created with the source's visibility and description if it
+does not exist. Every active tag is copied with its manifests and blobs, so
+digests are unchanged; --history also replays the manifests each tag pointed at
+before, oldest first, and deletes tags that are deleted at the source. Labels
+added to manifests through the API are copied, and user, robot and team
+permissions are granted, with robots of the source namespace mapped to the
+destination's.
+
+At most --concurrency tags are copied at once, and layers are streamed without
+a time limit however large they are. Every finished step is recorded in a
+journal file, so rerunning an interrupted migration with the same journal
+resumes where it stopped; delete the journal to start over. A journal records
+the source and destination instances and repositories and is refused for any
+other migration. Manifests gone from the source's history and permissions the
+destination rejects (for example, for a robot or team it lacks) are listed as
+skipped in the JSON result.
+
+Examples:
+  go-quay migrate repo myorg/app neworg/app
+  go-quay migrate repo myorg/app myorg/app --dest-url https://quay.example.com/api/v1 --dest-token $DEST_TOKEN --history
+  go-quay migrate repo myorg/app backup/app --journal /var/tmp/app.jsonl --concurrency 8`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		src, err := lib.ParseImageRef(args[0])
+		if err != nil {
+			return err
+		}
+		dst, err := lib.ParseImageRef(args[1])
+		if err != nil {
+			return err
+		}
+
+		client, err := getClient()
+		if err != nil {
+			return fmt.Errorf("creating client: %w", err)
+		}
+
+		dest, err := getDestinationClient(client, migrateDestURL, migrateDestToken)
+		if err != nil {
+			return err
+		}
+		opts := &lib.MigrateRepositoryOptions{History: migrateHistory, Concurrency: migrateConcurrency, Destination: dest}
+		if !migrateQuiet {
+			opts.Progress = func(step lib.MigrationStep) {
+				fmt.Fprintf(os.Stderr, "  %s\n", step)
+			}
+		}
+
+		path := firstNonEmpty(migrateJournal, fmt.Sprintf("migrate-%s-%s-to-%s-%s.jsonl", src.Namespace, src.Repository, dst.Namespace, dst.Repository))
+		journal, err := lib.OpenMigrationJournal(path, client.BaseURL, src, cmp.Or(dest, client).BaseURL, dst)
+		if err != nil {
+			return err
+		}
+		defer journal.Close()
+		opts.Journal = journal
+		cmd.SilenceUsage = true
+
+		result, err := client.MigrateRepository(cmd.Context(), src, dst, opts)
+		if result != nil {
+			fmt.Fprintf(os.Stderr, "Migrated %s to %s: %d step(s) done, %d resumed from %s, %d skipped\n",
+				result.Source, result.Destination, result.Steps, result.Resumed, path, len(result.Skipped))
+			if printErr := printJSON(result); printErr != nil && err == nil {
+				err = printErr
+			}
+		}
+		return err
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+	migrateCmd.AddCommand(migrateRepoCmd)
+
+	migrateRepoCmd.Flags().StringVar(&migrateDestURL, "dest-url", "", "Destination Quay API base URL (default: same as --quay-url)")
+	migrateRepoCmd.Flags().StringVar(&migrateDestToken, "dest-token", "", "Destination API token ($QUAY_DEST_TOKEN; required when --dest-url differs, otherwise defaults to --token)")
+	migrateRepoCmd.Flags().BoolVar(&migrateHistory, "history", false, "Also replay each tag's earlier manifests and deleted tags")
+	migrateRepoCmd.Flags().IntVar(&migrateConcurrency, "concurrency", 4, "Maximum tags copied in parallel")
+	migrateRepoCmd.Flags().StringVar(&migrateJournal, "journal", "", "Progress journal file (default migrate-SRCNS-SRCREPO-to-DSTNS-DSTREPO.jsonl)")
+	migrateRepoCmd.Flags().BoolVarP(&migrateQuiet, "quiet", "q", false, "Do not print per-step progress")
+}
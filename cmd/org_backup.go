@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sebrandon1/go-quay/lib"
+	"github.com/spf13/cobra"
+)
+
+var (
+	backupFile      string
+	restoreFile     string
+	restoreOrg      string
+	restoreConflict string
+	restoreDryRun   bool
+	restoreRepos    map[string]string
+	restoreTeams    map[string]string
+	restoreUsers    map[string]string
+)
+
+// backupCmd groups commands that archive metadata
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Archive metadata for restore",
+	Long: `Commands that archive metadata into a file "restore" replays.
+
+Available commands:
+  org - Back up an organization`,
+}
+
+// backupOrgCmd archives the metadata of an organization
+var backupOrgCmd = &cobra.Command{
+	Use:     "org ORGANIZATION",
+	Aliases: []string{cmdOrganization},
+	Short:   "Back up an organization's metadata",
+	Long: `Write a portable archive (gzip-compressed JSON) of an organization's
+metadata: repositories with their description and visibility, teams with their
+members, robots with their federation, repository permissions, notifications
+and mirror configurations, prototypes, auto-prune policies, quota, proxy cache
+and the labels added to manifests through the API.
+
+Images are not included, and neither are robot tokens or registry passwords.
+The file is readable by its owner only.
+
+Examples:
+  go-quay backup org myorg
+  go-quay backup org myorg -f /backups/myorg-$(date +%F).json.gz`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := getClient()
+		if err != nil {
+			return fmt.Errorf("creating client: %w", err)
+		}
+		cmd.SilenceUsage = true
+		backup, err := client.BackupOrganization(cmd.Context(), args[0])
+		if err != nil {
+			return err
+		}
+
+		path := backupFile
+		if path == "" {
+			path = args[0] + "-backup.json.gz"
+		}
+		// Backups describe access to the organization, so only the owner may read them.
+		if err := writeFile(path, 0o600, func(w io.Writer) error { return lib.WriteOrgBackup(w, backup) }); err != nil {
+			return fmt.Errorf("writing backup: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Backed up %s (%d repositories) to %s\n", args[0], len(backup.Repositories), path)
+		return nil
+	},
+}
+
+// restoreCmd replays a backup into an organization
+var restoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restore an organization from a backup",
+	Long: `Replay a backup made by "backup org" into an organization, which may be on
+another registry (--quay-url) and must already exist. Repositories, teams,
+robots, members, permissions, prototypes, notifications, mirrors, auto-prune
+policies, quota, proxy cache and manifest labels the target lacks are created;
+nothing is deleted.
+
+Names can be remapped: --org restores into another organization (robots follow
+it), and --rename-repo, --rename-team and --rename-user take OLD=NEW pairs.
+
+Resources that exist with different settings are conflicts, handled by
+--on-conflict:
+  skip       leave them as they are (default)
+  overwrite  replace their settings with the backup's
+  fail       change nothing and exit with an error
+
+The report, printed as JSON (or -O yaml), lists the changes, the conflicts,
+labels skipped because the target lacks the manifest, and the robot tokens,
+mirror and proxy cache credentials and notification configs to set or check by
+hand. Use --dry-run to see it without changing anything.
+
+Examples:
+  go-quay restore -f myorg-backup.json.gz --dry-run
+  go-quay restore -f myorg-backup.json.gz --quay-url https://quay.example.com/api/v1 \
+    --org myorg-dr --rename-user alice=asmith --on-conflict overwrite`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, err := os.Open(restoreFile) // #nosec G304 -- path is an explicit CLI argument
+		if err != nil {
+			return fmt.Errorf("reading backup: %w", err)
+		}
+		defer file.Close()
+		backup, err := lib.ReadOrgBackup(file)
+		if err != nil {
+			return err
+		}
+
+		client, err := getClient()
+		if err != nil {
+			return fmt.Errorf("creating client: %w", err)
+		}
+		cmd.SilenceUsage = true
+		report, err := client.RestoreOrganization(cmd.Context(), backup, &lib.RestoreOptions{
+			Organization: restoreOrg,
+			Repositories: restoreRepos,
+			Teams:        restoreTeams,
+			Users:        restoreUsers,
+			Conflict:     restoreConflict,
+			DryRun:       restoreDryRun,
+			Progress: func(change lib.OrgChange) {
+				fmt.Fprintln(os.Stderr, change.String())
+			},
+		})
+		if report != nil {
+			verb, count := "Restored", report.Applied
+			if restoreDryRun {
+				verb, count = "Would restore", len(report.Changes)
+			}
+			fmt.Fprintf(os.Stderr, "%s %d change(s) into %s; %d conflict(s), %d skipped, %d to finish by hand\n",
+				verb, count, report.Organization, len(report.Conflicts), len(report.Skipped), len(report.Manual))
+			if printErr := printJSON(report); printErr != nil && err == nil {
+				err = printErr
+			}
+		}
+		return err
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(backupCmd)
+	backupCmd.AddCommand(backupOrgCmd)
+	rootCmd.AddCommand(restoreCmd)
+
+	backupOrgCmd.Flags().StringVarP(&backupFile, "file", "f", "", "Backup file to write (default ORGANIZATION-backup.json.gz)")
+
+	restoreCmd.Flags().StringVarP(&restoreFile, "file", "f", "", "Backup file written by backup org")
+	_ = restoreCmd.MarkFlagRequired("file")
+	restoreCmd.Flags().StringVar(&restoreOrg, "org", "", "Organization to restore into (default: the backed up one)")
+	restoreCmd.Flags().StringVar(&restoreConflict, "on-conflict", lib.ConflictSkip, "Existing resources that differ: skip, overwrite or fail")
+	restoreCmd.Flags().BoolVar(&restoreDryRun, "dry-run", false, "Report what would change without changing anything")
+	restoreCmd.Flags().StringToStringVar(&restoreRepos, "rename-repo", nil, "Rename repositories, OLD=NEW (repeatable)")
+	restoreCmd.Flags().StringToStringVar(&restoreTeams, "rename-team", nil, "Rename teams, OLD=NEW (repeatable)")
+	restoreCmd.Flags().StringToStringVar(&restoreUsers, "rename-user", nil, "Rename users, OLD=NEW (repeatable)")
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sebrandon1/go-quay/lib"
+	"github.com/sebrandon1/go-quay/lib/quaytest"
+)
+
+func TestBackupAndRestoreOrg(t *testing.T) {
+	t.Cleanup(func() {
+		token = ""
+		quayURL = ""
+		backupFile = ""
+		restoreFile = ""
+		restoreOrg = ""
+		restoreUsers = nil
+		rootCmd.SetArgs([]string{})
+	})
+	ctx := context.Background()
+
+	source := quaytest.New()
+	defer source.Close()
+	src := source.Client()
+	for _, setup := range []func() error{
+		func() error { _, err := src.CreateOrganization(ctx, testOrgName, "ops@example.com"); return err },
+		func() error {
+			_, err := src.CreateRepository(ctx, testOrgName, testRepository, "public", "The API")
+			return err
+		},
+		func() error { _, err := src.CreateTeam(ctx, testOrgName, "developers", "", "member"); return err },
+		func() error { return src.AddTeamMember(ctx, testOrgName, "developers", "alice") },
+		func() error { _, err := src.CreateRobotAccount(ctx, testOrgName, "ci", "CI", nil); return err },
+		func() error {
+			return src.SetUserPermission(ctx, testOrgName, testRepository, testOrgName+"+ci", "write")
+		},
+	} {
+		if err := setup(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	image := lib.Manifest{MediaType: "application/vnd.oci.image.manifest.v1+json"}
+	digest, err := source.PushManifest(testOrgName, testRepository, "latest", image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.AddManifestLabel(ctx, testOrgName, testRepository, digest, "owner", "platform", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	archive := filepath.Join(t.TempDir(), "backup.json.gz")
+	if _, err := runCapturingStdout(t, "backup", "org", testOrgName, "-f", archive, testTokenFlag, testTokenValue, testQuayURLFlag, source.URL); err != nil {
+		t.Fatalf("backup returned error: %v", err)
+	}
+	if info, err := os.Stat(archive); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected the backup to be private, got %v, %v", info, err)
+	}
+
+	target := quaytest.New()
+	defer target.Close()
+	dst := target.Client()
+	if _, err := dst.CreateOrganization(ctx, "dr", "ops@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := target.PushManifest("dr", testRepository, "latest", image); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := runCapturingStdout(t, "restore", "-f", archive, "--org", "dr", "--rename-user", "alice=asmith",
+		testTokenFlag, testTokenValue, testQuayURLFlag, target.URL)
+	if err != nil {
+		t.Fatalf("restore returned error: %v", err)
+	}
+	var report lib.RestoreReport
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("Report is not JSON: %v\n%s", err, out)
+	}
+	if report.Applied != len(report.Changes) || len(report.Conflicts) != 1 || report.Conflicts[0].Resource != lib.OrgResourceRepository {
+		t.Errorf("Expected the visibility conflict to be skipped, got %+v", report)
+	}
+	if len(report.Manual) != 1 || report.Manual[0].Name != "dr+ci" {
+		t.Errorf("Expected the new robot token to be reported, got %+v", report.Manual)
+	}
+
+	members, err := dst.GetTeamMembers(ctx, "dr", "developers")
+	if err != nil || len(members.Members) != 1 || members.Members[0].Name != "asmith" {
+		t.Errorf("Expected the renamed member, got %+v, %v", members, err)
+	}
+	if perms, err := dst.ListUserPermissions(ctx, "dr", testRepository); err != nil || len(perms.Permissions) != 1 || perms.Permissions[0].Name != "dr+ci" {
+		t.Errorf("Expected the robot permission, got %+v, %v", perms, err)
+	}
+	labels, err := dst.GetManifestLabels(ctx, "dr", testRepository, digest)
+	if err != nil || len(labels.Labels) != 1 || labels.Labels[0].Value != "platform" {
+		t.Errorf("Expected the label to be restored, got %+v, %v", labels, err)
+	}
+
+	if out, err = runCapturingStdout(t, "restore", "-f", archive, "--org", "dr", "--rename-user", "alice=asmith", "--on-conflict", "fail",
+		testTokenFlag, testTokenValue, testQuayURLFlag, target.URL); err == nil {
+		t.Errorf("Expected the remaining conflict to fail the restore:\n%s", out)
+	}
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sebrandon1/go-quay/lib"
+	"github.com/sebrandon1/go-quay/lib/quaytest"
+)
+
+func TestExportOrgRoundTrip(t *testing.T) {
+	t.Cleanup(func() {
+		token = ""
+		quayURL = ""
+		orgSpecFile = ""
+		rootCmd.SetArgs([]string{})
+	})
+
+	srv := quaytest.New()
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+	for _, setup := range []func() error{
+		func() error { _, err := client.CreateOrganization(ctx, testOrgName, "ops@example.com"); return err },
+		func() error {
+			_, err := client.CreateRepository(ctx, testOrgName, testRepository, "private", "")
+			return err
+		},
+		func() error {
+			_, err := client.CreateTeam(ctx, testOrgName, "developers", "Developers", "creator")
+			return err
+		},
+		func() error { return client.AddTeamMember(ctx, testOrgName, "developers", "alice") },
+		func() error { _, err := client.CreateRobotAccount(ctx, testOrgName, "ci", "CI", nil); return err },
+		func() error {
+			return client.CreateRobotFederation(ctx, testOrgName, "ci", []lib.RobotFederationConfig{{Issuer: "https://issuer.example.com", Subject: "main"}})
+		},
+		func() error {
+			return client.SetUserPermission(ctx, testOrgName, testRepository, testOrgName+"+ci", "write")
+		},
+		func() error {
+			_, err := client.CreateNotification(ctx, testOrgName, testRepository, &lib.CreateNotificationRequest{
+				Event: "repo_push", Method: "webhook", Config: map[string]any{"url": "https://hooks.example.com"},
+			})
+			return err
+		},
+		func() error {
+			_, err := client.CreateAutoPrunePolicy(ctx, testOrgName, lib.AutoPruneMethodNumberOfTags, 10, "")
+			return err
+		},
+		func() error { _, err := client.CreateQuota(ctx, testOrgName, 1<<30); return err },
+	} {
+		if err := setup(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out, err := runCapturingStdout(t, "export", "org", testOrgName, testTokenFlag, testTokenValue, testQuayURLFlag, srv.URL)
+	if err != nil {
+		t.Fatalf("export returned error: %v", err)
+	}
+	for _, want := range []string{
+		"organization: " + testOrgName,
+		"  - name: developers\n    description: Developers\n    role: creator\n    members:\n      - alice",
+		"        " + testOrgName + "+ci: write",
+		"issuer: https://issuer.example.com",
+		"url: https://hooks.example.com",
+		"limit_bytes: 1073741824",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in export:\n%s", want, out)
+		}
+	}
+	if _, err := parseOrgSpec([]byte(out)); err != nil {
+		t.Fatalf("Export does not parse as a spec: %v", err)
+	}
+
+	spec := filepath.Join(t.TempDir(), "org.yaml")
+	if err := os.WriteFile(spec, []byte(out), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	out, err = runCapturingStdout(t, "plan", "-f", spec, testTokenFlag, testTokenValue, testQuayURLFlag, srv.URL)
+	if err != nil || !strings.Contains(out, "No changes.") {
+		t.Errorf("Expected an export to plan no changes, got %v:\n%s", err, out)
+	}
+}
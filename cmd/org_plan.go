@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sebrandon1/go-quay/lib"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var orgSpecFile string
+
+const orgSpecHelp = `
+The spec is a YAML document naming the organization and any of these
+sections; omitted sections are left alone, while a listed one is complete and
+whatever it does not list is deleted:
+
+  organization: myorg
+  teams:
+    - name: developers
+      description: Application developers
+      role: member              # member, creator or admin
+      members: [alice, bob, myorg+ci]
+  robots:
+    - name: ci                  # referenced elsewhere as myorg+ci
+      description: CI pipeline
+      federation:
+        - issuer: https://token.actions.githubusercontent.com
+          subject: repo:myorg/api:ref:refs/heads/main
+  repositories:
+    - name: api
+      permissions:
+        users: {alice: admin, myorg+ci: write}
+        teams: {developers: write}
+      notifications:
+        - event: vulnerability_found
+          method: webhook
+          config: {url: https://hooks.example.com/quay}
+    - name: nginx
+      mirror:
+        external_reference: docker.io/library/nginx
+        sync_interval: 86400
+        robot_username: myorg+ci
+        tags: ["1.*", latest]
+  prototypes:
+    - team: developers
+      role: read
+    - activating_user: alice
+      user: myorg+ci
+      role: write
+  auto_prune:
+    - method: number_of_tags    # or creation_date
+      value: 20
+      tag_pattern: "^pr-.*"
+  quota:
+    limit_bytes: 107374182400   # 0 removes the quota
+  proxy_cache:
+    upstream_registry: docker.io
+    expiration: 86400`
+
+// planCmd shows the changes that bring an organization to a spec
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Show the changes that bring an organization to a YAML spec",
+	Long: `Compare an organization spec with the live organization and print the
+changes apply would make: + create, ~ update, - delete. Nothing is modified.
+With --output json or yaml the plan is printed in that format instead.
+"export org" writes the spec of an existing organization.
+` + orgSpecHelp + `
+
+Examples:
+  go-quay plan -f myorg.yaml
+  go-quay plan -f myorg.yaml -O json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_, plan, err := planOrgSpec(cmd)
+		if err != nil {
+			return err
+		}
+		if cmd.Flag("output").Changed && outputFormat != outputTable {
+			return printJSON(plan)
+		}
+		printOrgPlan(os.Stdout, plan)
+		return nil
+	},
+}
+
+// applyCmd makes the changes that bring an organization to a spec
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Bring an organization to a YAML spec",
+	Long: `Plan the organization spec as "plan" does, print the plan and make only
+those changes, in dependency order: settings, then teams and robots, members,
+permissions and default permissions, then revocations and deletions. A plan
+that deletes or replaces anything requires --confirm. Apply stops at the
+first failure; running it again picks up what is left.
+` + orgSpecHelp + `
+
+Examples:
+  go-quay apply -f myorg.yaml
+  go-quay apply -f myorg.yaml --confirm`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, plan, err := planOrgSpec(cmd)
+		if err != nil {
+			return err
+		}
+		printOrgPlan(os.Stdout, plan)
+		if len(plan.Changes) == 0 {
+			return nil
+		}
+		if deletes := plan.Count(lib.OrgActionDelete) + plan.Count(lib.OrgActionReplace); deletes > 0 && !confirm {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("the plan deletes or replaces %d resources; pass --confirm to apply it", deletes)
+		}
+
+		applied, err := client.ApplyOrgPlan(cmd.Context(), plan, &lib.OrgApplyOptions{
+			Progress: func(change lib.OrgChange) {
+				fmt.Fprintf(os.Stderr, "%s: done\n", change)
+			},
+		})
+		if err != nil {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("applied %d of %d changes: %w", applied, len(plan.Changes), err)
+		}
+		fmt.Fprintf(os.Stderr, "Apply complete: %d created, %d updated, %d replaced, %d deleted\n",
+			plan.Count(lib.OrgActionCreate), plan.Count(lib.OrgActionUpdate), plan.Count(lib.OrgActionReplace), plan.Count(lib.OrgActionDelete))
+		return nil
+	},
+}
+
+// planOrgSpec reads --file and plans it against the live organization.
+func planOrgSpec(cmd *cobra.Command) (*lib.Client, *lib.OrgPlan, error) {
+	spec, err := readOrgSpec()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	client, err := getClient()
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating client: %w", err)
+	}
+	plan, err := client.PlanOrganization(cmd.Context(), spec)
+	if err != nil {
+		cmd.SilenceUsage = true
+		return nil, nil, fmt.Errorf("planning %s: %w", spec.Organization, err)
+	}
+	return client, plan, nil
+}
+
+// readOrgSpec reads and parses --file.
+func readOrgSpec() (*lib.OrgSpec, error) {
+	data, err := os.ReadFile(orgSpecFile) // #nosec G304 -- path is an explicit CLI argument
+	if err != nil {
+		return nil, fmt.Errorf("reading spec: %w", err)
+	}
+	spec, err := parseOrgSpec(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing spec %s: %w", orgSpecFile, err)
+	}
+	return spec, nil
+}
+
+// parseOrgSpec decodes a YAML organization spec, rejecting unknown fields.
+func parseOrgSpec(data []byte) (*lib.OrgSpec, error) {
+	spec := &lib.OrgSpec{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(spec); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// orgChangeSymbols marks each action in a printed plan.
+var orgChangeSymbols = map[string]string{
+	lib.OrgActionCreate:  "+",
+	lib.OrgActionUpdate:  "~",
+	lib.OrgActionReplace: "-/+",
+	lib.OrgActionDelete:  "-",
+}
+
+// printOrgPlan writes a plan the way terraform does: one block per change
+// with its changed attributes, then a summary line.
+func printOrgPlan(out io.Writer, plan *lib.OrgPlan) {
+	if len(plan.Changes) == 0 {
+		fmt.Fprintf(out, "No changes. %s matches the spec.\n", plan.Organization)
+		return
+	}
+	fmt.Fprintf(out, "Changes to %s:\n\n", plan.Organization)
+	for _, change := range plan.Changes {
+		symbol := orgChangeSymbols[change.Action]
+		fmt.Fprintf(out, "  %s %s %q\n", symbol, change.Resource, change.Name)
+		for _, field := range change.Fields {
+			switch change.Action {
+			case lib.OrgActionCreate:
+				fmt.Fprintf(out, "      %s %s = %q\n", symbol, field.Field, field.To)
+			case lib.OrgActionDelete:
+				fmt.Fprintf(out, "      %s %s = %q\n", symbol, field.Field, field.From)
+			default:
+				fmt.Fprintf(out, "      %s %s = %q -> %q\n", symbol, field.Field, field.From, field.To)
+			}
+		}
+		if change.Warning != "" {
+			fmt.Fprintf(out, "      ! %s\n", change.Warning)
+		}
+	}
+	fmt.Fprintf(out, "\nPlan: %d to create, %d to update, %d to replace, %d to delete.\n",
+		plan.Count(lib.OrgActionCreate), plan.Count(lib.OrgActionUpdate), plan.Count(lib.OrgActionReplace), plan.Count(lib.OrgActionDelete))
+}
+
+func init() {
+	rootCmd.AddCommand(planCmd)
+	rootCmd.AddCommand(applyCmd)
+
+	for _, cmd := range []*cobra.Command{planCmd, applyCmd} {
+		cmd.Flags().StringVarP(&orgSpecFile, "file", "f", "", "Organization spec (YAML)")
+		_ = cmd.MarkFlagRequired("file")
+	}
+	applyCmd.Flags().BoolVar(&confirm, "confirm", false, "Allow a plan that deletes resources")
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/sebrandon1/go-quay/lib"
+	"github.com/sebrandon1/go-quay/lib/quaytest"
+)
+
+// runCapturingStdout executes rootCmd with args and returns what it printed.
+func runCapturingStdout(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+
+	w.Close()
+	os.Stdout = oldStdout
+	var buf bytes.Buffer
+	_, _ = io.Copy(&buf, r)
+	return buf.String(), err
+}
+
+func TestPlanAndApplyOrgSpec(t *testing.T) {
+	t.Cleanup(func() {
+		token = ""
+		quayURL = ""
+		orgSpecFile = ""
+		confirm = false
+		rootCmd.SetArgs([]string{})
+	})
+
+	srv := quaytest.New()
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+	if _, err := client.CreateOrganization(ctx, testOrgName, "ops@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CreateRepository(ctx, testOrgName, testRepository, "private", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CreateTeam(ctx, testOrgName, "legacy", "", "member"); err != nil {
+		t.Fatal(err)
+	}
+
+	spec := filepath.Join(t.TempDir(), "org.yaml")
+	err := os.WriteFile(spec, []byte(`organization: `+testOrgName+`
+teams:
+  - name: developers
+    role: creator
+    members: [alice, `+testOrgName+`+ci]
+robots:
+  - name: ci
+repositories:
+  - name: `+testRepository+`
+    permissions:
+      users: {`+testOrgName+`+ci: write}
+      teams: {developers: read}
+`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	common := []string{testTokenFlag, testTokenValue, testQuayURLFlag, srv.URL, "-f", spec}
+
+	out, err := runCapturingStdout(t, append([]string{"plan"}, common...)...)
+	if err != nil {
+		t.Fatalf("plan returned error: %v", err)
+	}
+	for _, want := range []string{
+		`  + team "developers"`,
+		`      + role = "creator"`,
+		`  + robot "` + testOrgName + `+ci"`,
+		`  - team "legacy"`,
+		"Plan: 6 to create, 0 to update, 0 to replace, 1 to delete.",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in plan:\n%s", want, out)
+		}
+	}
+
+	if _, err := runCapturingStdout(t, append([]string{"apply"}, common...)...); err == nil || !strings.Contains(err.Error(), "--confirm") {
+		t.Fatalf("Expected apply to require --confirm, got %v", err)
+	}
+	if _, err := runCapturingStdout(t, append([]string{"apply", "--confirm"}, common...)...); err != nil {
+		t.Fatalf("apply returned error: %v", err)
+	}
+
+	teams, err := client.GetTeams(ctx, testOrgName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, team := range teams {
+		names = append(names, team.Name)
+	}
+	if slices.Sort(names); !slices.Equal(names, []string{"developers", "owners"}) {
+		t.Errorf("Unexpected teams after apply: %v", names)
+	}
+	if perms, err := client.ListUserPermissions(ctx, testOrgName, testRepository); err != nil || len(perms.Permissions) != 1 {
+		t.Errorf("Expected the robot permission only, got %+v, %v", perms, err)
+	}
+
+	confirm = false
+	if out, err = runCapturingStdout(t, append([]string{"plan"}, common...)...); err != nil || !strings.Contains(out, "No changes.") {
+		t.Errorf("Expected no changes after apply, got %v:\n%s", err, out)
+	}
+}
+
+func TestPrintOrgPlanReplace(t *testing.T) {
+	var out strings.Builder
+	printOrgPlan(&out, &lib.OrgPlan{Organization: testOrgName, Changes: []lib.OrgChange{{
+		Action: lib.OrgActionReplace, Resource: lib.OrgResourceProxyCache, Name: testOrgName,
+		Fields:  []lib.OrgFieldChange{{Field: "expiration", From: "86400", To: "3600"}},
+		Warning: "upstream registry credentials are cleared; set them again after applying",
+	}}})
+	for _, want := range []string{
+		`-/+ proxy_cache "` + testOrgName + `"`,
+		`-/+ expiration = "86400" -> "3600"`,
+		"! upstream registry credentials are cleared",
+		"0 to create, 0 to update, 1 to replace, 0 to delete",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected %q in the plan:\n%s", want, out.String())
+		}
+	}
+}
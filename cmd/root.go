@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
@@ -31,7 +32,7 @@ func persistentPreRunE(cmd *cobra.Command, _ []string) error {
 	token = resolveFlag(flagChanged(cmd, "token"), token, os.Getenv("QUAY_TOKEN"), appCfg.Token)
 	quayURL = resolveFlag(flagChanged(cmd, "quay-url"), quayURL, os.Getenv("QUAY_URL"), appCfg.QuayURL, lib.DefaultQuayURL)
 
-	if token == "" {
+	if token == "" && cmd.Annotations[annotationOffline] == "" {
 		return fmt.Errorf(`authentication token required
 
 Set QUAY_TOKEN environment variable, use --token/-t flag, or add to config file (%s).
@@ -100,6 +101,33 @@ func init() {
 	getCmd.AddCommand(mirrorCmd)
 }
 
+// exitError is an error that selects the process exit code.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+
+func (e *exitError) Unwrap() error { return e.err }
+
+// withExitCode wraps err so that the process exits with code.
+func withExitCode(code int, err error) error {
+	return &exitError{code: code, err: err}
+}
+
+// ExitCode returns the process exit code for an error returned by Execute:
+// 0 for nil, the code chosen by the failing command, or 1.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	if e, ok := errors.AsType[*exitError](err); ok {
+		return e.code
+	}
+	return exitCodeError
+}
+
 // Execute executes the root command, canceling in-flight HTTP on interrupt.
 func Execute() error {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sebrandon1/go-quay/lib"
+	"github.com/spf13/cobra"
+)
+
+var (
+	sbomFormat string
+	sbomFile   string
+)
+
+// sbomCmd exports the package inventory of an image as an SBOM
+var sbomCmd = &cobra.Command{
+	Use:   "sbom IMAGE",
+	Short: "Export an SBOM from an image's security scan",
+	Long: `Export the packages found by Quay's security scanner as an SPDX 2.3 or
+CycloneDX 1.5 SBOM, including the layer that introduced each package.
+
+IMAGE is namespace/repository[:tag|@digest]; the tag defaults to "latest".
+The image must have been scanned.
+
+Examples:
+  go-quay sbom myorg/app:1.4.0 > app.spdx.json
+  go-quay sbom myorg/app@sha256:... --format cyclonedx --file app.cdx.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		image, err := lib.ParseImageRef(args[0])
+		if err != nil {
+			return err
+		}
+
+		client, err := getClient()
+		if err != nil {
+			return fmt.Errorf("creating client: %w", err)
+		}
+		digest, err := client.ResolveImageDigest(cmd.Context(), image)
+		if err != nil {
+			return err
+		}
+
+		write := func(out io.Writer) error {
+			return client.WriteManifestSBOM(cmd.Context(), out, sbomFormat, image.Namespace, image.Repository, digest)
+		}
+		if sbomFile != "" {
+			err = writeFile(sbomFile, 0o644, write)
+		} else {
+			err = write(os.Stdout)
+		}
+		if err != nil {
+			return fmt.Errorf("generating SBOM: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "SBOM (%s) for %s/%s@%s\n", sbomFormat, image.Namespace, image.Repository, digest)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(sbomCmd)
+
+	sbomCmd.Flags().StringVar(&sbomFormat, "format", lib.SBOMFormatSPDX, "SBOM format: spdx or cyclonedx")
+	sbomCmd.Flags().StringVar(&sbomFile, "file", "", "Write the SBOM to a file instead of stdout")
+}
@@ -0,0 +1,337 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sebrandon1/go-quay/lib"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	scanPolicyFile  string
+	scanMaxSeverity string
+	scanFixableOnly bool
+
+	scanDiffFrom     string
+	scanDiffTo       string
+	scanDiffTable    bool
+	scanDiffMarkdown bool
+)
+
+// scanCmd groups commands that make decisions from security scan results
+var scanCmd = &cobra.Command{
+	Use:   "scan",
+	Short: "Evaluate security scan results",
+	Long: `Commands that evaluate security scan results for container images.
+
+Available commands:
+  gate      - Fail when an image violates a vulnerability policy
+  diff      - Compare the vulnerabilities of two tags or manifests
+  inventory - Report vulnerabilities across every image in an organization`,
+}
+
+// scanGateCmd evaluates a manifest's vulnerabilities against a policy
+var scanGateCmd = &cobra.Command{
+	Use:   "gate IMAGE",
+	Short: "Fail when an image violates a vulnerability policy",
+	Long: `Evaluate the vulnerabilities of an image against a policy and exit non-zero
+when the policy is violated. A human summary is written to stderr and the full
+result to stdout.
+
+IMAGE is namespace/repository[:tag|@digest]; the tag defaults to "latest".
+
+The policy file is YAML or JSON:
+
+  max_severity: Medium          # Unknown, Negligible, Low, Medium, High, Critical
+  max_counts:
+    Medium: 10
+  fixable_only: true            # ignore findings without a fixed version
+  allowlist:
+    - id: CVE-2024-1234
+      package: openssl          # optional glob
+      expires: 2025-12-31       # last day the entry applies (UTC)
+      reason: not reachable
+  ignore_packages:
+    - name: kernel-*
+      version: 4.18*            # optional glob
+
+--max-severity and --fixable-only override the corresponding policy fields.
+
+Examples:
+  go-quay scan gate myorg/app:1.4.0 --policy policy.yaml
+  go-quay scan gate myorg/app@sha256:... --max-severity High --fixable-only`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		image, err := lib.ParseImageRef(args[0])
+		if err != nil {
+			return err
+		}
+		policy, err := scanGatePolicy(cmd)
+		if err != nil {
+			return err
+		}
+
+		client, err := getClient()
+		if err != nil {
+			return fmt.Errorf("creating client: %w", err)
+		}
+		digest, err := client.ResolveImageDigest(cmd.Context(), image)
+		if err != nil {
+			return err
+		}
+
+		result, err := client.CheckManifestPolicy(cmd.Context(), image.Namespace, image.Repository, digest, policy)
+		if err != nil {
+			return err
+		}
+
+		image.Reference = digest
+		printPolicySummary(os.Stderr, image, result)
+		if err := printJSON(result); err != nil {
+			return err
+		}
+		if !result.Passed {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("vulnerability policy violated: %d violation(s)", len(result.Violations))
+		}
+		return nil
+	},
+}
+
+// scanGatePolicy loads --policy, if given, and applies the inline overrides.
+func scanGatePolicy(cmd *cobra.Command) (*lib.VulnerabilityPolicy, error) {
+	policy := &lib.VulnerabilityPolicy{}
+	if scanPolicyFile != "" {
+		data, err := os.ReadFile(scanPolicyFile) // #nosec G304 -- path is an explicit CLI argument
+		if err != nil {
+			return nil, fmt.Errorf("reading policy: %w", err)
+		}
+		if policy, err = parseVulnerabilityPolicy(data); err != nil {
+			return nil, fmt.Errorf("parsing policy %s: %w", scanPolicyFile, err)
+		}
+	}
+	if cmd.Flags().Changed("max-severity") {
+		policy.MaxSeverity = scanMaxSeverity
+	}
+	if cmd.Flags().Changed("fixable-only") {
+		policy.FixableOnly = scanFixableOnly
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy: %w", err)
+	}
+	return policy, nil
+}
+
+// parseVulnerabilityPolicy decodes a YAML or JSON policy, rejecting unknown
+// fields so that misspelled rules do not silently pass.
+func parseVulnerabilityPolicy(data []byte) (*lib.VulnerabilityPolicy, error) {
+	policy := &lib.VulnerabilityPolicy{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(policy); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// printPolicySummary writes a human-readable account of a policy result.
+func printPolicySummary(out io.Writer, image lib.ImageRef, result *lib.PolicyResult) {
+	verdict := "PASSED"
+	if !result.Passed {
+		verdict = "FAILED"
+	}
+	fmt.Fprintf(out, "Vulnerability policy %s for %s\n\n", verdict, image)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SEVERITY\tFINDINGS")
+	for i := len(lib.Severities) - 1; i >= 0; i-- {
+		fmt.Fprintf(w, "%s\t%d\n", lib.Severities[i], result.Counts[lib.Severities[i]])
+	}
+	_ = w.Flush()
+
+	if len(result.Violations) > 0 {
+		fmt.Fprintln(out, "\nViolations:")
+		for _, violation := range result.Violations {
+			fmt.Fprintf(out, "  %s\n", violation.Message)
+			for _, finding := range violation.Findings {
+				fmt.Fprintf(out, "    %s\n", describeFinding(finding))
+			}
+		}
+	}
+	for _, entry := range result.ExpiredAllowlist {
+		fmt.Fprintf(out, "\nWarning: allowlist entry %s expired on %s\n", entry.ID, entry.Expires)
+	}
+	fmt.Fprintf(out, "\n%d allowed, %d ignored\n", len(result.Allowed), len(result.Ignored))
+}
+
+func describeFinding(finding lib.VulnerabilityFinding) string {
+	fix := "no fix available"
+	if finding.FixedBy != "" {
+		fix = "fixed in " + finding.FixedBy
+	}
+	return fmt.Sprintf("%s %s %s (%s)", finding.ID, finding.Package, finding.Version, fix)
+}
+
+// scanDiffCmd compares the vulnerabilities of two images
+var scanDiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compare the vulnerabilities of two tags or manifests",
+	Long: `Compare the security scans of two images and report which vulnerabilities
+were added, fixed, changed (severity or fixed version) or left unchanged, and
+which packages were added, removed or updated.
+
+--from and --to accept tag:NAME, a manifest digest, or a full
+namespace/repository[:tag|@digest] to compare across repositories. Tags are
+resolved to digests before the scans are fetched.
+
+Output is JSON by default; use --table (or -O table) for a table or
+--markdown for Markdown suitable for pull request comments.
+
+Examples:
+  go-quay scan diff -n myorg -r app --from tag:1.2 --to tag:1.3 --table
+  go-quay scan diff --from myorg/base:ubi8 --to myorg/base:ubi9 --markdown`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		from, err := parseScanDiffRef(scanDiffFrom)
+		if err != nil {
+			return fmt.Errorf("--from: %w", err)
+		}
+		to, err := parseScanDiffRef(scanDiffTo)
+		if err != nil {
+			return fmt.Errorf("--to: %w", err)
+		}
+
+		client, err := getClient()
+		if err != nil {
+			return fmt.Errorf("creating client: %w", err)
+		}
+		diff, err := client.DiffImageScans(cmd.Context(), from, to)
+		if err != nil {
+			return fmt.Errorf("comparing scans: %w", err)
+		}
+
+		switch {
+		case scanDiffMarkdown:
+			printScanDiffMarkdown(os.Stdout, diff)
+			return nil
+		case scanDiffTable || outputFormat == outputTable:
+			printScanDiffTable(os.Stdout, diff)
+			return nil
+		}
+		return printJSON(diff)
+	},
+}
+
+// parseScanDiffRef parses tag:NAME, a digest, a bare tag, or a full image
+// reference. Short forms use the --namespace and --repository flags.
+func parseScanDiffRef(value string) (lib.ImageRef, error) {
+	if strings.Contains(value, "/") {
+		return lib.ParseImageRef(value)
+	}
+	if namespace == "" || repository == "" {
+		return lib.ImageRef{}, fmt.Errorf("%q needs --namespace and --repository, or use namespace/repository:tag", value)
+	}
+	reference := strings.TrimPrefix(value, "tag:")
+	if reference == "" {
+		return lib.ImageRef{}, fmt.Errorf("tag or digest is required")
+	}
+	return lib.ImageRef{Namespace: namespace, Repository: repository, Reference: reference}, nil
+}
+
+// scanDiffRows flattens the vulnerability changes of a diff into
+// CHANGE, SEVERITY, ID, PACKAGE, VERSION and FIXED BY columns.
+func scanDiffRows(diff *lib.ScanDiff) [][]string {
+	var rows [][]string
+	for _, f := range diff.Added {
+		rows = append(rows, []string{"added", f.Severity, f.ID, f.Package, f.Version, orDash(f.FixedBy)})
+	}
+	for _, f := range diff.Removed {
+		rows = append(rows, []string{"fixed", f.Severity, f.ID, f.Package, f.Version, orDash(f.FixedBy)})
+	}
+	for _, c := range diff.Changed {
+		rows = append(rows, []string{
+			"changed", changeOf(c.From.Severity, c.To.Severity), c.To.ID, c.To.Package,
+			changeOf(c.From.Version, c.To.Version), changeOf(orDash(c.From.FixedBy), orDash(c.To.FixedBy)),
+		})
+	}
+	return rows
+}
+
+func scanDiffHeadline(diff *lib.ScanDiff) string {
+	return fmt.Sprintf("%d added, %d fixed, %d changed, %d unchanged vulnerabilities; %d package changes",
+		len(diff.Added), len(diff.Removed), len(diff.Changed), len(diff.Unchanged), len(diff.Packages))
+}
+
+func printScanDiffTable(out io.Writer, diff *lib.ScanDiff) {
+	fmt.Fprintf(out, "%s (%s) -> %s (%s)\n%s\n\n", diff.From, diff.FromDigest, diff.To, diff.ToDigest, scanDiffHeadline(diff))
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CHANGE\tSEVERITY\tID\tPACKAGE\tVERSION\tFIXED BY")
+	for _, row := range scanDiffRows(diff) {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	_ = w.Flush()
+
+	if len(diff.Packages) > 0 {
+		fmt.Fprintln(out)
+		w = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PACKAGE\tCHANGE\tFROM\tTO")
+		for _, p := range diff.Packages {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", p.Name, p.Change, orDash(p.FromVersion), orDash(p.ToVersion))
+		}
+		_ = w.Flush()
+	}
+}
+
+func printScanDiffMarkdown(out io.Writer, diff *lib.ScanDiff) {
+	fmt.Fprintf(out, "### Vulnerability diff: `%s` → `%s`\n\n%s\n", diff.From, diff.To, scanDiffHeadline(diff))
+
+	if rows := scanDiffRows(diff); len(rows) > 0 {
+		fmt.Fprint(out, "\n| Change | Severity | ID | Package | Version | Fixed by |\n| --- | --- | --- | --- | --- | --- |\n")
+		for _, row := range rows {
+			fmt.Fprintf(out, "| %s |\n", strings.Join(row, " | "))
+		}
+	}
+	if len(diff.Packages) > 0 {
+		fmt.Fprint(out, "\n| Package | Change | From | To |\n| --- | --- | --- | --- |\n")
+		for _, p := range diff.Packages {
+			fmt.Fprintf(out, "| %s | %s | %s | %s |\n", p.Name, p.Change, orDash(p.FromVersion), orDash(p.ToVersion))
+		}
+	}
+}
+
+// changeOf formats a value that may have changed as "old → new".
+func changeOf(from, to string) string {
+	if from == to {
+		return to
+	}
+	return from + " → " + to
+}
+
+func orDash(s string) string {
+	return firstNonEmpty(s, "-")
+}
+
+func init() {
+	rootCmd.AddCommand(scanCmd)
+	scanCmd.AddCommand(scanGateCmd)
+	scanCmd.AddCommand(scanDiffCmd)
+
+	scanDiffCmd.Flags().StringVarP(&namespace, "namespace", "n", appCfg.Namespace, "Namespace for tag: and digest references (default: config file)")
+	scanDiffCmd.Flags().StringVarP(&repository, "repository", "r", "", "Repository for tag: and digest references")
+	scanDiffCmd.Flags().StringVar(&scanDiffFrom, "from", "", "Older image: tag:NAME, a digest, or namespace/repository[:tag|@digest]")
+	scanDiffCmd.Flags().StringVar(&scanDiffTo, "to", "", "Newer image: tag:NAME, a digest, or namespace/repository[:tag|@digest]")
+	scanDiffCmd.Flags().BoolVar(&scanDiffTable, "table", false, "Print a table instead of JSON")
+	scanDiffCmd.Flags().BoolVar(&scanDiffMarkdown, "markdown", false, "Print Markdown instead of JSON")
+	_ = scanDiffCmd.MarkFlagRequired("from")
+	_ = scanDiffCmd.MarkFlagRequired("to")
+
+	scanGateCmd.Flags().StringVar(&scanPolicyFile, "policy", "", "Policy file (YAML or JSON)")
+	scanGateCmd.Flags().StringVar(&scanMaxSeverity, "max-severity", "", "Highest severity allowed (overrides the policy file)")
+	scanGateCmd.Flags().BoolVar(&scanFixableOnly, "fixable-only", false, "Ignore findings without a fixed version (overrides the policy file)")
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sebrandon1/go-quay/lib"
+	"github.com/spf13/cobra"
+)
+
+const (
+	inventoryFormatCSV  = "csv"
+	inventoryFormatHTML = "html"
+)
+
+var (
+	inventoryCVEs         []string
+	inventoryPackages     []string
+	inventoryRepositories []string
+	inventoryMaxTags      int
+	inventoryConcurrency  int
+	inventoryWorst        int
+	inventoryFormat       string
+	inventoryFile         string
+)
+
+// scanInventoryCmd reports the vulnerabilities of every image in a namespace
+var scanInventoryCmd = &cobra.Command{
+	Use:   "inventory [NAMESPACE]",
+	Short: "Report vulnerabilities across every image in an organization",
+	Long: `Crawl every image repository in a namespace, fetch the security scan of
+each active tag, and report per-tag counts by severity, fixable counts and the
+worst CVEs. Scans are cached by manifest digest, so a digest shared by several
+tags or repositories is fetched once. Repositories or tags that cannot be read
+are listed as errors instead of aborting the report.
+
+--cve and --package keep only matching findings and drop images with none,
+answering "which of our images are exposed to CVE-X?". Package names accept
+globs.
+
+NAMESPACE defaults to --namespace or the config file.
+
+Examples:
+  go-quay scan inventory myorg --format csv --file inventory.csv
+  go-quay scan inventory myorg --cve CVE-2024-3094 --format html --file xz.html
+  go-quay scan inventory myorg --package 'openssl*' --repo 'api-*' --max-tags 5`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ns := namespace
+		if len(args) == 1 {
+			ns = args[0]
+		}
+		if ns == "" {
+			return fmt.Errorf("namespace is required")
+		}
+		if inventoryFormat != outputJSON && inventoryFormat != inventoryFormatCSV && inventoryFormat != inventoryFormatHTML {
+			return fmt.Errorf("unsupported format %q: use json, csv or html", inventoryFormat)
+		}
+
+		client, err := getClient()
+		if err != nil {
+			return fmt.Errorf("creating client: %w", err)
+		}
+		inv, err := client.VulnerabilityInventory(cmd.Context(), ns, &lib.InventoryOptions{
+			Concurrency:          inventoryConcurrency,
+			Repositories:         inventoryRepositories,
+			MaxTagsPerRepository: inventoryMaxTags,
+			CVEs:                 inventoryCVEs,
+			Packages:             inventoryPackages,
+			Worst:                inventoryWorst,
+		})
+		if err != nil {
+			return fmt.Errorf("building inventory: %w", err)
+		}
+
+		write := func(out io.Writer) error { return writeInventory(out, inventoryFormat, inv) }
+		if inventoryFile != "" {
+			err = writeFile(inventoryFile, 0o644, write)
+		} else {
+			err = write(os.Stdout)
+		}
+		if err != nil {
+			return fmt.Errorf("writing inventory: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Inventory of %s: %d repositories, %d tags, %d unique manifests, %d images reported, %d errors\n",
+			ns, inv.Repositories, inv.TagsScanned, inv.UniqueManifests, len(inv.Images), len(inv.Errors))
+		return nil
+	},
+}
+
+func writeInventory(out io.Writer, format string, inv *lib.VulnerabilityInventory) error {
+	switch format {
+	case inventoryFormatCSV:
+		return inv.WriteCSV(out)
+	case inventoryFormatHTML:
+		return inv.WriteHTML(out)
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(inv)
+}
+
+func init() {
+	scanCmd.AddCommand(scanInventoryCmd)
+
+	scanInventoryCmd.Flags().StringVarP(&namespace, "namespace", "n", appCfg.Namespace, "Namespace to inventory (default: config file)")
+	scanInventoryCmd.Flags().StringSliceVar(&inventoryCVEs, "cve", nil, "Only report these vulnerability IDs (repeatable)")
+	scanInventoryCmd.Flags().StringSliceVar(&inventoryPackages, "package", nil, "Only report findings in packages matching these globs (repeatable)")
+	scanInventoryCmd.Flags().StringSliceVar(&inventoryRepositories, "repo", nil, "Only crawl repositories matching these globs (repeatable)")
+	scanInventoryCmd.Flags().IntVar(&inventoryMaxTags, "max-tags", 0, "Only scan the most recent N active tags per repository (0 = all)")
+	scanInventoryCmd.Flags().IntVar(&inventoryConcurrency, "concurrency", 8, "Maximum API requests in flight")
+	scanInventoryCmd.Flags().IntVar(&inventoryWorst, "worst", 5, "Number of most severe CVEs listed per image")
+	scanInventoryCmd.Flags().StringVar(&inventoryFormat, "format", outputJSON, "Output format: json, csv or html")
+	scanInventoryCmd.Flags().StringVar(&inventoryFile, "file", "", "Write the report to a file instead of stdout")
+}
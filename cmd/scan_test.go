@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testScanSecurityResponse = `{
+	"status": "scanned",
+	"data": {"Layer": {"Name": "sha256:deadbeef", "Features": [
+		{"Name": "openssl", "Version": "1.1.1k", "Vulnerabilities": [
+			{"Name": "CVE-2021-3712", "Severity": "High", "FixedBy": "1.1.1l"}
+		]},
+		{"Name": "bash", "Version": "5.0", "Vulnerabilities": [
+			{"Name": "CVE-2019-18276", "Severity": "Low"}
+		]}
+	]}}
+}`
+
+func TestParseVulnerabilityPolicy(t *testing.T) {
+	policy, err := parseVulnerabilityPolicy([]byte(`
+max_severity: Medium
+max_counts:
+  Low: 3
+allowlist:
+  - id: CVE-2021-3712
+    expires: 2025-12-31
+`))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if policy.MaxSeverity != "Medium" || policy.MaxCounts["Low"] != 3 || policy.Allowlist[0].Expires != "2025-12-31" {
+		t.Errorf("unexpected policy: %+v", policy)
+	}
+
+	if _, err := parseVulnerabilityPolicy([]byte(`{"max_severty": "High"}`)); err == nil {
+		t.Error("expected unknown field to be rejected")
+	}
+	if _, err := parseVulnerabilityPolicy(nil); err != nil {
+		t.Errorf("expected empty policy to parse, got: %v", err)
+	}
+}
+
+func TestScanGateCmd(t *testing.T) {
+	t.Cleanup(func() {
+		token = ""
+		quayURL = ""
+		scanPolicyFile = ""
+		rootCmd.SetArgs([]string{})
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/tag/1.0"):
+			_, _ = w.Write([]byte(`{"name": "1.0", "manifest_digest": "sha256:deadbeef"}`))
+		case strings.HasSuffix(r.URL.Path, "/manifest/sha256:deadbeef/security"):
+			_, _ = w.Write([]byte(testScanSecurityResponse))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	strict := filepath.Join(dir, "strict.yaml")
+	lenient := filepath.Join(dir, "lenient.yaml")
+	_ = os.WriteFile(strict, []byte("max_severity: Medium\n"), 0o600)
+	_ = os.WriteFile(lenient, []byte("max_severity: Medium\nallowlist:\n  - id: CVE-2021-3712\n"), 0o600)
+
+	run := func(policyFile string) (string, error) {
+		oldStdout := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+
+		rootCmd.SetArgs([]string{
+			"scan", "gate", testTokenFlag, testTokenValue, testQuayURLFlag, server.URL,
+			testNamespace + "/" + testRepository + ":1.0", "--policy", policyFile,
+		})
+		err := rootCmd.Execute()
+
+		w.Close()
+		os.Stdout = oldStdout
+		var buf bytes.Buffer
+		_, _ = io.Copy(&buf, r)
+		return buf.String(), err
+	}
+
+	output, err := run(strict)
+	if err == nil || !strings.Contains(err.Error(), "1 violation(s)") {
+		t.Errorf("expected policy violation error, got: %v", err)
+	}
+	if !strings.Contains(output, `"passed": false`) || !strings.Contains(output, `"rule": "max_severity"`) {
+		t.Errorf("expected failing result in output, got: %s", output)
+	}
+
+	output, err = run(lenient)
+	if err != nil {
+		t.Fatalf("expected allowlisted finding to pass, got: %v", err)
+	}
+	if !strings.Contains(output, `"passed": true`) {
+		t.Errorf("expected passing result in output, got: %s", output)
+	}
+}
+
+func TestScanDiffCmdMarkdown(t *testing.T) {
+	t.Cleanup(func() {
+		token = ""
+		quayURL = ""
+		namespace = ""
+		repository = ""
+		scanDiffFrom = ""
+		scanDiffTo = ""
+		scanDiffMarkdown = false
+		rootCmd.SetArgs([]string{})
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/tag/1.2"):
+			_, _ = w.Write([]byte(`{"name": "1.2", "manifest_digest": "sha256:old"}`))
+		case strings.HasSuffix(r.URL.Path, "/tag/1.3"):
+			_, _ = w.Write([]byte(`{"name": "1.3", "manifest_digest": "sha256:deadbeef"}`))
+		case strings.HasSuffix(r.URL.Path, "/manifest/sha256:old/security"):
+			_, _ = w.Write([]byte(`{"status": "scanned", "data": {"Layer": {"Features": [
+				{"Name": "openssl", "Version": "1.1.1j", "Vulnerabilities": [
+					{"Name": "CVE-2021-3449", "Severity": "Medium", "FixedBy": "1.1.1k"}
+				]}
+			]}}}`))
+		case strings.HasSuffix(r.URL.Path, "/manifest/sha256:deadbeef/security"):
+			_, _ = w.Write([]byte(testScanSecurityResponse))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	rootCmd.SetArgs([]string{
+		"scan", "diff", testTokenFlag, testTokenValue, testQuayURLFlag, server.URL,
+		"-n", testNamespace, "-r", testRepository, "--from", "tag:1.2", "--to", "tag:1.3", "--markdown",
+	})
+	err := rootCmd.Execute()
+
+	w.Close()
+	os.Stdout = oldStdout
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	var buf bytes.Buffer
+	_, _ = io.Copy(&buf, r)
+	output := buf.String()
+	for _, want := range []string{
+		"2 added, 1 fixed, 0 changed",
+		"| added | High | CVE-2021-3712 | openssl | 1.1.1k | 1.1.1l |",
+		"| fixed | Medium | CVE-2021-3449 | openssl | 1.1.1j | 1.1.1k |",
+		"| openssl | updated | 1.1.1j | 1.1.1k |",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in output, got: %s", want, output)
+		}
+	}
+}
+
+func TestParseScanDiffRef(t *testing.T) {
+	t.Cleanup(func() {
+		namespace = ""
+		repository = ""
+	})
+
+	namespace, repository = "", ""
+	if _, err := parseScanDiffRef("tag:1.2"); err == nil {
+		t.Error("expected short reference without repository to fail")
+	}
+	if ref, err := parseScanDiffRef("myorg/base:ubi9"); err != nil || ref.Reference != "ubi9" {
+		t.Errorf("expected full reference to parse, got %+v (%v)", ref, err)
+	}
+
+	namespace, repository = testNamespace, testRepository
+	for input, want := range map[string]string{"tag:1.2": "1.2", "sha256:abc": "sha256:abc", "latest": "latest"} {
+		ref, err := parseScanDiffRef(input)
+		if err != nil || ref.Reference != want || ref.Repository != testRepository {
+			t.Errorf("parseScanDiffRef(%q) = %+v (%v), want reference %q", input, ref, err, want)
+		}
+	}
+}
+
+func TestScanInventoryCmdCSV(t *testing.T) {
+	t.Cleanup(func() {
+		token = ""
+		quayURL = ""
+		inventoryCVEs = nil
+		inventoryFormat = outputJSON
+		rootCmd.SetArgs([]string{})
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/repository":
+			_, _ = w.Write([]byte(`{"repositories": [{"namespace": "testns", "name": "testrepo", "kind": "image"}]}`))
+		case strings.HasSuffix(r.URL.Path, "/testrepo/tag/"):
+			_, _ = w.Write([]byte(`{"tags": [{"name": "1.0", "manifest_digest": "sha256:deadbeef"}]}`))
+		case strings.HasSuffix(r.URL.Path, "/manifest/sha256:deadbeef/security"):
+			_, _ = w.Write([]byte(testScanSecurityResponse))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	rootCmd.SetArgs([]string{
+		"scan", "inventory", testNamespace, testTokenFlag, testTokenValue, testQuayURLFlag, server.URL,
+		"--cve", "CVE-2021-3712", "--format", "csv",
+	})
+	err := rootCmd.Execute()
+
+	w.Close()
+	os.Stdout = oldStdout
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	var buf bytes.Buffer
+	_, _ = io.Copy(&buf, r)
+	if want := "testrepo,1.0,sha256:deadbeef,scanned,1,1,0,1,0,0,0,0,CVE-2021-3712"; !strings.Contains(buf.String(), want) {
+		t.Errorf("expected %q in output, got: %s", want, buf.String())
+	}
+}
@@ -2,14 +2,20 @@ package cmd
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"time"
 
+	"github.com/sebrandon1/go-quay/lib"
 	"github.com/spf13/cobra"
 )
 
 var (
 	secScanManifestRef     string
 	includeVulnerabilities bool
+	secScanWait            bool
+	secScanWaitTimeout     time.Duration
+	secScanFormat          string
 )
 
 // secscanCmd represents the secscan command group
@@ -36,23 +42,70 @@ The scan status can be:
   - queued: Scan is queued and pending
   - scanning: Scan is currently in progress
   - unsupported: Image type is not supported for scanning
-  - failed: Scan failed`,
+  - failed: Scan failed
+
+--format converts the results for other tools: sarif (GitHub code scanning),
+cyclonedx (CycloneDX 1.5 VEX) or junit (CI test reports). These formats need
+a completed scan with vulnerabilities.
+
+With --wait, the command polls until the scan is scanned, failed or
+unsupported, and exits non-zero unless it ends up scanned.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		client, err := getClient()
 		if err != nil {
 			return fmt.Errorf("creating client: %w", err)
 		}
 
-		security, err := client.GetManifestSecurity(cmd.Context(), namespace, repository, secScanManifestRef, includeVulnerabilities)
+		var security *lib.SecurityScan
+		if secScanWait {
+			security, err = client.WaitForSecurityScan(cmd.Context(), namespace, repository, secScanManifestRef, &lib.WaitForScanOptions{
+				Vulnerabilities: includeVulnerabilities,
+				Timeout:         secScanWaitTimeout,
+				OnPoll: func(scan *lib.SecurityScan) {
+					fmt.Fprintf(os.Stderr, "Waiting for security scan (status: %s)...\n", scan.Status)
+				},
+			})
+		} else {
+			security, err = client.GetManifestSecurity(cmd.Context(), namespace, repository, secScanManifestRef, includeVulnerabilities)
+		}
 		if err != nil {
 			return fmt.Errorf("getting security scan: %w", err)
 		}
 
 		fmt.Fprintf(os.Stderr, "Security scan for %s/%s@%s\n", namespace, repository, secScanManifestRef)
-		return printJSON(security)
+		if err := printSecurityScan(security); err != nil {
+			return err
+		}
+		if secScanWait && security.Status != lib.ScanStatusScanned {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("security scan finished with status %q", security.Status)
+		}
+		return nil
 	},
 }
 
+// printSecurityScan prints a scan as JSON, or converted with --format.
+func printSecurityScan(security *lib.SecurityScan) error {
+	if secScanFormat == "" || secScanFormat == outputJSON {
+		return printJSON(security)
+	}
+	return lib.WriteSecurityReport(os.Stdout, secScanFormat, security, lib.ScanReportInfo{
+		Image:       registryImageName(namespace, repository),
+		Digest:      secScanManifestRef,
+		ToolVersion: rootCmd.Version,
+	})
+}
+
+// registryImageName returns host/namespace/repository for the configured Quay
+// instance, or namespace/repository if the host cannot be determined.
+func registryImageName(ns, repo string) string {
+	name := ns + "/" + repo
+	if u, err := url.Parse(quayURL); err == nil && u.Host != "" {
+		return u.Host + "/" + name
+	}
+	return name
+}
+
 func init() {
 	// Add subcommands to secscan command
 	secscanCmd.AddCommand(secscanInfoCmd)
@@ -71,4 +124,7 @@ func init() {
 
 	// Info command specific flags
 	secscanInfoCmd.Flags().BoolVarP(&includeVulnerabilities, "vulnerabilities", "V", true, "Include vulnerability details in the response")
+	secscanInfoCmd.Flags().StringVar(&secScanFormat, "format", outputJSON, "Result format: json, sarif, cyclonedx, or junit")
+	secscanInfoCmd.Flags().BoolVar(&secScanWait, "wait", false, "Poll until the scan reaches a terminal status")
+	secscanInfoCmd.Flags().DurationVar(&secScanWaitTimeout, "wait-timeout", 10*time.Minute, "Maximum time to wait with --wait (0 waits indefinitely)")
 }
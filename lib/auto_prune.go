@@ -24,7 +24,7 @@ func (c *Client) GetAutoPrunePolicies(ctx context.Context, orgname string) (*Aut
 		return nil, fmt.Errorf("orgname is required")
 	}
 
-	req, err := newRequest(ctx, http.MethodGet, c.buildURL("/organization/%s/autoprunepolicy", orgname), nil)
+	req, err := newAPIRequest(ctx, http.MethodGet, c.buildURL("/organization/%s/autoprunepolicy", orgname), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create get auto-prune policies request: %w", err)
 	}
@@ -46,7 +46,7 @@ func (c *Client) CreateAutoPrunePolicy(ctx context.Context, orgname, method stri
 		return nil, fmt.Errorf("method is required")
 	}
 
-	req, err := newRequestWithBody(ctx, http.MethodPost, c.buildURL("/organization/%s/autoprunepolicy", orgname), CreateAutoPruneRequest{
+	req, err := newAPIRequestWithBody(ctx, http.MethodPost, c.buildURL("/organization/%s/autoprunepolicy", orgname), CreateAutoPruneRequest{
 		Method:     method,
 		Value:      value,
 		TagPattern: tagPattern,
@@ -72,7 +72,7 @@ func (c *Client) GetAutoPrunePolicy(ctx context.Context, orgname, policyUUID str
 		return nil, fmt.Errorf("policyUUID is required")
 	}
 
-	req, err := newRequest(ctx, http.MethodGet, c.buildURL("/organization/%s/autoprunepolicy/%s", orgname, policyUUID), nil)
+	req, err := newAPIRequest(ctx, http.MethodGet, c.buildURL("/organization/%s/autoprunepolicy/%s", orgname, policyUUID), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create get auto-prune policy request: %w", err)
 	}
@@ -97,7 +97,7 @@ func (c *Client) UpdateAutoPrunePolicy(ctx context.Context, orgname, policyUUID,
 		return nil, fmt.Errorf("method is required")
 	}
 
-	req, err := newRequestWithBody(ctx, http.MethodPut, c.buildURL("/organization/%s/autoprunepolicy/%s", orgname, policyUUID), CreateAutoPruneRequest{
+	req, err := newAPIRequestWithBody(ctx, http.MethodPut, c.buildURL("/organization/%s/autoprunepolicy/%s", orgname, policyUUID), CreateAutoPruneRequest{
 		Method:     method,
 		Value:      value,
 		TagPattern: tagPattern,
@@ -123,7 +123,7 @@ func (c *Client) DeleteAutoPrunePolicy(ctx context.Context, orgname, policyUUID
 		return fmt.Errorf("policyUUID is required")
 	}
 
-	req, err := newRequest(ctx, http.MethodDelete, c.buildURL("/organization/%s/autoprunepolicy/%s", orgname, policyUUID), nil)
+	req, err := newAPIRequest(ctx, http.MethodDelete, c.buildURL("/organization/%s/autoprunepolicy/%s", orgname, policyUUID), nil)
 	if err != nil {
 		return fmt.Errorf("failed to create delete auto-prune policy request: %w", err)
 	}
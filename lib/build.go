@@ -8,7 +8,12 @@ Build Management:
   - GET    /api/v1/repository/{namespace}/{repository}/build/{build_uuid}        - GetBuild()
   - POST   /api/v1/repository/{namespace}/{repository}/build/                    - RequestBuild()
   - DELETE /api/v1/repository/{namespace}/{repository}/build/{build_uuid}        - CancelBuild()
-  - GET    /api/v1/repository/{namespace}/{repository}/build/{build_uuid}/logs   - GetBuildLogs()
+  - GET    /api/v1/repository/{namespace}/{repository}/build/{build_uuid}/logs   - GetBuildLogs(), GetBuildLogsFrom()
+
+Following Builds:
+  - FollowBuildLogs(ctx, ns, repo, uuid, opts) iter.Seq2[BuildLogEntry, error] - Stream new log entries until the build ends
+  - WaitForBuild(ctx, ns, repo, uuid, opts) (*BuildResult, error)               - Poll until the build ends and resolve its tag digests
+  - IsTerminalBuildPhase(phase string) bool - Whether a build phase will no longer change
 
 Builds allow automated image creation from Dockerfiles stored in git repositories
 or uploaded archives.
@@ -16,11 +21,76 @@ or uploaded archives.
 package lib
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
+	"iter"
 	"net/http"
+	"strconv"
+	"time"
+)
+
+// Build phases reported in Build.Phase and BuildStatus.Phase.
+const (
+	BuildPhaseWaiting       = "waiting"
+	BuildPhaseUnpacking     = "unpacking"
+	BuildPhasePulling       = "pulling"
+	BuildPhaseBuilding      = "building"
+	BuildPhasePushing       = "pushing"
+	BuildPhaseComplete      = "complete"
+	BuildPhaseError         = "error"
+	BuildPhaseInternalError = "internalerror"
+	BuildPhaseCancelled     = "cancelled"
+	BuildPhaseExpired       = "expired"
 )
 
+// Build log entry types reported in BuildLogEntry.Type. Plain output lines
+// have no type.
+const (
+	BuildLogTypeCommand = "command"
+	BuildLogTypePhase   = "phase"
+	BuildLogTypeError   = "error"
+)
+
+// DefaultBuildPollInterval is the polling interval of FollowBuildLogs and WaitForBuild.
+const DefaultBuildPollInterval = 2 * time.Second
+
+// buildCancelTimeout bounds the CancelBuild request sent when a wait is abandoned.
+const buildCancelTimeout = 30 * time.Second
+
+// FollowBuildLogsOptions controls FollowBuildLogs.
+type FollowBuildLogsOptions struct {
+	// Start is the index of the first log entry to return.
+	Start int
+	// PollInterval is the delay between polls. Defaults to DefaultBuildPollInterval.
+	PollInterval time.Duration
+	// OnStatus, if set, is called with each build status fetched.
+	OnStatus func(*BuildStatus)
+}
+
+// WaitForBuildOptions controls WaitForBuild.
+type WaitForBuildOptions struct {
+	// PollInterval is the delay between polls. Defaults to DefaultBuildPollInterval.
+	PollInterval time.Duration
+	// Timeout bounds the wait. Zero waits until ctx is done.
+	Timeout time.Duration
+	// CancelOnAbort cancels the build with CancelBuild when the wait ends
+	// before the build does, because of Timeout or ctx.
+	CancelOnAbort bool
+	// OnPoll, if set, is called with the build after each poll that has not
+	// reached a terminal phase.
+	OnPoll func(*Build)
+}
+
+// BuildResult is the final state of a build.
+type BuildResult struct {
+	Build *Build `json:"build"`
+	// Digests maps each tag of a complete build to the manifest digest it
+	// points to.
+	Digests map[string]string `json:"digests,omitempty"`
+}
+
 // GetBuilds retrieves a list of builds for a repository
 func (c *Client) GetBuilds(ctx context.Context, namespace, repository string, limit int) (*Builds, error) {
 	if namespace == "" {
@@ -30,16 +100,15 @@ func (c *Client) GetBuilds(ctx context.Context, namespace, repository string, li
 		return nil, fmt.Errorf("repository is required")
 	}
 
-	url := c.buildURL("/repository/%s/%s/build/", namespace, repository)
-	if limit > 0 {
-		url = fmt.Sprintf("%s?limit=%d", url, limit)
-	}
-
-	req, err := newRequest(ctx, http.MethodGet, url, nil)
+	req, err := newAPIRequest(ctx, http.MethodGet, c.buildURL("/repository/%s/%s/build/", namespace, repository), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create get builds request: %w", err)
 	}
 
+	if limit > 0 {
+		addQueryParams(req, map[string]string{"limit": fmt.Sprintf("%d", limit)})
+	}
+
 	var builds Builds
 	if err := c.get(req, &builds); err != nil {
 		return nil, fmt.Errorf("failed to get builds: %w", err)
@@ -60,7 +129,7 @@ func (c *Client) GetBuild(ctx context.Context, namespace, repository, buildUUID
 		return nil, fmt.Errorf("buildUUID is required")
 	}
 
-	req, err := newRequest(ctx, http.MethodGet, c.buildURL("/repository/%s/%s/build/%s", namespace, repository, buildUUID), nil)
+	req, err := newAPIRequest(ctx, http.MethodGet, c.buildURL("/repository/%s/%s/build/%s", namespace, repository, buildUUID), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create get build request: %w", err)
 	}
@@ -75,6 +144,12 @@ func (c *Client) GetBuild(ctx context.Context, namespace, repository, buildUUID
 
 // GetBuildLogs retrieves the logs for a specific build
 func (c *Client) GetBuildLogs(ctx context.Context, namespace, repository, buildUUID string) (*BuildLogs, error) {
+	return c.GetBuildLogsFrom(ctx, namespace, repository, buildUUID, 0)
+}
+
+// GetBuildLogsFrom retrieves the logs for a specific build, starting at the
+// entry with index start.
+func (c *Client) GetBuildLogsFrom(ctx context.Context, namespace, repository, buildUUID string, start int) (*BuildLogs, error) {
 	if namespace == "" {
 		return nil, fmt.Errorf("namespace is required")
 	}
@@ -85,11 +160,15 @@ func (c *Client) GetBuildLogs(ctx context.Context, namespace, repository, buildU
 		return nil, fmt.Errorf("buildUUID is required")
 	}
 
-	req, err := newRequest(ctx, http.MethodGet, c.buildURL("/repository/%s/%s/build/%s/logs", namespace, repository, buildUUID), nil)
+	req, err := newAPIRequest(ctx, http.MethodGet, c.buildURL("/repository/%s/%s/build/%s/logs", namespace, repository, buildUUID), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create get build logs request: %w", err)
 	}
 
+	if start > 0 {
+		addQueryParams(req, map[string]string{"start": strconv.Itoa(start)})
+	}
+
 	var logs BuildLogs
 	if err := c.get(req, &logs); err != nil {
 		return nil, fmt.Errorf("failed to get build logs: %w", err)
@@ -107,7 +186,7 @@ func (c *Client) RequestBuild(ctx context.Context, namespace, repository string,
 		return nil, fmt.Errorf("repository is required")
 	}
 
-	req, err := newRequestWithBody(ctx, http.MethodPost, c.buildURL("/repository/%s/%s/build/", namespace, repository), buildRequest)
+	req, err := newAPIRequestWithBody(ctx, http.MethodPost, c.buildURL("/repository/%s/%s/build/", namespace, repository), buildRequest)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request build request: %w", err)
 	}
@@ -132,7 +211,7 @@ func (c *Client) CancelBuild(ctx context.Context, namespace, repository, buildUU
 		return fmt.Errorf("buildUUID is required")
 	}
 
-	req, err := newRequest(ctx, http.MethodDelete, c.buildURL("/repository/%s/%s/build/%s", namespace, repository, buildUUID), nil)
+	req, err := newAPIRequest(ctx, http.MethodDelete, c.buildURL("/repository/%s/%s/build/%s", namespace, repository, buildUUID), nil)
 	if err != nil {
 		return fmt.Errorf("failed to create cancel build request: %w", err)
 	}
@@ -156,7 +235,7 @@ func (c *Client) GetBuildStatus(ctx context.Context, namespace, repository, buil
 		return nil, fmt.Errorf("buildUUID is required")
 	}
 
-	req, err := newRequest(ctx, http.MethodGet, c.buildURL("/repository/%s/%s/build/%s/status", namespace, repository, buildUUID), nil)
+	req, err := newAPIRequest(ctx, http.MethodGet, c.buildURL("/repository/%s/%s/build/%s/status", namespace, repository, buildUUID), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create get build status request: %w", err)
 	}
@@ -168,3 +247,143 @@ func (c *Client) GetBuildStatus(ctx context.Context, namespace, repository, buil
 
 	return &status, nil
 }
+
+// FollowBuildLogs streams the log entries of a build as they are written. It
+// polls the build status and the logs from the last entry seen, yields only
+// new entries, and stops once the build reaches a terminal phase and its
+// remaining logs have been yielded. A request error or a cancelled context is
+// yielded once as the final element.
+func (c *Client) FollowBuildLogs(ctx context.Context, namespace, repository, buildUUID string, opts *FollowBuildLogsOptions) iter.Seq2[BuildLogEntry, error] {
+	if opts == nil {
+		opts = &FollowBuildLogsOptions{}
+	}
+	interval := cmp.Or(opts.PollInterval, DefaultBuildPollInterval)
+
+	return func(yield func(BuildLogEntry, error) bool) {
+		next := max(opts.Start, 0)
+		for {
+			// Fetch the status before the logs so that the logs fetched
+			// after a terminal status are complete.
+			status, err := c.GetBuildStatus(ctx, namespace, repository, buildUUID)
+			if err != nil {
+				yield(BuildLogEntry{}, err)
+				return
+			}
+			if opts.OnStatus != nil {
+				opts.OnStatus(status)
+			}
+
+			logs, err := c.GetBuildLogsFrom(ctx, namespace, repository, buildUUID, next)
+			if err != nil {
+				yield(BuildLogEntry{}, err)
+				return
+			}
+			// Archived logs of finished builds may be returned in full
+			// regardless of start.
+			skip := min(max(next-logs.Start, 0), len(logs.Logs))
+			for _, entry := range logs.Logs[skip:] {
+				if !yield(entry, nil) {
+					return
+				}
+			}
+			next = max(next, logs.Start+len(logs.Logs))
+
+			if IsTerminalBuildPhase(status.Phase) {
+				return
+			}
+			t := time.NewTimer(interval)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				yield(BuildLogEntry{}, ctx.Err())
+				return
+			case <-t.C:
+			}
+		}
+	}
+}
+
+// WaitForBuild polls a build until it reaches a terminal phase and returns
+// it. Reaching a phase other than complete is not an error; check
+// Build.Phase. For a complete build the manifest digest of each of its tags is
+// resolved; if that fails the result is returned along with the error.
+//
+// If Timeout expires or ctx is done first, the returned error wraps
+// context.DeadlineExceeded or context.Canceled, and the build is cancelled
+// when CancelOnAbort is set.
+func (c *Client) WaitForBuild(ctx context.Context, namespace, repository, buildUUID string, opts *WaitForBuildOptions) (*BuildResult, error) {
+	if opts == nil {
+		opts = &WaitForBuildOptions{}
+	}
+	waitCtx := ctx
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	build, err := c.pollBuild(waitCtx, namespace, repository, buildUUID, opts)
+	if err != nil {
+		if waitCtx.Err() != nil && opts.CancelOnAbort {
+			cancelCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), buildCancelTimeout)
+			defer cancel()
+			if cancelErr := c.CancelBuild(cancelCtx, namespace, repository, buildUUID); cancelErr != nil {
+				err = errors.Join(err, cancelErr)
+			}
+		}
+		return nil, err
+	}
+
+	result := &BuildResult{Build: build}
+	if build.Phase != BuildPhaseComplete {
+		return result, nil
+	}
+	result.Digests = make(map[string]string, len(build.Tags))
+	for _, tag := range build.Tags {
+		t, err := c.GetTag(ctx, namespace, repository, tag)
+		if err != nil {
+			return result, fmt.Errorf("failed to resolve built tag %s: %w", tag, err)
+		}
+		result.Digests[tag] = t.ManifestDigest
+	}
+	return result, nil
+}
+
+// pollBuild fetches a build until it reaches a terminal phase or ctx is done.
+func (c *Client) pollBuild(ctx context.Context, namespace, repository, buildUUID string, opts *WaitForBuildOptions) (*Build, error) {
+	interval := cmp.Or(opts.PollInterval, DefaultBuildPollInterval)
+	phase := BuildPhaseWaiting
+	for {
+		build, err := c.GetBuild(ctx, namespace, repository, buildUUID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("build %s is still %q: %w", buildUUID, phase, ctx.Err())
+			}
+			return nil, err
+		}
+		if IsTerminalBuildPhase(build.Phase) {
+			return build, nil
+		}
+		phase = build.Phase
+		if opts.OnPoll != nil {
+			opts.OnPoll(build)
+		}
+
+		t := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, fmt.Errorf("build %s is still %q: %w", buildUUID, phase, ctx.Err())
+		case <-t.C:
+		}
+	}
+}
+
+// IsTerminalBuildPhase reports whether a build in phase will no longer change.
+func IsTerminalBuildPhase(phase string) bool {
+	switch phase {
+	case BuildPhaseComplete, BuildPhaseError, BuildPhaseInternalError, BuildPhaseCancelled, BuildPhaseExpired:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,370 @@
+/*
+Package lib provides Quay.io API client functionality.
+
+This file covers LOCAL BUILD CONTEXT uploads:
+
+File Drop:
+  - POST /api/v1/filedrop/  - RequestFileDrop()
+  - PUT  {upload url}       - UploadFileDrop()
+
+Build Contexts:
+  - WriteBuildContext(w, dir, dockerfile) error - Gzipped tarball of a directory, honoring .dockerignore
+  - (*Client).RequestBuildFromDirectory(ctx, ns, repo, dir, req) (*Build, error) - Archive, upload and build a local directory
+
+A file drop is Quay's two-step upload: the API returns a pre-signed URL and a
+file ID, the archive is PUT to the URL, and the file ID is passed to
+RequestBuild in place of an archive URL. The upload goes directly to Quay's
+storage, so it is sent without the API token and bypasses Retry, Middleware,
+RateLimiter and Telemetry.
+
+.dockerignore follows Docker's rules: one pattern per line, "#" comments,
+"*", "?", "[...]" and "**" wildcards, "!" exceptions, and the last matching
+pattern wins. A pattern that matches a directory excludes everything in it.
+The Dockerfile and .dockerignore are always included, as Docker does.
+*/
+package lib
+
+import (
+	"archive/tar"
+	"bufio"
+	"cmp"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// BuildContextMimeType is the MIME type of archives written by WriteBuildContext.
+const BuildContextMimeType = "application/gzip"
+
+const (
+	defaultDockerfile = "Dockerfile"
+	dockerignoreFile  = ".dockerignore"
+)
+
+// RequestFileDrop asks Quay for a URL to upload a build context of the given
+// MIME type to.
+func (c *Client) RequestFileDrop(ctx context.Context, mimeType string) (*FileDrop, error) {
+	if mimeType == "" {
+		return nil, fmt.Errorf("mimeType is required")
+	}
+
+	req, err := newAPIRequestWithBody(ctx, http.MethodPost, c.buildURL("/filedrop/"), &FileDropRequest{MimeType: mimeType})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file drop request: %w", err)
+	}
+
+	var drop FileDrop
+	if err := c.post(req, &drop); err != nil {
+		return nil, fmt.Errorf("failed to request file drop: %w", err)
+	}
+
+	return &drop, nil
+}
+
+// UploadFileDrop uploads size bytes from content to the URL of a file drop.
+// mimeType must match the one passed to RequestFileDrop. The upload is not
+// bounded by HTTPClient.Timeout, since a large context can take longer to
+// send; cancel ctx to stop it.
+func (c *Client) UploadFileDrop(ctx context.Context, drop *FileDrop, mimeType string, content io.Reader, size int64) error {
+	if drop == nil || drop.URL == "" {
+		return fmt.Errorf("file drop URL is required")
+	}
+
+	req, err := newRequest(ctx, http.MethodPut, drop.URL, content)
+	if err != nil {
+		return fmt.Errorf("failed to create file drop upload request: %w", err)
+	}
+	req.ContentLength = size
+	req.Header.Set(headerContentType, mimeType)
+
+	resp, err := streamingHTTPClient(c.HTTPClient).Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload file drop: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		return fmt.Errorf("failed to upload file drop: unexpected status code: %d, response: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// RequestBuildFromDirectory archives dir with WriteBuildContext, uploads it
+// through a file drop and requests a build of it. buildRequest supplies the
+// tags, Dockerfile path and other options; its FileID and ArchiveURL are
+// replaced.
+func (c *Client) RequestBuildFromDirectory(ctx context.Context, namespace, repository, dir string, buildRequest *RequestBuildRequest) (*Build, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("dir is required")
+	}
+	request := RequestBuildRequest{}
+	if buildRequest != nil {
+		request = *buildRequest
+	}
+
+	archive, err := os.CreateTemp("", "go-quay-build-*.tar.gz")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create build context archive: %w", err)
+	}
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+
+	dockerfile := path.Join(request.Subdirectory, cmp.Or(request.DockerfilePath, defaultDockerfile))
+	if err := WriteBuildContext(archive, dir, dockerfile); err != nil {
+		return nil, err
+	}
+	size, err := archive.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read build context archive: %w", err)
+	}
+	if _, err := archive.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to read build context archive: %w", err)
+	}
+
+	drop, err := c.RequestFileDrop(ctx, BuildContextMimeType)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.UploadFileDrop(ctx, drop, BuildContextMimeType, archive, size); err != nil {
+		return nil, err
+	}
+
+	request.FileID = drop.FileID
+	request.ArchiveURL = ""
+	return c.RequestBuild(ctx, namespace, repository, &request)
+}
+
+// WriteBuildContext writes dir to w as a gzipped tarball, leaving out paths
+// excluded by dir/.dockerignore. dockerfile is the path of the Dockerfile
+// relative to dir ("Dockerfile" if empty); it is included even if ignored.
+func WriteBuildContext(w io.Writer, dir, dockerfile string) error {
+	ignore, err := readDockerignore(filepath.Join(dir, dockerignoreFile))
+	if err != nil {
+		return err
+	}
+	keep := map[string]bool{
+		path.Clean(strings.TrimPrefix(filepath.ToSlash(cmp.Or(dockerfile, defaultDockerfile)), "/")): true,
+		dockerignoreFile: true,
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	err = filepath.WalkDir(dir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !keep[rel] && ignore.excludes(rel) {
+			if entry.IsDir() && !ignore.hasExceptions() && !keepsWithin(keep, rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		return addToTar(tw, file, rel, entry)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to archive build context: %w", err)
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to archive build context: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to archive build context: %w", err)
+	}
+	return nil
+}
+
+// keepsWithin reports whether a path that is always included lies in dir.
+func keepsWithin(keep map[string]bool, dir string) bool {
+	for name := range keep {
+		if strings.HasPrefix(name, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// addToTar writes the header and, for regular files, the content of file.
+func addToTar(tw *tar.Writer, file, name string, entry fs.DirEntry) error {
+	info, err := entry.Info()
+	if err != nil {
+		return err
+	}
+	link := ""
+	if info.Mode()&fs.ModeSymlink != 0 {
+		if link, err = os.Readlink(file); err != nil {
+			return err
+		}
+	}
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	if info.IsDir() {
+		header.Name += "/"
+	}
+	header.Uid, header.Gid, header.Uname, header.Gname = 0, 0, "", ""
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	f, err := os.Open(file) // #nosec G304 -- file is within the build context being archived
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// dockerignore is a parsed .dockerignore file.
+type dockerignore []ignoreRule
+
+type ignoreRule struct {
+	exception bool
+	pattern   *regexp.Regexp
+}
+
+// readDockerignore parses the .dockerignore at file. A missing file ignores nothing.
+func readDockerignore(file string) (dockerignore, error) {
+	f, err := os.Open(file) // #nosec G304 -- .dockerignore of the build context
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", dockerignoreFile, err)
+	}
+	defer f.Close()
+
+	rules, err := parseDockerignore(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", dockerignoreFile, err)
+	}
+	return rules, nil
+}
+
+func parseDockerignore(r io.Reader) (dockerignore, error) {
+	var rules dockerignore
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule := ignoreRule{}
+		if strings.HasPrefix(line, "!") {
+			rule.exception = true
+			line = strings.TrimSpace(line[1:])
+		}
+		pattern := strings.TrimPrefix(path.Clean(filepath.ToSlash(line)), "/")
+		if pattern == "." || pattern == "" {
+			continue
+		}
+		re, err := ignorePatternRegexp(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", line, err)
+		}
+		rule.pattern = re
+		rules = append(rules, rule)
+	}
+	return rules, scanner.Err()
+}
+
+// excludes reports whether name, a slash-separated path relative to the
+// context root, is excluded. The last rule matching name or one of its parent
+// directories decides.
+func (d dockerignore) excludes(name string) bool {
+	excluded := false
+	for _, rule := range d {
+		if rule.matches(name) {
+			excluded = !rule.exception
+		}
+	}
+	return excluded
+}
+
+// hasExceptions reports whether any rule re-includes paths, in which case
+// excluded directories must still be walked.
+func (d dockerignore) hasExceptions() bool {
+	for _, rule := range d {
+		if rule.exception {
+			return true
+		}
+	}
+	return false
+}
+
+func (r ignoreRule) matches(name string) bool {
+	for {
+		if r.pattern.MatchString(name) {
+			return true
+		}
+		i := strings.LastIndexByte(name, '/')
+		if i < 0 {
+			return false
+		}
+		name = name[:i]
+	}
+}
+
+// ignorePatternRegexp translates a .dockerignore pattern into an anchored
+// regular expression.
+func ignorePatternRegexp(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch ch := pattern[i]; ch {
+		case '*':
+			if !strings.HasPrefix(pattern[i:], "**") {
+				b.WriteString("[^/]*")
+				continue
+			}
+			i++
+			if strings.HasPrefix(pattern[i+1:], "/") {
+				i++
+				b.WriteString("(.*/)?")
+			} else {
+				b.WriteString(".*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+			}
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
@@ -0,0 +1,221 @@
+package lib
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDockerignoreExcludes(t *testing.T) {
+	rules, err := parseDockerignore(strings.NewReader(`
+# comment
+*.log
+/build
+**/node_modules
+docs/*.md
+!docs/README.md
+src/**/*.tmp
+temp?
+[abc].txt
+`))
+	if err != nil {
+		t.Fatalf("parseDockerignore returned error: %v", err)
+	}
+
+	for name, want := range map[string]bool{
+		"app.log":                  true,
+		"sub/app.log":              false,
+		"build":                    true,
+		"build/out/bin":            true,
+		"node_modules/x/index.js":  true,
+		"web/node_modules/y.js":    true,
+		"docs/guide.md":            true,
+		"docs/README.md":           false,
+		"docs/api/guide.md":        false,
+		"src/a/b/c.tmp":            true,
+		"src/c.tmp":                true,
+		"temp1":                    true,
+		"temp12":                   false,
+		"a.txt":                    true,
+		"d.txt":                    false,
+		"main.go":                  false,
+		"buildfiles/keep.txt":      false,
+		"Dockerfile":               false,
+		"internal/build/output.go": false,
+	} {
+		if got := rules.excludes(name); got != want {
+			t.Errorf("excludes(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+// archiveNames lists the entries of a gzipped tarball.
+func archiveNames(t *testing.T, data []byte) []string {
+	t.Helper()
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("gzip.NewReader: %v", err)
+	}
+	tr := tar.NewReader(gz)
+	var names []string
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return names
+		}
+		if err != nil {
+			t.Fatalf("reading archive: %v", err)
+		}
+		names = append(names, header.Name)
+	}
+}
+
+func writeTestContext(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"Dockerfile":         "FROM scratch\n",
+		".dockerignore":      ".git\n*.log\nsecrets\nDockerfile\n",
+		"main.go":            "package main\n",
+		"debug.log":          "noise",
+		".git/HEAD":          "ref: refs/heads/main\n",
+		"secrets/token":      "s3cr3t",
+		"pkg/util/helper.go": "package util\n",
+	} {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestWriteBuildContext(t *testing.T) {
+	dir := writeTestContext(t)
+
+	var buf bytes.Buffer
+	if err := WriteBuildContext(&buf, dir, ""); err != nil {
+		t.Fatalf("WriteBuildContext returned error: %v", err)
+	}
+
+	names := archiveNames(t, buf.Bytes())
+	slices.Sort(names)
+	want := []string{".dockerignore", "Dockerfile", "main.go", "pkg/", "pkg/util/", "pkg/util/helper.go"}
+	if !slices.Equal(names, want) {
+		t.Errorf("Expected archive entries %v, got %v", want, names)
+	}
+}
+
+func TestRequestBuildFromDirectory(t *testing.T) {
+	dir := writeTestContext(t)
+
+	var uploaded []byte
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/filedrop/":
+			var req FileDropRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			if req.MimeType != BuildContextMimeType {
+				t.Errorf("Expected mimeType %s, got %s", BuildContextMimeType, req.MimeType)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"url": "` + server.URL + `/userfiles/file-123", "file_id": "file-123"}`))
+		case r.Method == http.MethodPut && r.URL.Path == "/userfiles/file-123":
+			if r.Header.Get("Authorization") != "" {
+				t.Error("Expected upload without API token")
+			}
+			if r.Header.Get("Content-Type") != BuildContextMimeType {
+				t.Errorf("Expected upload Content-Type %s, got %s", BuildContextMimeType, r.Header.Get("Content-Type"))
+			}
+			uploaded, _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/build/"):
+			var req RequestBuildRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			if req.FileID != "file-123" || req.ArchiveURL != "" || !slices.Equal(req.Tags, []string{"v1"}) {
+				t.Errorf("Unexpected build request: %+v", req)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": "` + testBuildUUID + `", "phase": "waiting"}`))
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	build, err := client.RequestBuildFromDirectory(context.Background(), testNamespace, testRepository, dir, &RequestBuildRequest{
+		ArchiveURL: "https://example.com/ignored.tar.gz",
+		Tags:       []string{"v1"},
+	})
+	if err != nil {
+		t.Fatalf("RequestBuildFromDirectory returned error: %v", err)
+	}
+	if build.ID != testBuildUUID {
+		t.Errorf("Unexpected build: %+v", build)
+	}
+	if names := archiveNames(t, uploaded); !slices.Contains(names, "main.go") || slices.Contains(names, "debug.log") {
+		t.Errorf("Unexpected uploaded archive entries: %v", names)
+	}
+}
+
+func TestUploadFileDropError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("SignatureDoesNotMatch"))
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	err = client.UploadFileDrop(context.Background(), &FileDrop{URL: server.URL + "/upload"}, BuildContextMimeType, strings.NewReader("x"), 1)
+	if err == nil || !strings.Contains(err.Error(), "403") || !strings.Contains(err.Error(), "SignatureDoesNotMatch") {
+		t.Errorf("Expected upload error with status and body, got %v", err)
+	}
+}
+
+func TestUploadFileDropSlowBody(t *testing.T) {
+	var received []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	const delay = 10 * time.Millisecond
+	client.HTTPClient.Timeout = 5 * delay
+
+	content := []byte("a build context slower than the client timeout")
+	err = client.UploadFileDrop(context.Background(), &FileDrop{URL: server.URL + "/upload"}, BuildContextMimeType,
+		&slowReader{data: content, delay: delay}, int64(len(content)))
+	if err != nil || string(received) != string(content) {
+		t.Errorf("Expected the slow upload to finish, got %q, %v", received, err)
+	}
+}
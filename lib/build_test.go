@@ -3,9 +3,14 @@ package lib
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 const (
@@ -310,3 +315,228 @@ func TestBuildHTTPErrors(t *testing.T) {
 		t.Error("Expected error from CancelBuild, got nil")
 	}
 }
+
+func TestGetBuildLogsFrom(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("start"); got != "5" {
+			t.Errorf("Expected start=5, got %q", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"start": 5, "total": 6, "logs": [{"message": "done"}]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	logs, err := client.GetBuildLogsFrom(context.Background(), testNamespace, testRepository, testBuildUUID, 5)
+	if err != nil {
+		t.Fatalf("GetBuildLogsFrom returned error: %v", err)
+	}
+	if logs.Start != 5 || len(logs.Logs) != 1 {
+		t.Errorf("Unexpected logs: %+v", logs)
+	}
+}
+
+// newTestBuildServer serves a build that advances one phase per status poll,
+// appending a phase entry and a command entry each time.
+func newTestBuildServer(t *testing.T, phases []string, honorStart bool) *httptest.Server {
+	t.Helper()
+	var mu sync.Mutex
+	var logs []BuildLogEntry
+	polls := 0
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/status"):
+			phase := phases[min(polls, len(phases)-1)]
+			polls++
+			logs = append(logs,
+				BuildLogEntry{Type: BuildLogTypePhase, Message: phase},
+				BuildLogEntry{Type: BuildLogTypeCommand, Message: "step " + strconv.Itoa(polls)})
+			data, _ := json.Marshal(BuildStatus{ID: testBuildUUID, Phase: phase})
+			w.Write(data)
+		case strings.HasSuffix(r.URL.Path, "/logs"):
+			start, _ := strconv.Atoi(r.URL.Query().Get("start"))
+			if !honorStart {
+				start = 0
+			}
+			data, _ := json.Marshal(BuildLogs{Start: start, Total: len(logs), Logs: logs[start:]})
+			w.Write(data)
+		default:
+			t.Errorf("Unexpected request %s", r.URL.Path)
+		}
+	}))
+}
+
+func TestFollowBuildLogs(t *testing.T) {
+	for _, honorStart := range []bool{true, false} {
+		server := newTestBuildServer(t, []string{BuildPhaseBuilding, BuildPhasePushing, BuildPhaseComplete}, honorStart)
+		defer server.Close()
+
+		client, err := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+
+		var statuses []string
+		var messages []string
+		opts := &FollowBuildLogsOptions{
+			PollInterval: time.Millisecond,
+			OnStatus:     func(s *BuildStatus) { statuses = append(statuses, s.Phase) },
+		}
+		for entry, err := range client.FollowBuildLogs(context.Background(), testNamespace, testRepository, testBuildUUID, opts) {
+			if err != nil {
+				t.Fatalf("FollowBuildLogs yielded error: %v", err)
+			}
+			messages = append(messages, entry.Message)
+		}
+
+		want := "building,step 1,pushing,step 2,complete,step 3"
+		if got := strings.Join(messages, ","); got != want {
+			t.Errorf("honorStart=%v: expected each entry once, got %s", honorStart, got)
+		}
+		if len(statuses) != 3 || statuses[2] != BuildPhaseComplete {
+			t.Errorf("Unexpected statuses: %v", statuses)
+		}
+	}
+}
+
+func TestFollowBuildLogsCancel(t *testing.T) {
+	server := newTestBuildServer(t, []string{BuildPhaseBuilding}, true)
+	defer server.Close()
+
+	client, err := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	var last error
+	for _, err := range client.FollowBuildLogs(ctx, testNamespace, testRepository, testBuildUUID, &FollowBuildLogsOptions{PollInterval: 5 * time.Millisecond}) {
+		last = err
+	}
+	if !errors.Is(last, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded as final element, got %v", last)
+	}
+}
+
+func TestIsTerminalBuildPhase(t *testing.T) {
+	for phase, want := range map[string]bool{
+		BuildPhaseComplete:      true,
+		BuildPhaseError:         true,
+		BuildPhaseInternalError: true,
+		BuildPhaseCancelled:     true,
+		BuildPhaseExpired:       true,
+		BuildPhaseWaiting:       false,
+		BuildPhaseBuilding:      false,
+		"":                      false,
+	} {
+		if got := IsTerminalBuildPhase(phase); got != want {
+			t.Errorf("IsTerminalBuildPhase(%q) = %v, want %v", phase, got, want)
+		}
+	}
+}
+
+// newTestWaitServer serves a build that moves through phases, one per GET,
+// and records whether it was cancelled.
+func newTestWaitServer(t *testing.T, phases []string, cancelled *bool) *httptest.Server {
+	t.Helper()
+	var mu sync.Mutex
+	polls := 0
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodDelete && strings.HasSuffix(r.URL.Path, "/build/"+testBuildUUID):
+			*cancelled = true
+			w.WriteHeader(http.StatusNoContent)
+		case strings.HasSuffix(r.URL.Path, "/build/"+testBuildUUID):
+			phase := phases[min(polls, len(phases)-1)]
+			polls++
+			data, _ := json.Marshal(Build{ID: testBuildUUID, Phase: phase, Tags: []string{"latest", "v1"}})
+			w.Write(data)
+		case strings.Contains(r.URL.Path, "/tag/"):
+			tag := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+			w.Write([]byte(`{"name": "` + tag + `", "manifest_digest": "sha256:` + tag + `"}`))
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+}
+
+func TestWaitForBuild(t *testing.T) {
+	cancelled := false
+	server := newTestWaitServer(t, []string{BuildPhaseWaiting, BuildPhaseBuilding, BuildPhaseComplete}, &cancelled)
+	defer server.Close()
+
+	client, err := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	var seen []string
+	result, err := client.WaitForBuild(context.Background(), testNamespace, testRepository, testBuildUUID, &WaitForBuildOptions{
+		PollInterval: time.Millisecond,
+		OnPoll:       func(b *Build) { seen = append(seen, b.Phase) },
+	})
+	if err != nil {
+		t.Fatalf("WaitForBuild returned error: %v", err)
+	}
+	if result.Build.Phase != BuildPhaseComplete || result.Digests["latest"] != "sha256:latest" || result.Digests["v1"] != "sha256:v1" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if strings.Join(seen, ",") != "waiting,building" {
+		t.Errorf("Expected OnPoll for non-terminal phases, got %v", seen)
+	}
+}
+
+func TestWaitForBuildFailed(t *testing.T) {
+	cancelled := false
+	server := newTestWaitServer(t, []string{BuildPhaseError}, &cancelled)
+	defer server.Close()
+
+	client, err := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	result, err := client.WaitForBuild(context.Background(), testNamespace, testRepository, testBuildUUID, nil)
+	if err != nil {
+		t.Fatalf("WaitForBuild returned error: %v", err)
+	}
+	if result.Build.Phase != BuildPhaseError || result.Digests != nil {
+		t.Errorf("Expected failed build without digests, got %+v", result)
+	}
+}
+
+func TestWaitForBuildTimeout(t *testing.T) {
+	cancelled := false
+	server := newTestWaitServer(t, []string{BuildPhaseBuilding}, &cancelled)
+	defer server.Close()
+
+	client, err := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	_, err = client.WaitForBuild(context.Background(), testNamespace, testRepository, testBuildUUID, &WaitForBuildOptions{
+		PollInterval:  5 * time.Millisecond,
+		Timeout:       20 * time.Millisecond,
+		CancelOnAbort: true,
+	})
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), `still "building"`) {
+		t.Errorf("Expected timeout error, got %v", err)
+	}
+	if !cancelled {
+		t.Error("Expected build to be cancelled on timeout")
+	}
+}
@@ -4,23 +4,25 @@ Package lib provides Quay.io API client functionality.
 This file covers HTTP CLIENT and helper methods:
 
 Client Setup:
-  - NewClient(bearerToken string) (*Client, error)                - Create authenticated client (default URL)
-  - NewClientWithURL(bearerToken, baseURL string) (*Client, error) - Create authenticated client with custom URL
+  - NewClient(bearerToken string, opts...) (*Client, error)                - Create authenticated client (default URL)
+  - NewClientWithURL(bearerToken, baseURL string, opts...) (*Client, error) - Create authenticated client with custom URL
 
 HTTP Helper Methods:
-  - do(req, v, acceptedStatuses...)          - Core HTTP executor (auth, headers, status check, decode)
+  - do(req, v, acceptedStatuses...)          - Core HTTP executor (auth, headers, middleware, rate limit, status check, decode)
   - get(req, v) / post(req, v) / put(req, v) / delete(req) - Thin wrappers with preset accepted statuses
 
 Request Helpers:
   - newRequest(ctx, method, url string, body io.Reader) (*http.Request, error)
   - newRequestWithBody(ctx, method, url string, body any) (*http.Request, error)
+  - newAPIRequest(ctx, method, target apiURL, body io.Reader) (*http.Request, error)
+  - newAPIRequestWithBody(ctx, method, target apiURL, body any) (*http.Request, error)
   - decodeJSON(r io.Reader, v any) error
 
 All HTTP methods include:
   - Bearer token authentication
-  - Proper headers (Content-Type, Authorization)
+  - Proper headers (Content-Type, Authorization), unless the request already sets them
   - Error handling for non-2xx responses
-  - JSON marshaling/unmarshaling
+  - JSON marshaling/unmarshaling, or a responseFunc for raw bodies and headers
 */
 package lib
 
@@ -58,17 +60,21 @@ type Client struct {
 	Version     string
 	Retry       *RetryConfig
 	HTTPClient  *http.Client
+	Middleware  []Middleware
+	RateLimiter *RateLimiter
+	Telemetry   Telemetry
 }
 
-func NewClientWithURL(bearerToken, baseURL string) (*Client, error) {
+func NewClientWithURL(bearerToken, baseURL string, opts ...ClientOption) (*Client, error) {
 	transport := &http.Transport{
 		DialContext: (&net.Dialer{
 			Timeout: 10 * time.Second,
 		}).DialContext,
-		TLSHandshakeTimeout: 10 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: 30 * time.Second,
 	}
 
-	return &Client{
+	c := &Client{
 		BearerToken: bearerToken,
 		BaseURL:     baseURL,
 		Version:     "dev",
@@ -76,14 +82,35 @@ func NewClientWithURL(bearerToken, baseURL string) (*Client, error) {
 			Timeout:   30 * time.Second,
 			Transport: transport,
 		},
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+func NewClient(bearerToken string, opts ...ClientOption) (*Client, error) {
+	return NewClientWithURL(bearerToken, DefaultQuayURL, opts...)
+}
+
+// streamingHTTPClient returns a copy of hc without its overall Timeout, for
+// requests whose bodies can take longer than that to transfer. Such requests
+// are bounded by their context and the transport's ResponseHeaderTimeout.
+func streamingHTTPClient(hc *http.Client) *http.Client {
+	streaming := *hc
+	streaming.Timeout = 0
+	return &streaming
 }
 
-func NewClient(bearerToken string) (*Client, error) {
-	return NewClientWithURL(bearerToken, DefaultQuayURL)
+// apiURL is a request URL together with the route template it was built from.
+type apiURL struct {
+	url   string
+	route string
 }
 
-func (c *Client) buildURL(pathFmt string, args ...any) string {
+// buildURL escapes args into pathFmt and keeps pathFmt's route template for
+// newAPIRequest.
+func (c *Client) buildURL(pathFmt string, args ...any) apiURL {
 	escaped := make([]any, len(args))
 	for i, a := range args {
 		if s, ok := a.(string); ok {
@@ -92,12 +119,49 @@ func (c *Client) buildURL(pathFmt string, args ...any) string {
 			escaped[i] = a
 		}
 	}
-	return c.BaseURL + fmt.Sprintf(pathFmt, escaped...)
+	return apiURL{url: c.BaseURL + fmt.Sprintf(pathFmt, escaped...), route: routeTemplate(pathFmt)}
+}
+
+// callStats accumulates per-call details reported to Telemetry.
+type callStats struct {
+	attempts   int
+	statusCode int
 }
 
 func (c *Client) do(req *http.Request, v any, acceptedStatuses ...int) error {
 	c.setHeaders(req)
 
+	if c.Telemetry == nil {
+		return c.doCall(req, v, acceptedStatuses, &callStats{})
+	}
+
+	route := RouteFromContext(req.Context())
+	if route == "" {
+		route = req.URL.Path
+	}
+	ctx, end := c.Telemetry.StartCall(req.Context(), CallInfo{Method: req.Method, Route: route})
+	req = req.WithContext(ctx)
+
+	start := time.Now()
+	var stats callStats
+	err := c.doCall(req, v, acceptedStatuses, &stats)
+	end(CallResult{
+		StatusCode: stats.statusCode,
+		Attempts:   stats.attempts,
+		Duration:   time.Since(start),
+		Err:        err,
+	})
+	return err
+}
+
+func (c *Client) doCall(req *http.Request, v any, acceptedStatuses []int, stats *callStats) error {
+	if err := c.doWithRetry(req, v, acceptedStatuses, stats); err != nil {
+		return c.runOnError(req, err)
+	}
+	return nil
+}
+
+func (c *Client) doWithRetry(req *http.Request, v any, acceptedStatuses []int, stats *callStats) error {
 	maxAttempts := 1
 	if c.Retry != nil && c.Retry.MaxRetries > 0 {
 		maxAttempts = 1 + c.Retry.MaxRetries
@@ -105,18 +169,21 @@ func (c *Client) do(req *http.Request, v any, acceptedStatuses ...int) error {
 
 	var lastErr error
 	for attempt := range maxAttempts {
-		result, err := c.doOnce(req, v, acceptedStatuses)
+		result, err := c.doLimited(req, v, acceptedStatuses, stats)
 		if err == nil {
 			return result
 		}
 
 		lastErr = err
+		retryable, retryAfter := isRetryableError(err)
+		if retryAfter > 0 && c.RateLimiter != nil {
+			c.RateLimiter.Pause(time.Duration(retryAfter) * time.Second)
+		}
+
 		if !c.shouldRetry(attempt, maxAttempts) {
 			return err
 		}
-
-		retryable, retryAfter := isRetryableError(err)
-		if !retryable {
+		if !retryable || !rewindBody(req) {
 			return err
 		}
 
@@ -128,14 +195,52 @@ func (c *Client) do(req *http.Request, v any, acceptedStatuses ...int) error {
 	return lastErr
 }
 
+// rewindBody resets the body of req for another attempt and reports whether it
+// could. A streamed body without GetBody cannot be sent twice.
+func rewindBody(req *http.Request) bool {
+	if req.Body == nil || req.Body == http.NoBody {
+		return true
+	}
+	if req.GetBody == nil {
+		return false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return false
+	}
+	req.Body = body
+	return true
+}
+
+// doLimited runs a single attempt under the client's RateLimiter, if any.
+func (c *Client) doLimited(req *http.Request, v any, acceptedStatuses []int, stats *callStats) (error, error) {
+	if c.RateLimiter == nil {
+		return c.doOnce(req, v, acceptedStatuses, stats)
+	}
+
+	release, err := c.RateLimiter.Acquire(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return c.doOnce(req, v, acceptedStatuses, stats)
+}
+
 func (c *Client) setHeaders(req *http.Request) {
-	if c.BearerToken != "" {
+	if c.BearerToken != "" && req.Header.Get("Authorization") == "" {
 		req.Header.Set("Authorization", "Bearer "+c.BearerToken)
 	}
-	req.Header.Set("Content-Type", "application/json")
+	if req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
 	req.Header.Set("User-Agent", "go-quay/"+c.Version)
 }
 
+// responseFunc consumes the raw response of an accepted request in place of
+// JSON decoding. The body is closed after it returns.
+type responseFunc func(resp *http.Response) error
+
 type retryableError struct {
 	err        error
 	retryAfter int
@@ -144,7 +249,12 @@ type retryableError struct {
 func (e *retryableError) Error() string { return e.err.Error() }
 func (e *retryableError) Unwrap() error { return e.err }
 
-func (c *Client) doOnce(req *http.Request, v any, acceptedStatuses []int) (error, error) {
+func (c *Client) doOnce(req *http.Request, v any, acceptedStatuses []int, stats *callStats) (error, error) {
+	if err := c.runBeforeRequest(req); err != nil {
+		return nil, err
+	}
+
+	stats.attempts++
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
 		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
@@ -153,8 +263,16 @@ func (c *Client) doOnce(req *http.Request, v any, acceptedStatuses []int) (error
 		return nil, &retryableError{err: err}
 	}
 	defer resp.Body.Close()
+	stats.statusCode = resp.StatusCode
+
+	if err := c.runAfterResponse(req, resp); err != nil {
+		return nil, err
+	}
 
 	if isAccepted(resp.StatusCode, acceptedStatuses) {
+		if fn, ok := v.(responseFunc); ok {
+			return fn(resp), nil
+		}
 		if v != nil && resp.StatusCode != http.StatusNoContent {
 			return decodeJSON(resp.Body, v), nil
 		}
@@ -189,6 +307,11 @@ func buildAPIError(status int, body []byte) error {
 		quayErr.Status = status
 		return &quayErr
 	}
+	var registryErr RegistryError
+	if json.Unmarshal(body, &registryErr) == nil && len(registryErr.Errors) > 0 {
+		registryErr.Status = status
+		return &registryErr
+	}
 	return fmt.Errorf("unexpected status code: %d, response: %s", status, string(body))
 }
 
@@ -285,3 +408,15 @@ func newRequestWithBody(ctx context.Context, method, url string, body any) (*htt
 	}
 	return newRequest(ctx, method, url, bodyReader)
 }
+
+// newAPIRequest creates a request for target and records its route template in
+// the request context for telemetry.
+func newAPIRequest(ctx context.Context, method string, target apiURL, body io.Reader) (*http.Request, error) {
+	return newRequest(context.WithValue(ctx, routeContextKey{}, target.route), method, target.url, body)
+}
+
+// newAPIRequestWithBody creates a request for target with a JSON body and
+// records its route template in the request context for telemetry.
+func newAPIRequestWithBody(ctx context.Context, method string, target apiURL, body any) (*http.Request, error) {
+	return newRequestWithBody(context.WithValue(ctx, routeContextKey{}, target.route), method, target.url, body)
+}
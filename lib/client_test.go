@@ -598,6 +598,50 @@ func TestRetryOn500(t *testing.T) {
 	}
 }
 
+func TestRetryResendsBody(t *testing.T) {
+	var attempts atomic.Int32
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.Retry = &RetryConfig{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+
+	req, err := newRequestWithBody(context.Background(), httpMethodPut, server.URL+"/api/v1/test", map[string]string{testFieldName: testUpdatedItem})
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	if err := client.put(req, nil); err != nil {
+		t.Fatalf("Expected success after retry, got error: %v", err)
+	}
+	if len(bodies) != 2 || bodies[0] == "" || bodies[1] != bodies[0] {
+		t.Errorf("Expected the body to be sent twice, got %q", bodies)
+	}
+
+	// A streamed body cannot be rewound, so it is not retried.
+	attempts.Store(0)
+	pr, pw := io.Pipe()
+	go func() { _, _ = pw.Write([]byte("blob")); _ = pw.Close() }()
+	req, err = newRequest(context.Background(), httpMethodPut, server.URL+"/api/v1/test", pr)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	if err := client.put(req, nil); err == nil || attempts.Load() != 1 {
+		t.Errorf("Expected one failed attempt, got %d attempts, %v", attempts.Load(), err)
+	}
+}
+
 func TestRetryExhausted(t *testing.T) {
 	var attempts atomic.Int32
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
@@ -0,0 +1,441 @@
+/*
+Package lib provides Quay.io API client functionality.
+
+This file covers IMAGE COPY between repositories:
+
+  - ParseImageRef(s string) (ImageRef, error)                          - Parse namespace/repository[:tag|@digest]
+  - (*Client).ResolveImageDigest(ctx, ref) (string, error)             - Resolve a tag to its manifest digest via GetTag
+  - (*Client).CopyImage(ctx, src, dst, opts) (*CopyImageResult, error) - Copy a manifest or manifest list with its blobs
+
+CopyImage works over the registry v2 API (see registry.go). Blobs already
+present at the destination are skipped, and on the same Quay instance missing
+blobs are linked with cross-repository mounts instead of being transferred.
+A copy within one repository only moves the tag, using ChangeTag. Before
+returning, CopyImage checks that the destination resolves to the source digest.
+*/
+package lib
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// defaultCopyConcurrency bounds parallel blob transfers per manifest.
+const defaultCopyConcurrency = 4
+
+// BlobCopyAction describes how CopyImage handled a blob.
+type BlobCopyAction string
+
+const (
+	// BlobCopied means the blob was streamed from source to destination.
+	BlobCopied BlobCopyAction = "copied"
+	// BlobMounted means the blob was linked from the source repository.
+	BlobMounted BlobCopyAction = "mounted"
+	// BlobSkipped means the blob already existed at the destination, or is a
+	// foreign layer that registries do not store.
+	BlobSkipped BlobCopyAction = "skipped"
+)
+
+// ImageRef identifies an image in a repository by tag or digest.
+type ImageRef struct {
+	Namespace  string `json:"namespace"`
+	Repository string `json:"repository"`
+	// Reference is a tag or a digest.
+	Reference string `json:"reference"`
+}
+
+// String formats the reference as namespace/repository:tag or namespace/repository@digest.
+func (r ImageRef) String() string {
+	name := r.Namespace + "/" + r.Repository
+	switch {
+	case r.Reference == "":
+		return name
+	case isDigest(r.Reference):
+		return name + "@" + r.Reference
+	default:
+		return name + ":" + r.Reference
+	}
+}
+
+// ParseImageRef parses namespace/repository, namespace/repository:tag or
+// namespace/repository@digest. Reference is left empty when omitted.
+func ParseImageRef(s string) (ImageRef, error) {
+	name, reference, hasDigest := strings.Cut(s, "@")
+	hasTag := false
+	if !hasDigest {
+		if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+			name, reference, hasTag = name[:i], name[i+1:], true
+		}
+	}
+
+	namespace, repository, ok := strings.Cut(name, "/")
+	if !ok || namespace == "" || repository == "" || strings.Contains(repository, "/") {
+		return ImageRef{}, fmt.Errorf("invalid image reference %q: expected namespace/repository[:tag|@digest]", s)
+	}
+	if (hasTag && reference == "") || (hasDigest && !isDigest(reference)) {
+		return ImageRef{}, fmt.Errorf("invalid image reference %q: malformed tag or digest", s)
+	}
+	return ImageRef{Namespace: namespace, Repository: repository, Reference: reference}, nil
+}
+
+// ResolveImageDigest returns the manifest digest ref points at, looking tags
+// up with GetTag. Digests are returned as-is and an empty reference means
+// "latest".
+func (c *Client) ResolveImageDigest(ctx context.Context, ref ImageRef) (string, error) {
+	reference := cmp.Or(ref.Reference, "latest")
+	if isDigest(reference) {
+		return reference, nil
+	}
+	tag, err := c.GetTag(ctx, ref.Namespace, ref.Repository, reference)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve tag %s: %w", reference, err)
+	}
+	if tag.ManifestDigest == "" {
+		return "", fmt.Errorf("tag %s has no manifest digest", reference)
+	}
+	return tag.ManifestDigest, nil
+}
+
+// CopyImageOptions controls CopyImage.
+type CopyImageOptions struct {
+	// Destination is the client for the destination Quay instance. Nil copies
+	// within the source client's instance.
+	Destination *Client
+	// Concurrency bounds parallel blob transfers. Defaults to 4.
+	Concurrency int
+	// Progress, if set, is called once per blob. It may be called concurrently.
+	Progress func(blob RegistryDescriptor, action BlobCopyAction)
+}
+
+// CopyImageResult summarizes a completed CopyImage.
+type CopyImageResult struct {
+	Source      ImageRef `json:"source"`
+	Destination ImageRef `json:"destination"`
+	Digest      string   `json:"digest"`
+	MediaType   string   `json:"media_type,omitempty"`
+	// TagOnly is set when source and destination share a repository and only
+	// the tag was moved.
+	TagOnly bool `json:"tag_only,omitempty"`
+	// Manifests lists every manifest written, child manifests before their index.
+	Manifests    []Manifest `json:"manifests,omitempty"`
+	BlobsCopied  int        `json:"blobs_copied"`
+	BlobsMounted int        `json:"blobs_mounted"`
+	BlobsSkipped int        `json:"blobs_skipped"`
+	BytesCopied  int64      `json:"bytes_copied"`
+}
+
+// CopyImage copies the image src refers to, including every platform of a
+// manifest list, to dst. An empty src reference means "latest" and an empty
+// dst reference reuses the source reference.
+func (c *Client) CopyImage(ctx context.Context, src, dst ImageRef, opts *CopyImageOptions) (*CopyImageResult, error) {
+	if opts == nil {
+		opts = &CopyImageOptions{}
+	}
+	if src.Reference == "" {
+		src.Reference = "latest"
+	}
+	if dst.Reference == "" {
+		dst.Reference = src.Reference
+	}
+	if err := validateRegistryRef(src.Namespace, src.Repository, src.Reference); err != nil {
+		return nil, fmt.Errorf("invalid source: %w", err)
+	}
+	if err := validateRegistryRef(dst.Namespace, dst.Repository, dst.Reference); err != nil {
+		return nil, fmt.Errorf("invalid destination: %w", err)
+	}
+
+	cp := &imageCopier{
+		srcRef:      src,
+		dstRef:      dst,
+		src:         c.Registry(),
+		progress:    opts.Progress,
+		concurrency: opts.Concurrency,
+		seen:        make(map[string]bool),
+		result:      &CopyImageResult{Source: src, Destination: dst},
+	}
+	cp.dst, cp.dstClient = cp.src, c
+	if opts.Destination != nil {
+		cp.dst, cp.dstClient = opts.Destination.Registry(), opts.Destination
+	}
+	cp.sameInstance = cp.src.BaseURL == cp.dst.BaseURL
+	if cp.concurrency <= 0 {
+		cp.concurrency = defaultCopyConcurrency
+	}
+
+	if err := cp.run(ctx); err != nil {
+		return nil, fmt.Errorf("failed to copy %s to %s: %w", src, dst, err)
+	}
+	return cp.result, nil
+}
+
+// imageCopier holds the state of one CopyImage call.
+type imageCopier struct {
+	srcRef, dstRef ImageRef
+	src, dst       *RegistryClient
+	dstClient      *Client
+	sameInstance   bool
+	concurrency    int
+	progress       func(RegistryDescriptor, BlobCopyAction)
+
+	mu     sync.Mutex
+	seen   map[string]bool
+	result *CopyImageResult
+}
+
+func (cp *imageCopier) run(ctx context.Context) error {
+	if cp.sameInstance && cp.srcRef.Namespace == cp.dstRef.Namespace && cp.srcRef.Repository == cp.dstRef.Repository {
+		return cp.retag(ctx)
+	}
+
+	top, err := cp.src.GetManifest(ctx, cp.srcRef.Namespace, cp.srcRef.Repository, cp.srcRef.Reference)
+	if err != nil {
+		return err
+	}
+	if isDigest(cp.dstRef.Reference) && cp.dstRef.Reference != top.Digest {
+		return fmt.Errorf("destination digest %s does not match source digest %s", cp.dstRef.Reference, top.Digest)
+	}
+	cp.result.Digest = top.Digest
+	cp.result.MediaType = top.MediaType
+
+	if err := cp.copyManifest(ctx, top, cp.dstRef.Reference); err != nil {
+		return err
+	}
+	return cp.verify(ctx)
+}
+
+// retag points the destination tag at the source digest within one repository.
+func (cp *imageCopier) retag(ctx context.Context) error {
+	m, err := cp.src.GetManifest(ctx, cp.srcRef.Namespace, cp.srcRef.Repository, cp.srcRef.Reference)
+	if err != nil {
+		return err
+	}
+	cp.result.Digest = m.Digest
+	cp.result.MediaType = m.MediaType
+	cp.result.TagOnly = true
+
+	if isDigest(cp.dstRef.Reference) {
+		if cp.dstRef.Reference != m.Digest {
+			return fmt.Errorf("destination digest %s does not match source digest %s", cp.dstRef.Reference, m.Digest)
+		}
+		return nil
+	}
+	if err := cp.dstClient.ChangeTag(ctx, cp.dstRef.Namespace, cp.dstRef.Repository, cp.dstRef.Reference, m.Digest); err != nil {
+		return err
+	}
+	return cp.verify(ctx)
+}
+
+// verify checks that the destination reference resolves to the source digest.
+func (cp *imageCopier) verify(ctx context.Context) error {
+	desc, err := cp.dst.HeadManifest(ctx, cp.dstRef.Namespace, cp.dstRef.Repository, cp.dstRef.Reference)
+	if err != nil {
+		return fmt.Errorf("verifying destination: %w", err)
+	}
+	digest := desc.Digest
+	if digest == "" {
+		// Without a Docker-Content-Digest header, hash the manifest itself.
+		m, err := cp.dst.GetManifest(ctx, cp.dstRef.Namespace, cp.dstRef.Repository, cp.dstRef.Reference)
+		if err != nil {
+			return fmt.Errorf("verifying destination: %w", err)
+		}
+		digest = m.Digest
+	}
+	if digest != cp.result.Digest {
+		return fmt.Errorf("destination resolved to %s, expected %s", digest, cp.result.Digest)
+	}
+	return nil
+}
+
+// copyManifest copies the content m references, then writes m under reference.
+func (cp *imageCopier) copyManifest(ctx context.Context, m *RegistryManifest, reference string) error {
+	image, err := m.Image()
+	if err != nil {
+		return err
+	}
+
+	if m.IsIndex() {
+		for _, child := range image.Manifests {
+			childManifest, err := cp.src.GetManifest(ctx, cp.srcRef.Namespace, cp.srcRef.Repository, child.Digest)
+			if err != nil {
+				return err
+			}
+			if err := cp.copyManifest(ctx, childManifest, childManifest.Digest); err != nil {
+				return err
+			}
+		}
+	} else {
+		blobs := image.Layers
+		if image.Config != nil {
+			blobs = append([]RegistryDescriptor{*image.Config}, blobs...)
+		}
+		if err := cp.copyBlobs(ctx, blobs); err != nil {
+			return err
+		}
+	}
+
+	digest, err := cp.dst.PutManifest(ctx, cp.dstRef.Namespace, cp.dstRef.Repository, reference, m.MediaType, m.Content)
+	if err != nil {
+		return err
+	}
+	if digest != m.Digest {
+		return fmt.Errorf("destination stored manifest %s as %s", m.Digest, digest)
+	}
+	cp.result.Manifests = append(cp.result.Manifests, toManifest(m, image))
+	return nil
+}
+
+// copyBlobs copies blobs with at most cp.concurrency transfers in flight and
+// returns the first error.
+func (cp *imageCopier) copyBlobs(ctx context.Context, blobs []RegistryDescriptor) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sem := make(chan struct{}, cp.concurrency)
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+
+	for _, blob := range blobs {
+		if !cp.claim(blob.Digest) {
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Go(func() {
+			defer func() { <-sem }()
+			if err := cp.copyBlob(ctx, blob); err != nil {
+				once.Do(func() {
+					firstErr = fmt.Errorf("blob %s: %w", blob.Digest, err)
+					cancel()
+				})
+			}
+		})
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// claim reports whether digest has not been handled yet in this copy.
+func (cp *imageCopier) claim(digest string) bool {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	if cp.seen[digest] {
+		return false
+	}
+	cp.seen[digest] = true
+	return true
+}
+
+func (cp *imageCopier) copyBlob(ctx context.Context, blob RegistryDescriptor) error {
+	if len(blob.URLs) > 0 {
+		cp.record(blob, BlobSkipped)
+		return nil
+	}
+
+	_, err := cp.dst.HeadBlob(ctx, cp.dstRef.Namespace, cp.dstRef.Repository, blob.Digest)
+	var registryErr *RegistryError
+	switch {
+	case err == nil:
+		cp.record(blob, BlobSkipped)
+		return nil
+	case !errors.As(err, &registryErr) || registryErr.Status != http.StatusNotFound:
+		return err
+	}
+
+	if cp.sameInstance {
+		mounted, err := cp.dst.MountBlob(ctx, cp.dstRef.Namespace, cp.dstRef.Repository, blob.Digest, cp.srcRef.Namespace, cp.srcRef.Repository)
+		if err != nil {
+			return err
+		}
+		if mounted {
+			cp.record(blob, BlobMounted)
+			return nil
+		}
+	}
+
+	if err := cp.transfer(ctx, blob); err != nil {
+		return err
+	}
+	cp.record(blob, BlobCopied)
+	return nil
+}
+
+// transfer streams a blob from source to destination without buffering it.
+func (cp *imageCopier) transfer(ctx context.Context, blob RegistryDescriptor) error {
+	pr, pw := io.Pipe()
+	downloadErr := make(chan error, 1)
+	go func() {
+		_, err := cp.src.DownloadBlob(ctx, cp.srcRef.Namespace, cp.srcRef.Repository, blob.Digest, pw)
+		pw.CloseWithError(err)
+		downloadErr <- err
+	}()
+
+	uploadErr := cp.dst.UploadBlob(ctx, cp.dstRef.Namespace, cp.dstRef.Repository, blob.Digest, pr, blob.Size)
+	pr.CloseWithError(uploadErr)
+	err := <-downloadErr
+	if uploadErr != nil {
+		// A failed upload also fails the download through the pipe.
+		return uploadErr
+	}
+	return err
+}
+
+func (cp *imageCopier) record(blob RegistryDescriptor, action BlobCopyAction) {
+	cp.mu.Lock()
+	switch action {
+	case BlobCopied:
+		cp.result.BlobsCopied++
+		cp.result.BytesCopied += blob.Size
+	case BlobMounted:
+		cp.result.BlobsMounted++
+	case BlobSkipped:
+		cp.result.BlobsSkipped++
+	}
+	cp.mu.Unlock()
+
+	if cp.progress != nil {
+		cp.progress(blob, action)
+	}
+}
+
+// toManifest describes a registry manifest with the REST API Manifest type.
+func toManifest(m *RegistryManifest, image *ImageManifest) Manifest {
+	manifest := Manifest{
+		Digest:         m.Digest,
+		SchemaVersion:  image.SchemaVersion,
+		MediaType:      m.MediaType,
+		Size:           m.Size,
+		IsManifestList: m.IsIndex(),
+	}
+	if image.Config != nil {
+		manifest.Config = ManifestConfig{
+			MediaType: image.Config.MediaType,
+			Size:      image.Config.Size,
+			Digest:    image.Config.Digest,
+		}
+	}
+	for i, layer := range image.Layers {
+		manifest.Layers = append(manifest.Layers, ManifestLayer{
+			MediaType: layer.MediaType,
+			Size:      layer.Size,
+			Digest:    layer.Digest,
+			Index:     i,
+		})
+	}
+	return manifest
+}
@@ -0,0 +1,274 @@
+package lib
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// pushTestImage seeds a config blob and layers into a repository of f and
+// pushes an OCI image manifest referencing them under tag.
+func pushTestImage(t *testing.T, f *fakeRegistry, namespace, repository, tag string, layers ...string) string {
+	t.Helper()
+	repo := f.repo(namespace, repository)
+	config := []byte(fmt.Sprintf(`{"architecture": "amd64", "layers": %d}`, len(layers)))
+	repo.blobs[digestOf(config)] = config
+
+	image := ImageManifest{
+		SchemaVersion: 2,
+		MediaType:     MediaTypeOCIManifest,
+		Config:        &RegistryDescriptor{MediaType: "application/vnd.oci.image.config.v1+json", Digest: digestOf(config), Size: int64(len(config))},
+	}
+	for _, layer := range layers {
+		repo.blobs[digestOf([]byte(layer))] = []byte(layer)
+		image.Layers = append(image.Layers, RegistryDescriptor{
+			MediaType: "application/vnd.oci.image.layer.v1.tar+gzip",
+			Digest:    digestOf([]byte(layer)),
+			Size:      int64(len(layer)),
+		})
+	}
+	content, err := json.Marshal(image)
+	if err != nil {
+		t.Fatalf("Failed to marshal manifest: %v", err)
+	}
+	return f.pushManifest(namespace, repository, tag, MediaTypeOCIManifest, content)
+}
+
+func TestParseImageRef(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    ImageRef
+		wantErr bool
+	}{
+		{input: "myorg/app", want: ImageRef{Namespace: "myorg", Repository: "app"}},
+		{input: "myorg/app:rc", want: ImageRef{Namespace: "myorg", Repository: "app", Reference: "rc"}},
+		{input: "myorg/app@sha256:abc", want: ImageRef{Namespace: "myorg", Repository: "app", Reference: "sha256:abc"}},
+		{input: "app:rc", wantErr: true},
+		{input: "quay.io/myorg/app:rc", wantErr: true},
+		{input: "myorg/app:", wantErr: true},
+		{input: "myorg/app@latest", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseImageRef(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseImageRef(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseImageRef(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+		if !tt.wantErr && got.String() != tt.input {
+			t.Errorf("ImageRef.String() = %q, want %q", got.String(), tt.input)
+		}
+	}
+}
+
+func TestCopyImageMountsOnSameInstance(t *testing.T) {
+	fake, client := newFakeRegistryClient(t)
+	digest := pushTestImage(t, fake, "myorg", "app", "rc", "layer-one", "layer-two")
+
+	var mu sync.Mutex
+	actions := map[BlobCopyAction]int{}
+	result, err := client.CopyImage(context.Background(),
+		ImageRef{Namespace: "myorg", Repository: "app", Reference: "rc"},
+		ImageRef{Namespace: "myorg", Repository: "app-prod", Reference: "1.4.0"},
+		&CopyImageOptions{Progress: func(_ RegistryDescriptor, action BlobCopyAction) {
+			mu.Lock()
+			actions[action]++
+			mu.Unlock()
+		}})
+	if err != nil {
+		t.Fatalf("CopyImage returned error: %v", err)
+	}
+
+	if result.Digest != digest || result.BlobsMounted != 3 || result.BlobsCopied != 0 {
+		t.Errorf("Expected 3 mounted blobs for %s, got %+v", digest, result)
+	}
+	if actions[BlobMounted] != 3 {
+		t.Errorf("Expected 3 mount progress events, got %v", actions)
+	}
+	if len(result.Manifests) != 1 || len(result.Manifests[0].Layers) != 2 || result.Manifests[0].Config.Digest == "" {
+		t.Errorf("Expected one manifest with config and 2 layers, got %+v", result.Manifests)
+	}
+	if got := fake.repo("myorg", "app-prod").tags["1.4.0"]; got != digest {
+		t.Errorf("Expected destination tag to point at %s, got %s", digest, got)
+	}
+}
+
+func TestCopyImageManifestList(t *testing.T) {
+	fake, client := newFakeRegistryClient(t)
+	amd64 := pushTestImage(t, fake, "myorg", "app", "", "shared-base", "amd64-bits")
+	arm64 := pushTestImage(t, fake, "myorg", "app", "", "shared-base", "arm64-bits")
+	src := fake.repo("myorg", "app")
+	index, _ := json.Marshal(ImageManifest{
+		SchemaVersion: 2,
+		MediaType:     MediaTypeOCIIndex,
+		Manifests: []RegistryDescriptor{
+			{MediaType: MediaTypeOCIManifest, Digest: amd64, Size: int64(len(src.manifests[amd64])), Platform: &RegistryPlatform{Architecture: "amd64", OS: "linux"}},
+			{MediaType: MediaTypeOCIManifest, Digest: arm64, Size: int64(len(src.manifests[arm64])), Platform: &RegistryPlatform{Architecture: "arm64", OS: "linux"}},
+		},
+	})
+	indexDigest := fake.pushManifest("myorg", "app", "rc", MediaTypeOCIIndex, index)
+
+	result, err := client.CopyImage(context.Background(),
+		ImageRef{Namespace: "myorg", Repository: "app", Reference: "rc"},
+		ImageRef{Namespace: "myorg", Repository: "app-prod"}, nil)
+	if err != nil {
+		t.Fatalf("CopyImage returned error: %v", err)
+	}
+
+	if result.Digest != indexDigest || result.Destination.Reference != "rc" {
+		t.Errorf("Expected index %s copied to tag rc, got %+v", indexDigest, result)
+	}
+	if len(result.Manifests) != 3 || !result.Manifests[2].IsManifestList {
+		t.Errorf("Expected 2 images then the index, got %+v", result.Manifests)
+	}
+	// Both images share their config and base layer; each adds one layer.
+	if result.BlobsMounted != 4 {
+		t.Errorf("Expected shared blobs to be handled once (4 blobs), got %d", result.BlobsMounted)
+	}
+	dst := fake.repo("myorg", "app-prod")
+	if dst.tags["rc"] != indexDigest || dst.manifests[amd64] == nil || dst.manifests[arm64] == nil {
+		t.Error("Expected index and both platform manifests at the destination")
+	}
+}
+
+func TestCopyImageAcrossInstances(t *testing.T) {
+	srcFake, srcClient := newFakeRegistryClient(t)
+	dstFake, dstClient := newFakeRegistryClient(t)
+	digest := pushTestImage(t, srcFake, "myorg", "app", "rc", "layer-one", "layer-two")
+
+	src := ImageRef{Namespace: "myorg", Repository: "app", Reference: "rc"}
+	dst := ImageRef{Namespace: "backup", Repository: "app", Reference: "rc"}
+	opts := &CopyImageOptions{Destination: dstClient, Concurrency: 1}
+	result, err := srcClient.CopyImage(context.Background(), src, dst, opts)
+	if err != nil {
+		t.Fatalf("CopyImage returned error: %v", err)
+	}
+	if result.BlobsCopied != 3 || result.BlobsMounted != 0 || result.BytesCopied == 0 {
+		t.Errorf("Expected 3 streamed blobs, got %+v", result)
+	}
+	if dstFake.repo("backup", "app").tags["rc"] != digest {
+		t.Error("Expected destination tag to point at the source digest")
+	}
+
+	result, err = srcClient.CopyImage(context.Background(), src, dst, opts)
+	if err != nil {
+		t.Fatalf("Second CopyImage returned error: %v", err)
+	}
+	if result.BlobsSkipped != 3 || result.BlobsCopied != 0 {
+		t.Errorf("Expected existing blobs to be skipped, got %+v", result)
+	}
+}
+
+func TestCopyImageStreamsSlowLayers(t *testing.T) {
+	srcFake, srcClient := newSlowFakeRegistryClient(t, 5*time.Millisecond)
+	dstFake, dstClient := newFakeRegistryClient(t)
+	dstClient.HTTPClient.Timeout = srcClient.HTTPClient.Timeout
+	digest := pushTestImage(t, srcFake, "myorg", "app", "rc", "a layer slower than the client timeout")
+
+	result, err := srcClient.CopyImage(context.Background(),
+		ImageRef{Namespace: "myorg", Repository: "app", Reference: "rc"},
+		ImageRef{Namespace: "backup", Repository: "app", Reference: "rc"}, &CopyImageOptions{Destination: dstClient})
+	if err != nil || result.BlobsCopied != 2 {
+		t.Fatalf("Expected the slow layers to be copied, got %+v, %v", result, err)
+	}
+	if dstFake.repo("backup", "app").tags["rc"] != digest {
+		t.Error("Expected destination tag to point at the source digest")
+	}
+}
+
+func TestCopyImageVerifiesWithoutDigestHeader(t *testing.T) {
+	srcFake, srcClient := newFakeRegistryClient(t)
+	dstFake, dstClient := newFakeRegistryClient(t)
+	digest := pushTestImage(t, srcFake, "myorg", "app", "rc", "layer-one")
+	dstFake.omitDigest = true
+
+	result, err := srcClient.CopyImage(context.Background(),
+		ImageRef{Namespace: "myorg", Repository: "app", Reference: "rc"},
+		ImageRef{Namespace: "backup", Repository: "app", Reference: "rc"}, &CopyImageOptions{Destination: dstClient})
+	if err != nil || result.Digest != digest {
+		t.Fatalf("Expected the fetched manifest to verify, got %+v, %v", result, err)
+	}
+
+	// A destination serving other content under the tag fails verification.
+	dstFake.mu.Lock()
+	other := pushTestImage(t, dstFake, "backup", "app", "", "layer-two")
+	dstFake.mu.Unlock()
+	dstClient.Middleware = append(dstClient.Middleware, Middleware{
+		AfterResponse: func(req *http.Request, _ *http.Response) error {
+			if req.Method == http.MethodPut && strings.HasSuffix(req.URL.Path, "/manifests/rc") {
+				dstFake.mu.Lock()
+				dstFake.repo("backup", "app").tags["rc"] = other
+				dstFake.mu.Unlock()
+			}
+			return nil
+		},
+	})
+	_, err = srcClient.CopyImage(context.Background(),
+		ImageRef{Namespace: "myorg", Repository: "app", Reference: "rc"},
+		ImageRef{Namespace: "backup", Repository: "app", Reference: "rc"}, &CopyImageOptions{Destination: dstClient})
+	if err == nil || !strings.Contains(err.Error(), "destination resolved to "+other) {
+		t.Errorf("Expected a verification error, got %v", err)
+	}
+}
+
+func TestCopyImageUploadFailure(t *testing.T) {
+	srcFake, srcClient := newFakeRegistryClient(t)
+	dstFake, dstClient := newFakeRegistryClient(t)
+	pushTestImage(t, srcFake, "myorg", "app", "rc", strings.Repeat("layer", 1<<16))
+	dstFake.rejectUploads = true
+
+	_, err := srcClient.CopyImage(context.Background(),
+		ImageRef{Namespace: "myorg", Repository: "app", Reference: "rc"},
+		ImageRef{Namespace: "backup", Repository: "app", Reference: "rc"}, &CopyImageOptions{Destination: dstClient})
+	if err == nil || !strings.Contains(err.Error(), "BLOB_UPLOAD_INVALID") || strings.Contains(err.Error(), "failed to get blob") {
+		t.Errorf("Expected the upload error, got %v", err)
+	}
+}
+
+func TestCopyImageWithinRepositoryMovesTag(t *testing.T) {
+	fake, client := newFakeRegistryClient(t)
+	digest := pushTestImage(t, fake, "myorg", "app", "rc", "layer-one")
+
+	result, err := client.CopyImage(context.Background(),
+		ImageRef{Namespace: "myorg", Repository: "app", Reference: "rc"},
+		ImageRef{Namespace: "myorg", Repository: "app", Reference: "stable"}, nil)
+	if err != nil {
+		t.Fatalf("CopyImage returned error: %v", err)
+	}
+	if !result.TagOnly || result.Digest != digest {
+		t.Errorf("Expected tag-only promotion of %s, got %+v", digest, result)
+	}
+	if fake.repo("myorg", "app").tags["stable"] != digest {
+		t.Error("Expected ChangeTag to point stable at the source digest")
+	}
+}
+
+func TestCopyImageDigestMismatch(t *testing.T) {
+	fake, client := newFakeRegistryClient(t)
+	pushTestImage(t, fake, "myorg", "app", "rc", "layer-one")
+
+	_, err := client.CopyImage(context.Background(),
+		ImageRef{Namespace: "myorg", Repository: "app", Reference: "rc"},
+		ImageRef{Namespace: "myorg", Repository: "app-prod", Reference: "sha256:0000"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "does not match source digest") {
+		t.Errorf("Expected digest mismatch error, got %v", err)
+	}
+}
+
+func TestCopyImageMissingSource(t *testing.T) {
+	_, client := newFakeRegistryClient(t)
+
+	_, err := client.CopyImage(context.Background(),
+		ImageRef{Namespace: "myorg", Repository: "app", Reference: "missing"},
+		ImageRef{Namespace: "myorg", Repository: "app-prod"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "MANIFEST_UNKNOWN") {
+		t.Errorf("Expected MANIFEST_UNKNOWN error, got %v", err)
+	}
+}
@@ -0,0 +1,449 @@
+/*
+Package lib provides Quay.io API client functionality.
+
+This file covers the organization VULNERABILITY INVENTORY:
+
+Inventory:
+  - (*Client).VulnerabilityInventory(ctx, namespace, opts) (*VulnerabilityInventory, error) - Crawl every repository and active tag
+  - (*VulnerabilityInventory).WriteCSV(w io.Writer) error   - One row per image
+  - (*VulnerabilityInventory).WriteHTML(w io.Writer) error  - Self-contained HTML report
+  - (*ScanCache) - Security scans memoized by manifest digest
+
+The crawler lists the repositories of a namespace, then the active tags of
+each repository, and fetches the security scan of every tagged manifest with
+bounded concurrency. Scans are cached by manifest digest, so a digest shared
+by several tags or repositories is fetched once. Failures for individual
+repositories or tags are collected in the report instead of aborting it.
+*/
+package lib
+
+import (
+	"cmp"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultInventoryConcurrency = 8
+	defaultInventoryWorst       = 5
+	repositoryKindImage         = "image"
+)
+
+// InventoryOptions controls VulnerabilityInventory.
+type InventoryOptions struct {
+	// Concurrency bounds the API requests in flight. Defaults to 8.
+	Concurrency int
+	// Repositories limits the crawl to repositories matching any of these globs.
+	Repositories []string
+	// MaxTagsPerRepository limits each repository to its most recent active
+	// tags. Zero includes every active tag.
+	MaxTagsPerRepository int
+	// CVEs keeps only findings with one of these IDs (case-insensitive).
+	CVEs []string
+	// Packages keeps only findings in packages matching one of these globs.
+	Packages []string
+	// Worst is the number of most severe CVEs listed per image. Defaults to 5.
+	Worst int
+	// Cache memoizes scans by digest. Nil uses a cache private to this crawl;
+	// pass one to share scans between crawls.
+	Cache *ScanCache
+	// Progress, if set, is called once per scanned tag. It may be called concurrently.
+	Progress func(repository, tag string, cached bool)
+}
+
+// VulnerabilityInventory is the vulnerability exposure of every active tag in
+// a namespace.
+type VulnerabilityInventory struct {
+	Namespace   string    `json:"namespace"`
+	GeneratedAt time.Time `json:"generated_at"`
+	// Filtered is set when CVE or package filters were applied. Images without
+	// matching findings are then left out.
+	Filtered     bool `json:"filtered,omitempty"`
+	Repositories int  `json:"repositories"`
+	TagsScanned  int  `json:"tags_scanned"`
+	// UniqueManifests is the number of distinct digests whose scan was fetched.
+	UniqueManifests int `json:"unique_manifests"`
+	// Totals sums Counts over all listed images; a digest shared by several
+	// tags is counted once per tag.
+	Totals map[string]int   `json:"totals"`
+	Images []InventoryImage `json:"images"`
+	Errors []InventoryError `json:"errors,omitempty"`
+}
+
+// InventoryImage summarizes the scan of one tag.
+type InventoryImage struct {
+	Repository    string                 `json:"repository"`
+	Tag           string                 `json:"tag"`
+	Digest        string                 `json:"digest"`
+	Status        string                 `json:"status"`
+	Total         int                    `json:"total"`
+	Counts        map[string]int         `json:"counts"`
+	Fixable       int                    `json:"fixable"`
+	FixableCounts map[string]int         `json:"fixable_counts"`
+	Worst         []VulnerabilityFinding `json:"worst,omitempty"`
+}
+
+// InventoryError records a repository or tag that could not be inventoried.
+type InventoryError struct {
+	Repository string `json:"repository"`
+	Tag        string `json:"tag,omitempty"`
+	Error      string `json:"error"`
+}
+
+// ScanCache memoizes security scans by manifest digest. Concurrent requests
+// for the same digest share one fetch, and failed fetches are not cached. It
+// is safe for concurrent use and the zero value is ready to use.
+type ScanCache struct {
+	mu      sync.Mutex
+	entries map[string]*scanCacheEntry
+}
+
+type scanCacheEntry struct {
+	done chan struct{}
+	scan *SecurityScan
+	err  error
+}
+
+// get returns the cached scan for digest, calling fetch on a miss. cached
+// reports whether the scan came from an earlier or concurrent fetch.
+func (sc *ScanCache) get(ctx context.Context, digest string, fetch func() (*SecurityScan, error)) (scan *SecurityScan, cached bool, err error) {
+	sc.mu.Lock()
+	if sc.entries == nil {
+		sc.entries = make(map[string]*scanCacheEntry)
+	}
+	if entry, ok := sc.entries[digest]; ok {
+		sc.mu.Unlock()
+		select {
+		case <-entry.done:
+			return entry.scan, true, entry.err
+		case <-ctx.Done():
+			return nil, true, ctx.Err()
+		}
+	}
+	entry := &scanCacheEntry{done: make(chan struct{})}
+	sc.entries[digest] = entry
+	sc.mu.Unlock()
+
+	entry.scan, entry.err = fetch()
+	if entry.err != nil {
+		sc.mu.Lock()
+		delete(sc.entries, digest)
+		sc.mu.Unlock()
+	}
+	close(entry.done)
+	return entry.scan, false, entry.err
+}
+
+// VulnerabilityInventory crawls every image repository of namespace and
+// summarizes the security scan of each active tag. It returns an error only
+// if the repositories cannot be listed or ctx is canceled.
+func (c *Client) VulnerabilityInventory(ctx context.Context, namespace string, opts *InventoryOptions) (*VulnerabilityInventory, error) {
+	if namespace == "" {
+		return nil, fmt.Errorf("namespace is required")
+	}
+	if opts == nil {
+		opts = &InventoryOptions{}
+	}
+	repos, err := c.ListAllRepositories(ctx, namespace, true, false, false)
+	if err != nil {
+		return nil, err
+	}
+
+	cr := &inventoryCrawler{
+		client:    c,
+		namespace: namespace,
+		opts:      opts,
+		cache:     cmp.Or(opts.Cache, &ScanCache{}),
+		sem:       make(chan struct{}, cmp.Or(max(opts.Concurrency, 0), defaultInventoryConcurrency)),
+		inventory: &VulnerabilityInventory{
+			Namespace:   namespace,
+			GeneratedAt: time.Now().UTC(),
+			Filtered:    len(opts.CVEs) > 0 || len(opts.Packages) > 0,
+			Totals:      make(map[string]int),
+			Images:      []InventoryImage{},
+		},
+	}
+	for _, repo := range repos {
+		if !cr.wantRepository(repo) {
+			continue
+		}
+		if !cr.acquire(ctx) {
+			break
+		}
+		cr.inventory.Repositories++
+		cr.wg.Go(func() { cr.crawlRepository(ctx, repo.Name) })
+	}
+	cr.wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	inv := cr.inventory
+	slices.SortFunc(inv.Images, func(a, b InventoryImage) int {
+		return cmp.Or(cmp.Compare(a.Repository, b.Repository), cmp.Compare(a.Tag, b.Tag))
+	})
+	slices.SortFunc(inv.Errors, func(a, b InventoryError) int {
+		return cmp.Or(cmp.Compare(a.Repository, b.Repository), cmp.Compare(a.Tag, b.Tag))
+	})
+	return inv, nil
+}
+
+type inventoryCrawler struct {
+	client    *Client
+	namespace string
+	opts      *InventoryOptions
+	cache     *ScanCache
+	sem       chan struct{}
+	wg        sync.WaitGroup
+
+	mu        sync.Mutex
+	inventory *VulnerabilityInventory
+}
+
+func (cr *inventoryCrawler) wantRepository(repo OrganizationRepository) bool {
+	if repo.Kind != "" && repo.Kind != repositoryKindImage {
+		return false
+	}
+	if len(cr.opts.Repositories) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(cr.opts.Repositories, func(pattern string) bool { return globMatch(pattern, repo.Name) })
+}
+
+// acquire takes a request slot, returning false if ctx is done first. Slots
+// are taken before starting a goroutine, so goroutines stay within the bound.
+func (cr *inventoryCrawler) acquire(ctx context.Context) bool {
+	select {
+	case cr.sem <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (cr *inventoryCrawler) release() { <-cr.sem }
+
+// crawlRepository lists the tags of repository and scans each in a goroutine
+// of its own slot. It runs in the slot its caller took and releases it before
+// taking slots for the tags.
+func (cr *inventoryCrawler) crawlRepository(ctx context.Context, repository string) {
+	tags, err := cr.client.ListAllTags(ctx, cr.namespace, repository, true)
+	cr.release()
+	if err != nil {
+		cr.addError(repository, "", err)
+		return
+	}
+
+	count := 0
+	for _, tag := range tags {
+		if tag.ManifestDigest == "" || isArtifactTag(tag.Name) {
+			continue
+		}
+		if cr.opts.MaxTagsPerRepository > 0 && count == cr.opts.MaxTagsPerRepository {
+			break
+		}
+		if !cr.acquire(ctx) {
+			return
+		}
+		count++
+		cr.wg.Go(func() {
+			defer cr.release()
+			cr.scanTag(ctx, repository, tag)
+		})
+	}
+}
+
+func (cr *inventoryCrawler) scanTag(ctx context.Context, repository string, tag Tag) {
+	scan, cached, err := cr.cache.get(ctx, tag.ManifestDigest, func() (*SecurityScan, error) {
+		return cr.client.GetManifestSecurity(ctx, cr.namespace, repository, tag.ManifestDigest, true)
+	})
+	if err != nil {
+		cr.addError(repository, tag.Name, err)
+		return
+	}
+	if cr.opts.Progress != nil {
+		cr.opts.Progress(repository, tag.Name, cached)
+	}
+
+	image, matched := cr.summarize(repository, tag, scan)
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	cr.inventory.TagsScanned++
+	if !cached {
+		cr.inventory.UniqueManifests++
+	}
+	if !matched {
+		return
+	}
+	cr.inventory.Images = append(cr.inventory.Images, image)
+	for severity, n := range image.Counts {
+		cr.inventory.Totals[severity] += n
+	}
+}
+
+// summarize counts the findings of scan that pass the CVE and package
+// filters. matched is false when filters are set and nothing passes them.
+func (cr *inventoryCrawler) summarize(repository string, tag Tag, scan *SecurityScan) (InventoryImage, bool) {
+	image := InventoryImage{
+		Repository:    repository,
+		Tag:           tag.Name,
+		Digest:        tag.ManifestDigest,
+		Status:        scan.Status,
+		Counts:        make(map[string]int),
+		FixableCounts: make(map[string]int),
+	}
+	var findings []VulnerabilityFinding
+	for _, finding := range scan.Findings() {
+		if !cr.matches(finding) {
+			continue
+		}
+		findings = append(findings, finding)
+		image.Counts[finding.Severity]++
+		if finding.FixedBy != "" {
+			image.FixableCounts[finding.Severity]++
+			image.Fixable++
+		}
+	}
+	image.Total = len(findings)
+	image.Worst = worstFindings(findings, cmp.Or(cr.opts.Worst, defaultInventoryWorst))
+	return image, !cr.inventory.Filtered || len(findings) > 0
+}
+
+func (cr *inventoryCrawler) matches(finding VulnerabilityFinding) bool {
+	if len(cr.opts.CVEs) > 0 && !slices.ContainsFunc(cr.opts.CVEs, func(id string) bool { return strings.EqualFold(id, finding.ID) }) {
+		return false
+	}
+	if len(cr.opts.Packages) > 0 && !slices.ContainsFunc(cr.opts.Packages, func(pattern string) bool { return globMatch(pattern, finding.Package) }) {
+		return false
+	}
+	return true
+}
+
+func (cr *inventoryCrawler) addError(repository, tag string, err error) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	cr.inventory.Errors = append(cr.inventory.Errors, InventoryError{Repository: repository, Tag: tag, Error: err.Error()})
+}
+
+// worstFindings returns up to n distinct vulnerabilities, most severe and
+// highest CVSS score first.
+func worstFindings(findings []VulnerabilityFinding, n int) []VulnerabilityFinding {
+	sorted := slices.Clone(findings)
+	slices.SortFunc(sorted, func(a, b VulnerabilityFinding) int {
+		return cmp.Or(
+			cmp.Compare(SeverityRank(b.Severity), SeverityRank(a.Severity)),
+			cmp.Compare(b.CVSSScore, a.CVSSScore),
+			cmp.Compare(a.ID, b.ID),
+		)
+	})
+	sorted = slices.CompactFunc(sorted, func(a, b VulnerabilityFinding) bool { return a.ID == b.ID })
+	return sorted[:min(n, len(sorted))]
+}
+
+// isArtifactTag reports whether tag names a cosign signature, attestation or
+// SBOM rather than an image.
+func isArtifactTag(tag string) bool {
+	return strings.HasPrefix(tag, "sha256-") &&
+		(strings.HasSuffix(tag, ".sig") || strings.HasSuffix(tag, ".att") || strings.HasSuffix(tag, ".sbom"))
+}
+
+// inventoryColumns are the severities reported as CSV and HTML columns, most
+// severe first.
+func inventoryColumns() []string {
+	columns := slices.Clone(Severities)
+	slices.Reverse(columns)
+	return columns
+}
+
+// WriteCSV writes one row per image with per-severity counts, fixable counts
+// and the worst CVE IDs separated by semicolons.
+func (inv *VulnerabilityInventory) WriteCSV(w io.Writer) error {
+	severities := inventoryColumns()
+	header := []string{"repository", "tag", "digest", "status", "total", "fixable"}
+	for _, severity := range severities {
+		header = append(header, strings.ToLower(severity))
+	}
+	header = append(header, "worst")
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, image := range inv.Images {
+		row := []string{image.Repository, image.Tag, image.Digest, image.Status, strconv.Itoa(image.Total), strconv.Itoa(image.Fixable)}
+		for _, severity := range severities {
+			row = append(row, strconv.Itoa(image.Counts[severity]))
+		}
+		row = append(row, strings.Join(findingIDs(image.Worst), ";"))
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func findingIDs(findings []VulnerabilityFinding) []string {
+	ids := make([]string, len(findings))
+	for i, finding := range findings {
+		ids[i] = finding.ID
+	}
+	return ids
+}
+
+var inventoryHTML = template.Must(template.New("inventory").Funcs(template.FuncMap{
+	"lower": strings.ToLower,
+	"count": func(counts map[string]int, severity string) int { return counts[severity] },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Vulnerability inventory: {{.Inventory.Namespace}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+td.n { text-align: right; }
+.critical { background: #f8d7da; } .high { background: #fde2c8; } .medium { background: #fff3cd; }
+</style>
+</head>
+<body>
+<h1>Vulnerability inventory: {{.Inventory.Namespace}}</h1>
+<p>Generated {{.Inventory.GeneratedAt.Format "2006-01-02 15:04 MST"}}: {{.Inventory.Repositories}} repositories, {{.Inventory.TagsScanned}} tags, {{.Inventory.UniqueManifests}} unique manifests{{if .Inventory.Filtered}} (filtered){{end}}.</p>
+<table>
+<tr>{{range .Severities}}<th>{{.}}</th>{{end}}</tr>
+<tr>{{range .Severities}}<td class="n">{{count $.Inventory.Totals .}}</td>{{end}}</tr>
+</table>
+<h2>Images</h2>
+<table>
+<tr><th>Repository</th><th>Tag</th><th>Status</th><th>Total</th><th>Fixable</th>{{range .Severities}}<th>{{.}}</th>{{end}}<th>Worst</th></tr>
+{{range .Inventory.Images}}{{$image := .}}<tr>
+<td>{{.Repository}}</td><td title="{{.Digest}}">{{.Tag}}</td><td>{{.Status}}</td><td class="n">{{.Total}}</td><td class="n">{{.Fixable}}</td>
+{{range $.Severities}}<td class="n{{if count $image.Counts .}} {{lower .}}{{end}}">{{count $image.Counts .}}</td>{{end}}
+<td>{{range $i, $f := .Worst}}{{if $i}}, {{end}}{{if $f.Link}}<a href="{{$f.Link}}">{{$f.ID}}</a>{{else}}{{$f.ID}}{{end}} ({{$f.Package}}){{end}}</td>
+</tr>
+{{end}}</table>
+{{if .Inventory.Errors}}<h2>Errors</h2>
+<ul>
+{{range .Inventory.Errors}}<li>{{.Repository}}{{if .Tag}}:{{.Tag}}{{end}}: {{.Error}}</li>
+{{end}}</ul>
+{{end}}</body>
+</html>
+`))
+
+// WriteHTML writes a self-contained HTML report with totals, one row per
+// image, and any errors.
+func (inv *VulnerabilityInventory) WriteHTML(w io.Writer) error {
+	return inventoryHTML.Execute(w, struct {
+		Inventory  *VulnerabilityInventory
+		Severities []string
+	}{inv, inventoryColumns()})
+}
@@ -0,0 +1,194 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func newTestInventoryServer(t *testing.T, securityCalls *atomic.Int32) *httptest.Server {
+	t.Helper()
+	scans := map[string][]byte{}
+	for digest, findings := range map[string][]string{
+		"sha256:shared": {"openssl@1.1.1k:CVE-2021-3712:High:1.1.1l", "bash@5.0:CVE-2019-18276:Low"},
+		"sha256:clean":  {"zlib@1.2.13:CVE-2022-0000:Negligible"},
+	} {
+		data, _ := json.Marshal(newTestScan(t, findings...))
+		scans[digest] = data
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		path := r.URL.Path
+		switch {
+		case path == "/api/v1/repository":
+			_, _ = w.Write([]byte(`{"repositories": [
+				{"namespace": "testorg", "name": "api", "kind": "image"},
+				{"namespace": "testorg", "name": "web", "kind": "image"},
+				{"namespace": "testorg", "name": "chart", "kind": "application"},
+				{"namespace": "testorg", "name": "broken", "kind": "image"}
+			]}`))
+		case strings.HasSuffix(path, "/api/tag/"):
+			_, _ = w.Write([]byte(`{"tags": [
+				{"name": "1.0", "manifest_digest": "sha256:shared"},
+				{"name": "sha256-shared.sig", "manifest_digest": "sha256:sig"}
+			]}`))
+		case strings.HasSuffix(path, "/web/tag/"):
+			_, _ = w.Write([]byte(`{"tags": [
+				{"name": "latest", "manifest_digest": "sha256:shared"},
+				{"name": "slim", "manifest_digest": "sha256:clean"}
+			]}`))
+		case strings.HasSuffix(path, "/broken/tag/"):
+			w.WriteHeader(http.StatusForbidden)
+		case strings.HasSuffix(path, "/security"):
+			securityCalls.Add(1)
+			digest := strings.TrimSuffix(path[strings.LastIndex(path, "/manifest/")+len("/manifest/"):], "/security")
+			_, _ = w.Write(scans[digest])
+		default:
+			t.Errorf("unexpected request %s", path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestVulnerabilityInventory(t *testing.T) {
+	var securityCalls atomic.Int32
+	server := newTestInventoryServer(t, &securityCalls)
+	defer server.Close()
+
+	client, err := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	inv, err := client.VulnerabilityInventory(context.Background(), testNamespace, &InventoryOptions{Concurrency: 2})
+	if err != nil {
+		t.Fatalf("VulnerabilityInventory returned error: %v", err)
+	}
+
+	if securityCalls.Load() != 2 || inv.UniqueManifests != 2 || inv.TagsScanned != 3 {
+		t.Errorf("expected shared digest to be fetched once, got %d calls, %+v", securityCalls.Load(), inv)
+	}
+	if inv.Repositories != 3 {
+		t.Errorf("expected 3 image repositories, got %d", inv.Repositories)
+	}
+	if len(inv.Images) != 3 || inv.Images[0].Repository != "api" || inv.Images[2].Tag != "slim" {
+		t.Fatalf("unexpected images: %+v", inv.Images)
+	}
+	api := inv.Images[0]
+	if api.Total != 2 || api.Counts[SeverityHigh] != 1 || api.Fixable != 1 || api.FixableCounts[SeverityHigh] != 1 {
+		t.Errorf("unexpected counts: %+v", api)
+	}
+	if len(api.Worst) != 2 || api.Worst[0].ID != "CVE-2021-3712" {
+		t.Errorf("expected worst CVE first, got %+v", api.Worst)
+	}
+	if inv.Totals[SeverityHigh] != 2 || inv.Totals[SeverityNegligible] != 1 {
+		t.Errorf("unexpected totals: %v", inv.Totals)
+	}
+	if len(inv.Errors) != 1 || inv.Errors[0].Repository != "broken" {
+		t.Errorf("expected one error for broken repository, got %+v", inv.Errors)
+	}
+}
+
+func TestVulnerabilityInventoryFilters(t *testing.T) {
+	var securityCalls atomic.Int32
+	server := newTestInventoryServer(t, &securityCalls)
+	defer server.Close()
+
+	client, err := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	cache := &ScanCache{}
+	inv, err := client.VulnerabilityInventory(context.Background(), testNamespace, &InventoryOptions{
+		CVEs:         []string{"cve-2021-3712"},
+		Repositories: []string{"api", "web"},
+		Cache:        cache,
+	})
+	if err != nil {
+		t.Fatalf("VulnerabilityInventory returned error: %v", err)
+	}
+	if !inv.Filtered || len(inv.Images) != 2 || inv.Images[1].Tag != "latest" {
+		t.Fatalf("expected only images exposed to the CVE, got %+v", inv.Images)
+	}
+	if inv.Images[0].Total != 1 || inv.Totals[SeverityLow] != 0 {
+		t.Errorf("expected filtered counts, got %+v totals %v", inv.Images[0], inv.Totals)
+	}
+
+	inv, err = client.VulnerabilityInventory(context.Background(), testNamespace, &InventoryOptions{
+		Packages:     []string{"zlib*"},
+		Repositories: []string{"web"},
+		Cache:        cache,
+		Concurrency:  -1, // falls back to the default
+	})
+	if err != nil {
+		t.Fatalf("VulnerabilityInventory returned error: %v", err)
+	}
+	if len(inv.Images) != 1 || inv.Images[0].Tag != "slim" {
+		t.Errorf("expected package filter to match slim, got %+v", inv.Images)
+	}
+	if securityCalls.Load() != 2 || inv.UniqueManifests != 0 {
+		t.Errorf("expected shared cache to serve second crawl, got %d calls", securityCalls.Load())
+	}
+}
+
+func TestVulnerabilityInventoryWriters(t *testing.T) {
+	inv := &VulnerabilityInventory{
+		Namespace: testNamespace,
+		Totals:    map[string]int{SeverityHigh: 1},
+		Images: []InventoryImage{{
+			Repository: "api",
+			Tag:        "1.0",
+			Digest:     testDigestSHA256,
+			Status:     ScanStatusScanned,
+			Total:      1,
+			Counts:     map[string]int{SeverityHigh: 1},
+			Fixable:    1,
+			Worst:      []VulnerabilityFinding{{ID: "CVE-2021-3712", Package: "openssl<script>"}},
+		}},
+		Errors: []InventoryError{{Repository: "broken", Error: "forbidden"}},
+	}
+
+	var buf bytes.Buffer
+	if err := inv.WriteCSV(&buf); err != nil {
+		t.Fatalf("WriteCSV returned error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || lines[0] != "repository,tag,digest,status,total,fixable,critical,high,medium,low,negligible,unknown,worst" {
+		t.Fatalf("unexpected CSV: %s", buf.String())
+	}
+	if lines[1] != "api,1.0,"+testDigestSHA256+",scanned,1,1,0,1,0,0,0,0,CVE-2021-3712" {
+		t.Errorf("unexpected CSV row: %s", lines[1])
+	}
+
+	buf.Reset()
+	if err := inv.WriteHTML(&buf); err != nil {
+		t.Fatalf("WriteHTML returned error: %v", err)
+	}
+	html := buf.String()
+	for _, want := range []string{"<td>api</td>", `class="n high"`, "openssl&lt;script&gt;", "broken: forbidden"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected %q in HTML, got: %s", want, html)
+		}
+	}
+}
+
+func TestIsArtifactTag(t *testing.T) {
+	for tag, want := range map[string]bool{
+		"sha256-abc.sig":  true,
+		"sha256-abc.att":  true,
+		"sha256-abc.sbom": true,
+		"sha256-abc":      false,
+		"v1.sig":          false,
+	} {
+		if got := isArtifactTag(tag); got != want {
+			t.Errorf("isArtifactTag(%q) = %v, want %v", tag, got, want)
+		}
+	}
+}
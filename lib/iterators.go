@@ -0,0 +1,181 @@
+/*
+Package lib provides Quay.io API client functionality.
+
+This file covers STREAMING ITERATORS over paginated endpoints:
+
+  - RepositoriesSeq()      - GET /api/v1/repository (page/limit)
+  - TagsSeq()              - GET /api/v1/repository/{namespace}/{repository}/tag/ (page/limit)
+  - LogsSeq()              - GET /api/v1/repository/{namespace}/{repository}/logs (next_page)
+  - OrganizationLogsSeq()  - GET /api/v1/organization/{orgname}/logs (next_page)
+  - UserLogsSeq()          - GET /api/v1/user/logs (next_page)
+  - SearchSeq()            - GET /api/v1/find/repositories (page)
+
+Iterators fetch one page at a time as the caller ranges over them, so memory
+use is bounded by the page size rather than the result set:
+
+	for tag, err := range client.TagsSeq(ctx, "myorg", "myrepo", true) {
+		if err != nil {
+			return err
+		}
+		fmt.Println(tag.Name)
+	}
+
+Breaking out of the loop stops further requests. A request error or a
+cancelled context is yielded once as the final element.
+*/
+package lib
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"strconv"
+)
+
+// seqPageSize is the page size requested by page/limit iterators.
+const seqPageSize = 100
+
+// pageFetcher returns one page of results for cursor ("" for the first page)
+// and the cursor of the following page, or "" when there are no more.
+type pageFetcher[T any] func(ctx context.Context, cursor string) (items []T, next string, err error)
+
+// seqPages turns a pageFetcher into a lazy iterator. Each range over the
+// result starts again from the first page.
+func seqPages[T any](ctx context.Context, fetch pageFetcher[T]) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		cursor := ""
+		for {
+			if err := ctx.Err(); err != nil {
+				yield(zero, err)
+				return
+			}
+			items, next, err := fetch(ctx, cursor)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if next == "" || next == cursor {
+				return
+			}
+			cursor = next
+		}
+	}
+}
+
+// errSeq yields err once.
+func errSeq[T any](err error) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		yield(zero, err)
+	}
+}
+
+// pageNumber converts a page cursor to a 1-based page number.
+func pageNumber(cursor string) int {
+	if n, err := strconv.Atoi(cursor); err == nil && n > 0 {
+		return n
+	}
+	return 1
+}
+
+// nextPageCursor returns the cursor following page when more results exist.
+func nextPageCursor(page int, hasAdditional bool) string {
+	if !hasAdditional {
+		return ""
+	}
+	return strconv.Itoa(page + 1)
+}
+
+// RepositoriesSeq iterates over repositories visible to the user, fetching pages lazily.
+func (c *Client) RepositoriesSeq(ctx context.Context, namespace string, public, starred, popularity bool) iter.Seq2[OrganizationRepository, error] {
+	return seqPages(ctx, func(ctx context.Context, cursor string) ([]OrganizationRepository, string, error) {
+		page := pageNumber(cursor)
+		repos, err := c.ListRepositories(ctx, namespace, public, starred, popularity, page, seqPageSize)
+		if err != nil {
+			return nil, "", err
+		}
+		return repos.Repositories, nextPageCursor(page, repos.HasAdditional), nil
+	})
+}
+
+// TagsSeq iterates over the tags of a repository, fetching pages lazily.
+func (c *Client) TagsSeq(ctx context.Context, namespace, repository string, onlyActive bool) iter.Seq2[Tag, error] {
+	if namespace == "" {
+		return errSeq[Tag](fmt.Errorf("namespace is required"))
+	}
+	if repository == "" {
+		return errSeq[Tag](fmt.Errorf("repository is required"))
+	}
+
+	return seqPages(ctx, func(ctx context.Context, cursor string) ([]Tag, string, error) {
+		page := pageNumber(cursor)
+		tags, err := c.ListTagsPage(ctx, namespace, repository, seqPageSize, page, onlyActive)
+		if err != nil {
+			return nil, "", err
+		}
+		return tags.Tags, nextPageCursor(page, tags.HasAdditional), nil
+	})
+}
+
+// LogsSeq iterates over the logs of a repository, following next_page tokens.
+func (c *Client) LogsSeq(ctx context.Context, namespace, repository, startDate, endDate string) iter.Seq2[LogEntry, error] {
+	return seqPages(ctx, func(ctx context.Context, cursor string) ([]LogEntry, string, error) {
+		logs, err := c.GetLogs(ctx, namespace, repository, cursor, startDate, endDate)
+		if err != nil {
+			return nil, "", err
+		}
+		return logs.Logs, logs.NextPage, nil
+	})
+}
+
+// OrganizationLogsSeq iterates over the logs of an organization, following next_page tokens.
+func (c *Client) OrganizationLogsSeq(ctx context.Context, orgname, startDate, endDate string) iter.Seq2[LogEntry, error] {
+	return seqPages(ctx, func(ctx context.Context, cursor string) ([]LogEntry, string, error) {
+		logs, err := c.GetOrganizationLogs(ctx, orgname, cursor, startDate, endDate)
+		if err != nil {
+			return nil, "", err
+		}
+		return logs.Logs, logs.NextPage, nil
+	})
+}
+
+// UserLogsSeq iterates over the current user's logs, following next_page tokens.
+func (c *Client) UserLogsSeq(ctx context.Context, startDate, endDate string) iter.Seq2[LogEntry, error] {
+	return seqPages(ctx, func(ctx context.Context, cursor string) ([]LogEntry, string, error) {
+		logs, err := c.GetUserLogs(ctx, cursor, startDate, endDate)
+		if err != nil {
+			return nil, "", err
+		}
+		return logs.Logs, logs.NextPage, nil
+	})
+}
+
+// SearchSeq iterates over repository search results, fetching pages lazily.
+func (c *Client) SearchSeq(ctx context.Context, query string) iter.Seq2[SearchRepository, error] {
+	return seqPages(ctx, func(ctx context.Context, cursor string) ([]SearchRepository, string, error) {
+		page := pageNumber(cursor)
+		result, err := c.SearchRepositories(ctx, query, page)
+		if err != nil {
+			return nil, "", err
+		}
+		return result.Results, nextPageCursor(page, result.HasAdditional), nil
+	})
+}
+
+// collectSeq drains seq into a slice, stopping at the first error.
+func collectSeq[T any](seq iter.Seq2[T, error]) ([]T, error) {
+	var all []T
+	for item, err := range seq {
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, item)
+	}
+	return all, nil
+}
@@ -0,0 +1,173 @@
+package lib
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+// newPagedTagServer serves pages tag pages of one tag each and counts requests.
+func newPagedTagServer(t *testing.T, pages int, requests *atomic.Int32) *Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if r.URL.Query().Get("limit") != strconv.Itoa(seqPageSize) {
+			t.Errorf("Expected limit %d, got %q", seqPageSize, r.URL.Query().Get("limit"))
+		}
+		data, _ := json.Marshal(RepositoryTags{
+			Tags:          []Tag{{Name: fmt.Sprintf("v%d", page)}},
+			Page:          page,
+			HasAdditional: page < pages,
+		})
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	return client
+}
+
+func TestTagsSeq(t *testing.T) {
+	var requests atomic.Int32
+	client := newPagedTagServer(t, 3, &requests)
+
+	var names []string
+	for tag, err := range client.TagsSeq(context.Background(), testNamespace, testRepository, true) {
+		if err != nil {
+			t.Fatalf("TagsSeq returned error: %v", err)
+		}
+		names = append(names, tag.Name)
+	}
+
+	if fmt.Sprint(names) != "[v1 v2 v3]" {
+		t.Errorf("Expected tags from 3 pages, got %v", names)
+	}
+	if requests.Load() != 3 {
+		t.Errorf("Expected 3 requests, got %d", requests.Load())
+	}
+}
+
+func TestTagsSeqStopsOnBreak(t *testing.T) {
+	var requests atomic.Int32
+	client := newPagedTagServer(t, 100, &requests)
+
+	for tag, err := range client.TagsSeq(context.Background(), testNamespace, testRepository, false) {
+		if err != nil {
+			t.Fatalf("TagsSeq returned error: %v", err)
+		}
+		if tag.Name == "v2" {
+			break
+		}
+	}
+
+	if requests.Load() != 2 {
+		t.Errorf("Expected fetching to stop after 2 pages, got %d requests", requests.Load())
+	}
+}
+
+func TestTagsSeqContextCancelled(t *testing.T) {
+	var requests atomic.Int32
+	client := newPagedTagServer(t, 100, &requests)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var count int
+	var lastErr error
+	for _, err := range client.TagsSeq(ctx, testNamespace, testRepository, false) {
+		if err != nil {
+			lastErr = err
+			break
+		}
+		count++
+		if count == 2 {
+			cancel()
+		}
+	}
+
+	if !errors.Is(lastErr, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", lastErr)
+	}
+	if count != 2 || requests.Load() != 2 {
+		t.Errorf("Expected 2 tags from 2 requests, got %d tags from %d requests", count, requests.Load())
+	}
+}
+
+func TestTagsSeqValidation(t *testing.T) {
+	client, _ := NewClientWithURL(testTokenValue, "http://unused.invalid/api/v1")
+	for _, err := range client.TagsSeq(context.Background(), "", testRepository, false) {
+		if err == nil || err.Error() != "namespace is required" {
+			t.Errorf("Expected namespace error, got %v", err)
+		}
+	}
+}
+
+func TestOrganizationLogsSeq(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/organization/"+testNamespace+"/logs" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		logs := Logs{Logs: []LogEntry{{Kind: "push_repo"}}, NextPage: "token-2"}
+		if r.URL.Query().Get("next_page") == "token-2" {
+			logs = Logs{Logs: []LogEntry{{Kind: "pull_repo"}, {Kind: "delete_tag"}}}
+		}
+		if r.URL.Query().Get("starttime") != "01/01/2024" {
+			t.Errorf("Expected starttime to be forwarded on every page")
+		}
+		data, _ := json.Marshal(logs)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	client, _ := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	var kinds []string
+	for entry, err := range client.OrganizationLogsSeq(context.Background(), testNamespace, "01/01/2024", "") {
+		if err != nil {
+			t.Fatalf("OrganizationLogsSeq returned error: %v", err)
+		}
+		kinds = append(kinds, entry.Kind)
+	}
+
+	if fmt.Sprint(kinds) != "[push_repo pull_repo delete_tag]" {
+		t.Errorf("Unexpected log kinds %v", kinds)
+	}
+}
+
+func TestSearchSeqYieldsErrorOnce(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": "bad page"}`))
+			return
+		}
+		data, _ := json.Marshal(SearchRepositoryResult{Results: []SearchRepository{{Name: "one"}}, HasAdditional: true})
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	client, _ := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	var results, errs int
+	for _, err := range client.SearchSeq(context.Background(), "one") {
+		if err != nil {
+			errs++
+			continue
+		}
+		results++
+	}
+
+	if results != 1 || errs != 1 {
+		t.Errorf("Expected 1 result then 1 error, got %d results and %d errors", results, errs)
+	}
+}
@@ -0,0 +1,445 @@
+/*
+Package lib provides Quay.io API client functionality.
+
+This file covers the OFFLINE AUDIT LOG archive:
+
+Archive:
+  - OpenLogArchive(dir) (*LogArchive, error) - Open or create an archive directory
+  - (*Client).ArchiveOrganizationLogs(ctx, archive, orgname, opts) (*LogArchiveSync, error) - Append new entries
+  - (*LogArchive).Cursor() LogArchiveCursor - Where the last sync stopped
+
+Query:
+  - (*LogArchive).Query(query) iter.Seq2[LogEntry, error] - Archived entries matching a LogQuery, oldest first
+
+An archive is a directory holding one append-only JSONL segment per day
+(2026-05-19.jsonl, in UTC) and cursor.json. A sync walks the organization
+logs day by day from the day after the last complete day, or from the day of
+the newest archived entry, through today, following next_page tokens. Each
+day is appended and the cursor saved before the next day is read, so an
+interrupted sync resumes where it stopped. Entries already archived are
+recognized by their datetime and identity and are not appended again; a
+query also drops duplicates left by a sync that died between appending a
+day and saving the cursor.
+*/
+package lib
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultLogArchiveLookback is how far back the first sync of an archive
+// reads when LogArchiveOptions.Since is not set.
+const DefaultLogArchiveLookback = 30 * 24 * time.Hour
+
+const (
+	logArchiveCursorFile   = "cursor.json"
+	logArchiveSegmentExt   = ".jsonl"
+	logArchiveSegmentDate  = time.DateOnly
+	logArchiveSegmentPerm  = 0o600
+	logArchiveDirPerm      = 0o750
+	maxLogArchiveLineBytes = 1 << 20
+)
+
+// LogArchive is an on-disk archive of one organization's audit logs.
+type LogArchive struct {
+	dir    string
+	cursor LogArchiveCursor
+}
+
+// LogArchiveCursor records how far an archive has been synced.
+type LogArchiveCursor struct {
+	// Namespace is the organization the archive holds.
+	Namespace string `json:"namespace"`
+	// Newest is the datetime of the newest archived entry.
+	Newest time.Time `json:"newest,omitzero"`
+	// NewestKeys identify the entries logged at Newest, so entries sharing
+	// that second are not appended twice.
+	NewestKeys []string `json:"newest_keys,omitempty"`
+	// SyncedThrough is the last day (UTC, YYYY-MM-DD) that was read to the
+	// end after it was over.
+	SyncedThrough string `json:"synced_through,omitempty"`
+	// Entries counts the archived entries.
+	Entries int `json:"entries"`
+	// LastSync is when the last sync finished.
+	LastSync time.Time `json:"last_sync,omitzero"`
+}
+
+// LogArchiveOptions controls ArchiveOrganizationLogs.
+type LogArchiveOptions struct {
+	// Since is where the first sync starts. Defaults to
+	// DefaultLogArchiveLookback ago. Ignored once the archive has a cursor.
+	Since time.Time
+	// Progress, if set, is called after each day is archived.
+	Progress func(day time.Time, appended int)
+}
+
+// LogArchiveSync summarizes one sync.
+type LogArchiveSync struct {
+	Namespace string    `json:"namespace"`
+	From      string    `json:"from"`
+	Through   string    `json:"through"`
+	Days      int       `json:"days"`
+	Appended  int       `json:"appended"`
+	Total     int       `json:"total"`
+	Newest    time.Time `json:"newest,omitzero"`
+}
+
+// LogQuery selects archived entries. Empty fields match everything; list
+// fields match when any element does. Globs use path.Match syntax.
+type LogQuery struct {
+	// Start and End bound the entry datetime; End is exclusive.
+	Start, End time.Time
+	// Kinds are globs on the entry kind.
+	Kinds []string
+	// Repositories are globs on the repository name.
+	Repositories []string
+	// Performers are globs on the performer name.
+	Performers []string
+	// Robots are globs on robot performer names. Set, it keeps robot entries only.
+	Robots []string
+	// IPs are addresses or CIDR prefixes of the client IP.
+	IPs []string
+}
+
+// OpenLogArchive opens the archive in dir, creating the directory if needed.
+func OpenLogArchive(dir string) (*LogArchive, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("archive directory is required")
+	}
+	if err := os.MkdirAll(dir, logArchiveDirPerm); err != nil {
+		return nil, fmt.Errorf("failed to create log archive: %w", err)
+	}
+
+	a := &LogArchive{dir: dir}
+	data, err := os.ReadFile(filepath.Join(dir, logArchiveCursorFile)) // #nosec G304 -- cursor of the archive being opened
+	if errors.Is(err, os.ErrNotExist) {
+		return a, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log archive cursor: %w", err)
+	}
+	if err := json.Unmarshal(data, &a.cursor); err != nil {
+		return nil, fmt.Errorf("failed to parse log archive cursor: %w", err)
+	}
+	return a, nil
+}
+
+// Dir returns the archive directory.
+func (a *LogArchive) Dir() string {
+	return a.dir
+}
+
+// Cursor returns where the last sync stopped.
+func (a *LogArchive) Cursor() LogArchiveCursor {
+	return a.cursor
+}
+
+// ArchiveOrganizationLogs appends the organization's log entries that are not
+// yet in archive, day by day through today. An archive holds a single
+// organization.
+func (c *Client) ArchiveOrganizationLogs(ctx context.Context, archive *LogArchive, orgname string, opts *LogArchiveOptions) (*LogArchiveSync, error) {
+	if orgname == "" {
+		return nil, fmt.Errorf("orgname is required")
+	}
+	if ns := archive.cursor.Namespace; ns != "" && ns != orgname {
+		return nil, fmt.Errorf("log archive %s holds %s, not %s", archive.dir, ns, orgname)
+	}
+	if opts == nil {
+		opts = &LogArchiveOptions{}
+	}
+	archive.cursor.Namespace = orgname
+
+	today := logArchiveDay(time.Now())
+	day := archive.startDay(opts.Since)
+	summary := &LogArchiveSync{Namespace: orgname, From: day.Format(logArchiveSegmentDate), Through: today.Format(logArchiveSegmentDate)}
+	for ; !day.After(today); day = day.AddDate(0, 0, 1) {
+		date := day.Format(LogDateFormat)
+		appended, err := archive.appendDay(day, c.OrganizationLogsSeq(ctx, orgname, date, date))
+		if err != nil {
+			return summary, fmt.Errorf("failed to archive logs of %s: %w", day.Format(logArchiveSegmentDate), err)
+		}
+		if day.Before(today) {
+			archive.cursor.SyncedThrough = day.Format(logArchiveSegmentDate)
+		}
+		archive.cursor.LastSync = time.Now().UTC()
+		if err := archive.saveCursor(); err != nil {
+			return summary, err
+		}
+
+		summary.Days++
+		summary.Appended += appended
+		if opts.Progress != nil {
+			opts.Progress(day, appended)
+		}
+	}
+	summary.Total = archive.cursor.Entries
+	summary.Newest = archive.cursor.Newest
+	return summary, nil
+}
+
+// startDay is the first day a sync reads: the day of the newest archived
+// entry, or the day after the last complete day if that is later.
+func (a *LogArchive) startDay(since time.Time) time.Time {
+	var start time.Time
+	if !a.cursor.Newest.IsZero() {
+		start = logArchiveDay(a.cursor.Newest)
+	}
+	if synced, err := time.Parse(logArchiveSegmentDate, a.cursor.SyncedThrough); err == nil && !synced.Before(start) {
+		start = synced.AddDate(0, 0, 1)
+	}
+	if start.IsZero() {
+		start = logArchiveDay(cmp.Or(since, time.Now().Add(-DefaultLogArchiveLookback)))
+	}
+	return start
+}
+
+// appendDay appends the entries of one day that are newer than the cursor
+// to the day's segment and advances the cursor.
+func (a *LogArchive) appendDay(day time.Time, entries iter.Seq2[LogEntry, error]) (int, error) {
+	boundary := make(map[string]bool, len(a.cursor.NewestKeys))
+	for _, key := range a.cursor.NewestKeys {
+		boundary[key] = true
+	}
+
+	var fresh []timedLogEntry
+	for entry, err := range entries {
+		if err != nil {
+			return 0, err
+		}
+		at, err := ParseLogTime(entry.Datetime)
+		if err != nil {
+			return 0, fmt.Errorf("invalid log datetime %q: %w", entry.Datetime, err)
+		}
+		if at.Before(a.cursor.Newest) || at.Equal(a.cursor.Newest) && boundary[logEntryKey(entry)] {
+			continue
+		}
+		fresh = append(fresh, timedLogEntry{entry: entry, at: at})
+	}
+	if len(fresh) == 0 {
+		return 0, nil
+	}
+	slices.SortStableFunc(fresh, func(a, b timedLogEntry) int { return a.at.Compare(b.at) })
+
+	if err := a.writeSegment(day, fresh); err != nil {
+		return 0, err
+	}
+	a.cursor.Entries += len(fresh)
+	newest := fresh[len(fresh)-1].at
+	if !newest.Equal(a.cursor.Newest) {
+		a.cursor.Newest = newest.UTC()
+		a.cursor.NewestKeys = nil
+	}
+	for _, e := range fresh {
+		if e.at.Equal(newest) {
+			a.cursor.NewestKeys = append(a.cursor.NewestKeys, logEntryKey(e.entry))
+		}
+	}
+	return len(fresh), nil
+}
+
+func (a *LogArchive) writeSegment(day time.Time, entries []timedLogEntry) error {
+	name := filepath.Join(a.dir, day.Format(logArchiveSegmentDate)+logArchiveSegmentExt)
+	f, err := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, logArchiveSegmentPerm) // #nosec G304 -- segment of the archive directory
+	if err != nil {
+		return fmt.Errorf("failed to open log archive segment: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	encoder := json.NewEncoder(w)
+	for _, e := range entries {
+		if err := encoder.Encode(e.entry); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to write log archive segment: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write log archive segment: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write log archive segment: %w", err)
+	}
+	return f.Close()
+}
+
+// saveCursor replaces cursor.json atomically.
+func (a *LogArchive) saveCursor() error {
+	data, err := json.MarshalIndent(a.cursor, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode log archive cursor: %w", err)
+	}
+	tmp := filepath.Join(a.dir, logArchiveCursorFile+".tmp")
+	if err := os.WriteFile(tmp, data, logArchiveSegmentPerm); err != nil {
+		return fmt.Errorf("failed to save log archive cursor: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(a.dir, logArchiveCursorFile)); err != nil {
+		return fmt.Errorf("failed to save log archive cursor: %w", err)
+	}
+	return nil
+}
+
+// Query yields the archived entries matching query, oldest first. Only the
+// segments of days overlapping query's time range are read.
+func (a *LogArchive) Query(query LogQuery) iter.Seq2[LogEntry, error] {
+	return func(yield func(LogEntry, error) bool) {
+		matcher, err := query.matcher()
+		if err != nil {
+			yield(LogEntry{}, err)
+			return
+		}
+		days, err := a.segmentDays()
+		if err != nil {
+			yield(LogEntry{}, err)
+			return
+		}
+		for _, day := range days {
+			if !matcher.overlaps(day) {
+				continue
+			}
+			entries, err := a.readSegment(day, matcher)
+			if err != nil {
+				yield(LogEntry{}, err)
+				return
+			}
+			for _, entry := range entries {
+				if !yield(entry, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// segmentDays lists the days with a segment, in order.
+func (a *LogArchive) segmentDays() ([]time.Time, error) {
+	files, err := os.ReadDir(a.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log archive: %w", err)
+	}
+	var days []time.Time
+	for _, file := range files {
+		name, ok := strings.CutSuffix(file.Name(), logArchiveSegmentExt)
+		if !ok || file.IsDir() {
+			continue
+		}
+		if day, err := time.Parse(logArchiveSegmentDate, name); err == nil {
+			days = append(days, day)
+		}
+	}
+	slices.SortFunc(days, time.Time.Compare)
+	return days, nil
+}
+
+// readSegment returns the distinct entries of a day that match, oldest first.
+func (a *LogArchive) readSegment(day time.Time, matcher *logMatcher) ([]LogEntry, error) {
+	name := filepath.Join(a.dir, day.Format(logArchiveSegmentDate)+logArchiveSegmentExt)
+	f, err := os.Open(name) // #nosec G304 -- segment of the archive directory
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log archive segment: %w", err)
+	}
+	defer f.Close()
+
+	var matched []timedLogEntry
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, maxLogArchiveLineBytes)
+	for line := 1; scanner.Scan(); line++ {
+		var entry LogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse %s line %d: %w", filepath.Base(name), line, err)
+		}
+		at, err := ParseLogTime(entry.Datetime)
+		if err != nil || !matcher.matches(entry, at) {
+			continue
+		}
+		if key := logEntryKey(entry); !seen[key] {
+			seen[key] = true
+			matched = append(matched, timedLogEntry{entry: entry, at: at})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read log archive segment: %w", err)
+	}
+
+	slices.SortStableFunc(matched, func(a, b timedLogEntry) int { return a.at.Compare(b.at) })
+	entries := make([]LogEntry, len(matched))
+	for i, e := range matched {
+		entries[i] = e.entry
+	}
+	return entries, nil
+}
+
+// logMatcher is a compiled LogQuery.
+type logMatcher struct {
+	query    LogQuery
+	prefixes []netip.Prefix
+}
+
+func (q LogQuery) matcher() (*logMatcher, error) {
+	m := &logMatcher{query: q}
+	for _, ip := range q.IPs {
+		prefix, err := netip.ParsePrefix(ip)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(ip)
+			if addrErr != nil {
+				return nil, fmt.Errorf("invalid IP or CIDR %q", ip)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		m.prefixes = append(m.prefixes, prefix.Masked())
+	}
+	return m, nil
+}
+
+// overlaps reports whether the query's time range overlaps the UTC day.
+func (m *logMatcher) overlaps(day time.Time) bool {
+	q := m.query
+	return (q.End.IsZero() || day.Before(q.End)) && (q.Start.IsZero() || day.AddDate(0, 0, 1).After(q.Start))
+}
+
+func (m *logMatcher) matches(entry LogEntry, at time.Time) bool {
+	q := m.query
+	if !q.Start.IsZero() && at.Before(q.Start) || !q.End.IsZero() && !at.Before(q.End) {
+		return false
+	}
+	if !matchesAnyGlob(q.Kinds, entry.Kind) ||
+		!matchesAnyGlob(q.Repositories, entry.Metadata.Repo) ||
+		!matchesAnyGlob(q.Performers, logPerformer(entry)) {
+		return false
+	}
+	if len(q.Robots) > 0 && (!logIsRobot(entry) || !matchesAnyGlob(q.Robots, logPerformer(entry))) {
+		return false
+	}
+	return m.matchesIP(entry.IP)
+}
+
+func (m *logMatcher) matchesIP(ip string) bool {
+	if len(m.prefixes) == 0 {
+		return true
+	}
+	addr, err := netip.ParseAddr(ip)
+	return err == nil && slices.ContainsFunc(m.prefixes, func(p netip.Prefix) bool { return p.Contains(addr.Unmap()) })
+}
+
+// matchesAnyGlob reports whether name matches one of patterns, or patterns is empty.
+func matchesAnyGlob(patterns []string, name string) bool {
+	return len(patterns) == 0 || slices.ContainsFunc(patterns, func(p string) bool { return globMatch(p, name) })
+}
+
+// logArchiveDay truncates t to its UTC day.
+func logArchiveDay(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
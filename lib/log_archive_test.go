@@ -0,0 +1,227 @@
+package lib
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testLogDays serves organization logs for the requested day, one entry per
+// page and newest first, and can be told to fail a day.
+type testLogDays struct {
+	mu      sync.Mutex
+	entries []LogEntry
+	failDay string
+}
+
+func (d *testLogDays) add(at time.Time, kind, performer, ip string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.entries = append(d.entries, LogEntry{
+		Kind:      kind,
+		IP:        ip,
+		Datetime:  at.UTC().Format(time.RFC1123Z),
+		Performer: Performer{Kind: "user", Name: performer, IsRobot: strings.Contains(performer, "+")},
+		Metadata:  Metadata{Namespace: testNamespace, Repo: testRepository},
+	})
+}
+
+func (d *testLogDays) serve(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/organization/"+testNamespace+"/logs") {
+			t.Errorf("Unexpected request %s", r.URL.Path)
+		}
+		day := r.URL.Query().Get(startTimeParam)
+		if end := r.URL.Query().Get(endTimeParam); end != day {
+			t.Errorf("Expected one-day window, got %s to %s", day, end)
+		}
+
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if day == d.failDay {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var page []LogEntry
+		for _, entry := range slices.Backward(d.entries) {
+			if at, _ := ParseLogTime(entry.Datetime); at.UTC().Format(LogDateFormat) == day {
+				page = append(page, entry)
+			}
+		}
+		i, _ := strconv.Atoi(r.URL.Query().Get("next_page"))
+		logs := Logs{}
+		if i < len(page) {
+			logs.Logs = page[i : i+1]
+			if i+1 < len(page) {
+				logs.NextPage = strconv.Itoa(i + 1)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(logs)
+	}))
+}
+
+func archiveTestClient(t *testing.T, server *httptest.Server) *Client {
+	t.Helper()
+	client, err := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	return client
+}
+
+func performers(t *testing.T, archive *LogArchive, query LogQuery) []string {
+	t.Helper()
+	var names []string
+	for entry, err := range archive.Query(query) {
+		if err != nil {
+			t.Fatalf("Query yielded error: %v", err)
+		}
+		names = append(names, entry.Performer.Name)
+	}
+	return names
+}
+
+func TestArchiveOrganizationLogsIncremental(t *testing.T) {
+	today := logArchiveDay(time.Now())
+	days := &testLogDays{}
+	days.add(today.Add(-47*time.Hour), "push_repo", "alice", "203.0.113.7")
+	days.add(today.Add(-46*time.Hour), "pull_repo", testNamespace+"+ci", "10.0.0.5")
+	days.add(today.Add(-23*time.Hour), "delete_tag", "bob", "2001:db8::1")
+	server := days.serve(t)
+	defer server.Close()
+	client := archiveTestClient(t, server)
+
+	dir := t.TempDir()
+	archive, err := OpenLogArchive(dir)
+	if err != nil {
+		t.Fatalf("OpenLogArchive returned error: %v", err)
+	}
+	summary, err := client.ArchiveOrganizationLogs(context.Background(), archive, testNamespace, &LogArchiveOptions{Since: today.AddDate(0, 0, -2)})
+	if err != nil {
+		t.Fatalf("ArchiveOrganizationLogs returned error: %v", err)
+	}
+	if summary.Days != 3 || summary.Appended != 3 || summary.Total != 3 {
+		t.Errorf("Unexpected first sync: %+v", summary)
+	}
+
+	// A new entry today, sharing its second with nothing archived yet.
+	days.add(today, "push_repo", "carol", "203.0.113.8")
+	archive, err = OpenLogArchive(dir)
+	if err != nil {
+		t.Fatalf("OpenLogArchive returned error: %v", err)
+	}
+	summary, err = client.ArchiveOrganizationLogs(context.Background(), archive, testNamespace, nil)
+	if err != nil {
+		t.Fatalf("ArchiveOrganizationLogs returned error: %v", err)
+	}
+	if summary.Days != 1 || summary.Appended != 1 || summary.Total != 4 {
+		t.Errorf("Expected only today to be read and carol appended, got %+v", summary)
+	}
+	if cursor := archive.Cursor(); !cursor.Newest.Equal(today) || cursor.SyncedThrough != today.AddDate(0, 0, -1).Format(time.DateOnly) {
+		t.Errorf("Unexpected cursor: %+v", cursor)
+	}
+
+	// Syncing again appends nothing.
+	if summary, err = client.ArchiveOrganizationLogs(context.Background(), archive, testNamespace, nil); err != nil || summary.Appended != 0 {
+		t.Errorf("Expected nothing new, got %+v, %v", summary, err)
+	}
+
+	if got := performers(t, archive, LogQuery{}); !slices.Equal(got, []string{"alice", testNamespace + "+ci", "bob", "carol"}) {
+		t.Errorf("Expected all entries oldest first, got %v", got)
+	}
+	if _, err := client.ArchiveOrganizationLogs(context.Background(), archive, "otherorg", nil); err == nil {
+		t.Error("Expected an error archiving another organization into the same archive")
+	}
+}
+
+func TestArchiveOrganizationLogsResumes(t *testing.T) {
+	today := logArchiveDay(time.Now())
+	days := &testLogDays{failDay: today.AddDate(0, 0, -1).Format(LogDateFormat)}
+	days.add(today.Add(-47*time.Hour), "push_repo", "alice", "")
+	days.add(today.Add(-23*time.Hour), "push_repo", "bob", "")
+	server := days.serve(t)
+	defer server.Close()
+	client := archiveTestClient(t, server)
+
+	archive, err := OpenLogArchive(t.TempDir())
+	if err != nil {
+		t.Fatalf("OpenLogArchive returned error: %v", err)
+	}
+	opts := &LogArchiveOptions{Since: today.AddDate(0, 0, -2)}
+	summary, err := client.ArchiveOrganizationLogs(context.Background(), archive, testNamespace, opts)
+	if err == nil || summary.Days != 1 || summary.Appended != 1 {
+		t.Fatalf("Expected failure after the first day, got %+v, %v", summary, err)
+	}
+
+	days.mu.Lock()
+	days.failDay = ""
+	days.mu.Unlock()
+	summary, err = client.ArchiveOrganizationLogs(context.Background(), archive, testNamespace, opts)
+	if err != nil {
+		t.Fatalf("ArchiveOrganizationLogs returned error: %v", err)
+	}
+	if summary.From != today.AddDate(0, 0, -1).Format(time.DateOnly) || summary.Appended != 1 || summary.Total != 2 {
+		t.Errorf("Expected the sync to resume at the failed day, got %+v", summary)
+	}
+}
+
+func TestLogArchiveQuery(t *testing.T) {
+	dir := t.TempDir()
+	day := time.Date(2026, 5, 19, 0, 0, 0, 0, time.UTC)
+	lines := []LogEntry{
+		{Kind: "push_repo", IP: "10.1.2.3", Datetime: day.Add(2 * time.Hour).Format(time.RFC1123Z),
+			Performer: Performer{Name: "myorg+ci", IsRobot: true}, Metadata: Metadata{Repo: "api"}},
+		{Kind: "delete_tag", IP: "203.0.113.7", Datetime: day.Add(time.Hour).Format(time.RFC1123Z),
+			Performer: Performer{Name: "alice"}, Metadata: Metadata{Repo: "web"}},
+		{Kind: "pull_repo", IP: "10.9.9.9", Datetime: day.Add(3 * time.Hour).Format(time.RFC1123Z),
+			Performer: Performer{Name: "myorg+deploy", IsRobot: true}, Metadata: Metadata{Repo: "web"}},
+	}
+	// The last line repeats the first, as an interrupted sync could leave it.
+	var segment strings.Builder
+	for _, entry := range append(lines, lines[0]) {
+		data, _ := json.Marshal(entry)
+		segment.Write(append(data, '\n'))
+	}
+	if err := os.WriteFile(filepath.Join(dir, "2026-05-19.jsonl"), []byte(segment.String()), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	archive, err := OpenLogArchive(dir)
+	if err != nil {
+		t.Fatalf("OpenLogArchive returned error: %v", err)
+	}
+
+	for name, tc := range map[string]struct {
+		query LogQuery
+		want  []string
+	}{
+		"all, deduplicated": {LogQuery{}, []string{"alice", "myorg+ci", "myorg+deploy"}},
+		"kind":              {LogQuery{Kinds: []string{"p*_repo"}}, []string{"myorg+ci", "myorg+deploy"}},
+		"repository":        {LogQuery{Repositories: []string{"web"}}, []string{"alice", "myorg+deploy"}},
+		"performer":         {LogQuery{Performers: []string{"alice"}}, []string{"alice"}},
+		"robot":             {LogQuery{Robots: []string{"*+deploy"}}, []string{"myorg+deploy"}},
+		"cidr":              {LogQuery{IPs: []string{"10.0.0.0/8"}}, []string{"myorg+ci", "myorg+deploy"}},
+		"ip":                {LogQuery{IPs: []string{"203.0.113.7"}}, []string{"alice"}},
+		"time range":        {LogQuery{Start: day.Add(90 * time.Minute), End: day.Add(3 * time.Hour)}, []string{"myorg+ci"}},
+		"other day":         {LogQuery{Start: day.AddDate(0, 0, 1)}, nil},
+	} {
+		if got := performers(t, archive, tc.query); !slices.Equal(got, tc.want) {
+			t.Errorf("%s: expected %v, got %v", name, tc.want, got)
+		}
+	}
+
+	for _, err := range archive.Query(LogQuery{IPs: []string{"not-an-ip"}}) {
+		if err == nil || !strings.Contains(err.Error(), "not-an-ip") {
+			t.Errorf("Expected invalid IP error, got %v", err)
+		}
+	}
+}
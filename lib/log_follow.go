@@ -0,0 +1,212 @@
+/*
+Package lib provides Quay.io API client functionality.
+
+This file covers FOLLOWING audit logs in near real time:
+
+  - (*Client).FollowOrganizationLogs(ctx, orgname, opts) iter.Seq2[LogEntry, error]
+  - (*Client).FollowRepositoryLogs(ctx, namespace, repository, opts) iter.Seq2[LogEntry, error]
+  - (*Client).FollowUserLogs(ctx, opts) iter.Seq2[LogEntry, error]
+  - ParseLogTime(datetime string) (time.Time, error) - Parse LogEntry.Datetime
+
+Quay filters logs by whole days, so each poll re-reads the window from the
+day of the newest entry seen (less LogFollowOverlap) to today. Entries seen in
+an earlier poll are recognized by their datetime, kind, performer, IP and
+metadata and are not yielded again. New entries are yielded oldest first; an
+entry whose datetime does not parse is yielded once, after the others.
+*/
+package lib
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"iter"
+	"slices"
+	"time"
+)
+
+// LogDateFormat is the date format of Quay's starttime and endtime log parameters.
+const LogDateFormat = "01/02/2006"
+
+const (
+	// DefaultLogPollInterval is the polling interval of the Follow*Logs iterators.
+	DefaultLogPollInterval = 15 * time.Second
+	// LogFollowOverlap is how far before the newest entry seen each poll
+	// looks, to pick up entries that are indexed late.
+	LogFollowOverlap = 5 * time.Minute
+)
+
+// FollowLogsOptions controls the Follow*Logs iterators.
+type FollowLogsOptions struct {
+	// Since yields entries at or after this time. Zero starts from now, so
+	// only entries logged after the call are yielded.
+	Since time.Time
+	// PollInterval is the delay between polls. Defaults to DefaultLogPollInterval.
+	PollInterval time.Duration
+	// Kinds keeps only entries whose kind matches one of these globs.
+	Kinds []string
+	// Performers keeps only entries whose performer name matches one of these globs.
+	Performers []string
+}
+
+// FollowOrganizationLogs yields new log entries of an organization until ctx
+// is done, which is yielded as the final error.
+func (c *Client) FollowOrganizationLogs(ctx context.Context, orgname string, opts *FollowLogsOptions) iter.Seq2[LogEntry, error] {
+	return followLogs(ctx, opts, func(startDate, endDate string) iter.Seq2[LogEntry, error] {
+		return c.OrganizationLogsSeq(ctx, orgname, startDate, endDate)
+	})
+}
+
+// FollowRepositoryLogs yields new log entries of a repository until ctx is
+// done, which is yielded as the final error.
+func (c *Client) FollowRepositoryLogs(ctx context.Context, namespace, repository string, opts *FollowLogsOptions) iter.Seq2[LogEntry, error] {
+	return followLogs(ctx, opts, func(startDate, endDate string) iter.Seq2[LogEntry, error] {
+		return c.LogsSeq(ctx, namespace, repository, startDate, endDate)
+	})
+}
+
+// FollowUserLogs yields new log entries of the current user until ctx is
+// done, which is yielded as the final error.
+func (c *Client) FollowUserLogs(ctx context.Context, opts *FollowLogsOptions) iter.Seq2[LogEntry, error] {
+	return followLogs(ctx, opts, func(startDate, endDate string) iter.Seq2[LogEntry, error] {
+		return c.UserLogsSeq(ctx, startDate, endDate)
+	})
+}
+
+// ParseLogTime parses the datetime of a log entry, which Quay formats as
+// RFC 1123 with a numeric zone.
+func ParseLogTime(datetime string) (time.Time, error) {
+	t, err := time.Parse(time.RFC1123Z, datetime)
+	if err != nil {
+		return time.Parse(time.RFC3339, datetime)
+	}
+	return t, nil
+}
+
+// logWindow reads the logs between two dates, newest first.
+type logWindow func(startDate, endDate string) iter.Seq2[LogEntry, error]
+
+// timedLogEntry is a log entry with its parsed datetime.
+type timedLogEntry struct {
+	entry LogEntry
+	at    time.Time
+}
+
+// logFollower tracks the entries already seen across polls.
+type logFollower struct {
+	opts  *FollowLogsOptions
+	floor time.Time
+	seen  map[string]time.Time
+	// undated holds the entries whose datetime does not parse. They never
+	// age out of the window, so they are remembered for good.
+	undated map[string]bool
+}
+
+func followLogs(ctx context.Context, opts *FollowLogsOptions, window logWindow) iter.Seq2[LogEntry, error] {
+	if opts == nil {
+		opts = &FollowLogsOptions{}
+	}
+	interval := cmp.Or(opts.PollInterval, DefaultLogPollInterval)
+
+	return func(yield func(LogEntry, error) bool) {
+		f := &logFollower{opts: opts, floor: opts.Since, seen: make(map[string]time.Time), undated: make(map[string]bool)}
+		if f.floor.IsZero() {
+			// Log datetimes have 1-second precision.
+			f.floor = time.Now().Truncate(time.Second)
+		}
+		for {
+			entries, err := f.poll(window)
+			if err != nil {
+				yield(LogEntry{}, err)
+				return
+			}
+			for _, entry := range entries {
+				if !yield(entry, nil) {
+					return
+				}
+			}
+
+			t := time.NewTimer(interval)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				yield(LogEntry{}, ctx.Err())
+				return
+			case <-t.C:
+			}
+		}
+	}
+}
+
+// poll reads the current window and returns the unseen entries that pass the
+// filters, oldest first. It then slides the window to the newest entry seen.
+func (f *logFollower) poll(window logWindow) ([]LogEntry, error) {
+	startDate := f.floor.UTC().Format(LogDateFormat)
+	endDate := time.Now().UTC().Format(LogDateFormat)
+	polled := time.Now()
+
+	var fresh []timedLogEntry
+	newest := f.floor
+	for entry, err := range window(startDate, endDate) {
+		if err != nil {
+			return nil, err
+		}
+		at, err := ParseLogTime(entry.Datetime)
+		if err != nil {
+			// Yielded once, after the dated entries, without moving the window.
+			key := logEntryKey(entry)
+			if !f.undated[key] && f.matches(entry) {
+				fresh = append(fresh, timedLogEntry{entry: entry, at: polled})
+			}
+			f.undated[key] = true
+			continue
+		}
+		if at.Before(f.floor) {
+			// Logs are returned newest first, so the rest are older still.
+			break
+		}
+		key := logEntryKey(entry)
+		if _, ok := f.seen[key]; ok {
+			continue
+		}
+		f.seen[key] = at
+		if at.After(newest) {
+			newest = at
+		}
+		if f.matches(entry) {
+			fresh = append(fresh, timedLogEntry{entry: entry, at: at})
+		}
+	}
+
+	f.slide(newest.Add(-LogFollowOverlap))
+	slices.SortStableFunc(fresh, func(a, b timedLogEntry) int { return a.at.Compare(b.at) })
+	entries := make([]LogEntry, len(fresh))
+	for i, e := range fresh {
+		entries[i] = e.entry
+	}
+	return entries, nil
+}
+
+// slide raises the floor to floor, forgetting entries below it, which later
+// polls skip anyway.
+func (f *logFollower) slide(floor time.Time) {
+	if !floor.After(f.floor) {
+		return
+	}
+	f.floor = floor
+	for key, at := range f.seen {
+		if at.Before(floor) {
+			delete(f.seen, key)
+		}
+	}
+}
+
+func (f *logFollower) matches(entry LogEntry) bool {
+	return matchesAnyGlob(f.opts.Kinds, entry.Kind) && matchesAnyGlob(f.opts.Performers, entry.Performer.Name)
+}
+
+// logEntryKey identifies a log entry across overlapping windows.
+func logEntryKey(entry LogEntry) string {
+	metadata, _ := json.Marshal(entry.Metadata)
+	return entry.Datetime + "\x00" + entry.Kind + "\x00" + entry.Performer.Kind + "/" + entry.Performer.Name + "\x00" + entry.IP + "\x00" + string(metadata)
+}
@@ -0,0 +1,185 @@
+package lib
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testLogFeed serves organization logs newest first and lets tests append entries.
+type testLogFeed struct {
+	mu      sync.Mutex
+	entries []LogEntry
+}
+
+func (f *testLogFeed) add(at time.Time, kind, performer string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.entries = append([]LogEntry{{
+		Kind:      kind,
+		Datetime:  at.UTC().Format(time.RFC1123Z),
+		Performer: Performer{Kind: "user", Name: performer},
+	}}, f.entries...)
+}
+
+func (f *testLogFeed) serve(t *testing.T) *httptest.Server {
+	datePattern := regexp.MustCompile(`^\d{2}/\d{2}/\d{4}$`)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, param := range []string{startTimeParam, endTimeParam} {
+			if !datePattern.MatchString(r.URL.Query().Get(param)) {
+				t.Errorf("Expected %s in MM/DD/YYYY, got %q", param, r.URL.Query().Get(param))
+			}
+		}
+		f.mu.Lock()
+		data, _ := json.Marshal(Logs{Logs: f.entries})
+		f.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}))
+}
+
+func TestFollowOrganizationLogs(t *testing.T) {
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	feed := &testLogFeed{}
+	feed.add(start.Add(-time.Minute), "push_repo", "alice") // before Since
+	feed.add(start.Add(time.Second), "push_repo", "alice")
+	feed.add(start.Add(2*time.Second), "pull_repo", "bob")
+	feed.add(start.Add(3*time.Second), "push_repo", "ci+robot")
+	server := feed.serve(t)
+	defer server.Close()
+
+	client, err := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opts := &FollowLogsOptions{Since: start, PollInterval: time.Millisecond, Kinds: []string{"push_*"}}
+
+	var got []string
+	for entry, err := range client.FollowOrganizationLogs(ctx, testNamespace, opts) {
+		if err != nil {
+			t.Fatalf("FollowOrganizationLogs yielded error: %v", err)
+		}
+		got = append(got, entry.Performer.Name)
+		if len(got) == 2 {
+			// Arrives between polls; everything before it must not repeat.
+			feed.add(start.Add(4*time.Second), "push_repo", "carol")
+		}
+		if len(got) == 3 {
+			break
+		}
+	}
+
+	if want := []string{"alice", "ci+robot", "carol"}; !slices.Equal(got, want) {
+		t.Errorf("Expected %v oldest first without repeats, got %v", want, got)
+	}
+}
+
+func TestFollowLogsPerformerFilterAndCancel(t *testing.T) {
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	feed := &testLogFeed{}
+	feed.add(start.Add(time.Second), "push_repo", "alice")
+	feed.add(start.Add(2*time.Second), "push_repo", "bob")
+	server := feed.serve(t)
+	defer server.Close()
+
+	client, err := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	opts := &FollowLogsOptions{Since: start, PollInterval: 5 * time.Millisecond, Performers: []string{"bob"}}
+
+	var got []string
+	var last error
+	for entry, err := range client.FollowOrganizationLogs(ctx, testNamespace, opts) {
+		if err != nil {
+			last = err
+			continue
+		}
+		got = append(got, entry.Performer.Name)
+	}
+	if !slices.Equal(got, []string{"bob"}) {
+		t.Errorf("Expected only bob's entry once, got %v", got)
+	}
+	if !errors.Is(last, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded as final element, got %v", last)
+	}
+}
+
+func TestFollowLogsFromNow(t *testing.T) {
+	// Stay clear of a second boundary between logging and following.
+	for time.Now().Nanosecond() > int(500*time.Millisecond) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	feed := &testLogFeed{}
+	feed.add(time.Now().Add(-time.Minute), "push_repo", "alice")
+	feed.add(time.Now(), "push_repo", "bob")
+	server := feed.serve(t)
+	defer server.Close()
+
+	client, err := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	var got []string
+	for entry, err := range client.FollowOrganizationLogs(ctx, testNamespace, &FollowLogsOptions{PollInterval: 5 * time.Millisecond}) {
+		if err == nil {
+			got = append(got, entry.Performer.Name)
+		}
+	}
+	if !slices.Equal(got, []string{"bob"}) {
+		t.Errorf("Expected the entry logged in the starting second, got %v", got)
+	}
+}
+
+func TestFollowLogsUndatedEntryOnce(t *testing.T) {
+	undated := LogEntry{Kind: "push_repo", Datetime: "yesterday", Performer: Performer{Kind: "user", Name: "alice"}}
+	window := func(startDate, endDate string) iter.Seq2[LogEntry, error] {
+		return func(yield func(LogEntry, error) bool) { yield(undated, nil) }
+	}
+	f := &logFollower{opts: &FollowLogsOptions{}, floor: time.Now().Add(-time.Hour), seen: map[string]time.Time{}, undated: map[string]bool{}}
+
+	var got []LogEntry
+	for range 3 {
+		entries, err := f.poll(window)
+		if err != nil {
+			t.Fatalf("poll returned error: %v", err)
+		}
+		got = append(got, entries...)
+		// Newer dated entries move the window past the poll time.
+		f.slide(time.Now())
+		time.Sleep(time.Millisecond)
+	}
+	if len(got) != 1 {
+		t.Errorf("Expected the undated entry once, got %d", len(got))
+	}
+}
+
+func TestParseLogTime(t *testing.T) {
+	got, err := ParseLogTime(testDatetime)
+	if err != nil {
+		t.Fatalf("ParseLogTime returned error: %v", err)
+	}
+	if want := time.Date(2026, 5, 19, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if _, err := ParseLogTime("2026-05-19T00:00:00Z"); err != nil {
+		t.Errorf("Expected RFC 3339 fallback, got %v", err)
+	}
+}
@@ -0,0 +1,404 @@
+/*
+Package lib provides Quay.io API client functionality.
+
+This file covers AUDIT LOG formatting for SIEM ingestion:
+
+Log Formats:
+  - LogFormatNDJSON - One LogEntry as JSON per line
+  - LogFormatECS    - Elastic Common Schema JSON, one event per line
+  - LogFormatCEF    - ArcSight Common Event Format
+  - LogFormatLEEF   - IBM QRadar Log Event Extended Format 2.0
+
+Formatting:
+  - NewLogFormatter(format) (*LogFormatter, error) - Validate a format name
+  - (*LogFormatter).FormatEntry(entry) (string, error) - One entry as a single line
+  - (*LogFormatter).WriteEntries(w, entries) error - Entries, one per line
+  - LogEntryECS(entry, host) ECSLogEvent - Map an entry onto ECS fields
+
+Every format carries the kind (as the event action/signature), the performer
+and whether it is a robot, the client IP with its resolved country and
+provider, the user agent, and the namespace, repository, tag and manifest
+digest the entry refers to. Severity is derived from the kind: failures rank
+highest, deletions and permission, robot and token changes next.
+*/
+package lib
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Log formats accepted by NewLogFormatter.
+const (
+	LogFormatNDJSON = "ndjson"
+	LogFormatECS    = "ecs"
+	LogFormatCEF    = "cef"
+	LogFormatLEEF   = "leef"
+)
+
+const (
+	logVendor  = "Red Hat"
+	logProduct = "Quay"
+
+	ecsVersion   = "8.11.0"
+	ecsDataset   = "quay.audit"
+	leefTimeForm = "2006-01-02T15:04:05.000-0700"
+
+	// Log severities on the CEF 0-10 scale, also used by LEEF and ECS.
+	logSeverityLow    = 3
+	logSeverityMedium = 5
+	logSeverityHigh   = 7
+)
+
+// Kinds ranked above logSeverityLow, as globs.
+var (
+	highSeverityLogKinds   = []string{"*failure*", "*failed*"}
+	mediumSeverityLogKinds = []string{"delete_*", "*permission*", "*robot*", "*token*", "change_repo_visibility", "*_team_*", "org_remove_*"}
+)
+
+// LogFormats lists the formats accepted by NewLogFormatter.
+func LogFormats() []string {
+	return []string{LogFormatNDJSON, LogFormatECS, LogFormatCEF, LogFormatLEEF}
+}
+
+// LogFormatter renders log entries as single lines for a SIEM collector.
+type LogFormatter struct {
+	// Format is one of the LogFormat constants.
+	Format string
+	// Host is the Quay hostname, reported as CEF dvchost and ECS
+	// observer.hostname. Optional.
+	Host string
+	// ProductVersion is reported as the CEF and LEEF product version. Optional.
+	ProductVersion string
+}
+
+// NewLogFormatter returns a formatter for format, which must be one of LogFormats.
+func NewLogFormatter(format string) (*LogFormatter, error) {
+	if !slices.Contains(LogFormats(), format) {
+		return nil, fmt.Errorf("unsupported log format %q (want %s)", format, strings.Join(LogFormats(), ", "))
+	}
+	return &LogFormatter{Format: format}, nil
+}
+
+// FormatEntry renders entry as a single line without the trailing newline.
+func (f *LogFormatter) FormatEntry(entry LogEntry) (string, error) {
+	switch f.Format {
+	case LogFormatNDJSON:
+		data, err := json.Marshal(entry)
+		return string(data), err
+	case LogFormatECS:
+		data, err := json.Marshal(LogEntryECS(entry, f.Host))
+		return string(data), err
+	case LogFormatCEF:
+		return f.cef(entry), nil
+	case LogFormatLEEF:
+		return f.leef(entry), nil
+	}
+	return "", fmt.Errorf("unsupported log format %q (want %s)", f.Format, strings.Join(LogFormats(), ", "))
+}
+
+// WriteEntries writes entries to w, one per line.
+func (f *LogFormatter) WriteEntries(w io.Writer, entries []LogEntry) error {
+	for _, entry := range entries {
+		line, err := f.FormatEntry(entry)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, line+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ECS Structures
+
+// ECSLogEvent is a log entry mapped onto Elastic Common Schema fields.
+// Quay-specific fields that have no ECS equivalent are under "quay".
+type ECSLogEvent struct {
+	Timestamp string        `json:"@timestamp"`
+	ECS       ECSVersion    `json:"ecs"`
+	Event     ECSEvent      `json:"event"`
+	Message   string        `json:"message"`
+	Source    *ECSSource    `json:"source,omitempty"`
+	User      *ECSUser      `json:"user,omitempty"`
+	UserAgent *ECSUserAgent `json:"user_agent,omitempty"`
+	Observer  ECSObserver   `json:"observer"`
+	Quay      ECSQuay       `json:"quay"`
+}
+
+// ECSVersion is the "ecs" field set.
+type ECSVersion struct {
+	Version string `json:"version"`
+}
+
+// ECSEvent is the "event" field set.
+type ECSEvent struct {
+	Kind     string `json:"kind"`
+	Module   string `json:"module"`
+	Dataset  string `json:"dataset"`
+	Action   string `json:"action"`
+	Outcome  string `json:"outcome"`
+	Severity int    `json:"severity"`
+}
+
+// ECSSource is the "source" field set.
+type ECSSource struct {
+	IP  string  `json:"ip,omitempty"`
+	Geo *ECSGeo `json:"geo,omitempty"`
+}
+
+// ECSGeo is the "source.geo" field set.
+type ECSGeo struct {
+	CountryISOCode string `json:"country_iso_code,omitempty"`
+	ContinentCode  string `json:"continent_code,omitempty"`
+}
+
+// ECSUser is the "user" field set.
+type ECSUser struct {
+	Name string `json:"name"`
+}
+
+// ECSUserAgent is the "user_agent" field set.
+type ECSUserAgent struct {
+	Original string `json:"original"`
+}
+
+// ECSObserver is the "observer" field set, describing the Quay instance.
+type ECSObserver struct {
+	Vendor   string `json:"vendor"`
+	Product  string `json:"product"`
+	Type     string `json:"type"`
+	Hostname string `json:"hostname,omitempty"`
+}
+
+// ECSQuay holds the Quay-specific fields of an ECSLogEvent.
+type ECSQuay struct {
+	Namespace      string `json:"namespace,omitempty"`
+	Repository     string `json:"repository,omitempty"`
+	Tag            string `json:"tag,omitempty"`
+	ManifestDigest string `json:"manifest_digest,omitempty"`
+	PerformerKind  string `json:"performer_kind,omitempty"`
+	IsRobot        bool   `json:"is_robot,omitempty"`
+	IPProvider     string `json:"ip_provider,omitempty"`
+	IPService      string `json:"ip_service,omitempty"`
+}
+
+// LogEntryECS maps entry onto ECS fields. host, if set, is reported as
+// observer.hostname.
+func LogEntryECS(entry LogEntry, host string) ECSLogEvent {
+	event := ECSLogEvent{
+		Timestamp: logEntryTime(entry).Format(time.RFC3339Nano),
+		ECS:       ECSVersion{Version: ecsVersion},
+		Event: ECSEvent{
+			Kind:     "event",
+			Module:   "quay",
+			Dataset:  ecsDataset,
+			Action:   entry.Kind,
+			Outcome:  logOutcome(entry.Kind),
+			Severity: logSeverity(entry.Kind),
+		},
+		Message:  logMessage(entry),
+		Observer: ECSObserver{Vendor: logVendor, Product: logProduct, Type: "registry", Hostname: host},
+		Quay: ECSQuay{
+			Namespace:      entry.Metadata.Namespace,
+			Repository:     entry.Metadata.Repo,
+			Tag:            entry.Metadata.Tag,
+			ManifestDigest: entry.Metadata.ManifestDigest,
+			PerformerKind:  entry.Performer.Kind,
+			IsRobot:        logIsRobot(entry),
+			IPProvider:     entry.Metadata.ResolvedIP.Provider,
+			IPService:      entry.Metadata.ResolvedIP.Service,
+		},
+	}
+	if entry.IP != "" {
+		event.Source = &ECSSource{IP: entry.IP}
+		if ip := entry.Metadata.ResolvedIP; ip.CountryIsoCode != "" || ip.Continent != "" {
+			event.Source.Geo = &ECSGeo{CountryISOCode: ip.CountryIsoCode, ContinentCode: ip.Continent}
+		}
+	}
+	if name := logPerformer(entry); name != "" {
+		event.User = &ECSUser{Name: name}
+	}
+	if entry.Metadata.UserAgent != "" {
+		event.UserAgent = &ECSUserAgent{Original: entry.Metadata.UserAgent}
+	}
+	return event
+}
+
+// cef renders entry as a CEF:0 line. Custom strings carry the namespace,
+// repository, tag, digest and resolved country and provider of the IP.
+func (f *LogFormatter) cef(entry LogEntry) string {
+	header := []string{
+		"CEF:0", logVendor, logProduct, f.ProductVersion,
+		entry.Kind, strings.ReplaceAll(entry.Kind, "_", " "), strconv.Itoa(logSeverity(entry.Kind)),
+	}
+	for i := 1; i < len(header); i++ {
+		header[i] = cefHeaderEscaper.Replace(header[i])
+	}
+
+	var ext extension
+	ext.add("rt", strconv.FormatInt(logEntryTime(entry).UnixMilli(), 10))
+	ext.add("act", entry.Kind)
+	ext.add("outcome", logOutcome(entry.Kind))
+	ext.add("src", entry.IP)
+	ext.add("suser", logPerformer(entry))
+	ext.add("requestClientApplication", entry.Metadata.UserAgent)
+	ext.add("dvchost", f.Host)
+	ext.addLabeled("cs1", "namespace", entry.Metadata.Namespace)
+	ext.addLabeled("cs2", "repository", entry.Metadata.Repo)
+	ext.addLabeled("cs3", "tag", entry.Metadata.Tag)
+	ext.addLabeled("cs4", "manifestDigest", entry.Metadata.ManifestDigest)
+	ext.addLabeled("cs5", "srcCountry", entry.Metadata.ResolvedIP.CountryIsoCode)
+	ext.addLabeled("cs6", "srcProvider", entry.Metadata.ResolvedIP.Provider)
+	ext.addLabeled("flexString1", "performerKind", logPerformerKind(entry))
+
+	var b strings.Builder
+	b.WriteString(strings.Join(header, "|"))
+	b.WriteString("|")
+	for i, field := range ext {
+		if i > 0 {
+			b.WriteString(" ")
+		}
+		b.WriteString(field.key + "=" + cefValueEscaper.Replace(field.value))
+	}
+	return b.String()
+}
+
+// leef renders entry as a LEEF:2.0 line with tab-delimited attributes.
+func (f *LogFormatter) leef(entry LogEntry) string {
+	header := []string{"LEEF:2.0", logVendor, logProduct, f.ProductVersion, entry.Kind, "x09"}
+	for i := 1; i < len(header); i++ {
+		header[i] = leefHeaderEscaper.Replace(header[i])
+	}
+
+	var ext extension
+	ext.add("devTime", logEntryTime(entry).Format(leefTimeForm))
+	ext.add("devTimeFormat", "yyyy-MM-dd'T'HH:mm:ss.SSSZ")
+	ext.add("cat", entry.Kind)
+	ext.add("sev", strconv.Itoa(logSeverity(entry.Kind)))
+	ext.add("src", entry.IP)
+	ext.add("usrName", logPerformer(entry))
+	ext.add("accountName", entry.Metadata.Namespace)
+	ext.add("userAgent", entry.Metadata.UserAgent)
+	ext.add("repository", entry.Metadata.Repo)
+	ext.add("tag", entry.Metadata.Tag)
+	ext.add("manifestDigest", entry.Metadata.ManifestDigest)
+	ext.add("performerKind", logPerformerKind(entry))
+	ext.add("srcCountry", entry.Metadata.ResolvedIP.CountryIsoCode)
+	ext.add("srcProvider", entry.Metadata.ResolvedIP.Provider)
+
+	var b strings.Builder
+	b.WriteString(strings.Join(header, "|"))
+	b.WriteString("|")
+	for i, field := range ext {
+		if i > 0 {
+			b.WriteString("\t")
+		}
+		b.WriteString(field.key + "=" + leefValueEscaper.Replace(field.value))
+	}
+	return b.String()
+}
+
+var (
+	cefHeaderEscaper  = strings.NewReplacer(`\`, `\\`, "|", `\|`, "\n", " ", "\r", " ")
+	cefValueEscaper   = strings.NewReplacer(`\`, `\\`, "=", `\=`, "\n", `\n`, "\r", `\r`)
+	leefHeaderEscaper = strings.NewReplacer(`\`, `\\`, "|", `\|`, "\n", " ", "\r", " ")
+	leefValueEscaper  = strings.NewReplacer("\t", " ", "\n", " ", "\r", " ")
+)
+
+// extension is an ordered list of CEF or LEEF key=value attributes.
+type extension []extensionField
+
+type extensionField struct {
+	key, value string
+}
+
+// add appends key=value unless value is empty.
+func (e *extension) add(key, value string) {
+	if value != "" {
+		*e = append(*e, extensionField{key, value})
+	}
+}
+
+// addLabeled appends a CEF custom field and its label unless value is empty.
+func (e *extension) addLabeled(key, label, value string) {
+	if value != "" {
+		e.add(key+"Label", label)
+		e.add(key, value)
+	}
+}
+
+// logEntryTime parses the entry datetime, falling back to now when it is
+// missing or malformed so the event still carries a timestamp.
+func logEntryTime(entry LogEntry) time.Time {
+	t, err := ParseLogTime(entry.Datetime)
+	if err != nil {
+		return time.Now().UTC()
+	}
+	return t.UTC()
+}
+
+func logSeverity(kind string) int {
+	matches := func(p string) bool { return globMatch(p, kind) }
+	switch {
+	case slices.ContainsFunc(highSeverityLogKinds, matches):
+		return logSeverityHigh
+	case slices.ContainsFunc(mediumSeverityLogKinds, matches):
+		return logSeverityMedium
+	}
+	return logSeverityLow
+}
+
+func logOutcome(kind string) string {
+	if slices.ContainsFunc(highSeverityLogKinds, func(p string) bool { return globMatch(p, kind) }) {
+		return "failure"
+	}
+	return "success"
+}
+
+// logPerformer names who performed the action: the performer, or the
+// username in the metadata for entries without one (e.g. failed logins).
+func logPerformer(entry LogEntry) string {
+	if entry.Performer.Name != "" {
+		return entry.Performer.Name
+	}
+	return entry.Metadata.Username
+}
+
+func logPerformerKind(entry LogEntry) string {
+	if logIsRobot(entry) {
+		return "robot"
+	}
+	return entry.Performer.Kind
+}
+
+func logIsRobot(entry LogEntry) bool {
+	return entry.Performer.IsRobot || entry.Metadata.IsRobot
+}
+
+// logMessage summarizes entry for the ECS message field, e.g.
+// "alice push_repo myorg/app:v1 from 203.0.113.7".
+func logMessage(entry LogEntry) string {
+	parts := []string{cmp.Or(logPerformer(entry), "anonymous"), entry.Kind}
+	target := entry.Metadata.Namespace
+	if entry.Metadata.Repo != "" {
+		target = strings.TrimPrefix(target+"/"+entry.Metadata.Repo, "/")
+	}
+	if entry.Metadata.Tag != "" && target != "" {
+		target += ":" + entry.Metadata.Tag
+	}
+	if target != "" {
+		parts = append(parts, target)
+	}
+	if entry.IP != "" {
+		parts = append(parts, "from", entry.IP)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,146 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func testSIEMLogEntry() LogEntry {
+	return LogEntry{
+		Kind:     "push_repo",
+		Datetime: testDatetime,
+		IP:       "203.0.113.7",
+		Performer: Performer{
+			Kind:    "user",
+			Name:    "myorg+ci",
+			IsRobot: true,
+		},
+		Metadata: Metadata{
+			Namespace: testNamespace,
+			Repo:      testRepository,
+			Tag:       "v1",
+			UserAgent: "docker/27.0 go/go1.22|os=linux",
+			ResolvedIP: ResolvedIP{
+				Provider:       "aws",
+				CountryIsoCode: "US",
+				Continent:      "NA",
+			},
+		},
+	}
+}
+
+func TestNewLogFormatterUnsupported(t *testing.T) {
+	if _, err := NewLogFormatter("syslog"); err == nil || !strings.Contains(err.Error(), "cef") {
+		t.Errorf("Expected unsupported format error listing formats, got %v", err)
+	}
+}
+
+func TestFormatEntryCEF(t *testing.T) {
+	f := &LogFormatter{Format: LogFormatCEF, Host: "quay.example.com", ProductVersion: "3.12"}
+	line, err := f.FormatEntry(testSIEMLogEntry())
+	if err != nil {
+		t.Fatalf("FormatEntry returned error: %v", err)
+	}
+
+	wantPrefix := "CEF:0|Red Hat|Quay|3.12|push_repo|push repo|3|rt=1779148800000 act=push_repo outcome=success src=203.0.113.7 suser=myorg+ci "
+	if !strings.HasPrefix(line, wantPrefix) {
+		t.Errorf("Unexpected CEF line:\n%s", line)
+	}
+	for _, want := range []string{
+		`requestClientApplication=docker/27.0 go/go1.22|os\=linux`,
+		"dvchost=quay.example.com",
+		"cs1Label=namespace cs1=" + testNamespace,
+		"cs2Label=repository cs2=" + testRepository,
+		"cs5Label=srcCountry cs5=US",
+		"cs6Label=srcProvider cs6=aws",
+		"flexString1Label=performerKind flexString1=robot",
+	} {
+		if !strings.Contains(line, want) {
+			t.Errorf("Expected CEF line to contain %q:\n%s", want, line)
+		}
+	}
+	if strings.Contains(line, "cs4") {
+		t.Errorf("Expected empty manifest digest to be omitted:\n%s", line)
+	}
+}
+
+func TestFormatEntryLEEF(t *testing.T) {
+	entry := testSIEMLogEntry()
+	entry.Kind = "delete_tag"
+	entry.Metadata.UserAgent = "agent\twith tab"
+
+	line, err := (&LogFormatter{Format: LogFormatLEEF}).FormatEntry(entry)
+	if err != nil {
+		t.Fatalf("FormatEntry returned error: %v", err)
+	}
+
+	if !strings.HasPrefix(line, "LEEF:2.0|Red Hat|Quay||delete_tag|x09|devTime=2026-05-19T00:00:00.000+0000\t") {
+		t.Errorf("Unexpected LEEF header:\n%s", line)
+	}
+	attrs := map[string]string{}
+	for _, attr := range strings.Split(line[strings.LastIndex(line, "|")+1:], "\t") {
+		key, value, _ := strings.Cut(attr, "=")
+		attrs[key] = value
+	}
+	for key, want := range map[string]string{
+		"sev":           "5",
+		"src":           "203.0.113.7",
+		"usrName":       "myorg+ci",
+		"accountName":   testNamespace,
+		"userAgent":     "agent with tab",
+		"performerKind": "robot",
+	} {
+		if attrs[key] != want {
+			t.Errorf("Expected LEEF %s=%q, got %q", key, want, attrs[key])
+		}
+	}
+}
+
+func TestFormatEntryECS(t *testing.T) {
+	line, err := (&LogFormatter{Format: LogFormatECS, Host: "quay.example.com"}).FormatEntry(testSIEMLogEntry())
+	if err != nil {
+		t.Fatalf("FormatEntry returned error: %v", err)
+	}
+
+	var event ECSLogEvent
+	if err := json.Unmarshal([]byte(line), &event); err != nil {
+		t.Fatalf("ECS line is not JSON: %v", err)
+	}
+	if event.Timestamp != "2026-05-19T00:00:00Z" || event.Event.Action != "push_repo" || event.Event.Dataset != "quay.audit" {
+		t.Errorf("Unexpected ECS event: %+v", event)
+	}
+	if event.Source == nil || event.Source.IP != "203.0.113.7" || event.Source.Geo == nil || event.Source.Geo.CountryISOCode != "US" {
+		t.Errorf("Unexpected ECS source: %+v", event.Source)
+	}
+	if event.User == nil || event.User.Name != "myorg+ci" || !event.Quay.IsRobot || event.Observer.Hostname != "quay.example.com" {
+		t.Errorf("Unexpected ECS user or observer: %+v", event)
+	}
+	if want := "myorg+ci push_repo testorg/testrepo:v1 from 203.0.113.7"; event.Message != want {
+		t.Errorf("Expected message %q, got %q", want, event.Message)
+	}
+}
+
+func TestWriteEntriesNDJSON(t *testing.T) {
+	failed := LogEntry{Kind: "login_failure", Datetime: testDatetime, Metadata: Metadata{Username: "mallory"}}
+
+	var buf bytes.Buffer
+	if err := (&LogFormatter{Format: LogFormatNDJSON}).WriteEntries(&buf, []LogEntry{testSIEMLogEntry(), failed}); err != nil {
+		t.Fatalf("WriteEntries returned error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d:\n%s", len(lines), buf.String())
+	}
+	var entry LogEntry
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil || entry.Kind != "login_failure" {
+		t.Errorf("Unexpected NDJSON line %q: %v", lines[1], err)
+	}
+
+	ecs := LogEntryECS(failed, "")
+	if ecs.Event.Severity != logSeverityHigh || ecs.Event.Outcome != "failure" || ecs.User == nil || ecs.User.Name != "mallory" {
+		t.Errorf("Expected failed login to be high severity by mallory, got %+v", ecs)
+	}
+}
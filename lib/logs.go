@@ -52,7 +52,7 @@ func (c *Client) GetAggregatedLogs(ctx context.Context, namespace, repository, s
 	}
 
 	// Get new request
-	req, err := newRequest(ctx, http.MethodGet, c.buildURL("/repository/%s/%s/aggregatelogs", namespace, repository), nil)
+	req, err := newAPIRequest(ctx, http.MethodGet, c.buildURL("/repository/%s/%s/aggregatelogs", namespace, repository), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create get repository aggregate logs request: %w", err)
 	}
@@ -80,7 +80,7 @@ func (c *Client) GetLogs(ctx context.Context, namespace, repository, nextPage, s
 		return nil, fmt.Errorf("repository is required")
 	}
 
-	req, err := newRequest(ctx, http.MethodGet, c.buildURL("/repository/%s/%s/logs", namespace, repository), nil)
+	req, err := newAPIRequest(ctx, http.MethodGet, c.buildURL("/repository/%s/%s/logs", namespace, repository), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create get repository logs request: %w", err)
 	}
@@ -101,7 +101,7 @@ func (c *Client) GetOrganizationLogs(ctx context.Context, orgname, nextPage, sta
 		return nil, fmt.Errorf("orgname is required")
 	}
 
-	req, err := newRequest(ctx, http.MethodGet, c.buildURL("/organization/%s/logs", orgname), nil)
+	req, err := newAPIRequest(ctx, http.MethodGet, c.buildURL("/organization/%s/logs", orgname), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create get organization logs request: %w", err)
 	}
@@ -122,7 +122,7 @@ func (c *Client) GetOrganizationAggregatedLogs(ctx context.Context, orgname, sta
 		return nil, fmt.Errorf("orgname is required")
 	}
 
-	req, err := newRequest(ctx, http.MethodGet, c.buildURL("/organization/%s/aggregatelogs", orgname), nil)
+	req, err := newAPIRequest(ctx, http.MethodGet, c.buildURL("/organization/%s/aggregatelogs", orgname), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create get organization aggregate logs request: %w", err)
 	}
@@ -146,7 +146,7 @@ func (c *Client) ExportOrganizationLogs(ctx context.Context, orgname string, req
 		return fmt.Errorf("orgname is required")
 	}
 
-	req, err := newRequestWithBody(ctx, http.MethodPost, c.buildURL("/organization/%s/exportlogs", orgname), request)
+	req, err := newAPIRequestWithBody(ctx, http.MethodPost, c.buildURL("/organization/%s/exportlogs", orgname), request)
 	if err != nil {
 		return fmt.Errorf("failed to create export organization logs request: %w", err)
 	}
@@ -160,7 +160,7 @@ func (c *Client) ExportOrganizationLogs(ctx context.Context, orgname string, req
 
 // GetUserLogs returns the logs for the current user
 func (c *Client) GetUserLogs(ctx context.Context, nextPage, startDate, endDate string) (*Logs, error) {
-	req, err := newRequest(ctx, http.MethodGet, c.buildURL("/user/logs"), nil)
+	req, err := newAPIRequest(ctx, http.MethodGet, c.buildURL("/user/logs"), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create get user logs request: %w", err)
 	}
@@ -177,7 +177,7 @@ func (c *Client) GetUserLogs(ctx context.Context, nextPage, startDate, endDate s
 
 // GetUserAggregatedLogs returns the aggregated logs for the current user
 func (c *Client) GetUserAggregatedLogs(ctx context.Context, startDate, endDate string) (*AggregatedLogs, error) {
-	req, err := newRequest(ctx, http.MethodGet, c.buildURL("/user/aggregatelogs"), nil)
+	req, err := newAPIRequest(ctx, http.MethodGet, c.buildURL("/user/aggregatelogs"), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create get user aggregate logs request: %w", err)
 	}
@@ -197,7 +197,7 @@ func (c *Client) GetUserAggregatedLogs(ctx context.Context, startDate, endDate s
 
 // ExportUserLogs exports the logs for the current user
 func (c *Client) ExportUserLogs(ctx context.Context, request *ExportLogsRequest) error {
-	req, err := newRequestWithBody(ctx, http.MethodPost, c.buildURL("/user/exportlogs"), request)
+	req, err := newAPIRequestWithBody(ctx, http.MethodPost, c.buildURL("/user/exportlogs"), request)
 	if err != nil {
 		return fmt.Errorf("failed to create export user logs request: %w", err)
 	}
@@ -218,7 +218,7 @@ func (c *Client) ExportRepositoryLogs(ctx context.Context, namespace, repository
 		return fmt.Errorf("repository is required")
 	}
 
-	req, err := newRequestWithBody(ctx, http.MethodPost, c.buildURL("/repository/%s/%s/exportlogs", namespace, repository), request)
+	req, err := newAPIRequestWithBody(ctx, http.MethodPost, c.buildURL("/repository/%s/%s/exportlogs", namespace, repository), request)
 	if err != nil {
 		return fmt.Errorf("failed to create export repository logs request: %w", err)
 	}
@@ -19,7 +19,7 @@ import (
 
 // GetMessages retrieves system messages for the user
 func (c *Client) GetMessages(ctx context.Context) (*Messages, error) {
-	req, err := newRequest(ctx, http.MethodGet, c.buildURL("/messages"), nil)
+	req, err := newAPIRequest(ctx, http.MethodGet, c.buildURL("/messages"), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create messages request: %w", err)
 	}
@@ -59,7 +59,7 @@ func (c *Client) CreateMessage(ctx context.Context, content, severity, mediaType
 		},
 	}
 
-	req, err := newRequestWithBody(ctx, http.MethodPost, c.buildURL("/messages"), body)
+	req, err := newAPIRequestWithBody(ctx, http.MethodPost, c.buildURL("/messages"), body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create message request: %w", err)
 	}
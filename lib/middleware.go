@@ -0,0 +1,79 @@
+/*
+Package lib provides Quay.io API client functionality.
+
+This file covers CLIENT MIDDLEWARE:
+
+Middleware Registration:
+  - (*Client).Use(mw ...Middleware) - Append hooks to the client's middleware chain
+
+Hook Points (run in registration order):
+  - BeforeRequest(req)        - Before each HTTP attempt, after default headers are set
+  - AfterResponse(req, resp)  - After each HTTP attempt that produced a response
+  - OnError(req, err)         - Once per call, after retries, with the final error
+
+Middleware lets callers add tracing, audit logging, header injection and request
+mutation without replacing HTTPClient. Errors passed to OnError still wrap
+*QuayError, so errors.As works inside hooks.
+*/
+package lib
+
+import "net/http"
+
+// Middleware is a set of optional hooks invoked around every API request.
+// Any nil hook is skipped.
+type Middleware struct {
+	// BeforeRequest runs before each attempt, including retries. It may mutate
+	// req (headers, URL, query). Returning an error aborts the call without retrying.
+	BeforeRequest func(req *http.Request) error
+
+	// AfterResponse runs after each attempt that received a response, before the
+	// status is checked or the body decoded. It must not consume resp.Body.
+	// Returning an error aborts the call without retrying.
+	AfterResponse func(req *http.Request, resp *http.Response) error
+
+	// OnError runs once when a call fails, after any retries. It returns the
+	// error to propagate; returning nil leaves err unchanged.
+	OnError func(req *http.Request, err error) error
+}
+
+// Use appends middleware to the client's chain. It is not safe to call Use
+// concurrently with in-flight requests.
+func (c *Client) Use(mw ...Middleware) {
+	c.Middleware = append(c.Middleware, mw...)
+}
+
+func (c *Client) runBeforeRequest(req *http.Request) error {
+	for _, mw := range c.Middleware {
+		if mw.BeforeRequest == nil {
+			continue
+		}
+		if err := mw.BeforeRequest(req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) runAfterResponse(req *http.Request, resp *http.Response) error {
+	for _, mw := range c.Middleware {
+		if mw.AfterResponse == nil {
+			continue
+		}
+		if err := mw.AfterResponse(req, resp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) runOnError(req *http.Request, err error) error {
+	for _, mw := range c.Middleware {
+		if mw.OnError == nil {
+			continue
+		}
+		if replaced := mw.OnError(req, err); replaced != nil {
+			err = replaced
+		}
+	}
+	return err
+}
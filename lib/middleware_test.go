@@ -0,0 +1,213 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMiddlewareBeforeRequestInjectsHeader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Trace-Id"); got != "trace-123" {
+			t.Errorf("Expected X-Trace-Id 'trace-123', got '%s'", got)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer "+testTokenValue {
+			t.Errorf("Expected default Authorization header, got '%s'", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.Use(Middleware{
+		BeforeRequest: func(req *http.Request) error {
+			req.Header.Set("X-Trace-Id", "trace-123")
+			return nil
+		},
+	})
+
+	req, err := newRequest(context.Background(), httpMethodGet, server.URL+"/api/v1/test", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+
+	var result map[string]any
+	if err := client.get(req, &result); err != nil {
+		t.Fatalf("get returned error: %v", err)
+	}
+}
+
+func TestMiddlewareBeforeRequestErrorAborts(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.Retry = &RetryConfig{MaxRetries: 3, InitialBackoff: time.Millisecond}
+
+	errBlocked := errors.New("blocked by policy")
+	client.Use(Middleware{
+		BeforeRequest: func(*http.Request) error { return errBlocked },
+	})
+
+	req, err := newRequest(context.Background(), httpMethodGet, server.URL+"/api/v1/test", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+
+	err = client.get(req, nil)
+	if !errors.Is(err, errBlocked) {
+		t.Fatalf("Expected errBlocked, got %v", err)
+	}
+	if attempts.Load() != 0 {
+		t.Errorf("Expected 0 HTTP attempts, got %d", attempts.Load())
+	}
+}
+
+func TestMiddlewareAfterResponseSeesEachAttempt(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"error":"unavailable"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"name":"ok"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.Retry = &RetryConfig{MaxRetries: 2, InitialBackoff: time.Millisecond}
+
+	var before, statuses []int
+	client.Use(Middleware{
+		BeforeRequest: func(*http.Request) error {
+			before = append(before, len(before)+1)
+			return nil
+		},
+		AfterResponse: func(_ *http.Request, resp *http.Response) error {
+			statuses = append(statuses, resp.StatusCode)
+			return nil
+		},
+	})
+
+	req, err := newRequest(context.Background(), httpMethodGet, server.URL+"/api/v1/test", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+
+	var result map[string]string
+	if err := client.get(req, &result); err != nil {
+		t.Fatalf("get returned error: %v", err)
+	}
+	if result["name"] != "ok" {
+		t.Errorf("Expected decoded body after middleware, got %v", result)
+	}
+	if len(before) != 2 {
+		t.Errorf("Expected BeforeRequest on 2 attempts, got %d", len(before))
+	}
+	if len(statuses) != 2 || statuses[0] != http.StatusServiceUnavailable || statuses[1] != http.StatusOK {
+		t.Errorf("Expected statuses [503 200], got %v", statuses)
+	}
+}
+
+func TestMiddlewareOnErrorReceivesQuayError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"status":403,"error":"forbidden","detail":"no access"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	var seenStatus int
+	client.Use(
+		Middleware{
+			OnError: func(req *http.Request, err error) error {
+				var qerr *QuayError
+				if errors.As(err, &qerr) {
+					seenStatus = qerr.StatusCode()
+				}
+				return fmt.Errorf("audit %s %s: %w", req.Method, req.URL.Path, err)
+			},
+		},
+		Middleware{
+			OnError: func(*http.Request, error) error { return nil },
+		},
+	)
+
+	req, err := newRequest(context.Background(), httpMethodGet, server.URL+"/api/v1/test", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+
+	err = client.get(req, nil)
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+	if seenStatus != http.StatusForbidden {
+		t.Errorf("Expected OnError to see status 403, got %d", seenStatus)
+	}
+
+	var qerr *QuayError
+	if !errors.As(err, &qerr) {
+		t.Fatalf("Expected wrapped QuayError, got %T: %v", err, err)
+	}
+	want := "audit GET /api/v1/test: quay API error (status 403): forbidden — no access"
+	if err.Error() != want {
+		t.Errorf("Expected error %q, got %q", want, err.Error())
+	}
+}
+
+func TestMiddlewareAfterResponseErrorAborts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	errRejected := errors.New("response rejected")
+	client.Use(Middleware{
+		AfterResponse: func(*http.Request, *http.Response) error { return errRejected },
+	})
+
+	req, err := newRequest(context.Background(), httpMethodGet, server.URL+"/api/v1/test", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+
+	if err := client.get(req, nil); !errors.Is(err, errRejected) {
+		t.Fatalf("Expected errRejected, got %v", err)
+	}
+}
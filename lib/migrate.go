@@ -0,0 +1,565 @@
+/*
+Package lib provides Quay.io API client functionality.
+
+This file covers REPOSITORY MIGRATION between registries:
+
+  - MigrateRepository(ctx, src, dst, opts) (*MigrationResult, error) - Copy a repository with its tags, labels and permissions
+  - OpenMigrationJournal(path, srcURL, src, dstURL, dst) (*MigrationJournal, error) - Open or create the progress journal of a migration
+
+MigrateRepository creates the destination repository if needed, with the
+source's visibility and description, then copies every active tag with
+CopyImage, so manifests keep their digests and blobs already at the
+destination are not transferred again. It then adds the API-added labels of
+every copied manifest and grants the source's user, robot and team
+permissions; robots of the source namespace map to the destination's.
+
+With History, every manifest a tag has pointed at is pushed to it oldest
+first, so the destination's tag history holds the same manifests, and tags
+since deleted are replayed and deleted again. Earlier manifests Quay has
+already garbage collected, and permissions for accounts the destination lacks,
+are reported as skipped rather than failing the migration.
+
+At most Concurrency tags are copied at once. Each completed step is appended
+to the journal, and a rerun with the same journal skips it, so an interrupted
+migration resumes where it stopped.
+*/
+package lib
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Migration step kinds.
+const (
+	MigrationStepRepository = "repository"
+	MigrationStepTag        = "tag"
+	MigrationStepDeleteTag  = "delete_tag"
+	MigrationStepLabels     = "labels"
+	MigrationStepPermission = "permission"
+)
+
+const migrationJournalPerm = 0o600
+
+// MigrationStep is one unit of work of a migration.
+type MigrationStep struct {
+	Kind string `json:"kind"`
+	// Name is the tag, or user:NAME or team:NAME for a permission.
+	Name   string `json:"name,omitempty"`
+	Digest string `json:"digest,omitempty"`
+	// Seq orders the manifests replayed into one tag.
+	Seq int `json:"seq,omitempty"`
+}
+
+// String describes the step, for example "tag latest@sha256:...".
+func (s MigrationStep) String() string {
+	text := s.Kind
+	if s.Name != "" {
+		text += " " + s.Name
+	}
+	if s.Digest != "" {
+		text += "@" + s.Digest
+	}
+	return text
+}
+
+func (s MigrationStep) key() string {
+	return fmt.Sprintf("%s %s %d %s", s.Kind, s.Name, s.Seq, s.Digest)
+}
+
+// MigrationJournal is an append-only file of the completed steps of one
+// migration. It is safe for concurrent use.
+type MigrationJournal struct {
+	mu     sync.Mutex
+	file   *os.File
+	header migrationJournalHeader
+	done   map[string]bool
+}
+
+// migrationJournalHeader is the first line of a journal. The URLs are the
+// API base URLs of the source and destination instances.
+type migrationJournalHeader struct {
+	SourceURL      string `json:"source_url"`
+	Source         string `json:"source"`
+	DestinationURL string `json:"destination_url"`
+	Destination    string `json:"destination"`
+}
+
+func (h migrationJournalHeader) String() string {
+	return fmt.Sprintf("%s on %s to %s on %s", h.Source, h.SourceURL, h.Destination, h.DestinationURL)
+}
+
+// OpenMigrationJournal opens the journal at path, creating it if needed. A
+// journal belongs to one source and destination repository on the instances
+// with the API base URLs srcURL and dstURL; opening it for another migration
+// fails. A step torn by a crash while it was written is dropped.
+func OpenMigrationJournal(path, srcURL string, src ImageRef, dstURL string, dst ImageRef) (*MigrationJournal, error) {
+	if path == "" {
+		return nil, fmt.Errorf("journal path is required")
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, migrationJournalPerm) // #nosec G304 -- path is chosen by the caller
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+	j := &MigrationJournal{file: file, done: map[string]bool{}}
+	j.header = migrationJournalHeader{SourceURL: srcURL, Source: src.String(), DestinationURL: dstURL, Destination: dst.String()}
+	if err := j.load(); err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("failed to open journal %s: %w", path, err)
+	}
+	return j, nil
+}
+
+// load reads the steps of the journal, or writes the header of a new one. A
+// last line without its newline was torn and is cut off.
+func (j *MigrationJournal) load() error {
+	data, err := io.ReadAll(j.file)
+	if err != nil {
+		return err
+	}
+	complete := data[:bytes.LastIndexByte(data, '\n')+1]
+	if len(complete) < len(data) {
+		if err := j.file.Truncate(int64(len(complete))); err != nil {
+			return err
+		}
+	}
+	if len(complete) == 0 {
+		return j.append(j.header)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(complete), "\n"), "\n")
+	var got migrationJournalHeader
+	if err := json.Unmarshal([]byte(lines[0]), &got); err != nil || got != j.header {
+		return fmt.Errorf("journal is for %s, not %s", got, j.header)
+	}
+	for i, line := range lines[1:] {
+		var step MigrationStep
+		if err := json.Unmarshal([]byte(line), &step); err != nil {
+			return fmt.Errorf("line %d: %w", i+2, err)
+		}
+		j.done[step.key()] = true
+	}
+	return nil
+}
+
+// Done reports whether step was recorded. A nil journal records nothing.
+func (j *MigrationJournal) Done(step MigrationStep) bool {
+	if j == nil {
+		return false
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.done[step.key()]
+}
+
+// Len returns the number of recorded steps.
+func (j *MigrationJournal) Len() int {
+	if j == nil {
+		return 0
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return len(j.done)
+}
+
+// Record appends step to the journal and syncs it to disk.
+func (j *MigrationJournal) Record(step MigrationStep) error {
+	if j == nil {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := j.append(step); err != nil {
+		return fmt.Errorf("failed to record %s: %w", step, err)
+	}
+	j.done[step.key()] = true
+	return nil
+}
+
+func (j *MigrationJournal) append(v any) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if _, err := j.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return j.file.Sync()
+}
+
+// Close closes the journal file.
+func (j *MigrationJournal) Close() error {
+	if j == nil {
+		return nil
+	}
+	return j.file.Close()
+}
+
+// MigrateRepositoryOptions controls MigrateRepository.
+type MigrateRepositoryOptions struct {
+	// Destination is the client for the destination Quay instance. Nil
+	// migrates within the source client's instance.
+	Destination *Client
+	// History also replays every manifest the tags pointed at before.
+	History bool
+	// Concurrency bounds the tags copied at once. Defaults to 4.
+	Concurrency int
+	// Journal, if set, records completed steps and skips those it has. It
+	// must have been opened for this migration's instances and repositories.
+	Journal *MigrationJournal
+	// Progress, if set, is called after each step. It may be called
+	// concurrently.
+	Progress func(step MigrationStep)
+}
+
+// MigrationIssue is a step that was skipped, and why.
+type MigrationIssue struct {
+	Step  MigrationStep `json:"step"`
+	Error string        `json:"error"`
+}
+
+// MigrationResult summarizes MigrateRepository.
+type MigrationResult struct {
+	Source      ImageRef `json:"source"`
+	Destination ImageRef `json:"destination"`
+	// Steps counts the steps completed by this call and Resumed those the
+	// journal already had.
+	Steps   int `json:"steps"`
+	Resumed int `json:"resumed"`
+	// Manifests counts manifests pushed to tags, Labels the labels added and
+	// Permissions the permissions granted.
+	Manifests    int              `json:"manifests"`
+	Labels       int              `json:"labels"`
+	Permissions  int              `json:"permissions"`
+	BlobsCopied  int              `json:"blobs_copied"`
+	BlobsMounted int              `json:"blobs_mounted"`
+	BlobsSkipped int              `json:"blobs_skipped"`
+	BytesCopied  int64            `json:"bytes_copied"`
+	Skipped      []MigrationIssue `json:"skipped,omitempty"`
+}
+
+// MigrateRepository copies the repository src to dst, on the same or another
+// Quay instance. Both references name a repository without a tag. On error,
+// the result describes the work done so far.
+func (c *Client) MigrateRepository(ctx context.Context, src, dst ImageRef, opts *MigrateRepositoryOptions) (*MigrationResult, error) {
+	if opts == nil {
+		opts = &MigrateRepositoryOptions{}
+	}
+	if src.Namespace == "" || src.Repository == "" || src.Reference != "" {
+		return nil, fmt.Errorf("invalid source %q: expected namespace/repository", src)
+	}
+	if dst.Namespace == "" || dst.Repository == "" || dst.Reference != "" {
+		return nil, fmt.Errorf("invalid destination %q: expected namespace/repository", dst)
+	}
+	m := &repoMigrator{
+		src:         src,
+		dst:         dst,
+		source:      c,
+		dest:        cmp.Or(opts.Destination, c),
+		opts:        opts,
+		concurrency: cmp.Or(max(opts.Concurrency, 0), defaultCopyConcurrency),
+		digests:     map[string]bool{},
+		result:      &MigrationResult{Source: src, Destination: dst},
+	}
+	if m.source.BaseURL == m.dest.BaseURL && src == dst {
+		return nil, fmt.Errorf("source and destination are the same repository")
+	}
+	if j := opts.Journal; j != nil {
+		want := migrationJournalHeader{SourceURL: m.source.BaseURL, Source: src.String(), DestinationURL: m.dest.BaseURL, Destination: dst.String()}
+		if j.header != want {
+			return nil, fmt.Errorf("journal is for %s, not %s", j.header, want)
+		}
+	}
+
+	for _, run := range []func(context.Context) error{m.repository, m.tags, m.labels, m.permissions} {
+		if err := run(ctx); err != nil {
+			return m.result, fmt.Errorf("failed to migrate %s to %s: %w", src, dst, err)
+		}
+	}
+	return m.result, nil
+}
+
+// repoMigrator holds the state of one MigrateRepository call.
+type repoMigrator struct {
+	src, dst     ImageRef
+	source, dest *Client
+	opts         *MigrateRepositoryOptions
+	concurrency  int
+
+	mu      sync.Mutex
+	digests map[string]bool // manifests at the destination, for labels
+	result  *MigrationResult
+}
+
+// step runs fn unless the journal has step, then records it.
+func (m *repoMigrator) step(step MigrationStep, fn func() error) error {
+	if m.opts.Journal.Done(step) {
+		m.mu.Lock()
+		m.result.Resumed++
+		m.mu.Unlock()
+		return nil
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+	if err := m.opts.Journal.Record(step); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.result.Steps++
+	m.mu.Unlock()
+	if m.opts.Progress != nil {
+		m.opts.Progress(step)
+	}
+	return nil
+}
+
+func (m *repoMigrator) skip(step MigrationStep, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.result.Skipped = append(m.result.Skipped, MigrationIssue{Step: step, Error: err.Error()})
+}
+
+// repository creates the destination repository, or brings its description
+// in line with the source.
+func (m *repoMigrator) repository(ctx context.Context) error {
+	return m.step(MigrationStep{Kind: MigrationStepRepository, Name: m.dst.Repository}, func() error {
+		source, err := m.source.GetRepository(ctx, m.src.Namespace, m.src.Repository)
+		if err != nil {
+			return err
+		}
+		existing, err := m.dest.GetRepository(ctx, m.dst.Namespace, m.dst.Repository)
+		switch {
+		case isNotFound(err):
+			visibility := visibilityPrivate
+			if source.IsPublic {
+				visibility = visibilityPublic
+			}
+			_, err = m.dest.CreateRepository(ctx, m.dst.Namespace, m.dst.Repository, visibility, source.Description)
+			return err
+		case err != nil:
+			return err
+		case source.Description != "" && source.Description != existing.Description:
+			_, err = m.dest.UpdateRepository(ctx, m.dst.Namespace, m.dst.Repository, source.Description, "")
+			return err
+		}
+		return nil
+	})
+}
+
+// tagSteps returns, per tag, the manifests to push in order and, for a tag
+// since deleted, a final delete.
+func (m *repoMigrator) tagSteps(ctx context.Context) ([][]MigrationStep, error) {
+	tags, err := m.source.ListAllTags(ctx, m.src.Namespace, m.src.Repository, !m.opts.History)
+	if err != nil {
+		return nil, err
+	}
+	byName := map[string][]Tag{}
+	for _, tag := range tags {
+		if tag.ManifestDigest != "" {
+			byName[tag.Name] = append(byName[tag.Name], tag)
+		}
+	}
+	now := time.Now().Unix()
+	var sequences [][]MigrationStep
+	for _, name := range slices.Sorted(maps.Keys(byName)) {
+		history := byName[name]
+		slices.SortStableFunc(history, func(a, b Tag) int { return cmp.Compare(a.StartTs, b.StartTs) })
+		var steps []MigrationStep
+		for i, tag := range history {
+			steps = append(steps, MigrationStep{Kind: MigrationStepTag, Name: name, Digest: tag.ManifestDigest, Seq: i})
+		}
+		if last := history[len(history)-1]; last.EndTs != 0 && last.EndTs <= now {
+			steps = append(steps, MigrationStep{Kind: MigrationStepDeleteTag, Name: name, Seq: len(history)})
+		}
+		sequences = append(sequences, steps)
+	}
+	return sequences, nil
+}
+
+// tags replays the tag sequences with at most m.concurrency in flight and
+// returns the first error.
+func (m *repoMigrator) tags(ctx context.Context) error {
+	sequences, err := m.tagSteps(ctx)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sem := make(chan struct{}, m.concurrency)
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	for _, steps := range sequences {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Go(func() {
+			defer func() { <-sem }()
+			if err := m.replay(ctx, steps); err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		})
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// replay pushes the manifests of one tag in order. Earlier manifests that
+// are gone from the source are skipped.
+func (m *repoMigrator) replay(ctx context.Context, steps []MigrationStep) error {
+	for i, step := range steps {
+		if step.Kind == MigrationStepDeleteTag {
+			if err := m.step(step, func() error { return m.dest.DeleteTag(ctx, m.dst.Namespace, m.dst.Repository, step.Name) }); err != nil {
+				return err
+			}
+			continue
+		}
+		err := m.step(step, func() error { return m.copyManifest(ctx, step) })
+		switch {
+		case err == nil:
+			m.mu.Lock()
+			m.digests[step.Digest] = true
+			m.mu.Unlock()
+		case isNotFound(err) && i < len(steps)-1:
+			m.skip(step, err)
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *repoMigrator) copyManifest(ctx context.Context, step MigrationStep) error {
+	result, err := m.source.CopyImage(ctx,
+		ImageRef{Namespace: m.src.Namespace, Repository: m.src.Repository, Reference: step.Digest},
+		ImageRef{Namespace: m.dst.Namespace, Repository: m.dst.Repository, Reference: step.Name},
+		&CopyImageOptions{Destination: m.dest})
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.result.Manifests++
+	m.result.BlobsCopied += result.BlobsCopied
+	m.result.BlobsMounted += result.BlobsMounted
+	m.result.BlobsSkipped += result.BlobsSkipped
+	m.result.BytesCopied += result.BytesCopied
+	return nil
+}
+
+// labels adds the API-added labels of every copied manifest that the
+// destination manifest lacks.
+func (m *repoMigrator) labels(ctx context.Context) error {
+	for _, digest := range slices.Sorted(maps.Keys(m.digests)) {
+		err := m.step(MigrationStep{Kind: MigrationStepLabels, Digest: digest}, func() error {
+			return m.copyLabels(ctx, digest)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *repoMigrator) copyLabels(ctx context.Context, digest string) error {
+	source, err := m.source.GetManifestLabels(ctx, m.src.Namespace, m.src.Repository, digest)
+	if err != nil {
+		return err
+	}
+	existing, err := m.dest.GetManifestLabels(ctx, m.dst.Namespace, m.dst.Repository, digest)
+	if err != nil {
+		return err
+	}
+	present := map[string]bool{}
+	for _, label := range existing.Labels {
+		present[label.Key+"="+label.Value] = true
+	}
+	for _, label := range source.Labels {
+		if label.SourceType != labelSourceAPI || present[label.Key+"="+label.Value] {
+			continue
+		}
+		if _, err := m.dest.AddManifestLabel(ctx, m.dst.Namespace, m.dst.Repository, digest, label.Key, label.Value, label.MediaType); err != nil {
+			return err
+		}
+		m.mu.Lock()
+		m.result.Labels++
+		m.mu.Unlock()
+	}
+	return nil
+}
+
+// permissions grants the source's user, robot and team permissions at the
+// destination. Grants the destination rejects as invalid or unknown (400 or
+// 404), such as for a robot or team it lacks, are skipped; other failures stop
+// the migration.
+func (m *repoMigrator) permissions(ctx context.Context) error {
+	users, err := m.source.ListUserPermissions(ctx, m.src.Namespace, m.src.Repository)
+	if err != nil {
+		return err
+	}
+	teams, err := m.source.ListTeamPermissions(ctx, m.src.Namespace, m.src.Repository)
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+	grants := map[string]func(ctx context.Context) error{}
+	for name, role := range permissionRoles(users) {
+		if robot, ok := strings.CutPrefix(name, m.src.Namespace+"+"); ok {
+			name = m.dst.Namespace + "+" + robot
+		}
+		grants[delegateUser+":"+name] = func(ctx context.Context) error {
+			return m.dest.SetUserPermission(ctx, m.dst.Namespace, m.dst.Repository, name, role)
+		}
+	}
+	if teams != nil {
+		for name, role := range permissionRoles(teams) {
+			grants[delegateTeam+":"+name] = func(ctx context.Context) error {
+				return m.dest.SetTeamPermission(ctx, m.dst.Namespace, m.dst.Repository, name, role)
+			}
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(grants)) {
+		step := MigrationStep{Kind: MigrationStepPermission, Name: name}
+		err := m.step(step, func() error {
+			if err := grants[name](ctx); err != nil {
+				return err
+			}
+			m.result.Permissions++
+			return nil
+		})
+		var quayErr *QuayError
+		switch {
+		case err == nil:
+		case errors.As(err, &quayErr) && (quayErr.Status == http.StatusBadRequest || quayErr.Status == http.StatusNotFound):
+			m.skip(step, err)
+		default:
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,340 @@
+package lib
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeMigrateRepo serves the REST endpoints MigrateRepository uses for one
+// repository and hands registry requests to a fakeRegistry.
+type fakeMigrateRepo struct {
+	registry *fakeRegistry
+
+	mu         sync.Mutex
+	repo       *Repository
+	tags       []Tag
+	users      map[string]string
+	teams      map[string]string
+	labels     map[string][]ManifestLabel
+	deleted    []string
+	failLabels bool
+	// blobDelay, if set, slows blob downloads to one byte per delay.
+	blobDelay time.Duration
+}
+
+func newFakeMigrateRepo(t *testing.T) (*fakeMigrateRepo, *Client) {
+	t.Helper()
+	f := &fakeMigrateRepo{
+		registry: &fakeRegistry{t: t, repos: map[string]*fakeRegistryRepo{}},
+		users:    map[string]string{},
+		teams:    map[string]string{},
+		labels:   map[string][]ManifestLabel{},
+	}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	client, err := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	return f, client
+}
+
+func (f *fakeMigrateRepo) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path, ok := strings.CutPrefix(r.URL.Path, "/api/v1/repository")
+	if !ok || r.Method == http.MethodPut && strings.Contains(path, "/tag/") {
+		if f.blobDelay > 0 && r.Method == http.MethodGet && strings.Contains(r.URL.Path, "/blobs/") {
+			w = &slowResponseWriter{ResponseWriter: w, delay: f.blobDelay}
+		}
+		f.registry.ServeHTTP(w, r)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+
+	// /{namespace}/{repository}/{kind}/{rest}
+	parts := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 4)
+	switch {
+	case path == "" && r.Method == http.MethodPost:
+		var req CreateRepositoryRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		f.repo = &Repository{Namespace: req.Namespace, Name: req.Repository, Description: req.Description, IsPublic: req.Visibility == visibilityPublic}
+		_ = json.NewEncoder(w).Encode(f.repo)
+	case len(parts) == 2 && f.repo == nil:
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error": "not found"}`))
+	case len(parts) == 2 && r.Method == http.MethodPut:
+		var req UpdateRepositoryRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		f.repo.Description = req.Description
+		_ = json.NewEncoder(w).Encode(f.repo)
+	case len(parts) == 2:
+		_ = json.NewEncoder(w).Encode(f.repo)
+	case parts[2] == "tag" && r.Method == http.MethodDelete:
+		f.deleted = append(f.deleted, parts[3])
+	case parts[2] == "tag":
+		var tags []Tag
+		for _, tag := range f.tags {
+			if tag.EndTs == 0 || r.URL.Query().Get("onlyActiveTags") != queryValueTrue {
+				tags = append(tags, tag)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(RepositoryTags{Tags: tags, Page: 1})
+	case parts[2] == "permissions":
+		f.servePermissions(w, r, parts[3])
+	case parts[2] == "manifest":
+		f.serveLabels(w, r, strings.TrimSuffix(parts[3], "/labels"))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeMigrateRepo) servePermissions(w http.ResponseWriter, r *http.Request, rest string) {
+	kind, name, _ := strings.Cut(rest, "/")
+	perms := f.users
+	if kind == delegateTeam {
+		perms = f.teams
+	}
+	if r.Method == http.MethodPut {
+		switch name {
+		case "ghost":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error": "invalid username"}`))
+			return
+		case "locked":
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"error": "unauthorized"}`))
+			return
+		}
+		var req SetRepositoryPermissionRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		perms[name] = req.Role
+		_ = json.NewEncoder(w).Encode(RepositoryPermission{Name: name, Role: req.Role})
+		return
+	}
+	var list RepositoryPermissions
+	for _, name := range slices.Sorted(maps.Keys(perms)) {
+		list.Permissions = append(list.Permissions, RepositoryPermission{Name: name, Role: perms[name]})
+	}
+	_ = json.NewEncoder(w).Encode(list)
+}
+
+func (f *fakeMigrateRepo) serveLabels(w http.ResponseWriter, r *http.Request, digest string) {
+	f.registry.mu.Lock()
+	_, ok := f.registry.repo(testNamespace, testRepository).manifests[digest]
+	f.registry.mu.Unlock()
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method == http.MethodPost {
+		if f.failLabels {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var req AddManifestLabelRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		label := ManifestLabel{Key: req.Key, Value: req.Value, SourceType: labelSourceAPI, MediaType: req.MediaType}
+		f.labels[digest] = append(f.labels[digest], label)
+		_ = json.NewEncoder(w).Encode(label)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(ManifestLabels{Labels: f.labels[digest]})
+}
+
+func TestMigrateRepository(t *testing.T) {
+	source, src := newFakeMigrateRepo(t)
+	latest := pushTestImage(t, source.registry, testNamespace, testRepository, "latest", "base", "app")
+	v1 := pushTestImage(t, source.registry, testNamespace, testRepository, "v1", "base", "app-v1")
+	old := pushTestImage(t, source.registry, testNamespace, testRepository, "", "old")
+	source.repo = &Repository{Namespace: testNamespace, Name: testRepository, Description: "The API", IsPublic: true}
+	source.tags = []Tag{
+		{Name: "latest", ManifestDigest: latest, StartTs: 200},
+		{Name: "latest", ManifestDigest: old, StartTs: 100, EndTs: 200},
+		{Name: "latest", ManifestDigest: "sha256:" + strings.Repeat("0", 64), StartTs: 10, EndTs: 100},
+		{Name: "v1", ManifestDigest: v1, StartTs: 150},
+		{Name: "rc", ManifestDigest: old, StartTs: 50, EndTs: 60},
+	}
+	source.users = map[string]string{testNamespace + "+ci": testRoleWrite, "alice": testRoleRead, "ghost": testRoleRead}
+	source.teams = map[string]string{testTeamName: testRoleRead}
+	source.labels[latest] = []ManifestLabel{
+		{Key: "owner", Value: "platform", SourceType: labelSourceAPI},
+		{Key: "org.opencontainers.image.version", Value: "1.0", SourceType: "manifest"},
+	}
+
+	target, dst := newFakeMigrateRepo(t)
+	target.failLabels = true
+	srcRef := ImageRef{Namespace: testNamespace, Repository: testRepository}
+	dstRef := ImageRef{Namespace: testNamespace, Repository: testRepository}
+	journalPath := filepath.Join(t.TempDir(), "migrate.jsonl")
+	migrate := func() (*MigrationResult, error) {
+		t.Helper()
+		journal, err := OpenMigrationJournal(journalPath, src.BaseURL, srcRef, dst.BaseURL, dstRef)
+		if err != nil {
+			t.Fatalf("OpenMigrationJournal returned error: %v", err)
+		}
+		defer journal.Close()
+		return src.MigrateRepository(context.Background(), srcRef, dstRef, &MigrateRepositoryOptions{
+			Destination: dst, History: true, Concurrency: 2, Journal: journal,
+		})
+	}
+
+	// The first run stops at the labels; the second resumes there.
+	first, err := migrate()
+	if err == nil {
+		t.Fatal("Expected the failing label to stop the migration")
+	}
+	if first.Manifests != 4 || first.Resumed != 0 {
+		t.Errorf("Expected 4 manifests copied before the failure, got %+v", first)
+	}
+	target.failLabels = false
+	result, err := migrate()
+	if err != nil {
+		t.Fatalf("MigrateRepository returned error: %v", err)
+	}
+	if result.Manifests != 0 || result.Resumed != first.Steps || result.Labels != 1 || result.Permissions != 3 {
+		t.Errorf("Expected the second run to resume after the tags, got %+v (first %+v)", result, first)
+	}
+	var skipped []string
+	for _, issue := range slices.Concat(first.Skipped, result.Skipped) {
+		skipped = append(skipped, issue.Step.String())
+	}
+	gone := "tag latest@" + source.tags[2].ManifestDigest
+	wantSkipped := []string{gone, gone, "permission user:ghost"}
+	if !reflect.DeepEqual(skipped, wantSkipped) {
+		t.Errorf("Unexpected skipped steps:\n got %q\nwant %q", skipped, wantSkipped)
+	}
+
+	if target.repo == nil || target.repo.Description != "The API" || !target.repo.IsPublic {
+		t.Errorf("Expected the repository to be created like the source, got %+v", target.repo)
+	}
+	repo := target.registry.repo(testNamespace, testRepository)
+	if repo.tags["latest"] != latest || repo.tags["v1"] != v1 || repo.manifests[old] == nil {
+		t.Errorf("Expected manifests with their digests, got tags %v", repo.tags)
+	}
+	if !reflect.DeepEqual(target.deleted, []string{"rc"}) {
+		t.Errorf("Expected the deleted tag to be deleted again, got %q", target.deleted)
+	}
+	if labels := target.labels[latest]; len(labels) != 1 || labels[0].Key != "owner" {
+		t.Errorf("Expected only the API label to be copied, got %+v", labels)
+	}
+	wantUsers := map[string]string{testNamespace + "+ci": testRoleWrite, "alice": testRoleRead}
+	if !reflect.DeepEqual(target.users, wantUsers) || target.teams[testTeamName] != testRoleRead {
+		t.Errorf("Unexpected permissions: users %v, teams %v", target.users, target.teams)
+	}
+
+	again, err := migrate()
+	if err != nil || again.Steps != 0 || again.Resumed != first.Steps+result.Steps {
+		t.Errorf("Expected a finished migration to resume every step, got %+v, %v", again, err)
+	}
+
+	journal, err := OpenMigrationJournal(journalPath, src.BaseURL, srcRef, dst.BaseURL, dstRef)
+	if err != nil {
+		t.Fatalf("OpenMigrationJournal returned error: %v", err)
+	}
+	defer journal.Close()
+	if _, err := src.MigrateRepository(context.Background(), srcRef, dstRef, &MigrateRepositoryOptions{Journal: journal}); err == nil {
+		t.Error("Expected a journal for another destination instance to be rejected")
+	}
+	if _, err := src.MigrateRepository(context.Background(), srcRef, srcRef, nil); err == nil {
+		t.Error("Expected migrating a repository onto itself to fail")
+	}
+	if _, err := src.MigrateRepository(context.Background(), ImageRef{Namespace: testNamespace, Repository: testRepository, Reference: "latest"}, dstRef, nil); err == nil {
+		t.Error("Expected a tagged source to be rejected")
+	}
+}
+
+func TestMigrateRepositoryStreamsSlowLayers(t *testing.T) {
+	source, src := newFakeMigrateRepo(t)
+	latest := pushTestImage(t, source.registry, testNamespace, testRepository, "latest", "a layer slower than the client timeout")
+	source.repo = &Repository{Namespace: testNamespace, Name: testRepository}
+	source.tags = []Tag{{Name: "latest", ManifestDigest: latest, StartTs: 100}}
+	source.blobDelay = 5 * time.Millisecond
+	src.HTTPClient.Timeout = 5 * source.blobDelay
+	target, dst := newFakeMigrateRepo(t)
+	dst.HTTPClient.Timeout = src.HTTPClient.Timeout
+
+	ref := ImageRef{Namespace: testNamespace, Repository: testRepository}
+	result, err := src.MigrateRepository(context.Background(), ref, ref, &MigrateRepositoryOptions{Destination: dst})
+	if err != nil || result.Manifests != 1 {
+		t.Fatalf("Expected the slow tag to migrate, got %+v, %v", result, err)
+	}
+	if target.registry.repo(testNamespace, testRepository).tags["latest"] != latest {
+		t.Error("Expected the destination tag to point at the source digest")
+	}
+}
+
+func TestMigrateRepositoryPermissionDenied(t *testing.T) {
+	source, src := newFakeMigrateRepo(t)
+	source.repo = &Repository{Namespace: testNamespace, Name: testRepository}
+	source.users = map[string]string{"locked": testRoleRead}
+	_, dst := newFakeMigrateRepo(t)
+	srcRef := ImageRef{Namespace: testNamespace, Repository: testRepository}
+	dstRef := ImageRef{Namespace: "mirror", Repository: testRepository}
+
+	result, err := src.MigrateRepository(context.Background(), srcRef, dstRef, &MigrateRepositoryOptions{Destination: dst})
+	if err == nil || !strings.Contains(err.Error(), "unauthorized") {
+		t.Fatalf("Expected a forbidden grant to stop the migration, got %v", err)
+	}
+	if len(result.Skipped) != 0 {
+		t.Errorf("Expected nothing skipped, got %+v", result.Skipped)
+	}
+}
+
+func TestMigrationJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "migrate.jsonl")
+	src := ImageRef{Namespace: testNamespace, Repository: testRepository}
+	dst := ImageRef{Namespace: "mirror", Repository: testRepository}
+	srcURL, dstURL := "https://quay.io/api/v1", "https://dr.example.com/api/v1"
+	step := MigrationStep{Kind: MigrationStepTag, Name: "latest", Digest: "sha256:abc"}
+
+	journal, err := OpenMigrationJournal(path, srcURL, src, dstURL, dst)
+	if err != nil {
+		t.Fatalf("OpenMigrationJournal returned error: %v", err)
+	}
+	if err := journal.Record(step); err != nil {
+		t.Fatalf("Record returned error: %v", err)
+	}
+	_ = journal.Close()
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = file.WriteString(`{"kind": "tag", "na`)
+	_ = file.Close()
+
+	journal, err = OpenMigrationJournal(path, srcURL, src, dstURL, dst)
+	if err != nil {
+		t.Fatalf("Expected a torn line to be dropped, got %v", err)
+	}
+	if !journal.Done(step) || journal.Done(MigrationStep{Kind: MigrationStepTag, Name: "latest", Digest: "sha256:abc", Seq: 1}) || journal.Len() != 1 {
+		t.Errorf("Unexpected journal state after reopening: %d steps", journal.Len())
+	}
+	if err := journal.Record(MigrationStep{Kind: MigrationStepLabels, Digest: "sha256:abc"}); err != nil {
+		t.Fatalf("Record returned error: %v", err)
+	}
+	_ = journal.Close()
+	data, _ := os.ReadFile(path)
+	if lines := strings.Count(string(data), "\n"); lines != 3 || strings.Contains(string(data), `"tag", "na`) {
+		t.Errorf("Expected the header and two steps, got:\n%s", data)
+	}
+
+	if _, err := OpenMigrationJournal(path, srcURL, src, dstURL, src); err == nil {
+		t.Error("Expected a journal for another migration to be rejected")
+	}
+	if _, err := OpenMigrationJournal(path, srcURL, src, srcURL, dst); err == nil {
+		t.Error("Expected a journal for another destination instance to be rejected")
+	}
+}
@@ -25,7 +25,7 @@ func (c *Client) GetMirrorConfig(ctx context.Context, namespace, repository stri
 		return nil, fmt.Errorf("repository is required")
 	}
 
-	req, err := newRequest(ctx, http.MethodGet, c.buildURL("/repository/%s/%s/mirror", namespace, repository), nil)
+	req, err := newAPIRequest(ctx, http.MethodGet, c.buildURL("/repository/%s/%s/mirror", namespace, repository), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create get mirror config request: %w", err)
 	}
@@ -47,7 +47,7 @@ func (c *Client) CreateMirrorConfig(ctx context.Context, namespace, repository s
 		return nil, fmt.Errorf("repository is required")
 	}
 
-	req, err := newRequestWithBody(ctx, http.MethodPost, c.buildURL("/repository/%s/%s/mirror", namespace, repository), config)
+	req, err := newAPIRequestWithBody(ctx, http.MethodPost, c.buildURL("/repository/%s/%s/mirror", namespace, repository), config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create mirror config request: %w", err)
 	}
@@ -69,7 +69,7 @@ func (c *Client) UpdateMirrorConfig(ctx context.Context, namespace, repository s
 		return nil, fmt.Errorf("repository is required")
 	}
 
-	req, err := newRequestWithBody(ctx, http.MethodPut, c.buildURL("/repository/%s/%s/mirror", namespace, repository), config)
+	req, err := newAPIRequestWithBody(ctx, http.MethodPut, c.buildURL("/repository/%s/%s/mirror", namespace, repository), config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update mirror config request: %w", err)
 	}
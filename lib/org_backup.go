@@ -0,0 +1,564 @@
+/*
+Package lib provides Quay.io API client functionality.
+
+This file covers ORGANIZATION BACKUP AND RESTORE:
+
+Backup:
+  - BackupOrganization(ctx, orgname) (*OrgBackup, error) - Snapshot an organization's metadata
+  - WriteOrgBackup(w, backup) error - Write a backup as gzip-compressed JSON
+  - ReadOrgBackup(r) (*OrgBackup, error) - Read a backup written by WriteOrgBackup
+
+Restore:
+  - RestoreOrganization(ctx, backup, opts) (*RestoreReport, error) - Replay a backup into an organization
+
+A backup is the organization's exported spec (see ExportOrganization) plus the
+description, visibility and API-added manifest labels of every repository. It
+holds metadata only: images are copied separately, and labels are restored on
+manifests the target already has.
+
+Restore creates what the target lacks and never deletes. Resources that exist
+with different settings are conflicts, handled by the conflict policy: skip
+them, overwrite them, or fail before making any change. Robot tokens, mirror
+and proxy cache credentials are not in a backup, and notification configs may
+hold secrets that no longer apply, so the report lists them for manual
+follow-up.
+*/
+package lib
+
+import (
+	"cmp"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// OrgBackupVersion is the backup format WriteOrgBackup writes.
+const OrgBackupVersion = 1
+
+// Restore conflict policies.
+const (
+	ConflictSkip      = "skip"
+	ConflictOverwrite = "overwrite"
+	ConflictFail      = "fail"
+)
+
+// Resources that only restores change.
+const (
+	OrgResourceRepository    = "repository"
+	OrgResourceManifestLabel = "manifest_label"
+)
+
+const (
+	visibilityPublic  = "public"
+	visibilityPrivate = "private"
+	labelSourceAPI    = "api"
+)
+
+// OrgBackup is a snapshot of an organization's metadata.
+type OrgBackup struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	// Source is the API URL the backup was taken from.
+	Source       string             `json:"source,omitempty"`
+	Spec         *OrgSpec           `json:"spec"`
+	Repositories []RepositoryBackup `json:"repositories"`
+}
+
+// RepositoryBackup holds the repository settings an OrgSpec does not.
+type RepositoryBackup struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Visibility  string                 `json:"visibility"`
+	Labels      []ManifestLabelsBackup `json:"labels,omitempty"`
+}
+
+// ManifestLabelsBackup holds the API-added labels of one manifest and the
+// tags that pointed at it.
+type ManifestLabelsBackup struct {
+	Digest string          `json:"digest"`
+	Tags   []string        `json:"tags"`
+	Labels []ManifestLabel `json:"labels"`
+}
+
+// RestoreOptions configures RestoreOrganization.
+type RestoreOptions struct {
+	// Organization is the target organization; it defaults to the backed up
+	// one and must exist.
+	Organization string
+	// Repositories, Teams and Users rename resources from the backup, by
+	// old name. Robots follow the organization.
+	Repositories map[string]string
+	Teams        map[string]string
+	Users        map[string]string
+	// Conflict is ConflictSkip (the default), ConflictOverwrite or ConflictFail.
+	Conflict string
+	// DryRun plans the restore without changing anything.
+	DryRun bool
+	// Progress, if set, is called after each change is made.
+	Progress func(change OrgChange)
+}
+
+// RestoreConflict is an existing resource whose settings differ from the
+// backup. From in Fields is the target's value and To the backup's.
+type RestoreConflict struct {
+	Resource    string           `json:"resource"`
+	Name        string           `json:"name"`
+	Fields      []OrgFieldChange `json:"fields,omitempty"`
+	Overwritten bool             `json:"overwritten"`
+}
+
+// RestoreNote is an item a restore left for manual follow-up.
+type RestoreNote struct {
+	Resource string `json:"resource"`
+	Name     string `json:"name"`
+	Note     string `json:"note"`
+}
+
+// RestoreReport describes a restore.
+type RestoreReport struct {
+	Organization string `json:"organization"`
+	// Changes are the changes to make, in order; Applied counts those made.
+	Changes   []OrgChange       `json:"changes"`
+	Applied   int               `json:"applied"`
+	Conflicts []RestoreConflict `json:"conflicts,omitempty"`
+	// Skipped lists labels of manifests the target does not have.
+	Skipped []RestoreNote `json:"skipped,omitempty"`
+	// Manual lists robot tokens, registry credentials and notification
+	// configs to set or check by hand.
+	Manual []RestoreNote `json:"manual,omitempty"`
+}
+
+// BackupOrganization snapshots the metadata of an organization.
+func (c *Client) BackupOrganization(ctx context.Context, orgname string) (*OrgBackup, error) {
+	spec, err := c.ExportOrganization(ctx, orgname)
+	if err != nil {
+		return nil, fmt.Errorf("failed to back up organization: %w", err)
+	}
+	repos, err := c.ListAllRepositories(ctx, orgname, false, false, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to back up organization: %w", err)
+	}
+	backup := &OrgBackup{
+		Version:      OrgBackupVersion,
+		CreatedAt:    time.Now().UTC(),
+		Source:       c.BaseURL,
+		Spec:         spec,
+		Repositories: []RepositoryBackup{},
+	}
+	for _, repo := range slices.SortedFunc(slices.Values(repos), func(a, b OrganizationRepository) int { return strings.Compare(a.Name, b.Name) }) {
+		labels, err := c.backupLabels(ctx, orgname, repo.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to back up %s/%s: %w", orgname, repo.Name, err)
+		}
+		visibility := visibilityPrivate
+		if repo.IsPublic {
+			visibility = visibilityPublic
+		}
+		backup.Repositories = append(backup.Repositories, RepositoryBackup{
+			Name:        repo.Name,
+			Description: repo.Description,
+			Visibility:  visibility,
+			Labels:      labels,
+		})
+	}
+	return backup, nil
+}
+
+// backupLabels returns the API-added labels of the manifests active tags
+// point at. Labels from the image itself travel with the image.
+func (c *Client) backupLabels(ctx context.Context, namespace, repository string) ([]ManifestLabelsBackup, error) {
+	tags, err := c.ListAllTags(ctx, namespace, repository, true)
+	if err != nil {
+		return nil, err
+	}
+	byDigest := map[string][]string{}
+	for _, tag := range tags {
+		if tag.ManifestDigest != "" {
+			byDigest[tag.ManifestDigest] = append(byDigest[tag.ManifestDigest], tag.Name)
+		}
+	}
+	var backups []ManifestLabelsBackup
+	for _, digest := range slices.Sorted(maps.Keys(byDigest)) {
+		labels, err := c.GetManifestLabels(ctx, namespace, repository, digest)
+		if err != nil {
+			return nil, err
+		}
+		var kept []ManifestLabel
+		for _, label := range labels.Labels {
+			if label.SourceType == labelSourceAPI {
+				kept = append(kept, ManifestLabel{Key: label.Key, Value: label.Value, MediaType: label.MediaType})
+			}
+		}
+		if len(kept) == 0 {
+			continue
+		}
+		slices.SortFunc(kept, func(a, b ManifestLabel) int {
+			return cmp.Or(strings.Compare(a.Key, b.Key), strings.Compare(a.Value, b.Value))
+		})
+		backups = append(backups, ManifestLabelsBackup{Digest: digest, Tags: slices.Sorted(slices.Values(byDigest[digest])), Labels: kept})
+	}
+	return backups, nil
+}
+
+// WriteOrgBackup writes a backup as gzip-compressed JSON.
+func WriteOrgBackup(w io.Writer, backup *OrgBackup) error {
+	if backup == nil {
+		return fmt.Errorf("backup is required")
+	}
+	gz := gzip.NewWriter(w)
+	encoder := json.NewEncoder(gz)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(backup); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	return nil
+}
+
+// ReadOrgBackup reads a backup written by WriteOrgBackup.
+func ReadOrgBackup(r io.Reader) (*OrgBackup, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup: %w", err)
+	}
+	defer gz.Close()
+	backup := &OrgBackup{}
+	if err := json.NewDecoder(gz).Decode(backup); err != nil {
+		return nil, fmt.Errorf("failed to read backup: %w", err)
+	}
+	if backup.Version != OrgBackupVersion {
+		return nil, fmt.Errorf("unsupported backup version %d", backup.Version)
+	}
+	if backup.Spec == nil || backup.Spec.Organization == "" {
+		return nil, fmt.Errorf("backup has no organization")
+	}
+	return backup, nil
+}
+
+// RestoreOrganization replays a backup into an organization, possibly on
+// another registry. With ConflictFail, the report lists the conflicts and
+// nothing is changed. The report is returned with any error from applying,
+// so callers can show what was done.
+func (c *Client) RestoreOrganization(ctx context.Context, backup *OrgBackup, opts *RestoreOptions) (*RestoreReport, error) {
+	if backup == nil || backup.Spec == nil {
+		return nil, fmt.Errorf("backup is required")
+	}
+	if opts == nil {
+		opts = &RestoreOptions{}
+	}
+	policy := cmp.Or(opts.Conflict, ConflictSkip)
+	if !slices.Contains([]string{ConflictSkip, ConflictOverwrite, ConflictFail}, policy) {
+		return nil, fmt.Errorf("invalid conflict policy %q", opts.Conflict)
+	}
+	r := &orgRestore{
+		policy:  policy,
+		renamer: newRestoreRenamer(backup.Spec.Organization, opts),
+		report:  &RestoreReport{Changes: []OrgChange{}},
+	}
+	r.report.Organization = r.renamer.org
+	if _, err := c.GetOrganization(ctx, r.renamer.org); err != nil {
+		return nil, fmt.Errorf("failed to restore into %s: %w", r.renamer.org, err)
+	}
+
+	if err := r.plan(ctx, c, backup); err != nil {
+		return nil, fmt.Errorf("failed to restore into %s: %w", r.renamer.org, err)
+	}
+	if policy == ConflictFail && len(r.report.Conflicts) > 0 {
+		return r.report, fmt.Errorf("%d resource(s) in %s conflict with the backup", len(r.report.Conflicts), r.renamer.org)
+	}
+	if opts.DryRun {
+		return r.report, nil
+	}
+	applied, err := c.ApplyOrgPlan(ctx, &OrgPlan{Organization: r.renamer.org, Changes: r.report.Changes},
+		&OrgApplyOptions{Progress: opts.Progress})
+	r.report.Applied = applied
+	return r.report, err
+}
+
+// orgRestore accumulates the changes, conflicts and notes of a restore.
+type orgRestore struct {
+	policy  string
+	renamer *restoreRenamer
+	report  *RestoreReport
+	// missing holds the repositories the restore creates.
+	missing map[string]bool
+}
+
+func (r *orgRestore) plan(ctx context.Context, c *Client, backup *OrgBackup) error {
+	spec := r.renamer.spec(backup.Spec)
+	if err := spec.Validate(); err != nil {
+		return err
+	}
+	if err := r.planRepositories(ctx, c, backup.Repositories); err != nil {
+		return err
+	}
+
+	// Repositories the restore creates are planned as empty.
+	existing := *spec
+	existing.Repositories = slices.DeleteFunc(slices.Clone(spec.Repositories), func(repo RepositorySpec) bool { return r.missing[repo.Name] })
+	state, err := c.fetchOrgState(ctx, &existing)
+	if err != nil {
+		return err
+	}
+	for name := range r.missing {
+		state.userPerms[name], state.teamPerms[name] = map[string]string{}, map[string]string{}
+	}
+	for _, change := range planOrg(spec, state).Changes {
+		switch change.Action {
+		case OrgActionCreate:
+			r.report.Changes = append(r.report.Changes, change)
+		case OrgActionUpdate, OrgActionReplace:
+			r.conflict(change)
+		}
+	}
+	r.noteSecrets(spec)
+
+	for _, repo := range backup.Repositories {
+		if err := r.planLabels(ctx, c, r.renamer.repository(repo.Name), repo.Labels); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// conflict records an update of an existing resource and keeps it when
+// overwriting.
+func (r *orgRestore) conflict(change OrgChange) {
+	overwrite := r.policy == ConflictOverwrite
+	r.report.Conflicts = append(r.report.Conflicts, RestoreConflict{
+		Resource: change.Resource, Name: change.Name, Fields: change.Fields, Overwritten: overwrite,
+	})
+	if overwrite {
+		r.report.Changes = append(r.report.Changes, change)
+	}
+}
+
+// planRepositories creates missing repositories and reconciles the
+// visibility and description of existing ones. An empty description in the
+// backup leaves the target's alone, since Quay cannot clear it.
+func (r *orgRestore) planRepositories(ctx context.Context, c *Client, repos []RepositoryBackup) error {
+	org := r.renamer.org
+	live, err := c.ListAllRepositories(ctx, org, false, false, false)
+	if err != nil {
+		return err
+	}
+	current := map[string]OrganizationRepository{}
+	for _, repo := range live {
+		current[repo.Name] = repo
+	}
+	r.missing = map[string]bool{}
+	for _, backup := range repos {
+		name := r.renamer.repository(backup.Name)
+		visibility := cmp.Or(backup.Visibility, visibilityPrivate)
+		existing, exists := current[name]
+		if !exists {
+			r.missing[name] = true
+			r.report.Changes = append(r.report.Changes, OrgChange{Action: OrgActionCreate, Resource: OrgResourceRepository, Name: name,
+				Fields: slices.Concat(fieldChange("visibility", "", visibility), fieldChange("description", "", backup.Description)),
+				apply: func(ctx context.Context, c *Client) error {
+					_, err := c.CreateRepository(ctx, org, name, visibility, backup.Description)
+					return err
+				}})
+			continue
+		}
+		liveVisibility := visibilityPrivate
+		if existing.IsPublic {
+			liveVisibility = visibilityPublic
+		}
+		fields := fieldChange("visibility", liveVisibility, visibility)
+		if backup.Description != "" {
+			fields = append(fields, fieldChange("description", existing.Description, backup.Description)...)
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		r.conflict(OrgChange{Action: OrgActionUpdate, Resource: OrgResourceRepository, Name: name, Fields: fields,
+			apply: func(ctx context.Context, c *Client) error {
+				if _, err := c.UpdateRepository(ctx, org, name, backup.Description, ""); err != nil {
+					return err
+				}
+				return c.ChangeRepositoryVisibility(ctx, org, name, visibility)
+			}})
+	}
+	return nil
+}
+
+// planLabels adds the labels of manifests the target has. A label whose key
+// exists with another value is a conflict; overwriting replaces it.
+func (r *orgRestore) planLabels(ctx context.Context, c *Client, repo string, manifests []ManifestLabelsBackup) error {
+	org := r.renamer.org
+	for _, manifest := range manifests {
+		digest := manifest.Digest
+		var labels *ManifestLabels
+		if !r.missing[repo] {
+			var err error
+			labels, err = c.GetManifestLabels(ctx, org, repo, digest)
+			if err != nil && !isNotFound(err) {
+				return fmt.Errorf("failed to read labels of %s/%s@%s: %w", org, repo, digest, err)
+			}
+		}
+		if labels == nil {
+			r.report.Skipped = append(r.report.Skipped, RestoreNote{Resource: OrgResourceManifestLabel, Name: repo + "@" + digest,
+				Note: fmt.Sprintf("manifest (tags %s) is not in the target; copy the image, then restore again", strings.Join(manifest.Tags, ", "))})
+			continue
+		}
+		for _, label := range manifest.Labels {
+			r.planLabel(repo, digest, label, labels.Labels)
+		}
+	}
+	return nil
+}
+
+func (r *orgRestore) planLabel(repo, digest string, label ManifestLabel, live []ManifestLabel) {
+	org := r.renamer.org
+	var stale []string
+	var staleValue string
+	for _, existing := range live {
+		if existing.Key != label.Key {
+			continue
+		}
+		if existing.Value == label.Value {
+			return
+		}
+		stale, staleValue = append(stale, existing.ID), existing.Value
+	}
+	change := OrgChange{Action: OrgActionCreate, Resource: OrgResourceManifestLabel, Name: repo + "@" + digest + "/" + label.Key,
+		Fields: fieldChange("value", "", label.Value),
+		apply: func(ctx context.Context, c *Client) error {
+			for _, id := range stale {
+				if err := c.DeleteManifestLabel(ctx, org, repo, digest, id); err != nil {
+					return err
+				}
+			}
+			_, err := c.AddManifestLabel(ctx, org, repo, digest, label.Key, label.Value, label.MediaType)
+			return err
+		}}
+	if len(stale) == 0 {
+		r.report.Changes = append(r.report.Changes, change)
+		return
+	}
+	change.Action = OrgActionUpdate
+	change.Fields = fieldChange("value", staleValue, label.Value)
+	r.conflict(change)
+}
+
+// noteSecrets lists the robots the restore creates, whose new tokens must be
+// handed out, the proxy cache and notifications it writes, whose upstream
+// credentials and delivery secrets must be checked, and the mirrors whose
+// registry passwords must be set.
+func (r *orgRestore) noteSecrets(spec *OrgSpec) {
+	for _, change := range r.report.Changes {
+		switch {
+		case change.Resource == OrgResourceRobot && change.Action == OrgActionCreate:
+			r.report.Manual = append(r.report.Manual, RestoreNote{Resource: OrgResourceRobot, Name: change.Name,
+				Note: "new robot token; update the clients that use this robot"})
+		case change.Resource == OrgResourceProxyCache:
+			r.report.Manual = append(r.report.Manual, RestoreNote{Resource: OrgResourceProxyCache, Name: change.Name,
+				Note: "set the upstream registry credentials if the upstream needs them"})
+		case change.Resource == OrgResourceNotification:
+			r.report.Manual = append(r.report.Manual, RestoreNote{Resource: OrgResourceNotification, Name: change.Name,
+				Note: "check the delivery target and any tokens or keys in its config"})
+		}
+	}
+	for _, repo := range spec.Repositories {
+		if repo.Mirror != nil && repo.Mirror.ExternalRegistryUsername != "" {
+			r.report.Manual = append(r.report.Manual, RestoreNote{Resource: OrgResourceMirror, Name: repo.Name,
+				Note: fmt.Sprintf("set the password of %s on %s", repo.Mirror.ExternalRegistryUsername, repo.Mirror.ExternalReference)})
+		}
+	}
+}
+
+// restoreRenamer maps names from a backup to the target organization.
+type restoreRenamer struct {
+	source, org         string
+	repos, teams, users map[string]string
+}
+
+func newRestoreRenamer(source string, opts *RestoreOptions) *restoreRenamer {
+	return &restoreRenamer{
+		source: source,
+		org:    cmp.Or(opts.Organization, source),
+		repos:  opts.Repositories,
+		teams:  opts.Teams,
+		users:  opts.Users,
+	}
+}
+
+func (n *restoreRenamer) repository(name string) string {
+	return cmp.Or(n.repos[name], name)
+}
+
+func (n *restoreRenamer) team(name string) string {
+	return cmp.Or(n.teams[name], name)
+}
+
+// user maps a user, or a robot of the source organization to the same robot
+// of the target.
+func (n *restoreRenamer) user(name string) string {
+	if robot, ok := strings.CutPrefix(name, n.source+"+"); ok {
+		return n.org + "+" + robot
+	}
+	return cmp.Or(n.users[name], name)
+}
+
+func (n *restoreRenamer) renameKeys(m map[string]string, rename func(string) string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	renamed := map[string]string{}
+	for name, role := range m {
+		renamed[rename(name)] = role
+	}
+	return renamed
+}
+
+// spec returns a copy of spec with every name mapped. Empty names stay
+// empty.
+func (n *restoreRenamer) spec(spec *OrgSpec) *OrgSpec {
+	renamed := *spec
+	renamed.Organization = n.org
+	renamed.Teams = nil
+	for _, team := range spec.Teams {
+		team.Name = n.team(team.Name)
+		if team.Members != nil {
+			members := make([]string, 0, len(team.Members))
+			for _, member := range team.Members {
+				members = append(members, n.user(member))
+			}
+			team.Members = members
+		}
+		renamed.Teams = append(renamed.Teams, team)
+	}
+	renamed.Repositories = nil
+	for _, repo := range spec.Repositories {
+		repo.Name = n.repository(repo.Name)
+		if repo.Permissions != nil {
+			repo.Permissions = &RepositoryPermissionsSpec{
+				Users: n.renameKeys(repo.Permissions.Users, n.user),
+				Teams: n.renameKeys(repo.Permissions.Teams, n.team),
+			}
+		}
+		if repo.Mirror != nil {
+			mirror := *repo.Mirror
+			mirror.RobotUsername = n.user(mirror.RobotUsername)
+			repo.Mirror = &mirror
+		}
+		renamed.Repositories = append(renamed.Repositories, repo)
+	}
+	renamed.Prototypes = nil
+	for _, prototype := range spec.Prototypes {
+		prototype.ActivatingUser = n.user(prototype.ActivatingUser)
+		prototype.User = n.user(prototype.User)
+		prototype.Team = n.team(prototype.Team)
+		renamed.Prototypes = append(renamed.Prototypes, prototype)
+	}
+	return &renamed
+}
@@ -0,0 +1,204 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestWriteReadOrgBackup(t *testing.T) {
+	backup := &OrgBackup{
+		Version: OrgBackupVersion,
+		Spec:    &OrgSpec{Organization: testNamespace, Teams: []TeamSpec{{Name: testTeamName, Members: []string{"alice"}}}},
+		Repositories: []RepositoryBackup{{Name: testRepository, Visibility: visibilityPrivate, Labels: []ManifestLabelsBackup{
+			{Digest: "sha256:abc", Tags: []string{"latest"}, Labels: []ManifestLabel{{Key: "team", Value: "platform"}}},
+		}}},
+	}
+	var buf bytes.Buffer
+	if err := WriteOrgBackup(&buf, backup); err != nil {
+		t.Fatalf("WriteOrgBackup returned error: %v", err)
+	}
+	read, err := ReadOrgBackup(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadOrgBackup returned error: %v", err)
+	}
+	if !reflect.DeepEqual(read, backup) {
+		t.Errorf("Round trip mismatch:\n got %+v\nwant %+v", read, backup)
+	}
+
+	buf.Reset()
+	backup.Version = OrgBackupVersion + 1
+	_ = WriteOrgBackup(&buf, backup)
+	if _, err := ReadOrgBackup(&buf); err == nil || !strings.Contains(err.Error(), "unsupported backup version") {
+		t.Errorf("Expected a version error, got %v", err)
+	}
+	if _, err := ReadOrgBackup(strings.NewReader("{}")); err == nil {
+		t.Error("Expected an error for a file that is not gzip")
+	}
+}
+
+func TestRestoreRenamer(t *testing.T) {
+	renamer := newRestoreRenamer("oldorg", &RestoreOptions{
+		Organization: "neworg",
+		Repositories: map[string]string{"api": "api-v2"},
+		Teams:        map[string]string{"devs": testTeamName},
+		Users:        map[string]string{"alice": "alice.smith"},
+	})
+	spec := renamer.spec(&OrgSpec{
+		Organization: "oldorg",
+		Teams:        []TeamSpec{{Name: "devs", Members: []string{"alice", "bob", "oldorg+ci"}}},
+		Repositories: []RepositorySpec{{
+			Name:        "api",
+			Permissions: &RepositoryPermissionsSpec{Users: map[string]string{"oldorg+ci": testRoleWrite}, Teams: map[string]string{"devs": testRoleRead}},
+			Mirror:      &MirrorSpec{ExternalReference: "docker.io/library/nginx", SyncInterval: 60, RobotUsername: "oldorg+ci"},
+		}},
+		Prototypes: []PrototypeSpec{{ActivatingUser: "alice", Team: "devs", Role: testRoleRead}},
+	})
+
+	want := &OrgSpec{
+		Organization: "neworg",
+		Teams:        []TeamSpec{{Name: testTeamName, Members: []string{"alice.smith", "bob", "neworg+ci"}}},
+		Repositories: []RepositorySpec{{
+			Name:        "api-v2",
+			Permissions: &RepositoryPermissionsSpec{Users: map[string]string{"neworg+ci": testRoleWrite}, Teams: map[string]string{testTeamName: testRoleRead}},
+			Mirror:      &MirrorSpec{ExternalReference: "docker.io/library/nginx", SyncInterval: 60, RobotUsername: "neworg+ci"},
+		}},
+		Prototypes: []PrototypeSpec{{ActivatingUser: "alice.smith", Team: testTeamName, Role: testRoleRead}},
+	}
+	if !reflect.DeepEqual(spec, want) {
+		t.Errorf("Unexpected renamed spec:\n got %+v\nwant %+v", spec, want)
+	}
+}
+
+// restoreTarget serves a target organization and records the requests that
+// would change it.
+func restoreTarget(t *testing.T) (*Client, *[]string) {
+	t.Helper()
+	org := "/api/v1/organization/neworg"
+	repo := "/api/v1/repository/neworg/"
+	responses := map[string]string{
+		org:                  `{"name": "neworg"}`,
+		"/api/v1/repository": `{"repositories": [{"name": "api", "description": "API", "is_public": true}]}`,
+		org + "/teams":       `{"teams": [{"name": "owners", "role": "admin"}, {"name": "` + testTeamName + `", "role": "member"}]}`,
+		org + "/team/" + testTeamName + "/members": `{"members": []}`,
+		org + "/robots":                         `{"robots": []}`,
+		repo + "api/permissions/user/":          `{"permissions": []}`,
+		repo + "api/permissions/team/":          `{"permissions": []}`,
+		repo + "api/manifest/sha256:abc/labels": `{"labels": [{"id": "l1", "key": "team", "value": "web", "source_type": "api"}]}`,
+	}
+	var mu sync.Mutex
+	var mutations []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method != http.MethodGet {
+			mu.Lock()
+			mutations = append(mutations, r.Method+" "+r.URL.Path)
+			mu.Unlock()
+			_, _ = w.Write([]byte(`{}`))
+			return
+		}
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			body = `{"error": "not found"}`
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	client, err := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	return client, &mutations
+}
+
+func TestRestoreOrganization(t *testing.T) {
+	backup := &OrgBackup{
+		Version: OrgBackupVersion,
+		Spec: &OrgSpec{
+			Organization: "oldorg",
+			Teams:        []TeamSpec{{Name: testTeamName, Role: TeamRoleCreator, Members: []string{"alice"}}},
+			Robots:       []RobotSpec{{Name: "ci"}},
+			Repositories: []RepositorySpec{
+				{Name: "api", Permissions: &RepositoryPermissionsSpec{Users: map[string]string{"oldorg+ci": testRoleWrite}}},
+				{Name: "web", Permissions: &RepositoryPermissionsSpec{Teams: map[string]string{testTeamName: testRoleRead}},
+					Notifications: []NotificationSpec{{Event: "repo_push", Method: "webhook", Config: map[string]any{"url": "https://hooks.example.com"}}}},
+			},
+			ProxyCache: &ProxyCacheSpec{UpstreamRegistry: "docker.io"},
+		},
+		Repositories: []RepositoryBackup{
+			{Name: "api", Description: "API", Visibility: visibilityPrivate, Labels: []ManifestLabelsBackup{
+				{Digest: "sha256:abc", Tags: []string{"latest"}, Labels: []ManifestLabel{{Key: "team", Value: "platform"}, {Key: "tier", Value: "1"}}},
+			}},
+			{Name: "web", Visibility: visibilityPrivate, Labels: []ManifestLabelsBackup{
+				{Digest: "sha256:def", Tags: []string{"v1"}, Labels: []ManifestLabel{{Key: "team", Value: "web"}}},
+			}},
+		},
+	}
+	ctx := context.Background()
+
+	client, mutations := restoreTarget(t)
+	report, err := client.RestoreOrganization(ctx, backup, &RestoreOptions{Organization: "neworg", Conflict: ConflictFail})
+	if err == nil || len(report.Conflicts) != 3 || len(*mutations) != 0 {
+		t.Fatalf("Expected 3 conflicts and no changes, got %v, %+v, %q", err, report, *mutations)
+	}
+
+	report, err = client.RestoreOrganization(ctx, backup, &RestoreOptions{Organization: "neworg"})
+	if err != nil {
+		t.Fatalf("RestoreOrganization returned error: %v", err)
+	}
+	var changes []string
+	for _, change := range report.Changes {
+		changes = append(changes, change.String())
+	}
+	wantChanges := []string{
+		"create repository web",
+		"create proxy_cache neworg",
+		"create robot neworg+ci",
+		"create team_member " + testTeamName + "/alice",
+		"create repository_permission api/user:neworg+ci",
+		"create repository_permission web/team:" + testTeamName,
+		"create notification web/repo_push webhook",
+		"create manifest_label api@sha256:abc/tier",
+	}
+	if !reflect.DeepEqual(changes, wantChanges) {
+		t.Errorf("Unexpected changes:\n got %q\nwant %q", changes, wantChanges)
+	}
+	if report.Applied != len(wantChanges) || len(*mutations) != len(wantChanges) {
+		t.Errorf("Expected %d changes applied, got %d: %q", len(wantChanges), report.Applied, *mutations)
+	}
+	for _, conflict := range report.Conflicts {
+		if conflict.Overwritten {
+			t.Errorf("Expected conflicts to be skipped, got %+v", conflict)
+		}
+	}
+	var manual []string
+	for _, note := range report.Manual {
+		manual = append(manual, note.Resource+" "+note.Name)
+	}
+	wantManual := []string{"proxy_cache neworg", "robot neworg+ci", "notification web/repo_push webhook"}
+	if !reflect.DeepEqual(manual, wantManual) {
+		t.Errorf("Unexpected manual follow-up:\n got %q\nwant %q", manual, wantManual)
+	}
+	if len(report.Skipped) != 1 || report.Skipped[0].Name != "web@sha256:def" {
+		t.Errorf("Expected the labels of the missing manifest to be skipped, got %+v", report.Skipped)
+	}
+
+	*mutations = nil
+	report, err = client.RestoreOrganization(ctx, backup, &RestoreOptions{Organization: "neworg", Conflict: ConflictOverwrite, DryRun: true})
+	if err != nil || len(*mutations) != 0 {
+		t.Fatalf("Expected a dry run without changes, got %v, %q", err, *mutations)
+	}
+	if len(report.Changes) != len(wantChanges)+3 || !report.Conflicts[0].Overwritten {
+		t.Errorf("Expected the conflicts to be overwritten, got %+v", report)
+	}
+
+	if _, err := client.RestoreOrganization(ctx, backup, &RestoreOptions{Conflict: "merge"}); err == nil {
+		t.Error("Expected an invalid conflict policy to fail")
+	}
+}
@@ -0,0 +1,217 @@
+/*
+Package lib provides Quay.io API client functionality.
+
+This file covers ORGANIZATION DRIFT:
+
+Drift:
+  - CheckOrgDrift(ctx, spec) (*DriftReport, error) - Compare an organization with its spec
+
+Every difference PlanOrganization finds becomes a finding: something the spec
+lists that is missing, something live the spec does not list, a changed
+attribute, or a notification Quay disabled after failed deliveries. Findings
+are rated with the vulnerability severities by the access they grant beyond
+the spec: an unexpected member of an admin team is Critical, unexpected admin
+permissions, robots and federation are High, other unexpected access and
+changed notifications, auto-prune policies, mirrors, quota and proxy cache are
+Medium, and missing access or descriptions are Low.
+*/
+package lib
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Drift finding kinds.
+const (
+	DriftKindMissing    = "missing"
+	DriftKindUnexpected = "unexpected"
+	DriftKindChanged    = "changed"
+	DriftKindDisabled   = "disabled"
+)
+
+// roleRanks orders team and repository roles by the access they grant.
+var roleRanks = map[string]int{
+	RepositoryRoleRead:  1,
+	TeamRoleMember:      1,
+	RepositoryRoleWrite: 2,
+	TeamRoleCreator:     2,
+	RepositoryRoleAdmin: 3,
+}
+
+// DriftFinding is one difference between an organization and its spec.
+type DriftFinding struct {
+	Severity string `json:"severity"`
+	Kind     string `json:"kind"`
+	// Resource and Name identify the resource as in OrgChange.
+	Resource string       `json:"resource"`
+	Name     string       `json:"name"`
+	Message  string       `json:"message"`
+	Fields   []DriftField `json:"fields,omitempty"`
+}
+
+// DriftField is an attribute whose live value differs from the spec. Expected
+// is empty for unexpected resources and Actual for missing ones.
+type DriftField struct {
+	Field    string `json:"field"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+}
+
+// DriftReport lists the findings of CheckOrgDrift, most severe first.
+type DriftReport struct {
+	Organization string         `json:"organization"`
+	Findings     []DriftFinding `json:"findings"`
+}
+
+// Count returns the number of findings of the given severity.
+func (r *DriftReport) Count(severity string) int {
+	n := 0
+	for _, finding := range r.Findings {
+		if finding.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+// AtOrAbove returns the findings at least as severe as severity.
+func (r *DriftReport) AtOrAbove(severity string) []DriftFinding {
+	var findings []DriftFinding
+	for _, finding := range r.Findings {
+		if SeverityRank(finding.Severity) >= SeverityRank(severity) {
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+// CheckOrgDrift validates spec and reports how the organization differs from
+// it. Nothing is modified.
+func (c *Client) CheckOrgDrift(ctx context.Context, spec *OrgSpec) (*DriftReport, error) {
+	if spec == nil {
+		return nil, fmt.Errorf("spec is required")
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	state, err := c.fetchOrgState(ctx, spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check drift: %w", err)
+	}
+	return driftReport(spec, state, planOrg(spec, state)), nil
+}
+
+// driftReport turns the changes of a plan into findings. A team is an admin
+// team when it is admin live or in the spec; a spec team without a role keeps
+// its live one.
+func driftReport(spec *OrgSpec, state *orgState, plan *OrgPlan) *DriftReport {
+	adminTeams := map[string]bool{ownersTeam: true}
+	for name, team := range state.teams {
+		if team.Role == TeamRoleAdmin {
+			adminTeams[name] = true
+		}
+	}
+	for _, team := range spec.Teams {
+		if team.Role == TeamRoleAdmin {
+			adminTeams[team.Name] = true
+		}
+	}
+	report := &DriftReport{Organization: plan.Organization, Findings: []DriftFinding{}}
+	for _, change := range plan.Changes {
+		finding := DriftFinding{Kind: driftKind(change), Resource: change.Resource, Name: change.Name}
+		for _, field := range change.Fields {
+			finding.Fields = append(finding.Fields, DriftField{Field: field.Field, Expected: field.To, Actual: field.From})
+		}
+		finding.Severity = driftSeverity(finding, adminTeams)
+		finding.Message = driftMessage(finding)
+		report.Findings = append(report.Findings, finding)
+	}
+	slices.SortStableFunc(report.Findings, func(a, b DriftFinding) int {
+		return cmp.Compare(SeverityRank(b.Severity), SeverityRank(a.Severity))
+	})
+	return report
+}
+
+func driftKind(change OrgChange) string {
+	switch change.Action {
+	case OrgActionCreate:
+		return DriftKindMissing
+	case OrgActionDelete:
+		return DriftKindUnexpected
+	}
+	for _, field := range change.Fields {
+		if change.Resource == OrgResourceNotification && field.Field == "enabled" {
+			return DriftKindDisabled
+		}
+	}
+	return DriftKindChanged
+}
+
+// driftSeverity rates a finding by the access it grants beyond the spec.
+func driftSeverity(finding DriftFinding, adminTeams map[string]bool) string {
+	switch finding.Resource {
+	case OrgResourceTeamMember:
+		team, _, _ := strings.Cut(finding.Name, "/")
+		switch {
+		case finding.Kind != DriftKindUnexpected:
+			return SeverityLow
+		case adminTeams[team]:
+			return SeverityCritical
+		}
+		return SeverityHigh
+	case OrgResourceRobot, OrgResourceRobotFederation:
+		if finding.Kind == DriftKindMissing {
+			return SeverityLow
+		}
+		return SeverityHigh
+	case OrgResourceTeam, OrgResourceRepositoryPermission, OrgResourcePrototype:
+		return roleSeverity(finding)
+	}
+	return SeverityMedium
+}
+
+// roleSeverity rates a role difference: High when the live role is admin
+// beyond the spec, Medium for other extra access and Low otherwise.
+func roleSeverity(finding DriftFinding) string {
+	for _, field := range finding.Fields {
+		if field.Field != "role" {
+			continue
+		}
+		actual, expected := roleRanks[field.Actual], roleRanks[field.Expected]
+		switch {
+		case actual <= expected:
+			return SeverityLow
+		case actual == roleRanks[RepositoryRoleAdmin]:
+			return SeverityHigh
+		}
+		return SeverityMedium
+	}
+	return SeverityLow
+}
+
+// driftMessage describes a finding in one sentence.
+func driftMessage(finding DriftFinding) string {
+	subject := strings.ReplaceAll(finding.Resource, "_", " ") + " " + finding.Name
+	switch finding.Kind {
+	case DriftKindMissing:
+		return subject + " is missing"
+	case DriftKindDisabled:
+		return subject + " was disabled after failed deliveries"
+	case DriftKindUnexpected:
+		for _, field := range finding.Fields {
+			if field.Field == "role" {
+				return fmt.Sprintf("%s grants %s but is not in the spec", subject, field.Actual)
+			}
+		}
+		return subject + " is not in the spec"
+	}
+	diffs := make([]string, 0, len(finding.Fields))
+	for _, field := range finding.Fields {
+		diffs = append(diffs, fmt.Sprintf("%s is %q, expected %q", field.Field, field.Actual, field.Expected))
+	}
+	return subject + " differs: " + strings.Join(diffs, ", ")
+}
@@ -0,0 +1,84 @@
+package lib
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDriftReport(t *testing.T) {
+	spec := &OrgSpec{
+		Organization: testNamespace,
+		Teams: []TeamSpec{
+			{Name: ownersTeam, Members: []string{"root"}},
+			{Name: testTeamName, Members: []string{"alice", "bob"}},
+		},
+		Repositories: []RepositorySpec{{
+			Name:          testRepository,
+			Permissions:   &RepositoryPermissionsSpec{Users: map[string]string{"alice": testRoleWrite}},
+			Notifications: []NotificationSpec{{Event: "repo_push", Method: "webhook"}},
+		}},
+		AutoPrune: []AutoPruneSpec{{Method: AutoPruneMethodNumberOfTags, Value: 10}},
+	}
+	state := &orgState{
+		teams: map[string]Team{ownersTeam: {Name: ownersTeam, Role: roleAdmin}, testTeamName: {Name: testTeamName, Role: roleMember}},
+		members: map[string][]TeamMember{
+			ownersTeam:   {{Name: "root"}, {Name: "mallory"}},
+			testTeamName: {{Name: "alice"}, {Name: "eve"}},
+		},
+		userPerms: map[string]map[string]string{testRepository: {"alice": roleAdmin, "eve": testRoleRead}},
+		notifications: map[string][]RepositoryNotification{testRepository: {
+			{UUID: "n1", Event: "repo_push", Method: "webhook", NumberOfFailures: notificationFailureLimit},
+		}},
+		policies: []AutoPrunePolicy{{UUID: "a1", Method: AutoPruneMethodNumberOfTags, Value: 50}},
+	}
+
+	report := driftReport(spec, state, planOrg(spec, state))
+	type summary struct{ severity, kind, name string }
+	var got []summary
+	for _, finding := range report.Findings {
+		got = append(got, summary{finding.Severity, finding.Kind, finding.Name})
+	}
+	want := []summary{
+		{SeverityCritical, DriftKindUnexpected, ownersTeam + "/mallory"},
+		{SeverityHigh, DriftKindChanged, testRepository + "/user:alice"},
+		{SeverityHigh, DriftKindUnexpected, testTeamName + "/eve"},
+		{SeverityMedium, DriftKindDisabled, testRepository + "/repo_push webhook"},
+		{SeverityMedium, DriftKindChanged, "number_of_tags"},
+		{SeverityMedium, DriftKindUnexpected, testRepository + "/user:eve"},
+		{SeverityLow, DriftKindMissing, testTeamName + "/bob"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected findings:\n got %v\nwant %v", got, want)
+	}
+
+	admin := report.Findings[1]
+	if admin.Message != `repository permission `+testRepository+`/user:alice differs: role is "admin", expected "write"` {
+		t.Errorf("Unexpected message: %s", admin.Message)
+	}
+	if !reflect.DeepEqual(admin.Fields, []DriftField{{Field: "role", Expected: testRoleWrite, Actual: roleAdmin}}) {
+		t.Errorf("Unexpected fields: %+v", admin.Fields)
+	}
+	if n := len(report.AtOrAbove(SeverityHigh)); n != 3 {
+		t.Errorf("Expected 3 findings at or above High, got %d", n)
+	}
+	if n := report.Count(SeverityMedium); n != 3 {
+		t.Errorf("Expected 3 Medium findings, got %d", n)
+	}
+
+	state.members[ownersTeam] = state.members[ownersTeam][:1]
+	state.members[testTeamName] = []TeamMember{{Name: "alice"}, {Name: "bob"}}
+	state.userPerms[testRepository] = map[string]string{"alice": testRoleWrite}
+	state.notifications[testRepository][0].NumberOfFailures = 0
+	state.policies[0].Value = 10
+	if report := driftReport(spec, state, planOrg(spec, state)); len(report.Findings) != 0 {
+		t.Errorf("Expected no drift, got %+v", report.Findings)
+	}
+
+	// The spec leaves the team's role alone, so the live admin role counts.
+	state.teams[testTeamName] = Team{Name: testTeamName, Role: roleAdmin}
+	state.members[testTeamName] = append(state.members[testTeamName], TeamMember{Name: "eve"})
+	report = driftReport(spec, state, planOrg(spec, state))
+	if len(report.Findings) != 1 || report.Findings[0].Severity != SeverityCritical {
+		t.Errorf("Expected a Critical unexpected member of a live admin team, got %+v", report.Findings)
+	}
+}
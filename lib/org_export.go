@@ -0,0 +1,174 @@
+/*
+Package lib provides Quay.io API client functionality.
+
+This file covers ORGANIZATION EXPORT:
+
+Export:
+  - ExportOrganization(ctx, orgname) (*OrgSpec, error) - Snapshot an organization as an OrgSpec
+
+The snapshot holds every team with its members, every robot with its
+federation, the permissions, notifications and mirror of every repository,
+the prototypes, auto-prune policies, quota and proxy cache, so planning it
+against the same organization finds no changes. Lists are sorted and robot
+tokens and registry passwords are left out, so exports can be kept in version
+control and diffed. Empty lists are written as [], so they stay managed: a
+first member, robot or notification added later is planned for removal and
+reported as drift.
+*/
+package lib
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// ExportOrganization returns the current configuration of an organization
+// as a spec.
+func (c *Client) ExportOrganization(ctx context.Context, orgname string) (*OrgSpec, error) {
+	if orgname == "" {
+		return nil, fmt.Errorf("orgname is required")
+	}
+	skeleton, err := c.exportSkeleton(ctx, orgname)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export organization: %w", err)
+	}
+	state, err := c.fetchOrgState(ctx, skeleton)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export organization: %w", err)
+	}
+	return exportOrg(skeleton, state), nil
+}
+
+// exportSkeleton returns a spec that manages every section, every team's
+// members and every robot and repository, so fetchOrgState reads them all.
+func (c *Client) exportSkeleton(ctx context.Context, orgname string) (*OrgSpec, error) {
+	spec := &OrgSpec{
+		Organization: orgname,
+		Prototypes:   []PrototypeSpec{},
+		AutoPrune:    []AutoPruneSpec{},
+		Quota:        &QuotaSpec{},
+		ProxyCache:   &ProxyCacheSpec{},
+	}
+	teams, err := c.GetTeams(ctx, orgname)
+	if err != nil {
+		return nil, err
+	}
+	spec.Teams = []TeamSpec{}
+	for _, team := range teams {
+		spec.Teams = append(spec.Teams, TeamSpec{Name: team.Name, Members: []string{}})
+	}
+
+	robots, err := c.GetRobotAccounts(ctx, orgname)
+	if err != nil {
+		return nil, err
+	}
+	spec.Robots = []RobotSpec{}
+	for _, robot := range robots.Robots {
+		spec.Robots = append(spec.Robots, RobotSpec{Name: strings.TrimPrefix(robot.Name, orgname+"+"), Federation: []RobotFederationConfig{}})
+	}
+
+	repos, err := c.ListAllRepositories(ctx, orgname, false, false, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, repo := range repos {
+		spec.Repositories = append(spec.Repositories, RepositorySpec{
+			Name:          repo.Name,
+			Permissions:   &RepositoryPermissionsSpec{},
+			Notifications: []NotificationSpec{},
+			Mirror:        &MirrorSpec{},
+		})
+	}
+	return spec, nil
+}
+
+// exportOrg fills a spec from the state read for its skeleton.
+func exportOrg(skeleton *OrgSpec, state *orgState) *OrgSpec {
+	org := skeleton.Organization
+	spec := &OrgSpec{
+		Organization: org,
+		Teams:        []TeamSpec{},
+		Robots:       []RobotSpec{},
+		Prototypes:   []PrototypeSpec{},
+		AutoPrune:    []AutoPruneSpec{},
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(state.teams)) {
+		team := state.teams[name]
+		var members []string
+		if !team.IsSynced {
+			members = memberNames(state.members[name])
+		}
+		spec.Teams = append(spec.Teams, TeamSpec{
+			Name:        name,
+			Description: team.Description,
+			Role:        team.Role,
+			Members:     members,
+		})
+	}
+	for _, name := range slices.Sorted(maps.Keys(state.robots)) {
+		federation := append([]RobotFederationConfig{}, state.federation[name]...)
+		slices.SortFunc(federation, func(a, b RobotFederationConfig) int {
+			return strings.Compare(a.Issuer+" "+a.Subject, b.Issuer+" "+b.Subject)
+		})
+		spec.Robots = append(spec.Robots, RobotSpec{
+			Name:        strings.TrimPrefix(name, org+"+"),
+			Description: state.robots[name].Description,
+			Federation:  federation,
+		})
+	}
+	for _, repo := range slices.SortedFunc(slices.Values(skeleton.Repositories), func(a, b RepositorySpec) int { return strings.Compare(a.Name, b.Name) }) {
+		spec.Repositories = append(spec.Repositories, exportRepository(repo.Name, state))
+	}
+
+	for _, prototype := range state.prototypes {
+		spec.Prototypes = append(spec.Prototypes, prototypeSpec(prototype))
+	}
+	slices.SortFunc(spec.Prototypes, func(a, b PrototypeSpec) int { return strings.Compare(a.key(), b.key()) })
+	for _, policy := range state.policies {
+		spec.AutoPrune = append(spec.AutoPrune, AutoPruneSpec{Method: policy.Method, Value: policy.Value, TagPattern: policy.TagPattern})
+	}
+	slices.SortFunc(spec.AutoPrune, func(a, b AutoPruneSpec) int { return strings.Compare(a.key(), b.key()) })
+
+	if state.quota != nil && state.quota.LimitBytes > 0 {
+		spec.Quota = &QuotaSpec{LimitBytes: state.quota.LimitBytes}
+	}
+	if proxy := state.proxyCache; proxy != nil {
+		spec.ProxyCache = &ProxyCacheSpec{UpstreamRegistry: proxy.UpstreamRegistry, Insecure: proxy.Insecure, Expiration: proxy.Expiration}
+	}
+	return spec
+}
+
+// memberNames returns the sorted names of team members, leaving out pending
+// invitations, which cannot be added by name. It is never nil.
+func memberNames(members []TeamMember) []string {
+	names := []string{}
+	for _, member := range members {
+		if !member.Invited {
+			names = append(names, member.Name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+func exportRepository(name string, state *orgState) RepositorySpec {
+	repo := RepositorySpec{Name: name, Permissions: &RepositoryPermissionsSpec{}, Notifications: []NotificationSpec{}}
+	if users := state.userPerms[name]; len(users) > 0 {
+		repo.Permissions.Users = users
+	}
+	if teams := state.teamPerms[name]; len(teams) > 0 {
+		repo.Permissions.Teams = teams
+	}
+	for _, notification := range state.notifications[name] {
+		repo.Notifications = append(repo.Notifications, notificationSpec(notification))
+	}
+	slices.SortFunc(repo.Notifications, func(a, b NotificationSpec) int { return strings.Compare(a.key(), b.key()) })
+	if mirror := state.mirrors[name]; mirror != nil {
+		repo.Mirror = mirrorSpec(mirror)
+	}
+	return repo
+}
@@ -0,0 +1,160 @@
+package lib
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExportOrganization(t *testing.T) {
+	org := "/api/v1/organization/" + testNamespace
+	repo := "/api/v1/repository/" + testNamespace + "/"
+	responses := map[string]string{
+		org + "/teams": `{"teams": [{"name": "owners", "role": "admin"}, {"name": "` + testTeamName + `", "description": "` + testTeamDescDev + `", "role": "member"},
+			{"name": "synced", "role": "member", "is_synced": true}]}`,
+		org + "/team/owners/members":               `{"members": [{"name": "root"}]}`,
+		org + "/team/" + testTeamName + "/members": `{"members": [{"name": "zoe"}, {"name": "alice"}, {"name": "new@example.com", "invited": true}]}`,
+		org + "/robots":                            `{"robots": [{"name": "` + testNamespace + `+deploy", "token": "secret-token", "description": "` + testRobotDescValue + `"}, {"name": "` + testNamespace + `+ci"}]}`,
+		org + "/robots/deploy/federation":          `{"federation": [{"issuer": "https://token.actions.githubusercontent.com", "subject": "repo:acme/app:ref:refs/heads/main"}]}`,
+		org + "/robots/ci/federation":              `{}`,
+		"/api/v1/repository":                       `{"repositories": [{"name": "web"}, {"name": "api"}]}`,
+		repo + "api/permissions/user/":             `{"permissions": [{"name": "alice", "role": "admin"}, {"name": "` + testNamespace + `+ci", "role": "write", "is_robot": true}]}`,
+		repo + "api/permissions/team/":             `{"permissions": [{"name": "` + testTeamName + `", "role": "read"}]}`,
+		repo + "api/notification/": `{"notifications": [{"uuid": "n2", "event": "vulnerability_found", "method": "webhook", "config": {"url": "https://hooks.example.com"}},
+			{"uuid": "n1", "event": "repo_push", "method": "email", "title": "pushes", "config": {"email": "` + testEmailAddress + `"}}]}`,
+		repo + "web/permissions/user/": `{"permissions": []}`,
+		repo + "web/permissions/team/": `{"permissions": []}`,
+		repo + "web/notification/":     `{"notifications": []}`,
+		repo + "web/mirror": `{"is_enabled": true, "external_reference": "docker.io/library/nginx", "sync_interval": 3600,
+			"robot_username": "` + testNamespace + `+ci", "root_rule": {"rule_kind": "tag_glob_csv", "rule": "1.*,latest"}}`,
+		org + "/prototypes":      `{"prototypes": [{"id": "p1", "role": "write", "delegate": {"name": "` + testNamespace + `+ci", "kind": "user", "is_robot": true}}]}`,
+		org + "/autoprunepolicy": `{"policies": [{"uuid": "a1", "method": "creation_date", "value": 30, "tag_pattern": "^pr-"}]}`,
+		org + "/quota":           `{"id": "q1", "limit_bytes": 1073741824}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Export must only read, got %s %s", r.Method, r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			body = `{"error": "not found"}`
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+	client, err := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	spec, err := client.ExportOrganization(context.Background(), testNamespace)
+	if err != nil {
+		t.Fatalf("ExportOrganization returned error: %v", err)
+	}
+	if err := spec.Validate(); err != nil {
+		t.Errorf("Export is not a valid spec: %v", err)
+	}
+
+	if len(spec.Teams) != 3 || spec.Teams[0].Name != testTeamName || !slices.Equal(spec.Teams[0].Members, []string{"alice", "zoe"}) {
+		t.Errorf("Expected sorted teams and members without invitations, got %+v", spec.Teams)
+	}
+	if spec.Teams[2].Name != "synced" || spec.Teams[2].Members != nil {
+		t.Errorf("Expected the members of a synced team to be left out, got %+v", spec.Teams[2])
+	}
+	if len(spec.Robots) != 2 || spec.Robots[0].Name != "ci" || spec.Robots[1].Description != testRobotDescValue || len(spec.Robots[1].Federation) != 1 {
+		t.Errorf("Unexpected robots: %+v", spec.Robots)
+	}
+	if len(spec.Repositories) != 2 || spec.Repositories[0].Name != "api" {
+		t.Fatalf("Expected repositories sorted by name, got %+v", spec.Repositories)
+	}
+	api, web := spec.Repositories[0], spec.Repositories[1]
+	if !reflect.DeepEqual(api.Permissions, &RepositoryPermissionsSpec{
+		Users: map[string]string{"alice": roleAdmin, testNamespace + "+ci": testRoleWrite},
+		Teams: map[string]string{testTeamName: testRoleRead},
+	}) {
+		t.Errorf("Unexpected permissions: %+v", api.Permissions)
+	}
+	if len(api.Notifications) != 2 || api.Notifications[0].Event != "repo_push" || api.Mirror != nil {
+		t.Errorf("Expected notifications sorted by event and no mirror, got %+v", api)
+	}
+	if web.Mirror == nil || !slices.Equal(web.Mirror.Tags, []string{"1.*", "latest"}) || web.Mirror.SyncInterval != 3600 {
+		t.Errorf("Unexpected mirror: %+v", web.Mirror)
+	}
+	if len(spec.Prototypes) != 1 || spec.Prototypes[0].User != testNamespace+"+ci" {
+		t.Errorf("Unexpected prototypes: %+v", spec.Prototypes)
+	}
+	if spec.Quota == nil || spec.Quota.LimitBytes != 1<<30 || spec.ProxyCache != nil {
+		t.Errorf("Expected the quota and no proxy cache, got %+v, %+v", spec.Quota, spec.ProxyCache)
+	}
+
+	data, _ := json.Marshal(spec)
+	if strings.Contains(string(data), "secret-token") {
+		t.Error("Export must not contain robot tokens")
+	}
+}
+
+func TestExportedSpecPlansNoChanges(t *testing.T) {
+	state := &orgState{
+		teams:      map[string]Team{testTeamName: {Name: testTeamName, Role: roleMember}},
+		members:    map[string][]TeamMember{testTeamName: {{Name: "alice"}}},
+		robots:     map[string]RobotAccount{testNamespace + "+ci": {Name: testNamespace + "+ci"}},
+		federation: map[string][]RobotFederationConfig{testNamespace + "+ci": {{Issuer: "https://issuer", Subject: "sub"}}},
+		userPerms:  map[string]map[string]string{testRepository: {"alice": testRoleRead}},
+		teamPerms:  map[string]map[string]string{testRepository: {}},
+		notifications: map[string][]RepositoryNotification{testRepository: {
+			{UUID: "n1", Event: "repo_push", Method: "webhook", Config: map[string]any{"url": "https://hooks.example.com", "retries": float64(3)}},
+		}},
+		mirrors:  map[string]*MirrorConfig{testRepository: {IsEnabled: true, ExternalRef: "docker.io/library/nginx", SyncInterval: 60}},
+		policies: []AutoPrunePolicy{{UUID: "a1", Method: AutoPruneMethodNumberOfTags, Value: 10}},
+	}
+	skeleton := &OrgSpec{Organization: testNamespace, Repositories: []RepositorySpec{{Name: testRepository}}}
+	spec := exportOrg(skeleton, state)
+	// The YAML decoder reads numbers as ints where the API returns floats.
+	spec.Repositories[0].Notifications[0].Config["retries"] = 3
+
+	if plan := planOrg(spec, state); len(plan.Changes) != 0 {
+		t.Errorf("Expected no changes for an exported spec, got %q", changeNames(plan))
+	}
+
+	// Quay disables a notification after repeated failures.
+	state.notifications[testRepository][0].NumberOfFailures = notificationFailureLimit
+	plan := planOrg(spec, state)
+	if got := changeNames(plan); !slices.Equal(got, []string{"update notification " + testRepository + "/repo_push webhook"}) {
+		t.Errorf("Expected the notification to be re-enabled, got %q", got)
+	}
+}
+
+func TestExportedEmptyListsStayManaged(t *testing.T) {
+	state := &orgState{
+		teams:         map[string]Team{testTeamName: {Name: testTeamName, Role: roleMember}},
+		members:       map[string][]TeamMember{},
+		userPerms:     map[string]map[string]string{testRepository: {}},
+		teamPerms:     map[string]map[string]string{testRepository: {}},
+		notifications: map[string][]RepositoryNotification{},
+	}
+	skeleton := &OrgSpec{Organization: testNamespace, Repositories: []RepositorySpec{{Name: testRepository}}}
+	data, err := json.Marshal(exportOrg(skeleton, state))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var spec OrgSpec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatal(err)
+	}
+
+	state.members[testTeamName] = []TeamMember{{Name: "mallory"}}
+	report := driftReport(&spec, state, planOrg(&spec, state))
+	if len(report.Findings) != 1 {
+		t.Fatalf("Expected one finding, got %+v", report.Findings)
+	}
+	if got := report.Findings[0]; got.Kind != DriftKindUnexpected || got.Name != testTeamName+"/mallory" {
+		t.Errorf("Expected an unexpected member, got %+v", got)
+	}
+}
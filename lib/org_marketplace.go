@@ -23,7 +23,7 @@ func (c *Client) GetOrganizationMarketplace(ctx context.Context, orgname string)
 		return nil, fmt.Errorf("orgname is required")
 	}
 
-	req, err := newRequest(ctx, http.MethodGet, c.buildURL("/organization/%s/marketplace", orgname), nil)
+	req, err := newAPIRequest(ctx, http.MethodGet, c.buildURL("/organization/%s/marketplace", orgname), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create get organization marketplace request: %w", err)
 	}
@@ -42,7 +42,7 @@ func (c *Client) CreateOrganizationMarketplaceSubscription(ctx context.Context,
 		return fmt.Errorf("orgname is required")
 	}
 
-	req, err := newRequestWithBody(ctx, http.MethodPost, c.buildURL("/organization/%s/marketplace", orgname), subscription)
+	req, err := newAPIRequestWithBody(ctx, http.MethodPost, c.buildURL("/organization/%s/marketplace", orgname), subscription)
 	if err != nil {
 		return fmt.Errorf("failed to create marketplace subscription request: %w", err)
 	}
@@ -65,7 +65,7 @@ func (c *Client) BatchRemoveOrganizationMarketplaceSubscriptions(ctx context.Con
 	}{
 		SubscriptionIDs: subscriptionIDs,
 	}
-	req, err := newRequestWithBody(ctx, http.MethodPost, c.buildURL("/organization/%s/marketplace/batchremove", orgname), body)
+	req, err := newAPIRequestWithBody(ctx, http.MethodPost, c.buildURL("/organization/%s/marketplace/batchremove", orgname), body)
 	if err != nil {
 		return fmt.Errorf("failed to create batch remove marketplace subscriptions request: %w", err)
 	}
@@ -86,7 +86,7 @@ func (c *Client) DeleteOrganizationMarketplaceSubscription(ctx context.Context,
 		return fmt.Errorf("subscriptionID is required")
 	}
 
-	req, err := newRequest(ctx, http.MethodDelete, c.buildURL("/organization/%s/marketplace/%s", orgname, subscriptionID), nil)
+	req, err := newAPIRequest(ctx, http.MethodDelete, c.buildURL("/organization/%s/marketplace/%s", orgname, subscriptionID), nil)
 	if err != nil {
 		return fmt.Errorf("failed to create delete marketplace subscription request: %w", err)
 	}
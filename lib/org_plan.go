@@ -0,0 +1,978 @@
+/*
+Package lib provides Quay.io API client functionality.
+
+This file covers DECLARATIVE ORGANIZATION plans:
+
+Plan:
+  - PlanOrganization(ctx, spec) (*OrgPlan, error) - Diff an OrgSpec against the live organization
+  - ApplyOrgPlan(ctx, plan, opts) (int, error) - Make a plan's changes in dependency order
+
+A plan reads only the sections its spec manages and holds one OrgChange per
+API call. Changes are ordered so that teams and robots exist before they gain
+members or permissions, and grants are revoked before the teams and robots
+holding them are deleted. The owners team is never deleted, and members of
+teams synced from a directory are left alone.
+*/
+package lib
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Actions of an OrgChange. A replace deletes the resource and creates it
+// again, losing any settings Quay does not report back.
+const (
+	OrgActionCreate  = "create"
+	OrgActionUpdate  = "update"
+	OrgActionReplace = "replace"
+	OrgActionDelete  = "delete"
+)
+
+// Resources an OrgChange applies to.
+const (
+	OrgResourceTeam                 = "team"
+	OrgResourceTeamMember           = "team_member"
+	OrgResourceRobot                = "robot"
+	OrgResourceRobotFederation      = "robot_federation"
+	OrgResourceRepositoryPermission = "repository_permission"
+	OrgResourceNotification         = "notification"
+	OrgResourceMirror               = "mirror"
+	OrgResourcePrototype            = "prototype"
+	OrgResourceAutoPrune            = "auto_prune"
+	OrgResourceQuota                = "quota"
+	OrgResourceProxyCache           = "proxy_cache"
+)
+
+// Delegate kinds of prototypes and permissions.
+const (
+	delegateUser = "user"
+	delegateTeam = "team"
+)
+
+// notificationFailureLimit is the number of failed deliveries after which
+// Quay stops sending a notification.
+const notificationFailureLimit = 3
+
+// Apply phases, in order.
+const (
+	phaseSettings = iota
+	phaseAccounts
+	phaseMembers
+	phasePermissions
+	phaseDefaults
+	phaseRevokeDefaults
+	phaseRevokePermissions
+	phaseRemoveMembers
+	phaseRemoveAccounts
+	phaseRemoveSettings
+)
+
+// OrgChange is one API call of an OrgPlan.
+type OrgChange struct {
+	Action   string `json:"action"`
+	Resource string `json:"resource"`
+	// Name identifies the resource: a team, robot, prototype or policy,
+	// TEAM/MEMBER for team members, REPO/user:NAME or REPO/team:NAME for
+	// repository permissions and REPO/EVENT METHOD [TITLE] for notifications.
+	Name   string           `json:"name"`
+	Fields []OrgFieldChange `json:"fields,omitempty"`
+	// Warning describes what a change loses, such as credentials a
+	// replace clears.
+	Warning string `json:"warning,omitempty"`
+
+	phase int
+	apply func(ctx context.Context, c *Client) error
+}
+
+// String returns the action, resource and name of the change.
+func (ch OrgChange) String() string {
+	return ch.Action + " " + ch.Resource + " " + ch.Name
+}
+
+// OrgFieldChange is a changed attribute. From is empty on create and To is
+// empty on delete.
+type OrgFieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from,omitempty"`
+	To    string `json:"to,omitempty"`
+}
+
+// OrgPlan is the list of changes that bring an organization to its spec.
+type OrgPlan struct {
+	Organization string      `json:"organization"`
+	Changes      []OrgChange `json:"changes"`
+}
+
+// Count returns the number of changes with the given action.
+func (p *OrgPlan) Count(action string) int {
+	n := 0
+	for _, change := range p.Changes {
+		if change.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// OrgApplyOptions configures ApplyOrgPlan.
+type OrgApplyOptions struct {
+	// Progress, if set, is called after each change is made.
+	Progress func(change OrgChange)
+}
+
+// PlanOrganization validates spec and returns the changes that bring the
+// organization to it. Nothing is modified.
+func (c *Client) PlanOrganization(ctx context.Context, spec *OrgSpec) (*OrgPlan, error) {
+	if spec == nil {
+		return nil, fmt.Errorf("spec is required")
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	state, err := c.fetchOrgState(ctx, spec)
+	if err != nil {
+		return nil, err
+	}
+	return planOrg(spec, state), nil
+}
+
+// ApplyOrgPlan makes the changes of a plan from PlanOrganization in order and
+// returns how many were made. It stops at the first failure; planning again
+// picks up what is left.
+func (c *Client) ApplyOrgPlan(ctx context.Context, plan *OrgPlan, opts *OrgApplyOptions) (int, error) {
+	if plan == nil {
+		return 0, fmt.Errorf("plan is required")
+	}
+	if opts == nil {
+		opts = &OrgApplyOptions{}
+	}
+	for i, change := range plan.Changes {
+		if change.apply == nil {
+			return i, fmt.Errorf("change %q was not planned by PlanOrganization", change.String())
+		}
+		if err := change.apply(ctx, c); err != nil {
+			return i, fmt.Errorf("failed to %s: %w", change.String(), err)
+		}
+		if opts.Progress != nil {
+			opts.Progress(change)
+		}
+	}
+	return len(plan.Changes), nil
+}
+
+// orgState is the live state of the sections a spec manages.
+type orgState struct {
+	teams         map[string]Team
+	members       map[string][]TeamMember             // by team, for teams whose members are managed
+	robots        map[string]RobotAccount             // by full name
+	federation    map[string][]RobotFederationConfig  // by full name, for robots whose federation is managed
+	userPerms     map[string]map[string]string        // by repository, then user or robot
+	teamPerms     map[string]map[string]string        // by repository, then team
+	notifications map[string][]RepositoryNotification // by repository
+	mirrors       map[string]*MirrorConfig            // by repository, nil if not mirrored
+	prototypes    []Prototype
+	policies      []AutoPrunePolicy
+	quota         *Quota
+	proxyCache    *ProxyCacheConfig
+}
+
+func (c *Client) fetchOrgState(ctx context.Context, spec *OrgSpec) (*orgState, error) {
+	state := &orgState{
+		members:       map[string][]TeamMember{},
+		federation:    map[string][]RobotFederationConfig{},
+		userPerms:     map[string]map[string]string{},
+		teamPerms:     map[string]map[string]string{},
+		notifications: map[string][]RepositoryNotification{},
+		mirrors:       map[string]*MirrorConfig{},
+	}
+	for _, fetch := range []func(context.Context, *Client, *OrgSpec) error{
+		state.fetchTeams, state.fetchRobots, state.fetchRepositories, state.fetchDefaults, state.fetchSettings,
+	} {
+		if err := fetch(ctx, c, spec); err != nil {
+			return nil, err
+		}
+	}
+	return state, nil
+}
+
+func (s *orgState) fetchTeams(ctx context.Context, c *Client, spec *OrgSpec) error {
+	if spec.Teams == nil {
+		return nil
+	}
+	teams, err := c.GetTeams(ctx, spec.Organization)
+	if err != nil {
+		return err
+	}
+	s.teams = map[string]Team{}
+	for _, team := range teams {
+		s.teams[team.Name] = team
+	}
+	for _, team := range spec.Teams {
+		live, ok := s.teams[team.Name]
+		if team.Members == nil || !ok || live.IsSynced {
+			continue
+		}
+		members, err := c.GetTeamMembers(ctx, spec.Organization, team.Name)
+		if err != nil {
+			return err
+		}
+		s.members[team.Name] = members.Members
+	}
+	return nil
+}
+
+func (s *orgState) fetchRobots(ctx context.Context, c *Client, spec *OrgSpec) error {
+	if spec.Robots == nil {
+		return nil
+	}
+	robots, err := c.GetRobotAccounts(ctx, spec.Organization)
+	if err != nil {
+		return err
+	}
+	s.robots = map[string]RobotAccount{}
+	for _, robot := range robots.Robots {
+		s.robots[robot.Name] = robot
+	}
+	for _, robot := range spec.Robots {
+		name := spec.Organization + "+" + robot.Name
+		if _, ok := s.robots[name]; !ok || robot.Federation == nil {
+			continue
+		}
+		federation, err := c.GetRobotFederation(ctx, spec.Organization, robot.Name)
+		if err != nil && !isNotFound(err) {
+			return err
+		}
+		if federation != nil {
+			s.federation[name] = federation.Federation
+		}
+	}
+	return nil
+}
+
+func (s *orgState) fetchRepositories(ctx context.Context, c *Client, spec *OrgSpec) error {
+	for _, repo := range spec.Repositories {
+		if err := s.fetchRepository(ctx, c, spec.Organization, repo); err != nil {
+			return fmt.Errorf("failed to read %s/%s: %w", spec.Organization, repo.Name, err)
+		}
+	}
+	return nil
+}
+
+func (s *orgState) fetchRepository(ctx context.Context, c *Client, org string, repo RepositorySpec) error {
+	if repo.Permissions != nil {
+		users, err := c.ListUserPermissions(ctx, org, repo.Name)
+		if err != nil {
+			return err
+		}
+		teams, err := c.ListTeamPermissions(ctx, org, repo.Name)
+		if err != nil {
+			return err
+		}
+		s.userPerms[repo.Name] = permissionRoles(users)
+		s.teamPerms[repo.Name] = permissionRoles(teams)
+	}
+	if repo.Notifications != nil {
+		notifications, err := c.GetNotifications(ctx, org, repo.Name)
+		if err != nil {
+			return err
+		}
+		s.notifications[repo.Name] = notifications.Notifications
+	}
+	if repo.Mirror != nil {
+		mirror, err := c.GetMirrorConfig(ctx, org, repo.Name)
+		if err != nil && !isNotFound(err) {
+			return err
+		}
+		if mirror != nil && mirror.ExternalRef != "" {
+			s.mirrors[repo.Name] = mirror
+		}
+	}
+	return nil
+}
+
+func permissionRoles(permissions *RepositoryPermissions) map[string]string {
+	roles := map[string]string{}
+	for _, permission := range permissions.Permissions {
+		roles[permission.Name] = permission.Role
+	}
+	return roles
+}
+
+func (s *orgState) fetchDefaults(ctx context.Context, c *Client, spec *OrgSpec) error {
+	if spec.Prototypes != nil {
+		prototypes, err := c.GetPrototypes(ctx, spec.Organization)
+		if err != nil {
+			return err
+		}
+		s.prototypes = prototypes.Prototypes
+	}
+	if spec.AutoPrune != nil {
+		policies, err := c.GetAutoPrunePolicies(ctx, spec.Organization)
+		if err != nil {
+			return err
+		}
+		s.policies = policies.Policies
+	}
+	return nil
+}
+
+// fetchSettings reads the quota and proxy cache. Either one being absent,
+// or unsupported by the registry, is read as not configured.
+func (s *orgState) fetchSettings(ctx context.Context, c *Client, spec *OrgSpec) error {
+	if spec.Quota != nil {
+		quota, err := c.GetQuota(ctx, spec.Organization)
+		if err != nil && !isNotFound(err) {
+			return err
+		}
+		s.quota = quota
+	}
+	if spec.ProxyCache != nil {
+		config, err := c.GetProxyCacheConfig(ctx, spec.Organization)
+		if err != nil && !isNotFound(err) {
+			return err
+		}
+		if config != nil && config.UpstreamRegistry != "" {
+			s.proxyCache = config
+		}
+	}
+	return nil
+}
+
+// isNotFound reports whether err is a 404 from the API or the registry.
+func isNotFound(err error) bool {
+	var quayErr *QuayError
+	var registryErr *RegistryError
+	return errors.As(err, &quayErr) && quayErr.Status == http.StatusNotFound ||
+		errors.As(err, &registryErr) && registryErr.Status == http.StatusNotFound
+}
+
+// orgPlanner accumulates the changes of a plan.
+type orgPlanner struct {
+	org     string
+	changes []OrgChange
+}
+
+func planOrg(spec *OrgSpec, state *orgState) *OrgPlan {
+	p := &orgPlanner{org: spec.Organization}
+	p.planTeams(spec.Teams, state)
+	p.planRobots(spec.Robots, state)
+	for _, repo := range spec.Repositories {
+		if repo.Permissions != nil {
+			p.planPermissions(repo.Name, delegateUser, repo.Permissions.Users, state.userPerms[repo.Name])
+			p.planPermissions(repo.Name, delegateTeam, repo.Permissions.Teams, state.teamPerms[repo.Name])
+		}
+		if repo.Notifications != nil {
+			p.planNotifications(repo.Name, repo.Notifications, state.notifications[repo.Name])
+		}
+		if repo.Mirror != nil {
+			p.planMirror(repo.Name, repo.Mirror, state.mirrors[repo.Name])
+		}
+	}
+	p.planPrototypes(spec.Prototypes, state.prototypes)
+	p.planAutoPrune(spec.AutoPrune, state.policies)
+	p.planQuota(spec.Quota, state.quota)
+	p.planProxyCache(spec.ProxyCache, state.proxyCache)
+
+	slices.SortStableFunc(p.changes, func(a, b OrgChange) int {
+		return cmp.Compare(a.phase, b.phase)
+	})
+	return &OrgPlan{Organization: spec.Organization, Changes: p.changes}
+}
+
+func (p *orgPlanner) add(phase int, change OrgChange, apply func(ctx context.Context, c *Client) error) {
+	change.phase, change.apply = phase, apply
+	p.changes = append(p.changes, change)
+}
+
+// fieldChange returns the change of one attribute, or nothing if it is unchanged.
+func fieldChange(field, from, to string) []OrgFieldChange {
+	if from == to {
+		return nil
+	}
+	return []OrgFieldChange{{Field: field, From: from, To: to}}
+}
+
+func (p *orgPlanner) planTeams(teams []TeamSpec, state *orgState) {
+	if teams == nil {
+		return
+	}
+	org := p.org
+	desired := map[string]bool{}
+	for _, team := range slices.SortedFunc(slices.Values(teams), func(a, b TeamSpec) int { return strings.Compare(a.Name, b.Name) }) {
+		desired[team.Name] = true
+		live, exists := state.teams[team.Name]
+		role := cmp.Or(team.Role, live.Role, TeamRoleMember)
+		change := OrgChange{Action: OrgActionCreate, Resource: OrgResourceTeam, Name: team.Name, Fields: slices.Concat(
+			fieldChange("description", live.Description, team.Description),
+			fieldChange("role", live.Role, role),
+		)}
+		if exists {
+			change.Action = OrgActionUpdate
+		}
+		if !exists || len(change.Fields) > 0 {
+			p.add(phaseAccounts, change, func(ctx context.Context, c *Client) error {
+				if exists {
+					_, err := c.UpdateTeam(ctx, org, team.Name, team.Description, role)
+					return err
+				}
+				_, err := c.CreateTeam(ctx, org, team.Name, team.Description, role)
+				return err
+			})
+		}
+		if team.Members != nil && !live.IsSynced {
+			p.planMembers(team.Name, team.Members, state.members[team.Name])
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(state.teams)) {
+		if desired[name] || name == ownersTeam {
+			continue
+		}
+		live := state.teams[name]
+		p.add(phaseRemoveAccounts, OrgChange{Action: OrgActionDelete, Resource: OrgResourceTeam, Name: name, Fields: slices.Concat(
+			fieldChange("description", live.Description, ""),
+			fieldChange("role", live.Role, ""),
+		)}, func(ctx context.Context, c *Client) error {
+			return c.DeleteTeam(ctx, org, name)
+		})
+	}
+}
+
+// planMembers adds and removes team members. Pending invitations count as
+// members but are never removed.
+func (p *orgPlanner) planMembers(team string, desired []string, live []TeamMember) {
+	org := p.org
+	present := map[string]bool{}
+	for _, member := range live {
+		present[member.Name] = true
+	}
+	wanted := map[string]bool{}
+	for _, name := range slices.Compact(slices.Sorted(slices.Values(desired))) {
+		wanted[name] = true
+		if present[name] {
+			continue
+		}
+		p.add(phaseMembers, OrgChange{Action: OrgActionCreate, Resource: OrgResourceTeamMember, Name: team + "/" + name},
+			func(ctx context.Context, c *Client) error {
+				return c.AddTeamMember(ctx, org, team, name)
+			})
+	}
+	for _, member := range slices.SortedFunc(slices.Values(live), func(a, b TeamMember) int { return strings.Compare(a.Name, b.Name) }) {
+		if wanted[member.Name] || member.Invited {
+			continue
+		}
+		p.add(phaseRemoveMembers, OrgChange{Action: OrgActionDelete, Resource: OrgResourceTeamMember, Name: team + "/" + member.Name},
+			func(ctx context.Context, c *Client) error {
+				return c.RemoveTeamMember(ctx, org, team, member.Name)
+			})
+	}
+}
+
+// planRobots creates and deletes robots and sets their federation.
+func (p *orgPlanner) planRobots(robots []RobotSpec, state *orgState) {
+	if robots == nil {
+		return
+	}
+	org, live := p.org, state.robots
+	desired := map[string]bool{}
+	for _, robot := range slices.SortedFunc(slices.Values(robots), func(a, b RobotSpec) int { return strings.Compare(a.Name, b.Name) }) {
+		name := org + "+" + robot.Name
+		desired[name] = true
+		if _, exists := live[name]; !exists {
+			p.add(phaseAccounts, OrgChange{Action: OrgActionCreate, Resource: OrgResourceRobot, Name: name,
+				Fields: fieldChange("description", "", robot.Description)},
+				func(ctx context.Context, c *Client) error {
+					_, err := c.CreateRobotAccount(ctx, org, robot.Name, robot.Description, nil)
+					return err
+				})
+		}
+		if robot.Federation != nil {
+			p.planFederation(robot.Name, robot.Federation, state.federation[name])
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(live)) {
+		if desired[name] {
+			continue
+		}
+		p.add(phaseRemoveAccounts, OrgChange{Action: OrgActionDelete, Resource: OrgResourceRobot, Name: name,
+			Fields: fieldChange("description", live[name].Description, "")},
+			func(ctx context.Context, c *Client) error {
+				return c.DeleteRobotAccount(ctx, org, strings.TrimPrefix(name, org+"+"))
+			})
+	}
+}
+
+// federationString formats a federation configuration for a plan.
+func federationString(configs []RobotFederationConfig) string {
+	entries := make([]string, 0, len(configs))
+	for _, config := range configs {
+		entries = append(entries, config.Issuer+" "+config.Subject)
+	}
+	slices.Sort(entries)
+	return strings.Join(entries, ", ")
+}
+
+// planFederation replaces the federation of a robot, by short name.
+func (p *orgPlanner) planFederation(robot string, desired, live []RobotFederationConfig) {
+	org := p.org
+	from, to := federationString(live), federationString(desired)
+	if from == to {
+		return
+	}
+	change := OrgChange{Action: OrgActionUpdate, Resource: OrgResourceRobotFederation, Name: org + "+" + robot,
+		Fields: fieldChange("federation", from, to)}
+	switch {
+	case len(desired) == 0:
+		change.Action = OrgActionDelete
+		p.add(phaseAccounts, change, func(ctx context.Context, c *Client) error {
+			return c.DeleteRobotFederation(ctx, org, robot)
+		})
+		return
+	case len(live) == 0:
+		change.Action = OrgActionCreate
+	}
+	p.add(phaseAccounts, change, func(ctx context.Context, c *Client) error {
+		return c.CreateRobotFederation(ctx, org, robot, desired)
+	})
+}
+
+// planPermissions grants, changes and revokes the user (and robot) or team
+// permissions of a repository.
+func (p *orgPlanner) planPermissions(repo, kind string, desired, live map[string]string) {
+	org := p.org
+	set, revoke := (*Client).SetUserPermission, (*Client).DeleteUserPermission
+	if kind == delegateTeam {
+		set, revoke = (*Client).SetTeamPermission, (*Client).DeleteTeamPermission
+	}
+	for _, name := range slices.Sorted(maps.Keys(desired)) {
+		role := desired[name]
+		from, exists := live[name]
+		change := OrgChange{Action: OrgActionCreate, Resource: OrgResourceRepositoryPermission, Name: repo + "/" + kind + ":" + name,
+			Fields: fieldChange("role", from, role)}
+		if exists {
+			if from == role {
+				continue
+			}
+			change.Action = OrgActionUpdate
+		}
+		p.add(phasePermissions, change, func(ctx context.Context, c *Client) error {
+			return set(c, ctx, org, repo, name, role)
+		})
+	}
+	for _, name := range slices.Sorted(maps.Keys(live)) {
+		if _, ok := desired[name]; ok {
+			continue
+		}
+		p.add(phaseRevokePermissions, OrgChange{Action: OrgActionDelete, Resource: OrgResourceRepositoryPermission, Name: repo + "/" + kind + ":" + name,
+			Fields: fieldChange("role", live[name], "")},
+			func(ctx context.Context, c *Client) error {
+				return revoke(c, ctx, org, repo, name)
+			})
+	}
+}
+
+// jsonString formats a notification configuration for comparison; empty
+// configurations format as "".
+func jsonString(v map[string]any) string {
+	if len(v) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// notificationSpec returns the spec form of a live notification.
+func notificationSpec(notification RepositoryNotification) NotificationSpec {
+	return NotificationSpec{
+		Title:       notification.Title,
+		Event:       notification.Event,
+		Method:      notification.Method,
+		Config:      notification.Config,
+		EventConfig: notification.EventConfig,
+	}
+}
+
+// request returns the request that creates or updates the notification.
+func (n NotificationSpec) request() *CreateNotificationRequest {
+	return &CreateNotificationRequest{Event: n.Event, Method: n.Method, Config: n.Config, EventConfig: n.EventConfig, Title: n.Title}
+}
+
+// notificationFields lists the configuration changes between two notifications.
+func notificationFields(from, to NotificationSpec) []OrgFieldChange {
+	return slices.Concat(
+		fieldChange("config", jsonString(from.Config), jsonString(to.Config)),
+		fieldChange("event_config", jsonString(from.EventConfig), jsonString(to.EventConfig)),
+	)
+}
+
+// planNotifications matches notifications by event, method and title,
+// updates changed configurations, re-enables notifications Quay disabled
+// and deletes the rest.
+func (p *orgPlanner) planNotifications(repo string, desired []NotificationSpec, live []RepositoryNotification) {
+	org := p.org
+	current := map[string]RepositoryNotification{}
+	var extra []RepositoryNotification
+	for _, notification := range live {
+		key := notificationSpec(notification).key()
+		if _, dup := current[key]; dup {
+			extra = append(extra, notification)
+			continue
+		}
+		current[key] = notification
+	}
+	for _, notification := range slices.SortedFunc(slices.Values(desired), func(a, b NotificationSpec) int { return strings.Compare(a.key(), b.key()) }) {
+		key := notification.key()
+		existing, exists := current[key]
+		delete(current, key)
+		if !exists {
+			p.add(phaseDefaults, OrgChange{Action: OrgActionCreate, Resource: OrgResourceNotification, Name: repo + "/" + key,
+				Fields: notificationFields(NotificationSpec{}, notification)},
+				func(ctx context.Context, c *Client) error {
+					_, err := c.CreateNotification(ctx, org, repo, notification.request())
+					return err
+				})
+			continue
+		}
+		disabled := existing.NumberOfFailures >= notificationFailureLimit
+		fields := notificationFields(notificationSpec(existing), notification)
+		reconfigure := len(fields) > 0
+		if disabled {
+			fields = append(fields, fieldChange("enabled", "false", "true")...)
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		p.add(phaseDefaults, OrgChange{Action: OrgActionUpdate, Resource: OrgResourceNotification, Name: repo + "/" + key, Fields: fields},
+			func(ctx context.Context, c *Client) error {
+				if reconfigure {
+					if _, err := c.UpdateNotification(ctx, org, repo, existing.UUID, notification.request()); err != nil {
+						return err
+					}
+				}
+				if disabled {
+					return c.ResetNotification(ctx, org, repo, existing.UUID)
+				}
+				return nil
+			})
+	}
+	for _, key := range slices.Sorted(maps.Keys(current)) {
+		extra = append(extra, current[key])
+	}
+	for _, notification := range extra {
+		p.add(phaseRevokeDefaults, OrgChange{Action: OrgActionDelete, Resource: OrgResourceNotification,
+			Name: repo + "/" + notificationSpec(notification).key()},
+			func(ctx context.Context, c *Client) error {
+				return c.DeleteNotification(ctx, org, repo, notification.UUID)
+			})
+	}
+}
+
+// mirrorSpec returns the spec form of a live mirror configuration.
+func mirrorSpec(config *MirrorConfig) *MirrorSpec {
+	spec := &MirrorSpec{
+		ExternalReference:        config.ExternalRef,
+		SyncInterval:             config.SyncInterval,
+		RobotUsername:            config.RobotUsername,
+		Disabled:                 !config.IsEnabled,
+		ExternalRegistryUsername: config.ExternalRegistryUsername,
+	}
+	if config.RootRule.Rule != "" {
+		spec.Tags = strings.Split(config.RootRule.Rule, ",")
+	}
+	return spec
+}
+
+// mirrorAttributes lists the attributes of a mirror, or none for nil.
+func mirrorAttributes(mirror *MirrorSpec) map[string]string {
+	if mirror == nil {
+		return map[string]string{}
+	}
+	return map[string]string{
+		"external_reference":         mirror.ExternalReference,
+		"sync_interval":              strconv.Itoa(mirror.SyncInterval),
+		"robot_username":             mirror.RobotUsername,
+		"enabled":                    strconv.FormatBool(!mirror.Disabled),
+		"external_registry_username": mirror.ExternalRegistryUsername,
+		"tags":                       mirrorTagRule(mirror.Tags),
+	}
+}
+
+// mirrorTagRule returns the tag_glob_csv rule of a mirror's tags.
+func mirrorTagRule(tags []string) string {
+	if len(tags) == 0 {
+		return "*"
+	}
+	return strings.Join(tags, ",")
+}
+
+// attributeChanges compares two attribute sets, in the order of fields.
+func attributeChanges(fields []string, from, to map[string]string) []OrgFieldChange {
+	var changes []OrgFieldChange
+	for _, field := range fields {
+		changes = append(changes, fieldChange(field, from[field], to[field])...)
+	}
+	return changes
+}
+
+// planMirror creates or updates the mirror configuration of a repository.
+func (p *orgPlanner) planMirror(repo string, desired *MirrorSpec, live *MirrorConfig) {
+	org := p.org
+	if live == nil {
+		p.add(phaseDefaults, OrgChange{Action: OrgActionCreate, Resource: OrgResourceMirror, Name: repo,
+			Fields: attributeChanges([]string{"external_reference", "sync_interval", "robot_username", "enabled", "external_registry_username", "tags"},
+				nil, mirrorAttributes(desired))},
+			func(ctx context.Context, c *Client) error {
+				req := &CreateMirrorConfigRequest{
+					ExternalRef:              desired.ExternalReference,
+					SyncInterval:             desired.SyncInterval,
+					SyncStartDate:            time.Now().UTC().Format(time.RFC3339),
+					RobotUsername:            desired.RobotUsername,
+					ExternalRegistryUsername: desired.ExternalRegistryUsername,
+				}
+				req.RootRule.RuleKind, req.RootRule.Rule = "tag_glob_csv", mirrorTagRule(desired.Tags)
+				if _, err := c.CreateMirrorConfig(ctx, org, repo, req); err != nil {
+					return err
+				}
+				if desired.Disabled {
+					enabled := false
+					_, err := c.UpdateMirrorConfig(ctx, org, repo, &UpdateMirrorConfigRequest{IsEnabled: &enabled})
+					return err
+				}
+				return nil
+			})
+		return
+	}
+	fields := attributeChanges([]string{"external_reference", "sync_interval", "robot_username", "enabled", "external_registry_username"},
+		mirrorAttributes(mirrorSpec(live)), mirrorAttributes(desired))
+	if len(fields) == 0 {
+		return
+	}
+	p.add(phaseDefaults, OrgChange{Action: OrgActionUpdate, Resource: OrgResourceMirror, Name: repo, Fields: fields},
+		func(ctx context.Context, c *Client) error {
+			enabled, interval := !desired.Disabled, desired.SyncInterval
+			_, err := c.UpdateMirrorConfig(ctx, org, repo, &UpdateMirrorConfigRequest{
+				IsEnabled:                &enabled,
+				ExternalRef:              desired.ExternalReference,
+				SyncInterval:             &interval,
+				RobotUsername:            desired.RobotUsername,
+				ExternalRegistryUsername: desired.ExternalRegistryUsername,
+			})
+			return err
+		})
+}
+
+// prototypeSpec returns the spec form of a live prototype.
+func prototypeSpec(prototype Prototype) PrototypeSpec {
+	spec := PrototypeSpec{User: prototype.Delegate.Name, Role: prototype.Role}
+	if prototype.Delegate.Kind == delegateTeam {
+		spec.User, spec.Team = "", prototype.Delegate.Name
+	}
+	if prototype.ActivatingUser != nil {
+		spec.ActivatingUser = prototype.ActivatingUser.Name
+	}
+	return spec
+}
+
+// request returns the request that creates the prototype.
+func (p PrototypeSpec) request() *CreatePrototypeRequest {
+	req := &CreatePrototypeRequest{Delegate: PrototypeDelegateRequest{Name: p.User, Kind: delegateUser}, Role: p.Role}
+	if p.Team != "" {
+		req.Delegate = PrototypeDelegateRequest{Name: p.Team, Kind: delegateTeam}
+	}
+	if p.ActivatingUser != "" {
+		req.ActivatingUser = &PrototypeDelegateRequest{Name: p.ActivatingUser, Kind: delegateUser}
+	}
+	return req
+}
+
+// planPrototypes matches prototypes by activating user and delegate. Live
+// duplicates beyond the first are deleted.
+func (p *orgPlanner) planPrototypes(prototypes []PrototypeSpec, live []Prototype) {
+	if prototypes == nil {
+		return
+	}
+	org := p.org
+	current := map[string]Prototype{}
+	var extra []Prototype
+	for _, prototype := range live {
+		key := prototypeSpec(prototype).key()
+		if _, dup := current[key]; dup {
+			extra = append(extra, prototype)
+			continue
+		}
+		current[key] = prototype
+	}
+	for _, prototype := range slices.SortedFunc(slices.Values(prototypes), func(a, b PrototypeSpec) int { return strings.Compare(a.key(), b.key()) }) {
+		key := prototype.key()
+		existing, exists := current[key]
+		delete(current, key)
+		switch {
+		case !exists:
+			p.add(phaseDefaults, OrgChange{Action: OrgActionCreate, Resource: OrgResourcePrototype, Name: key,
+				Fields: fieldChange("role", "", prototype.Role)},
+				func(ctx context.Context, c *Client) error {
+					_, err := c.CreatePrototype(ctx, org, prototype.request())
+					return err
+				})
+		case existing.Role != prototype.Role:
+			p.add(phaseDefaults, OrgChange{Action: OrgActionUpdate, Resource: OrgResourcePrototype, Name: key,
+				Fields: fieldChange("role", existing.Role, prototype.Role)},
+				func(ctx context.Context, c *Client) error {
+					_, err := c.UpdatePrototype(ctx, org, existing.ID, &UpdatePrototypeRequest{Role: prototype.Role})
+					return err
+				})
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(current)) {
+		extra = append(extra, current[key])
+	}
+	for _, prototype := range extra {
+		p.add(phaseRevokeDefaults, OrgChange{Action: OrgActionDelete, Resource: OrgResourcePrototype, Name: prototypeSpec(prototype).key(),
+			Fields: fieldChange("role", prototype.Role, "")},
+			func(ctx context.Context, c *Client) error {
+				return c.DeletePrototype(ctx, org, prototype.ID)
+			})
+	}
+}
+
+// planAutoPrune matches policies by method and tag pattern. Live duplicates
+// beyond the first are deleted.
+func (p *orgPlanner) planAutoPrune(policies []AutoPruneSpec, live []AutoPrunePolicy) {
+	if policies == nil {
+		return
+	}
+	org := p.org
+	current := map[string]AutoPrunePolicy{}
+	var extra []AutoPrunePolicy
+	for _, policy := range live {
+		key := AutoPruneSpec{Method: policy.Method, TagPattern: policy.TagPattern}.key()
+		if _, dup := current[key]; dup {
+			extra = append(extra, policy)
+			continue
+		}
+		current[key] = policy
+	}
+	for _, policy := range slices.SortedFunc(slices.Values(policies), func(a, b AutoPruneSpec) int { return strings.Compare(a.key(), b.key()) }) {
+		key := policy.key()
+		existing, exists := current[key]
+		delete(current, key)
+		switch {
+		case !exists:
+			p.add(phaseDefaults, OrgChange{Action: OrgActionCreate, Resource: OrgResourceAutoPrune, Name: key,
+				Fields: fieldChange("value", "", strconv.Itoa(policy.Value))},
+				func(ctx context.Context, c *Client) error {
+					_, err := c.CreateAutoPrunePolicy(ctx, org, policy.Method, policy.Value, policy.TagPattern)
+					return err
+				})
+		case existing.Value != policy.Value:
+			p.add(phaseDefaults, OrgChange{Action: OrgActionUpdate, Resource: OrgResourceAutoPrune, Name: key,
+				Fields: fieldChange("value", strconv.Itoa(existing.Value), strconv.Itoa(policy.Value))},
+				func(ctx context.Context, c *Client) error {
+					_, err := c.UpdateAutoPrunePolicy(ctx, org, existing.UUID, policy.Method, policy.Value, policy.TagPattern)
+					return err
+				})
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(current)) {
+		extra = append(extra, current[key])
+	}
+	for _, policy := range extra {
+		p.add(phaseRevokeDefaults, OrgChange{Action: OrgActionDelete, Resource: OrgResourceAutoPrune,
+			Name:   AutoPruneSpec{Method: policy.Method, TagPattern: policy.TagPattern}.key(),
+			Fields: fieldChange("value", strconv.Itoa(policy.Value), "")},
+			func(ctx context.Context, c *Client) error {
+				return c.DeleteAutoPrunePolicy(ctx, org, policy.UUID)
+			})
+	}
+}
+
+func (p *orgPlanner) planQuota(quota *QuotaSpec, live *Quota) {
+	if quota == nil {
+		return
+	}
+	org := p.org
+	limit := strconv.FormatInt(quota.LimitBytes, 10)
+	switch {
+	case live == nil && quota.LimitBytes > 0:
+		p.add(phaseSettings, OrgChange{Action: OrgActionCreate, Resource: OrgResourceQuota, Name: org,
+			Fields: fieldChange("limit_bytes", "", limit)},
+			func(ctx context.Context, c *Client) error {
+				_, err := c.CreateQuota(ctx, org, quota.LimitBytes)
+				return err
+			})
+	case live != nil && quota.LimitBytes == 0:
+		p.add(phaseRemoveSettings, OrgChange{Action: OrgActionDelete, Resource: OrgResourceQuota, Name: org,
+			Fields: fieldChange("limit_bytes", strconv.FormatInt(live.LimitBytes, 10), "")},
+			func(ctx context.Context, c *Client) error {
+				return c.DeleteQuota(ctx, org)
+			})
+	case live != nil && live.LimitBytes != quota.LimitBytes:
+		p.add(phaseSettings, OrgChange{Action: OrgActionUpdate, Resource: OrgResourceQuota, Name: org,
+			Fields: fieldChange("limit_bytes", strconv.FormatInt(live.LimitBytes, 10), limit)},
+			func(ctx context.Context, c *Client) error {
+				_, err := c.UpdateQuota(ctx, org, quota.LimitBytes)
+				return err
+			})
+	}
+}
+
+// proxyCacheAttributes lists the attributes of a proxy cache, or none for nil.
+func proxyCacheAttributes(config *ProxyCacheConfig) map[string]string {
+	if config == nil {
+		return map[string]string{}
+	}
+	return map[string]string{
+		"upstream_registry": config.UpstreamRegistry,
+		"insecure":          strconv.FormatBool(config.Insecure),
+		"expiration":        strconv.Itoa(config.Expiration),
+	}
+}
+
+// planProxyCache configures the proxy cache. Quay cannot update one in
+// place, so a change replaces it, which clears its upstream credentials.
+func (p *orgPlanner) planProxyCache(proxy *ProxyCacheSpec, live *ProxyCacheConfig) {
+	if proxy == nil {
+		return
+	}
+	org := p.org
+	var desired *ProxyCacheConfig
+	if proxy.UpstreamRegistry != "" {
+		desired = &ProxyCacheConfig{UpstreamRegistry: proxy.UpstreamRegistry, Insecure: proxy.Insecure, Expiration: proxy.Expiration}
+	}
+	fields := attributeChanges([]string{"upstream_registry", "insecure", "expiration"}, proxyCacheAttributes(live), proxyCacheAttributes(desired))
+	switch {
+	case len(fields) == 0:
+	case desired == nil:
+		p.add(phaseRemoveSettings, OrgChange{Action: OrgActionDelete, Resource: OrgResourceProxyCache, Name: org, Fields: fields},
+			func(ctx context.Context, c *Client) error {
+				return c.DeleteProxyCacheConfig(ctx, org)
+			})
+	default:
+		change := OrgChange{Action: OrgActionCreate, Resource: OrgResourceProxyCache, Name: org, Fields: fields}
+		if live != nil {
+			change.Action = OrgActionReplace
+			change.Warning = "upstream registry credentials are cleared; set them again after applying"
+		}
+		p.add(phaseSettings, change, func(ctx context.Context, c *Client) error {
+			if live != nil {
+				if err := c.DeleteProxyCacheConfig(ctx, org); err != nil {
+					return err
+				}
+			}
+			_, err := c.CreateProxyCacheConfig(ctx, org, desired.UpstreamRegistry, desired.Insecure, desired.Expiration)
+			return err
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package lib
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// changeNames returns "action resource name" for each change of a plan.
+func changeNames(plan *OrgPlan) []string {
+	var names []string
+	for _, change := range plan.Changes {
+		names = append(names, change.String())
+	}
+	return names
+}
+
+func TestPlanOrgOrdersChanges(t *testing.T) {
+	spec := &OrgSpec{
+		Organization: testNamespace,
+		Teams: []TeamSpec{
+			{Name: testTeamName, Description: testTeamDescDev, Role: roleAdmin, Members: []string{testUserName, testNamespace + "+ci"}},
+			{Name: "owners"},
+		},
+		Robots: []RobotSpec{{Name: "ci"}},
+		Repositories: []RepositorySpec{{Name: testRepository, Permissions: &RepositoryPermissionsSpec{
+			Users: map[string]string{testNamespace + "+ci": testRoleWrite, testUserName: testRoleRead},
+		}}},
+		Prototypes: []PrototypeSpec{{Team: testTeamName, Role: testRoleWrite}},
+		AutoPrune:  []AutoPruneSpec{{Method: AutoPruneMethodNumberOfTags, Value: 20}},
+		Quota:      &QuotaSpec{},
+		ProxyCache: &ProxyCacheSpec{UpstreamRegistry: testUpstreamReg, Expiration: 3600},
+	}
+	state := &orgState{
+		teams: map[string]Team{
+			"owners":     {Name: "owners", Role: roleAdmin},
+			testTeamName: {Name: testTeamName, Description: testTeamDescDev, Role: roleMember},
+			"legacy":     {Name: "legacy", Role: roleMember},
+		},
+		members: map[string][]TeamMember{testTeamName: {
+			{Name: testUserName},
+			{Name: "olduser"},
+			{Name: "pending@example.com", Invited: true},
+		}},
+		robots:    map[string]RobotAccount{testNamespace + "+old": {Name: testNamespace + "+old"}},
+		userPerms: map[string]map[string]string{testRepository: {testUserName: testRoleRead, testNamespace + "+old": testRoleWrite}},
+		prototypes: []Prototype{
+			{ID: "p1", Role: testRoleRead, Delegate: PrototypeDelegate{Name: testTeamName, Kind: testKindTeam}},
+			{ID: "p2", Role: testRoleRead, Delegate: PrototypeDelegate{Name: "olduser", Kind: testKindUser}},
+		},
+		policies: []AutoPrunePolicy{
+			{UUID: "a1", Method: AutoPruneMethodNumberOfTags, Value: 20},
+			{UUID: "a2", Method: AutoPruneMethodNumberOfTags, Value: 5},
+		},
+		quota:      &Quota{LimitBytes: 1000},
+		proxyCache: &ProxyCacheConfig{UpstreamRegistry: testUpstreamReg, Expiration: 86400},
+	}
+
+	plan := planOrg(spec, state)
+	want := []string{
+		"replace proxy_cache testorg",
+		"update team developers",
+		"create robot testorg+ci",
+		"create team_member developers/testorg+ci",
+		"create repository_permission testrepo/user:testorg+ci",
+		"update prototype team:developers",
+		"delete prototype user:olduser",
+		"delete auto_prune number_of_tags",
+		"delete repository_permission testrepo/user:testorg+old",
+		"delete team_member developers/olduser",
+		"delete team legacy",
+		"delete robot testorg+old",
+		"delete quota testorg",
+	}
+	if got := changeNames(plan); !slices.Equal(got, want) {
+		t.Errorf("Unexpected plan:\n got %q\nwant %q", got, want)
+	}
+	if plan.Count(OrgActionCreate) != 3 || plan.Count(OrgActionUpdate) != 2 || plan.Count(OrgActionReplace) != 1 || plan.Count(OrgActionDelete) != 7 {
+		t.Errorf("Unexpected counts: %d to create, %d to update, %d to delete",
+			plan.Count(OrgActionCreate), plan.Count(OrgActionUpdate), plan.Count(OrgActionDelete))
+	}
+	if fields := plan.Changes[1].Fields; len(fields) != 1 || fields[0] != (OrgFieldChange{Field: "role", From: roleMember, To: roleAdmin}) {
+		t.Errorf("Expected only the team role to change, got %+v", fields)
+	}
+	if fields := plan.Changes[0].Fields; len(fields) != 1 || fields[0].Field != "expiration" || plan.Changes[0].Warning == "" {
+		t.Errorf("Expected only the proxy cache expiration to change, with a warning, got %+v", plan.Changes[0])
+	}
+}
+
+func TestPlanOrgIgnoresUnmanagedSections(t *testing.T) {
+	spec := &OrgSpec{
+		Organization: testNamespace,
+		Teams:        []TeamSpec{{Name: testTeamName}},
+		Repositories: []RepositorySpec{{Name: testRepository}},
+	}
+	state := &orgState{
+		teams:     map[string]Team{testTeamName: {Name: testTeamName, Role: roleMember, IsSynced: true}},
+		robots:    map[string]RobotAccount{testNamespace + "+old": {}},
+		userPerms: map[string]map[string]string{testRepository: {testUserName: testRoleRead}},
+		quota:     &Quota{LimitBytes: 1000},
+	}
+	if plan := planOrg(spec, state); len(plan.Changes) != 0 {
+		t.Errorf("Expected no changes, got %q", changeNames(plan))
+	}
+}
+
+func TestApplyOrgPlan(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/api/v1/organization/"+testNamespace)
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method != http.MethodGet:
+			mu.Lock()
+			calls = append(calls, r.Method+" "+path)
+			mu.Unlock()
+			_, _ = w.Write([]byte(`{}`))
+		case path == "/teams":
+			_, _ = w.Write([]byte(`{"teams": [{"name": "owners", "role": "admin"}, {"name": "legacy", "role": "member"}]}`))
+		case path == "/quota":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error": "quota not found"}`))
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+	client, err := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	plan, err := client.PlanOrganization(context.Background(), &OrgSpec{
+		Organization: testNamespace,
+		Teams:        []TeamSpec{{Name: testTeamName, Members: []string{testUserName}}},
+		Quota:        &QuotaSpec{LimitBytes: 1 << 30},
+	})
+	if err != nil {
+		t.Fatalf("PlanOrganization returned error: %v", err)
+	}
+	var progress []string
+	applied, err := client.ApplyOrgPlan(context.Background(), plan, &OrgApplyOptions{
+		Progress: func(change OrgChange) { progress = append(progress, change.String()) },
+	})
+	if err != nil || applied != 4 {
+		t.Fatalf("Expected 4 changes applied, got %d, %v", applied, err)
+	}
+	want := []string{
+		"POST /quota",
+		"PUT /team/" + testTeamName,
+		"PUT /team/" + testTeamName + "/members/" + testUserName,
+		"DELETE /team/legacy",
+	}
+	if !slices.Equal(calls, want) {
+		t.Errorf("Unexpected calls:\n got %q\nwant %q", calls, want)
+	}
+	if !slices.Equal(progress, changeNames(plan)) {
+		t.Errorf("Expected progress for every change, got %q", progress)
+	}
+
+	if _, err := client.ApplyOrgPlan(context.Background(), &OrgPlan{Changes: []OrgChange{{Action: OrgActionDelete}}}, nil); err == nil {
+		t.Error("Expected an error applying a change that was not planned")
+	}
+}
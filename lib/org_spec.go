@@ -0,0 +1,300 @@
+/*
+Package lib provides Quay.io API client functionality.
+
+This file covers DECLARATIVE ORGANIZATION specs:
+
+Spec:
+  - OrgSpec - Desired teams, robots, repository permissions, notifications and
+    mirrors, prototypes, auto-prune, quota and proxy cache
+  - (*OrgSpec).Validate() error - Check names, roles and references
+
+A spec only manages the sections it contains. An omitted section (or an
+omitted members, federation, permissions, notifications or mirror entry)
+leaves the live state alone; a present one, even if empty, is authoritative,
+so anything live that it does not list is planned for deletion. Robots are
+declared by short name and referenced elsewhere by their full name
+(myorg+ci), as Quay reports them.
+*/
+package lib
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Roles and methods accepted in an OrgSpec.
+const (
+	TeamRoleMember  = "member"
+	TeamRoleCreator = "creator"
+	TeamRoleAdmin   = "admin"
+
+	RepositoryRoleRead  = "read"
+	RepositoryRoleWrite = "write"
+	RepositoryRoleAdmin = "admin"
+
+	AutoPruneMethodNumberOfTags = "number_of_tags"
+	AutoPruneMethodCreationDate = "creation_date"
+
+	// ownersTeam is created with every organization and cannot be deleted.
+	ownersTeam = "owners"
+)
+
+// OrgSpec is the desired configuration of an organization. A list left out
+// of a spec file is unmanaged, while an empty list ([]) is managed and empty.
+// Lists are always written, so an encoded spec keeps which lists it manages;
+// a nil list in Go encodes as null in JSON but as [] in YAML.
+type OrgSpec struct {
+	// Organization is the organization name.
+	Organization string `json:"organization" yaml:"organization"`
+	// Teams, if set, are all the teams of the organization besides owners.
+	Teams []TeamSpec `json:"teams" yaml:"teams"`
+	// Robots, if set, are all the robot accounts of the organization.
+	Robots []RobotSpec `json:"robots" yaml:"robots"`
+	// Repositories lists the repositories whose permissions, notifications
+	// or mirror are managed.
+	Repositories []RepositorySpec `json:"repositories,omitempty" yaml:"repositories,omitempty"`
+	// Prototypes, if set, are all the default permissions of the organization.
+	Prototypes []PrototypeSpec `json:"prototypes" yaml:"prototypes"`
+	// AutoPrune, if set, are all the auto-prune policies of the organization.
+	AutoPrune []AutoPruneSpec `json:"auto_prune" yaml:"auto_prune"`
+	// Quota, if set, is the storage quota. A zero limit removes it.
+	Quota *QuotaSpec `json:"quota,omitempty" yaml:"quota,omitempty"`
+	// ProxyCache, if set, is the proxy cache configuration. An empty
+	// upstream registry removes it.
+	ProxyCache *ProxyCacheSpec `json:"proxy_cache,omitempty" yaml:"proxy_cache,omitempty"`
+}
+
+// TeamSpec is a team and, if Members is set, its complete membership of user
+// and robot names. An empty Role keeps the role of an existing team and
+// creates a member team.
+type TeamSpec struct {
+	Name        string   `json:"name" yaml:"name"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Role        string   `json:"role,omitempty" yaml:"role,omitempty"`
+	Members     []string `json:"members" yaml:"members"`
+}
+
+// RobotSpec is a robot account, by short name, and, if Federation is set,
+// its complete list of trusted OIDC issuers and subjects. Quay cannot change
+// a robot's description, so Description only applies when the robot is
+// created.
+type RobotSpec struct {
+	Name        string                  `json:"name" yaml:"name"`
+	Description string                  `json:"description,omitempty" yaml:"description,omitempty"`
+	Federation  []RobotFederationConfig `json:"federation" yaml:"federation"`
+}
+
+// RepositorySpec is a repository of the organization. If set, Permissions
+// holds its complete user, robot and team permissions and Notifications all
+// of its notifications.
+type RepositorySpec struct {
+	Name          string                     `json:"name" yaml:"name"`
+	Permissions   *RepositoryPermissionsSpec `json:"permissions,omitempty" yaml:"permissions,omitempty"`
+	Notifications []NotificationSpec         `json:"notifications" yaml:"notifications"`
+	Mirror        *MirrorSpec                `json:"mirror,omitempty" yaml:"mirror,omitempty"`
+}
+
+// RepositoryPermissionsSpec maps user or robot names and team names to roles.
+type RepositoryPermissionsSpec struct {
+	Users map[string]string `json:"users,omitempty" yaml:"users,omitempty"`
+	Teams map[string]string `json:"teams,omitempty" yaml:"teams,omitempty"`
+}
+
+// NotificationSpec is a repository notification, identified by event,
+// method and title. A notification Quay disabled after repeated delivery
+// failures is planned to be re-enabled.
+type NotificationSpec struct {
+	Title       string         `json:"title,omitempty" yaml:"title,omitempty"`
+	Event       string         `json:"event" yaml:"event"`
+	Method      string         `json:"method" yaml:"method"`
+	Config      map[string]any `json:"config,omitempty" yaml:"config,omitempty"`
+	EventConfig map[string]any `json:"event_config,omitempty" yaml:"event_config,omitempty"`
+}
+
+// MirrorSpec is the mirror configuration of a repository, syncing every
+// SyncInterval seconds. Tags are the tag globs to mirror, every tag if empty;
+// Quay cannot change them on an existing mirror, so they only apply when the
+// mirror is created. Registry credentials are never read back from Quay and
+// have to be set separately.
+type MirrorSpec struct {
+	ExternalReference        string   `json:"external_reference" yaml:"external_reference"`
+	SyncInterval             int      `json:"sync_interval" yaml:"sync_interval"`
+	RobotUsername            string   `json:"robot_username,omitempty" yaml:"robot_username,omitempty"`
+	Tags                     []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Disabled                 bool     `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+	ExternalRegistryUsername string   `json:"external_registry_username,omitempty" yaml:"external_registry_username,omitempty"`
+}
+
+// PrototypeSpec is a default permission granted on new repositories to a
+// user, robot or team, optionally only for repositories created by
+// ActivatingUser. Exactly one of User and Team is set.
+type PrototypeSpec struct {
+	ActivatingUser string `json:"activating_user,omitempty" yaml:"activating_user,omitempty"`
+	User           string `json:"user,omitempty" yaml:"user,omitempty"`
+	Team           string `json:"team,omitempty" yaml:"team,omitempty"`
+	Role           string `json:"role" yaml:"role"`
+}
+
+// AutoPruneSpec is an auto-prune policy, identified by method and tag pattern.
+type AutoPruneSpec struct {
+	Method     string `json:"method" yaml:"method"`
+	Value      int    `json:"value" yaml:"value"`
+	TagPattern string `json:"tag_pattern,omitempty" yaml:"tag_pattern,omitempty"`
+}
+
+// QuotaSpec is an organization storage quota.
+type QuotaSpec struct {
+	LimitBytes int64 `json:"limit_bytes" yaml:"limit_bytes"`
+}
+
+// ProxyCacheSpec is an organization proxy cache configuration.
+type ProxyCacheSpec struct {
+	UpstreamRegistry string `json:"upstream_registry,omitempty" yaml:"upstream_registry,omitempty"`
+	Insecure         bool   `json:"insecure,omitempty" yaml:"insecure,omitempty"`
+	Expiration       int    `json:"expiration,omitempty" yaml:"expiration,omitempty"`
+}
+
+// Validate checks that names are unique and roles and methods are known.
+func (s *OrgSpec) Validate() error {
+	if s.Organization == "" {
+		return fmt.Errorf("organization is required")
+	}
+	var errs specErrors
+	errs.checkTeams(s.Teams)
+	errs.checkRobots(s.Robots)
+	for _, repo := range s.Repositories {
+		if repo.Name == "" {
+			errs.add("repository: name is required")
+		}
+		errs.checkNotifications(repo.Name, repo.Notifications)
+		if repo.Mirror != nil && (repo.Mirror.ExternalReference == "" || repo.Mirror.SyncInterval <= 0) {
+			errs.add("repository %s: mirror needs an external_reference and a positive sync_interval", repo.Name)
+		}
+		if repo.Permissions == nil {
+			continue
+		}
+		for name, role := range repo.Permissions.Users {
+			errs.checkRepositoryRole("repository "+repo.Name+" user "+name, role)
+		}
+		for name, role := range repo.Permissions.Teams {
+			errs.checkRepositoryRole("repository "+repo.Name+" team "+name, role)
+		}
+	}
+	errs.checkPrototypes(s.Prototypes)
+	errs.checkAutoPrune(s.AutoPrune)
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid spec for %s:\n  %s", s.Organization, strings.Join(errs, "\n  "))
+	}
+	return nil
+}
+
+// specErrors collects the problems found by Validate.
+type specErrors []string
+
+func (e *specErrors) add(format string, args ...any) {
+	*e = append(*e, fmt.Sprintf(format, args...))
+}
+
+func (e *specErrors) checkTeams(teams []TeamSpec) {
+	seen := map[string]bool{}
+	for _, team := range teams {
+		if team.Name == "" || seen[team.Name] {
+			e.add("team %q: name is empty or duplicated", team.Name)
+		}
+		seen[team.Name] = true
+		if team.Role != "" && !slices.Contains([]string{TeamRoleMember, TeamRoleCreator, TeamRoleAdmin}, team.Role) {
+			e.add("team %s: unknown role %q", team.Name, team.Role)
+		}
+	}
+}
+
+func (e *specErrors) checkRobots(robots []RobotSpec) {
+	seen := map[string]bool{}
+	for _, robot := range robots {
+		if robot.Name == "" || strings.Contains(robot.Name, "+") || seen[robot.Name] {
+			e.add("robot %q: use a unique short name without the organization prefix", robot.Name)
+		}
+		seen[robot.Name] = true
+		for _, config := range robot.Federation {
+			if config.Issuer == "" || config.Subject == "" {
+				e.add("robot %s: federation needs an issuer and a subject", robot.Name)
+			}
+		}
+	}
+}
+
+func (e *specErrors) checkNotifications(repo string, notifications []NotificationSpec) {
+	seen := map[string]bool{}
+	for _, n := range notifications {
+		if n.Event == "" || n.Method == "" {
+			e.add("repository %s notification %q: event and method are required", repo, n.Title)
+		}
+		if seen[n.key()] {
+			e.add("repository %s notification %s: duplicated; give it a distinct title", repo, n.key())
+		}
+		seen[n.key()] = true
+	}
+}
+
+func (e *specErrors) checkPrototypes(prototypes []PrototypeSpec) {
+	seen := map[string]bool{}
+	for _, p := range prototypes {
+		if (p.User == "") == (p.Team == "") {
+			e.add("prototype %s: set exactly one of user and team", p.key())
+		}
+		if seen[p.key()] {
+			e.add("prototype %s: duplicated", p.key())
+		}
+		seen[p.key()] = true
+		e.checkRepositoryRole("prototype "+p.key(), p.Role)
+	}
+}
+
+func (e *specErrors) checkAutoPrune(policies []AutoPruneSpec) {
+	seen := map[string]bool{}
+	for _, p := range policies {
+		if p.Method != AutoPruneMethodNumberOfTags && p.Method != AutoPruneMethodCreationDate {
+			e.add("auto-prune %s: unknown method %q", p.key(), p.Method)
+		}
+		if seen[p.key()] {
+			e.add("auto-prune %s: duplicated", p.key())
+		}
+		seen[p.key()] = true
+	}
+}
+
+func (e *specErrors) checkRepositoryRole(what, role string) {
+	if !slices.Contains([]string{RepositoryRoleRead, RepositoryRoleWrite, RepositoryRoleAdmin}, role) {
+		e.add("%s: unknown role %q", what, role)
+	}
+}
+
+// key identifies a prototype: [activating user ->] user:NAME or team:NAME.
+func (p PrototypeSpec) key() string {
+	key := "user:" + p.User
+	if p.Team != "" {
+		key = "team:" + p.Team
+	}
+	if p.ActivatingUser != "" {
+		key = p.ActivatingUser + " -> " + key
+	}
+	return key
+}
+
+// key identifies an auto-prune policy by method and tag pattern.
+func (p AutoPruneSpec) key() string {
+	if p.TagPattern == "" {
+		return p.Method
+	}
+	return p.Method + " " + p.TagPattern
+}
+
+// key identifies a notification by event, method and title.
+func (n NotificationSpec) key() string {
+	key := n.Event + " " + n.Method
+	if n.Title != "" {
+		key += " " + n.Title
+	}
+	return key
+}
@@ -0,0 +1,55 @@
+package lib
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOrgSpecValidate(t *testing.T) {
+	valid := OrgSpec{
+		Organization: testNamespace,
+		Teams:        []TeamSpec{{Name: testTeamName, Role: roleAdmin, Members: []string{testUserName}}},
+		Robots:       []RobotSpec{{Name: "ci"}},
+		Repositories: []RepositorySpec{{Name: testRepository, Permissions: &RepositoryPermissionsSpec{
+			Users: map[string]string{testNamespace + "+ci": testRoleWrite},
+			Teams: map[string]string{testTeamName: testRoleRead},
+		}}},
+		Prototypes: []PrototypeSpec{{Team: testTeamName, Role: testRoleRead}},
+		AutoPrune:  []AutoPruneSpec{{Method: AutoPruneMethodNumberOfTags, Value: 10}},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Expected a valid spec, got %v", err)
+	}
+
+	invalid := OrgSpec{
+		Organization: testNamespace,
+		Teams:        []TeamSpec{{Name: testTeamName}, {Name: testTeamName, Role: "owner"}},
+		Robots:       []RobotSpec{{Name: testNamespace + "+ci"}},
+		Repositories: []RepositorySpec{{Name: testRepository, Permissions: &RepositoryPermissionsSpec{
+			Users: map[string]string{testUserName: "push"},
+		}}},
+		Prototypes: []PrototypeSpec{{User: testUserName, Team: testTeamName, Role: testRoleRead}},
+		AutoPrune:  []AutoPruneSpec{{Method: "age"}, {Method: "age"}},
+	}
+	err := invalid.Validate()
+	if err == nil {
+		t.Fatal("Expected an invalid spec")
+	}
+	for _, want := range []string{
+		`team "developers": name is empty or duplicated`,
+		`unknown role "owner"`,
+		`robot "testorg+ci"`,
+		`user testuser: unknown role "push"`,
+		"set exactly one of user and team",
+		`unknown method "age"`,
+		"auto-prune age: duplicated",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q in error:\n%v", want, err)
+		}
+	}
+
+	if err := (&OrgSpec{}).Validate(); err == nil {
+		t.Error("Expected an error without an organization")
+	}
+}
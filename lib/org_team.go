@@ -40,7 +40,7 @@ func (c *Client) GetTeams(ctx context.Context, orgname string) ([]Team, error) {
 		return nil, fmt.Errorf("orgname is required")
 	}
 
-	req, err := newRequest(ctx, http.MethodGet, c.buildURL("/organization/%s/teams", orgname), nil)
+	req, err := newAPIRequest(ctx, http.MethodGet, c.buildURL("/organization/%s/teams", orgname), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create get teams request: %w", err)
 	}
@@ -67,7 +67,7 @@ func (c *Client) CreateTeam(ctx context.Context, orgname, teamname, description,
 		return nil, fmt.Errorf("role is required")
 	}
 
-	req, err := newRequestWithBody(ctx, http.MethodPut, c.buildURL("/organization/%s/team/%s", orgname, teamname), CreateTeamRequest{
+	req, err := newAPIRequestWithBody(ctx, http.MethodPut, c.buildURL("/organization/%s/team/%s", orgname, teamname), CreateTeamRequest{
 		Name:        teamname,
 		Description: description,
 		Role:        role,
@@ -93,7 +93,7 @@ func (c *Client) GetTeam(ctx context.Context, orgname, teamname string) (*Team,
 		return nil, fmt.Errorf("teamname is required")
 	}
 
-	req, err := newRequest(ctx, http.MethodGet, c.buildURL("/organization/%s/team/%s", orgname, teamname), nil)
+	req, err := newAPIRequest(ctx, http.MethodGet, c.buildURL("/organization/%s/team/%s", orgname, teamname), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create get team request: %w", err)
 	}
@@ -115,7 +115,7 @@ func (c *Client) DeleteTeam(ctx context.Context, orgname, teamname string) error
 		return fmt.Errorf("teamname is required")
 	}
 
-	req, err := newRequest(ctx, http.MethodDelete, c.buildURL("/organization/%s/team/%s", orgname, teamname), nil)
+	req, err := newAPIRequest(ctx, http.MethodDelete, c.buildURL("/organization/%s/team/%s", orgname, teamname), nil)
 	if err != nil {
 		return fmt.Errorf("failed to create delete team request: %w", err)
 	}
@@ -139,7 +139,7 @@ func (c *Client) UpdateTeam(ctx context.Context, orgname, teamname, description,
 		return nil, fmt.Errorf("role is required")
 	}
 
-	req, err := newRequestWithBody(ctx, http.MethodPut, c.buildURL("/organization/%s/team/%s", orgname, teamname), UpdateTeamRequest{
+	req, err := newAPIRequestWithBody(ctx, http.MethodPut, c.buildURL("/organization/%s/team/%s", orgname, teamname), UpdateTeamRequest{
 		Description: description,
 		Role:        role,
 	})
@@ -166,7 +166,7 @@ func (c *Client) GetTeamMembers(ctx context.Context, orgname, teamname string) (
 		return nil, fmt.Errorf("teamname is required")
 	}
 
-	req, err := newRequest(ctx, http.MethodGet, c.buildURL("/organization/%s/team/%s/members", orgname, teamname), nil)
+	req, err := newAPIRequest(ctx, http.MethodGet, c.buildURL("/organization/%s/team/%s/members", orgname, teamname), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create get team members request: %w", err)
 	}
@@ -191,7 +191,7 @@ func (c *Client) AddTeamMember(ctx context.Context, orgname, teamname, membernam
 		return fmt.Errorf("membername is required")
 	}
 
-	req, err := newRequest(ctx, http.MethodPut, c.buildURL("/organization/%s/team/%s/members/%s", orgname, teamname, membername), nil)
+	req, err := newAPIRequest(ctx, http.MethodPut, c.buildURL("/organization/%s/team/%s/members/%s", orgname, teamname, membername), nil)
 	if err != nil {
 		return fmt.Errorf("failed to create add team member request: %w", err)
 	}
@@ -215,7 +215,7 @@ func (c *Client) RemoveTeamMember(ctx context.Context, orgname, teamname, member
 		return fmt.Errorf("membername is required")
 	}
 
-	req, err := newRequest(ctx, http.MethodDelete, c.buildURL("/organization/%s/team/%s/members/%s", orgname, teamname, membername), nil)
+	req, err := newAPIRequest(ctx, http.MethodDelete, c.buildURL("/organization/%s/team/%s/members/%s", orgname, teamname, membername), nil)
 	if err != nil {
 		return fmt.Errorf("failed to create remove team member request: %w", err)
 	}
@@ -238,7 +238,7 @@ func (c *Client) GetTeamPermissions(ctx context.Context, orgname, teamname strin
 		return nil, fmt.Errorf("teamname is required")
 	}
 
-	req, err := newRequest(ctx, http.MethodGet, c.buildURL("/organization/%s/team/%s/permissions", orgname, teamname), nil)
+	req, err := newAPIRequest(ctx, http.MethodGet, c.buildURL("/organization/%s/team/%s/permissions", orgname, teamname), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create get team permissions request: %w", err)
 	}
@@ -266,7 +266,7 @@ func (c *Client) SetTeamRepositoryPermission(ctx context.Context, orgname, teamn
 		return fmt.Errorf("role is required")
 	}
 
-	req, err := newRequestWithBody(ctx, http.MethodPut, c.buildURL("/organization/%s/team/%s/permissions/%s", orgname, teamname, repository), SetRepositoryPermissionRequest{
+	req, err := newAPIRequestWithBody(ctx, http.MethodPut, c.buildURL("/organization/%s/team/%s/permissions/%s", orgname, teamname, repository), SetRepositoryPermissionRequest{
 		Role: role,
 	})
 	if err != nil {
@@ -292,7 +292,7 @@ func (c *Client) RemoveTeamRepositoryPermission(ctx context.Context, orgname, te
 		return fmt.Errorf("repository is required")
 	}
 
-	req, err := newRequest(ctx, http.MethodDelete, c.buildURL("/organization/%s/team/%s/permissions/%s", orgname, teamname, repository), nil)
+	req, err := newAPIRequest(ctx, http.MethodDelete, c.buildURL("/organization/%s/team/%s/permissions/%s", orgname, teamname, repository), nil)
 	if err != nil {
 		return fmt.Errorf("failed to create remove team repository permission request: %w", err)
 	}
@@ -318,7 +318,7 @@ func (c *Client) InviteTeamMember(ctx context.Context, orgname, teamname, email
 		return fmt.Errorf("email is required")
 	}
 
-	req, err := newRequest(ctx, http.MethodPut, c.buildURL("/organization/%s/team/%s/invite/%s", orgname, teamname, email), nil)
+	req, err := newAPIRequest(ctx, http.MethodPut, c.buildURL("/organization/%s/team/%s/invite/%s", orgname, teamname, email), nil)
 	if err != nil {
 		return fmt.Errorf("failed to create invite team member request: %w", err)
 	}
@@ -342,7 +342,7 @@ func (c *Client) DeleteTeamInvite(ctx context.Context, orgname, teamname, email
 		return fmt.Errorf("email is required")
 	}
 
-	req, err := newRequest(ctx, http.MethodDelete, c.buildURL("/organization/%s/team/%s/invite/%s", orgname, teamname, email), nil)
+	req, err := newAPIRequest(ctx, http.MethodDelete, c.buildURL("/organization/%s/team/%s/invite/%s", orgname, teamname, email), nil)
 	if err != nil {
 		return fmt.Errorf("failed to create delete team invite request: %w", err)
 	}
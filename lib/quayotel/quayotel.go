@@ -0,0 +1,142 @@
+/*
+Package quayotel provides OpenTelemetry instrumentation for lib.Client.
+
+It implements lib.Telemetry, producing one client span per API call plus
+request/error counters and a latency histogram, all labeled with the
+low-cardinality route template (for example
+/repository/{namespace}/{repository}/tag/) rather than the concrete path:
+
+	tel, err := quayotel.New(quayotel.Config{})
+	if err != nil {
+		return err
+	}
+	client, err := lib.NewClientWithURL(token, url, lib.WithTelemetry(tel))
+
+Instruments:
+  - quay.client.requests         - Int64Counter, one per API call
+  - quay.client.errors           - Int64Counter, one per failed API call
+  - quay.client.request.duration - Float64Histogram, seconds including retries
+
+Zero-value Config fields fall back to the global otel providers.
+*/
+package quayotel
+
+import (
+	"context"
+
+	"github.com/sebrandon1/go-quay/lib"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/sebrandon1/go-quay/lib"
+
+// Attribute keys recorded on spans and metrics.
+const (
+	AttrMethod      = attribute.Key("http.request.method")
+	AttrRoute       = attribute.Key("url.template")
+	AttrStatusCode  = attribute.Key("http.response.status_code")
+	AttrResendCount = attribute.Key("http.request.resend_count")
+	AttrAttempts    = attribute.Key("quay.attempts")
+)
+
+// Config selects the providers used for instrumentation.
+type Config struct {
+	TracerProvider trace.TracerProvider
+	MeterProvider  metric.MeterProvider
+}
+
+// Telemetry is an OpenTelemetry implementation of lib.Telemetry.
+type Telemetry struct {
+	tracer   trace.Tracer
+	requests metric.Int64Counter
+	errors   metric.Int64Counter
+	duration metric.Float64Histogram
+}
+
+var _ lib.Telemetry = (*Telemetry)(nil)
+
+// New creates instruments from cfg.
+func New(cfg Config) (*Telemetry, error) {
+	tp := cfg.TracerProvider
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	mp := cfg.MeterProvider
+	if mp == nil {
+		mp = otel.GetMeterProvider()
+	}
+
+	meter := mp.Meter(instrumentationName)
+
+	requests, err := meter.Int64Counter("quay.client.requests",
+		metric.WithDescription("Quay API calls made by the client"),
+		metric.WithUnit("{call}"))
+	if err != nil {
+		return nil, err
+	}
+
+	errs, err := meter.Int64Counter("quay.client.errors",
+		metric.WithDescription("Quay API calls that returned an error"),
+		metric.WithUnit("{call}"))
+	if err != nil {
+		return nil, err
+	}
+
+	duration, err := meter.Float64Histogram("quay.client.request.duration",
+		metric.WithDescription("Duration of Quay API calls, including retries"),
+		metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Telemetry{
+		tracer:   tp.Tracer(instrumentationName),
+		requests: requests,
+		errors:   errs,
+		duration: duration,
+	}, nil
+}
+
+// StartCall starts a client span for the call and returns a func that ends it
+// and records metrics.
+func (t *Telemetry) StartCall(ctx context.Context, info lib.CallInfo) (context.Context, func(lib.CallResult)) {
+	base := []attribute.KeyValue{
+		AttrMethod.String(info.Method),
+		AttrRoute.String(info.Route),
+	}
+
+	ctx, span := t.tracer.Start(ctx, info.Method+" "+info.Route,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(base...))
+
+	return ctx, func(res lib.CallResult) {
+		attrs := base
+		if res.StatusCode > 0 {
+			attrs = append(attrs, AttrStatusCode.Int(res.StatusCode))
+		}
+
+		span.SetAttributes(AttrAttempts.Int(res.Attempts))
+		if res.StatusCode > 0 {
+			span.SetAttributes(AttrStatusCode.Int(res.StatusCode))
+		}
+		if res.Attempts > 1 {
+			span.SetAttributes(AttrResendCount.Int(res.Attempts - 1))
+		}
+		if res.Err != nil {
+			span.RecordError(res.Err)
+			span.SetStatus(codes.Error, res.Err.Error())
+		}
+		span.End()
+
+		set := metric.WithAttributeSet(attribute.NewSet(attrs...))
+		t.requests.Add(ctx, 1, set)
+		t.duration.Record(ctx, res.Duration.Seconds(), set)
+		if res.Err != nil {
+			t.errors.Add(ctx, 1, set)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package quayotel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sebrandon1/go-quay/lib"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const (
+	testNamespace  = "testorg"
+	testRepository = "testrepo"
+	testTagRoute   = "/repository/{namespace}/{repository}/tag/{tag}"
+)
+
+func newInstrumentedClient(t *testing.T, handler http.HandlerFunc) (*lib.Client, *tracetest.SpanRecorder, *sdkmetric.ManualReader) {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	spans := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+
+	tel, err := New(Config{
+		TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)),
+		MeterProvider:  sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+	})
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+
+	client, err := lib.NewClientWithURL("test-token", server.URL+"/api/v1", lib.WithTelemetry(tel))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	return client, spans, reader
+}
+
+func TestSpanPerCall(t *testing.T) {
+	client, spans, _ := newInstrumentedClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"name":"latest"}`))
+	})
+
+	if _, err := client.GetTag(context.Background(), testNamespace, testRepository, "latest"); err != nil {
+		t.Fatalf("GetTag returned error: %v", err)
+	}
+
+	ended := spans.Ended()
+	if len(ended) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(ended))
+	}
+
+	span := ended[0]
+	if span.Name() != "GET "+testTagRoute {
+		t.Errorf("Unexpected span name %q", span.Name())
+	}
+
+	attrs := map[string]any{}
+	for _, kv := range span.Attributes() {
+		attrs[string(kv.Key)] = kv.Value.AsInterface()
+	}
+	if attrs[string(AttrRoute)] != testTagRoute {
+		t.Errorf("Expected url.template %q, got %v", testTagRoute, attrs[string(AttrRoute)])
+	}
+	if attrs[string(AttrStatusCode)] != int64(http.StatusOK) {
+		t.Errorf("Expected status 200, got %v", attrs[string(AttrStatusCode)])
+	}
+	if attrs[string(AttrAttempts)] != int64(1) {
+		t.Errorf("Expected 1 attempt, got %v", attrs[string(AttrAttempts)])
+	}
+}
+
+func TestErrorSpanAndMetrics(t *testing.T) {
+	client, spans, reader := newInstrumentedClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"not_found"}`))
+	})
+
+	if _, err := client.GetTag(context.Background(), testNamespace, testRepository, "missing"); err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+
+	if got := spans.Ended()[0].Status().Code; got != codes.Error {
+		t.Errorf("Expected error span status, got %v", got)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect returned error: %v", err)
+	}
+
+	found := map[string]bool{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			found[m.Name] = true
+			if m.Name != "quay.client.errors" {
+				continue
+			}
+			sum, ok := m.Data.(metricdata.Sum[int64])
+			if !ok || len(sum.DataPoints) != 1 || sum.DataPoints[0].Value != 1 {
+				t.Errorf("Expected one error data point of 1, got %+v", m.Data)
+				continue
+			}
+			route, _ := sum.DataPoints[0].Attributes.Value(AttrRoute)
+			if route.AsString() != testTagRoute {
+				t.Errorf("Expected error metric labeled %q, got %q", testTagRoute, route.AsString())
+			}
+		}
+	}
+
+	for _, name := range []string{"quay.client.requests", "quay.client.errors", "quay.client.request.duration"} {
+		if !found[name] {
+			t.Errorf("Expected metric %s to be recorded", name)
+		}
+	}
+}
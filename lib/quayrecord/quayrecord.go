@@ -0,0 +1,375 @@
+/*
+Package quayrecord provides an http.RoundTripper that records Quay API
+interactions to golden files and replays them offline.
+
+Recording captures real traffic once, redacting credentials before anything
+touches disk:
+
+	rec, err := quayrecord.New("testdata/list-tags.json", quayrecord.ModeRecord)
+	client, _ := lib.NewClientWithURL(token, "https://quay.example.com/api/v1")
+	client.HTTPClient = rec.HTTPClient()
+	// ... exercise the client ...
+	err = rec.Save()
+
+Replaying serves the same responses with no network access:
+
+	rec, err := quayrecord.New("testdata/list-tags.json", quayrecord.ModeReplay)
+	client, _ := lib.NewClientWithURL("unused", "https://quay.example.com/api/v1")
+	client.HTTPClient = rec.HTTPClient()
+
+Requests are matched on method, path, query and (redacted) body, in recorded
+order, so repeated calls to the same endpoint replay successive responses.
+Hosts are not stored, which lets a cassette recorded against one Quay instance
+be replayed against any base URL.
+
+Redaction:
+  - Headers: Authorization, Proxy-Authorization, Cookie, Set-Cookie
+  - JSON fields (at any depth): token, password, client_secret, access_token,
+    external_registry_password
+*/
+package quayrecord
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Mode selects whether a Recorder talks to the network.
+type Mode int
+
+const (
+	// ModeReplay serves responses from the cassette and never touches the network.
+	ModeReplay Mode = iota
+	// ModeRecord forwards requests to the real transport and captures them.
+	ModeRecord
+)
+
+// ModeEnvVar is read by ModeFromEnv.
+const ModeEnvVar = "QUAYRECORD_MODE"
+
+// Redacted replaces secret values in recorded headers and bodies.
+const Redacted = "REDACTED"
+
+// ErrNoInteraction is returned in replay mode when no recorded interaction matches a request.
+var ErrNoInteraction = errors.New("quayrecord: no matching interaction")
+
+var (
+	defaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+	defaultRedactedFields  = []string{"token", "password", "client_secret", "access_token", "external_registry_password"}
+	droppedHeaders         = []string{"Date", "Content-Length"}
+)
+
+// Cassette is the on-disk golden file format.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one recorded request/response pair.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is the redacted request side of an interaction.
+type RecordedRequest struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+// RecordedResponse is the redacted response side of an interaction.
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Option customizes a Recorder.
+type Option func(*Recorder)
+
+// WithTransport sets the transport used in record mode. Defaults to http.DefaultTransport.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(r *Recorder) {
+		r.transport = rt
+	}
+}
+
+// WithRedactedHeaders adds header names to redact.
+func WithRedactedHeaders(names ...string) Option {
+	return func(r *Recorder) {
+		for _, n := range names {
+			r.headers = append(r.headers, http.CanonicalHeaderKey(n))
+		}
+	}
+}
+
+// WithRedactedFields adds JSON field names to redact in request and response bodies.
+func WithRedactedFields(names ...string) Option {
+	return func(r *Recorder) {
+		r.fields = append(r.fields, names...)
+	}
+}
+
+// Recorder is an http.RoundTripper that records or replays interactions.
+type Recorder struct {
+	path      string
+	mode      Mode
+	transport http.RoundTripper
+	headers   []string
+	fields    []string
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// New creates a Recorder for the cassette at path. In replay mode the cassette
+// must already exist; in record mode any existing cassette is replaced on Save.
+func New(path string, mode Mode, opts ...Option) (*Recorder, error) {
+	r := &Recorder{
+		path:      path,
+		mode:      mode,
+		transport: http.DefaultTransport,
+		headers:   slices.Clone(defaultRedactedHeaders),
+		fields:    slices.Clone(defaultRedactedFields),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if mode == ModeReplay {
+		data, err := os.ReadFile(path) // #nosec G304 -- cassette path is chosen by the test author
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cassette: %w", err)
+		}
+		if err := json.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+		}
+		r.used = make([]bool, len(r.cassette.Interactions))
+	}
+
+	return r, nil
+}
+
+// ModeFromEnv returns ModeRecord when $QUAYRECORD_MODE is "record", else ModeReplay.
+func ModeFromEnv() Mode {
+	if strings.EqualFold(os.Getenv(ModeEnvVar), "record") {
+		return ModeRecord
+	}
+	return ModeReplay
+}
+
+// HTTPClient returns an *http.Client that uses the recorder as its transport.
+func (r *Recorder) HTTPClient() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Interactions returns a copy of the interactions recorded or loaded so far.
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.cassette.Interactions)
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readAndRestore(&req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+
+	recReq := RecordedRequest{
+		Method:  req.Method,
+		URL:     req.URL.RequestURI(),
+		Headers: r.redactHeaders(req.Header),
+		Body:    r.redactBody(body),
+	}
+
+	if r.mode == ModeRecord {
+		return r.record(req, recReq)
+	}
+	return r.replay(req, recReq)
+}
+
+func (r *Recorder) record(req *http.Request, recReq RecordedRequest) (*http.Response, error) {
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := readAndRestore(&resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request: recReq,
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Headers:    r.redactHeaders(resp.Header),
+			Body:       r.redactBody(body),
+		},
+	})
+	r.mu.Unlock()
+
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, recReq RecordedRequest) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, in := range r.cassette.Interactions {
+		if r.used[i] || !matches(in.Request, recReq) {
+			continue
+		}
+		r.used[i] = true
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Response.StatusCode, http.StatusText(in.Response.StatusCode)),
+			StatusCode:    in.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        in.Response.Headers.Clone(),
+			Body:          io.NopCloser(strings.NewReader(in.Response.Body)),
+			ContentLength: int64(len(in.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("%w for %s %s", ErrNoInteraction, recReq.Method, recReq.URL)
+}
+
+// Save writes the recorded interactions to the cassette path. It is a no-op in replay mode.
+func (r *Recorder) Save() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal cassette: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o750); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+	if err := os.WriteFile(r.path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// Unused returns the interactions that have not been replayed yet, which lets
+// tests assert that every recorded call was exercised.
+func (r *Recorder) Unused() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var out []Interaction
+	for i, in := range r.cassette.Interactions {
+		if i < len(r.used) && !r.used[i] {
+			out = append(out, in)
+		}
+	}
+	return out
+}
+
+func matches(recorded, actual RecordedRequest) bool {
+	return recorded.Method == actual.Method &&
+		recorded.URL == actual.URL &&
+		recorded.Body == actual.Body
+}
+
+func (r *Recorder) redactHeaders(h http.Header) http.Header {
+	if len(h) == 0 {
+		return nil
+	}
+
+	out := h.Clone()
+	for _, name := range droppedHeaders {
+		out.Del(name)
+	}
+	for _, name := range r.headers {
+		if _, ok := out[name]; ok {
+			out[name] = []string{Redacted}
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// redactBody replaces secret fields in JSON bodies. Non-JSON bodies are kept verbatim.
+func (r *Recorder) redactBody(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return string(body)
+	}
+	if !r.redactValue(v) {
+		return string(body)
+	}
+
+	out, err := json.Marshal(v)
+	if err != nil {
+		return string(body)
+	}
+	return string(out)
+}
+
+func (r *Recorder) redactValue(v any) bool {
+	changed := false
+	switch t := v.(type) {
+	case map[string]any:
+		for k, val := range t {
+			if slices.Contains(r.fields, k) {
+				if s, ok := val.(string); ok && s != "" && s != Redacted {
+					t[k] = Redacted
+					changed = true
+				}
+				continue
+			}
+			if r.redactValue(val) {
+				changed = true
+			}
+		}
+	case []any:
+		for _, val := range t {
+			if r.redactValue(val) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+func readAndRestore(rc *io.ReadCloser) ([]byte, error) {
+	if *rc == nil || *rc == http.NoBody {
+		return nil, nil
+	}
+	data, err := io.ReadAll(*rc)
+	_ = (*rc).Close()
+	if err != nil {
+		return nil, err
+	}
+	*rc = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
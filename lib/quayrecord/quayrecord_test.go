@@ -0,0 +1,192 @@
+package quayrecord
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sebrandon1/go-quay/lib"
+)
+
+const (
+	testToken       = "super-secret-token"
+	testRobotSecret = "ROBOTSECRET123"
+	testOrg         = "testorg"
+	testRobot       = "deployer"
+)
+
+func newQuayServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/organization/testorg/robots/deployer":
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"name":"testorg+deployer","description":"ci","token":"` + testRobotSecret + `"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/repository/testorg/app/tag/":
+			w.WriteHeader(http.StatusOK)
+			if r.URL.Query().Get("page") == "2" {
+				w.Write([]byte(`{"tags":[{"name":"v1"}],"page":2,"has_additional":false}`))
+				return
+			}
+			w.Write([]byte(`{"tags":[{"name":"latest"}],"page":1,"has_additional":true}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"not_found"}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func recordCassette(t *testing.T, path string) {
+	t.Helper()
+
+	server := newQuayServer(t)
+	rec, err := New(path, ModeRecord)
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+
+	client, err := lib.NewClientWithURL(testToken, server.URL+"/api/v1")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.HTTPClient = rec.HTTPClient()
+
+	ctx := context.Background()
+	if _, err := client.GetRobotAccount(ctx, testOrg, testRobot); err != nil {
+		t.Fatalf("GetRobotAccount returned error: %v", err)
+	}
+	if _, err := client.ListAllTags(ctx, testOrg, "app", false); err != nil {
+		t.Fatalf("ListAllTags returned error: %v", err)
+	}
+
+	if err := rec.Save(); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+}
+
+func TestRecordRedactsSecrets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	recordCassette(t, path)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read cassette: %v", err)
+	}
+
+	golden := string(data)
+	for _, secret := range []string{testToken, testRobotSecret} {
+		if strings.Contains(golden, secret) {
+			t.Errorf("Cassette leaked secret %q", secret)
+		}
+	}
+	if !strings.Contains(golden, `"Authorization": [
+            "`+Redacted+`"`) {
+		t.Errorf("Expected redacted Authorization header in cassette, got:\n%s", golden)
+	}
+	if strings.Contains(golden, "127.0.0.1") {
+		t.Error("Cassette should not store the recorded host")
+	}
+}
+
+func TestReplayServesRecordedResponses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	recordCassette(t, path)
+
+	rec, err := New(path, ModeReplay)
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+
+	client, err := lib.NewClientWithURL("other-token", "https://quay.invalid/api/v1")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.HTTPClient = rec.HTTPClient()
+
+	ctx := context.Background()
+	robot, err := client.GetRobotAccount(ctx, testOrg, testRobot)
+	if err != nil {
+		t.Fatalf("GetRobotAccount returned error: %v", err)
+	}
+	if robot.Name != "testorg+deployer" || robot.Token != Redacted {
+		t.Errorf("Unexpected replayed robot %+v", robot)
+	}
+
+	tags, err := client.ListAllTags(ctx, testOrg, "app", false)
+	if err != nil {
+		t.Fatalf("ListAllTags returned error: %v", err)
+	}
+	if len(tags) != 2 || tags[0].Name != "latest" || tags[1].Name != "v1" {
+		t.Errorf("Unexpected replayed tags %+v", tags)
+	}
+
+	if unused := rec.Unused(); len(unused) != 0 {
+		t.Errorf("Expected every interaction to be replayed, %d unused", len(unused))
+	}
+}
+
+func TestReplayUnmatchedRequest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	recordCassette(t, path)
+
+	rec, err := New(path, ModeReplay)
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+
+	client, err := lib.NewClientWithURL("", "https://quay.invalid/api/v1")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.HTTPClient = rec.HTTPClient()
+
+	_, err = client.GetRepository(context.Background(), testOrg, "missing")
+	if !errors.Is(err, ErrNoInteraction) {
+		t.Fatalf("Expected ErrNoInteraction, got %v", err)
+	}
+}
+
+func TestReplayMissingCassette(t *testing.T) {
+	if _, err := New(filepath.Join(t.TempDir(), "missing.json"), ModeReplay); err == nil {
+		t.Fatal("Expected error for missing cassette, got nil")
+	}
+}
+
+func TestRedactBodyCustomFields(t *testing.T) {
+	rec, err := New("", ModeRecord, WithRedactedFields("sync_token"))
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+
+	got := rec.redactBody([]byte(`{"items":[{"sync_token":"abc","name":"x"}],"password":"hunter2"}`))
+	if strings.Contains(got, "abc") || strings.Contains(got, "hunter2") {
+		t.Errorf("Expected nested and default fields redacted, got %s", got)
+	}
+	if !strings.Contains(got, `"name":"x"`) {
+		t.Errorf("Expected non-secret fields kept, got %s", got)
+	}
+
+	if got := rec.redactBody([]byte("not json")); got != "not json" {
+		t.Errorf("Expected non-JSON body kept verbatim, got %q", got)
+	}
+}
+
+func TestModeFromEnv(t *testing.T) {
+	t.Setenv(ModeEnvVar, "record")
+	if ModeFromEnv() != ModeRecord {
+		t.Error("Expected ModeRecord")
+	}
+	t.Setenv(ModeEnvVar, "")
+	if ModeFromEnv() != ModeReplay {
+		t.Error("Expected ModeReplay")
+	}
+}
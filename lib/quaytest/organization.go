@@ -0,0 +1,892 @@
+package quaytest
+
+import (
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sebrandon1/go-quay/lib"
+)
+
+// Organizations
+
+func (s *Server) lookupOrg(w http.ResponseWriter, r *http.Request) (*organization, bool) {
+	name := r.PathValue("org")
+	org := s.orgs[name]
+	if org == nil {
+		writeNotFound(w, "organization", name)
+		return nil, false
+	}
+	return org, true
+}
+
+// orgInfo returns the organization with its quota report filled in.
+func (s *Server) orgInfo(org *organization) lib.Organization {
+	info := org.info
+	if org.quota != nil {
+		var used int64
+		for _, repo := range s.sortedRepos(info.Name) {
+			for _, m := range repo.manifests {
+				used += m.info.Size
+			}
+		}
+		info.QuotaReport = &lib.QuotaReport{QuotaBytes: used, ConfiguredQuota: org.quota.LimitBytes}
+	}
+	return info
+}
+
+func isRobot(name string) bool {
+	return strings.Contains(name, "+")
+}
+
+func (s *Server) createOrganization(w http.ResponseWriter, r *http.Request) {
+	var req lib.CreateOrganizationRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, "organization name is required")
+		return
+	}
+	if s.namespaceExists(req.Name) {
+		writeError(w, http.StatusBadRequest, "A user or organization with this name already exists")
+		return
+	}
+
+	// Like Quay, the creator becomes the sole member of an admin "owners" team.
+	org := &organization{
+		info: lib.Organization{
+			Name:          req.Name,
+			Email:         req.Email,
+			IsOrgAdmin:    true,
+			CanCreateRepo: true,
+		},
+		members: map[string]bool{},
+		teams: map[string]*team{
+			"owners": {info: lib.Team{Name: "owners", Role: "admin", CanView: true}, members: []string{s.user}},
+		},
+		robots: map[string]*robot{},
+	}
+	s.orgs[req.Name] = org
+	writeJSON(w, http.StatusCreated, s.orgInfo(org))
+}
+
+func (s *Server) getOrganization(w http.ResponseWriter, r *http.Request) {
+	if org, ok := s.lookupOrg(w, r); ok {
+		writeJSON(w, http.StatusOK, s.orgInfo(org))
+	}
+}
+
+func (s *Server) updateOrganization(w http.ResponseWriter, r *http.Request) {
+	org, ok := s.lookupOrg(w, r)
+	if !ok {
+		return
+	}
+	var req lib.UpdateOrganizationRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	org.info.Email = req.Email
+	writeJSON(w, http.StatusOK, s.orgInfo(org))
+}
+
+func (s *Server) deleteOrganization(w http.ResponseWriter, r *http.Request) {
+	org, ok := s.lookupOrg(w, r)
+	if !ok {
+		return
+	}
+	for _, repo := range s.sortedRepos(org.info.Name) {
+		delete(s.repos, repoKey(repo.info.Namespace, repo.info.Name))
+	}
+	delete(s.orgs, org.info.Name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// memberNames returns everyone in a team plus directly added members, by name.
+func (org *organization) memberNames() []string {
+	names := maps.Clone(org.members)
+	for _, t := range org.teams {
+		for _, m := range t.members {
+			names[m] = true
+		}
+	}
+	return slices.Sorted(maps.Keys(names))
+}
+
+func (s *Server) member(org *organization, name string) lib.OrganizationMember {
+	m := lib.OrganizationMember{Name: name, Kind: kindUser}
+	for _, teamName := range slices.Sorted(maps.Keys(org.teams)) {
+		if t := org.teams[teamName]; slices.Contains(t.members, name) {
+			m.Teams = append(m.Teams, lib.Team{Name: teamName, Role: t.info.Role})
+		}
+	}
+	for _, repo := range s.sortedRepos(org.info.Name) {
+		if _, ok := repo.userPerms[name]; ok {
+			m.Repositories = append(m.Repositories, repo.info.Name)
+		}
+	}
+	return m
+}
+
+func (s *Server) listMembers(w http.ResponseWriter, r *http.Request) {
+	org, ok := s.lookupOrg(w, r)
+	if !ok {
+		return
+	}
+	var members lib.OrganizationMembers
+	for _, name := range org.memberNames() {
+		if !isRobot(name) {
+			members.Members = append(members.Members, s.member(org, name))
+		}
+	}
+	writeJSON(w, http.StatusOK, members)
+}
+
+func (s *Server) getMember(w http.ResponseWriter, r *http.Request) {
+	org, ok := s.lookupOrg(w, r)
+	if !ok {
+		return
+	}
+	name := r.PathValue("member")
+	if !slices.Contains(org.memberNames(), name) {
+		writeNotFound(w, "member", name)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.member(org, name))
+}
+
+func (s *Server) addMember(w http.ResponseWriter, r *http.Request) {
+	org, ok := s.lookupOrg(w, r)
+	if !ok {
+		return
+	}
+	name := r.PathValue("member")
+	org.members[name] = true
+	writeJSON(w, http.StatusOK, s.member(org, name))
+}
+
+// removeMember drops the user from the organization and all of its teams.
+func (s *Server) removeMember(w http.ResponseWriter, r *http.Request) {
+	org, ok := s.lookupOrg(w, r)
+	if !ok {
+		return
+	}
+	name := r.PathValue("member")
+	if !slices.Contains(org.memberNames(), name) {
+		writeNotFound(w, "member", name)
+		return
+	}
+	delete(org.members, name)
+	for _, t := range org.teams {
+		t.members = slices.DeleteFunc(t.members, func(m string) bool { return m == name })
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listOrganizationRepositories(w http.ResponseWriter, r *http.Request) {
+	org, ok := s.lookupOrg(w, r)
+	if !ok {
+		return
+	}
+	var repos lib.OrganizationRepositories
+	for _, repo := range s.sortedRepos(org.info.Name) {
+		repos.Repositories = append(repos.Repositories, s.organizationRepository(repo))
+	}
+	writeJSON(w, http.StatusOK, repos)
+}
+
+// listCollaborators reports users with repository permissions who are not members.
+func (s *Server) listCollaborators(w http.ResponseWriter, r *http.Request) {
+	org, ok := s.lookupOrg(w, r)
+	if !ok {
+		return
+	}
+	members := org.memberNames()
+	byName := map[string]*lib.Collaborator{}
+	for _, repo := range s.sortedRepos(org.info.Name) {
+		for name := range repo.userPerms {
+			if isRobot(name) || slices.Contains(members, name) {
+				continue
+			}
+			if byName[name] == nil {
+				byName[name] = &lib.Collaborator{Name: name, Kind: kindUser}
+			}
+			byName[name].Repositories = append(byName[name].Repositories, repo.info.Name)
+		}
+	}
+	var collaborators lib.Collaborators
+	for _, name := range slices.Sorted(maps.Keys(byName)) {
+		collaborators.Collaborators = append(collaborators.Collaborators, *byName[name])
+	}
+	writeJSON(w, http.StatusOK, collaborators)
+}
+
+// Teams
+
+var validTeamRoles = map[string]bool{"member": true, "creator": true, "admin": true}
+
+func (s *Server) lookupTeam(w http.ResponseWriter, r *http.Request) (*organization, *team, bool) {
+	org, ok := s.lookupOrg(w, r)
+	if !ok {
+		return nil, nil, false
+	}
+	name := r.PathValue("team")
+	t := org.teams[name]
+	if t == nil {
+		writeNotFound(w, "team", name)
+		return nil, nil, false
+	}
+	return org, t, true
+}
+
+func (s *Server) teamInfo(org *organization, t *team) lib.Team {
+	info := t.info
+	info.MemberCount = len(t.members)
+	for _, repo := range s.sortedRepos(org.info.Name) {
+		if _, ok := repo.teamPerms[info.Name]; ok {
+			info.RepoCount++
+		}
+	}
+	return info
+}
+
+func (s *Server) listTeams(w http.ResponseWriter, r *http.Request) {
+	org, ok := s.lookupOrg(w, r)
+	if !ok {
+		return
+	}
+	var teams []lib.Team
+	for _, name := range slices.Sorted(maps.Keys(org.teams)) {
+		teams = append(teams, s.teamInfo(org, org.teams[name]))
+	}
+	writeJSON(w, http.StatusOK, map[string][]lib.Team{"teams": teams})
+}
+
+func (s *Server) getTeam(w http.ResponseWriter, r *http.Request) {
+	if org, t, ok := s.lookupTeam(w, r); ok {
+		writeJSON(w, http.StatusOK, s.teamInfo(org, t))
+	}
+}
+
+// putTeam creates the team or updates its description and role.
+func (s *Server) putTeam(w http.ResponseWriter, r *http.Request) {
+	org, ok := s.lookupOrg(w, r)
+	if !ok {
+		return
+	}
+	var req lib.CreateTeamRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if !validTeamRoles[req.Role] {
+		writeError(w, http.StatusBadRequest, "role must be member, creator or admin")
+		return
+	}
+	name := r.PathValue("team")
+	t := org.teams[name]
+	if t == nil {
+		t = &team{info: lib.Team{Name: name, CanView: true}}
+		org.teams[name] = t
+	}
+	t.info.Description = req.Description
+	t.info.Role = req.Role
+	writeJSON(w, http.StatusOK, s.teamInfo(org, t))
+}
+
+// deleteTeam also revokes the team's repository permissions and prototypes.
+func (s *Server) deleteTeam(w http.ResponseWriter, r *http.Request) {
+	org, t, ok := s.lookupTeam(w, r)
+	if !ok {
+		return
+	}
+	name := t.info.Name
+	for _, repo := range s.sortedRepos(org.info.Name) {
+		delete(repo.teamPerms, name)
+	}
+	org.prototypes = slices.DeleteFunc(org.prototypes, func(p lib.Prototype) bool {
+		return p.Delegate.Kind == kindTeam && p.Delegate.Name == name
+	})
+	delete(org.teams, name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listTeamMembers(w http.ResponseWriter, r *http.Request) {
+	_, t, ok := s.lookupTeam(w, r)
+	if !ok {
+		return
+	}
+	var members lib.TeamMembers
+	for _, name := range t.members {
+		members.Members = append(members.Members, lib.TeamMember{Name: name, Kind: kindUser, IsRobot: isRobot(name)})
+	}
+	for _, email := range t.invites {
+		members.Members = append(members.Members, lib.TeamMember{Name: email, Kind: "invite", Invited: true})
+	}
+	writeJSON(w, http.StatusOK, members)
+}
+
+func (s *Server) addTeamMember(w http.ResponseWriter, r *http.Request) {
+	_, t, ok := s.lookupTeam(w, r)
+	if !ok {
+		return
+	}
+	name := r.PathValue("member")
+	if !slices.Contains(t.members, name) {
+		t.members = append(t.members, name)
+	}
+	writeJSON(w, http.StatusOK, lib.TeamMember{Name: name, Kind: kindUser, IsRobot: isRobot(name)})
+}
+
+func (s *Server) removeTeamMember(w http.ResponseWriter, r *http.Request) {
+	_, t, ok := s.lookupTeam(w, r)
+	if !ok {
+		return
+	}
+	name := r.PathValue("member")
+	i := slices.Index(t.members, name)
+	if i < 0 {
+		writeNotFound(w, "team member", name)
+		return
+	}
+	t.members = slices.Delete(t.members, i, i+1)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) inviteTeamMember(w http.ResponseWriter, r *http.Request) {
+	_, t, ok := s.lookupTeam(w, r)
+	if !ok {
+		return
+	}
+	email := r.PathValue("email")
+	if !slices.Contains(t.invites, email) {
+		t.invites = append(t.invites, email)
+	}
+	writeJSON(w, http.StatusOK, lib.TeamMember{Name: email, Kind: "invite", Invited: true})
+}
+
+func (s *Server) deleteTeamInvite(w http.ResponseWriter, r *http.Request) {
+	_, t, ok := s.lookupTeam(w, r)
+	if !ok {
+		return
+	}
+	email := r.PathValue("email")
+	i := slices.Index(t.invites, email)
+	if i < 0 {
+		writeNotFound(w, "invite", email)
+		return
+	}
+	t.invites = slices.Delete(t.invites, i, i+1)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listTeamRepositories(w http.ResponseWriter, r *http.Request) {
+	org, t, ok := s.lookupTeam(w, r)
+	if !ok {
+		return
+	}
+	var perms lib.TeamPermissions
+	for _, repo := range s.sortedRepos(org.info.Name) {
+		if role, ok := repo.teamPerms[t.info.Name]; ok {
+			perms.Permissions = append(perms.Permissions, lib.TeamPermission{Repository: repo.info, Role: role})
+		}
+	}
+	writeJSON(w, http.StatusOK, perms)
+}
+
+// lookupOrgRepo resolves the {repository} path value within the organization.
+func (s *Server) lookupOrgRepo(w http.ResponseWriter, r *http.Request, org *organization) (*repository, bool) {
+	key := repoKey(org.info.Name, r.PathValue("repository"))
+	repo := s.repos[key]
+	if repo == nil {
+		writeNotFound(w, "repository", key)
+		return nil, false
+	}
+	return repo, true
+}
+
+func (s *Server) setTeamRepository(w http.ResponseWriter, r *http.Request) {
+	org, t, ok := s.lookupTeam(w, r)
+	if !ok {
+		return
+	}
+	repo, ok := s.lookupOrgRepo(w, r, org)
+	if !ok {
+		return
+	}
+	role, ok := decodeRole(w, r)
+	if !ok {
+		return
+	}
+	repo.teamPerms[t.info.Name] = role
+	writeJSON(w, http.StatusOK, lib.TeamPermission{Repository: repo.info, Role: role})
+}
+
+func (s *Server) removeTeamRepository(w http.ResponseWriter, r *http.Request) {
+	org, t, ok := s.lookupTeam(w, r)
+	if !ok {
+		return
+	}
+	repo, ok := s.lookupOrgRepo(w, r, org)
+	if !ok {
+		return
+	}
+	if _, ok := repo.teamPerms[t.info.Name]; !ok {
+		writeNotFound(w, "permission for team", t.info.Name)
+		return
+	}
+	delete(repo.teamPerms, t.info.Name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Robots
+
+// robotScope resolves the namespace and robot set addressed by a request:
+// an organization's robots or the user's own.
+type robotScope func(w http.ResponseWriter, r *http.Request) (namespace string, robots map[string]*robot, ok bool)
+
+func (s *Server) orgRobots(w http.ResponseWriter, r *http.Request) (string, map[string]*robot, bool) {
+	org, ok := s.lookupOrg(w, r)
+	if !ok {
+		return "", nil, false
+	}
+	return org.info.Name, org.robots, true
+}
+
+func (s *Server) userRobots(_ http.ResponseWriter, _ *http.Request) (string, map[string]*robot, bool) {
+	return s.user, s.robots, true
+}
+
+func lookupRobot(w http.ResponseWriter, r *http.Request, scope robotScope) (string, *robot, bool) {
+	namespace, robots, ok := scope(w, r)
+	if !ok {
+		return "", nil, false
+	}
+	short := r.PathValue("robot")
+	rb := robots[short]
+	if rb == nil {
+		writeNotFound(w, "robot", namespace+"+"+short)
+		return "", nil, false
+	}
+	return namespace, rb, true
+}
+
+// robotInfo returns the robot with its team memberships filled in.
+func (s *Server) robotInfo(namespace string, rb *robot) lib.RobotAccount {
+	info := rb.info
+	if org := s.orgs[namespace]; org != nil {
+		for _, name := range slices.Sorted(maps.Keys(org.teams)) {
+			if slices.Contains(org.teams[name].members, info.Name) {
+				info.Teams = append(info.Teams, lib.Team{Name: name, Role: org.teams[name].info.Role})
+			}
+		}
+	}
+	return info
+}
+
+func (s *Server) listRobots(scope robotScope) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		namespace, robots, ok := scope(w, r)
+		if !ok {
+			return
+		}
+		var list lib.RobotAccounts
+		for _, short := range slices.Sorted(maps.Keys(robots)) {
+			list.Robots = append(list.Robots, s.robotInfo(namespace, robots[short]))
+		}
+		writeJSON(w, http.StatusOK, list)
+	}
+}
+
+func (s *Server) createRobot(scope robotScope) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		namespace, robots, ok := scope(w, r)
+		if !ok {
+			return
+		}
+		var req lib.CreateRobotRequest
+		if r.ContentLength != 0 && !decodeBody(w, r, &req) {
+			return
+		}
+		short := r.PathValue("robot")
+		if robots[short] != nil {
+			writeError(w, http.StatusBadRequest, "Existing robot with name: "+namespace+"+"+short)
+			return
+		}
+		rb := &robot{info: lib.RobotAccount{
+			Name:         namespace + "+" + short,
+			Description:  req.Description,
+			Token:        randomToken(),
+			Created:      s.timestamp(),
+			Unstructured: req.Unstructured,
+		}}
+		robots[short] = rb
+		writeJSON(w, http.StatusCreated, s.robotInfo(namespace, rb))
+	}
+}
+
+func (s *Server) getRobot(scope robotScope) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if namespace, rb, ok := lookupRobot(w, r, scope); ok {
+			writeJSON(w, http.StatusOK, s.robotInfo(namespace, rb))
+		}
+	}
+}
+
+// deleteRobot also removes the robot's repository permissions and team memberships.
+func (s *Server) deleteRobot(scope robotScope) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		namespace, robots, ok := scope(w, r)
+		if !ok {
+			return
+		}
+		_, rb, ok := lookupRobot(w, r, scope)
+		if !ok {
+			return
+		}
+		name := rb.info.Name
+		for _, repo := range s.repos {
+			delete(repo.userPerms, name)
+		}
+		if org := s.orgs[namespace]; org != nil {
+			for _, t := range org.teams {
+				t.members = slices.DeleteFunc(t.members, func(m string) bool { return m == name })
+			}
+		}
+		delete(robots, r.PathValue("robot"))
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *Server) regenerateRobot(scope robotScope) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		namespace, rb, ok := lookupRobot(w, r, scope)
+		if !ok {
+			return
+		}
+		rb.info.Token = randomToken()
+		writeJSON(w, http.StatusOK, s.robotInfo(namespace, rb))
+	}
+}
+
+func (s *Server) listRobotPermissions(scope robotScope) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, rb, ok := lookupRobot(w, r, scope)
+		if !ok {
+			return
+		}
+		var perms lib.RobotPermissions
+		for _, repo := range s.sortedRepos("") {
+			if role, ok := repo.userPerms[rb.info.Name]; ok {
+				perms.Permissions = append(perms.Permissions, lib.RobotPermission{Repository: repo.info, Role: role})
+			}
+		}
+		writeJSON(w, http.StatusOK, perms)
+	}
+}
+
+func (s *Server) setRobotPermission(w http.ResponseWriter, r *http.Request) {
+	_, rb, ok := lookupRobot(w, r, s.orgRobots)
+	if !ok {
+		return
+	}
+	repo, ok := s.lookupOrgRepo(w, r, s.orgs[r.PathValue("org")])
+	if !ok {
+		return
+	}
+	role, ok := decodeRole(w, r)
+	if !ok {
+		return
+	}
+	repo.userPerms[rb.info.Name] = role
+	writeJSON(w, http.StatusOK, lib.RobotPermission{Repository: repo.info, Role: role})
+}
+
+func (s *Server) removeRobotPermission(w http.ResponseWriter, r *http.Request) {
+	_, rb, ok := lookupRobot(w, r, s.orgRobots)
+	if !ok {
+		return
+	}
+	repo, ok := s.lookupOrgRepo(w, r, s.orgs[r.PathValue("org")])
+	if !ok {
+		return
+	}
+	if _, ok := repo.userPerms[rb.info.Name]; !ok {
+		writeNotFound(w, "permission for robot", rb.info.Name)
+		return
+	}
+	delete(repo.userPerms, rb.info.Name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) getFederation(scope robotScope) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, rb, ok := lookupRobot(w, r, scope); ok {
+			writeJSON(w, http.StatusOK, lib.RobotFederation{Federation: rb.federation})
+		}
+	}
+}
+
+func (s *Server) setFederation(scope robotScope) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, rb, ok := lookupRobot(w, r, scope)
+		if !ok {
+			return
+		}
+		var configs []lib.RobotFederationConfig
+		if !decodeBody(w, r, &configs) {
+			return
+		}
+		rb.federation = configs
+		writeJSON(w, http.StatusOK, configs)
+	}
+}
+
+func (s *Server) deleteFederation(scope robotScope) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, rb, ok := lookupRobot(w, r, scope); ok {
+			rb.federation = nil
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
+
+// Quota
+
+func (s *Server) getQuota(w http.ResponseWriter, r *http.Request) {
+	org, ok := s.lookupOrg(w, r)
+	if !ok {
+		return
+	}
+	if org.quota == nil {
+		writeNotFound(w, "quota for organization", org.info.Name)
+		return
+	}
+	writeJSON(w, http.StatusOK, org.quota)
+}
+
+func (s *Server) createQuota(w http.ResponseWriter, r *http.Request) {
+	org, ok := s.lookupOrg(w, r)
+	if !ok {
+		return
+	}
+	var req lib.CreateQuotaRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if org.quota != nil {
+		writeError(w, http.StatusBadRequest, "Organization quota for '"+org.info.Name+"' already exists")
+		return
+	}
+	s.seq++
+	org.quota = &lib.Quota{ID: strconv.Itoa(s.seq), LimitBytes: req.LimitBytes}
+	writeJSON(w, http.StatusCreated, org.quota)
+}
+
+func (s *Server) updateQuota(w http.ResponseWriter, r *http.Request) {
+	org, ok := s.lookupOrg(w, r)
+	if !ok {
+		return
+	}
+	var req lib.CreateQuotaRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if org.quota == nil {
+		writeNotFound(w, "quota for organization", org.info.Name)
+		return
+	}
+	org.quota.LimitBytes = req.LimitBytes
+	writeJSON(w, http.StatusOK, org.quota)
+}
+
+func (s *Server) deleteQuota(w http.ResponseWriter, r *http.Request) {
+	org, ok := s.lookupOrg(w, r)
+	if !ok {
+		return
+	}
+	if org.quota == nil {
+		writeNotFound(w, "quota for organization", org.info.Name)
+		return
+	}
+	org.quota = nil
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Auto-prune policies
+
+var validPruneMethods = map[string]bool{"number_of_tags": true, "creation_date": true}
+
+func (s *Server) lookupPolicy(w http.ResponseWriter, r *http.Request) (*organization, int, bool) {
+	org, ok := s.lookupOrg(w, r)
+	if !ok {
+		return nil, 0, false
+	}
+	uuid := r.PathValue("uuid")
+	i := slices.IndexFunc(org.policies, func(p lib.AutoPrunePolicy) bool { return p.UUID == uuid })
+	if i < 0 {
+		writeNotFound(w, "auto-prune policy", uuid)
+		return nil, 0, false
+	}
+	return org, i, true
+}
+
+func decodePolicy(w http.ResponseWriter, r *http.Request) (lib.CreateAutoPruneRequest, bool) {
+	var req lib.CreateAutoPruneRequest
+	if !decodeBody(w, r, &req) {
+		return req, false
+	}
+	if !validPruneMethods[req.Method] {
+		writeError(w, http.StatusBadRequest, "method must be number_of_tags or creation_date")
+		return req, false
+	}
+	return req, true
+}
+
+func (s *Server) listAutoPrune(w http.ResponseWriter, r *http.Request) {
+	if org, ok := s.lookupOrg(w, r); ok {
+		writeJSON(w, http.StatusOK, lib.AutoPrunePolicies{Policies: org.policies})
+	}
+}
+
+func (s *Server) createAutoPrune(w http.ResponseWriter, r *http.Request) {
+	org, ok := s.lookupOrg(w, r)
+	if !ok {
+		return
+	}
+	req, ok := decodePolicy(w, r)
+	if !ok {
+		return
+	}
+	policy := lib.AutoPrunePolicy{
+		UUID:         s.nextID(),
+		Method:       req.Method,
+		Value:        req.Value,
+		TagPattern:   req.TagPattern,
+		CreationDate: s.timestamp(),
+		LastUpdated:  s.timestamp(),
+	}
+	org.policies = append(org.policies, policy)
+	writeJSON(w, http.StatusCreated, policy)
+}
+
+func (s *Server) getAutoPrune(w http.ResponseWriter, r *http.Request) {
+	if org, i, ok := s.lookupPolicy(w, r); ok {
+		writeJSON(w, http.StatusOK, org.policies[i])
+	}
+}
+
+func (s *Server) updateAutoPrune(w http.ResponseWriter, r *http.Request) {
+	org, i, ok := s.lookupPolicy(w, r)
+	if !ok {
+		return
+	}
+	req, ok := decodePolicy(w, r)
+	if !ok {
+		return
+	}
+	p := &org.policies[i]
+	p.Method, p.Value, p.TagPattern, p.LastUpdated = req.Method, req.Value, req.TagPattern, s.timestamp()
+	writeJSON(w, http.StatusOK, p)
+}
+
+func (s *Server) deleteAutoPrune(w http.ResponseWriter, r *http.Request) {
+	if org, i, ok := s.lookupPolicy(w, r); ok {
+		org.policies = slices.Delete(org.policies, i, i+1)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// Prototypes
+
+func (s *Server) lookupPrototype(w http.ResponseWriter, r *http.Request) (*organization, int, bool) {
+	org, ok := s.lookupOrg(w, r)
+	if !ok {
+		return nil, 0, false
+	}
+	id := r.PathValue("uuid")
+	i := slices.IndexFunc(org.prototypes, func(p lib.Prototype) bool { return p.ID == id })
+	if i < 0 {
+		writeNotFound(w, "prototype", id)
+		return nil, 0, false
+	}
+	return org, i, true
+}
+
+func (s *Server) listPrototypes(w http.ResponseWriter, r *http.Request) {
+	if org, ok := s.lookupOrg(w, r); ok {
+		writeJSON(w, http.StatusOK, lib.Prototypes{Prototypes: org.prototypes})
+	}
+}
+
+func (s *Server) createPrototype(w http.ResponseWriter, r *http.Request) {
+	org, ok := s.lookupOrg(w, r)
+	if !ok {
+		return
+	}
+	var req lib.CreatePrototypeRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if !validRoles[req.Role] {
+		writeError(w, http.StatusBadRequest, "role must be read, write or admin")
+		return
+	}
+	switch req.Delegate.Kind {
+	case kindUser:
+	case kindTeam:
+		if org.teams[req.Delegate.Name] == nil {
+			writeNotFound(w, "team", req.Delegate.Name)
+			return
+		}
+	default:
+		writeError(w, http.StatusBadRequest, "delegate kind must be user or team")
+		return
+	}
+
+	prototype := lib.Prototype{
+		ID:   s.nextID(),
+		Role: req.Role,
+		Delegate: lib.PrototypeDelegate{
+			Name:    req.Delegate.Name,
+			Kind:    req.Delegate.Kind,
+			IsRobot: isRobot(req.Delegate.Name),
+		},
+	}
+	if req.ActivatingUser != nil {
+		prototype.ActivatingUser = &lib.PrototypeDelegate{Name: req.ActivatingUser.Name, Kind: kindUser}
+	}
+	org.prototypes = append(org.prototypes, prototype)
+	writeJSON(w, http.StatusCreated, prototype)
+}
+
+func (s *Server) getPrototype(w http.ResponseWriter, r *http.Request) {
+	if org, i, ok := s.lookupPrototype(w, r); ok {
+		writeJSON(w, http.StatusOK, org.prototypes[i])
+	}
+}
+
+func (s *Server) updatePrototype(w http.ResponseWriter, r *http.Request) {
+	org, i, ok := s.lookupPrototype(w, r)
+	if !ok {
+		return
+	}
+	var req lib.UpdatePrototypeRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if !validRoles[req.Role] {
+		writeError(w, http.StatusBadRequest, "role must be read, write or admin")
+		return
+	}
+	org.prototypes[i].Role = req.Role
+	writeJSON(w, http.StatusOK, org.prototypes[i])
+}
+
+func (s *Server) deletePrototype(w http.ResponseWriter, r *http.Request) {
+	if org, i, ok := s.lookupPrototype(w, r); ok {
+		org.prototypes = slices.Delete(org.prototypes, i, i+1)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
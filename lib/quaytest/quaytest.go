@@ -0,0 +1,512 @@
+/*
+Package quaytest provides an in-memory fake of the Quay API for tests.
+
+A Server answers the same /api/v1 endpoints lib.Client calls and keeps state
+across requests, so tests can drive whole workflows instead of stubbing
+individual JSON responses:
+
+	srv := quaytest.New()
+	defer srv.Close()
+
+	client := srv.Client()
+	_, err := client.CreateOrganization(ctx, "acme", "ops@acme.test")
+	_, err = client.CreateRepository(ctx, "acme", "api", "private", "")
+	digest, err := srv.PushManifest("acme", "api", "latest", lib.Manifest{})
+	err = client.SetTeamPermission(ctx, "acme", "api", "owners", "admin")
+
+Covered areas:
+  - Repositories, visibility and repository permissions (users, robots, teams)
+  - Tags with history, revert and restore; manifests and manifest labels
+  - Manifest security reports seeded with SetSecurityScan
+  - Organizations, members, collaborators, teams, team permissions and prototypes
+  - Organization and user robot accounts, robot permissions and federation
+  - Repository notifications, builds and build logs
+  - Organization quota and auto-prune policies
+
+Images cannot be pushed through the API, so PushManifest stands in for a
+registry push; build progress is driven with UpdateBuild and AppendBuildLogs.
+Every request must carry a bearer token, but its value is not checked and all
+calls act as a single user (see WithUsername). Errors use Quay's JSON error
+shape so callers receive a *lib.QuayError.
+*/
+package quaytest
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sebrandon1/go-quay/lib"
+)
+
+const apiPrefix = "/api/v1"
+
+// DefaultUsername is the account every request acts as unless WithUsername is used.
+const DefaultUsername = "quaytest"
+
+// Token is the bearer token Client configures.
+const Token = "quaytest-token"
+
+const (
+	kindUser          = "user"
+	kindTeam          = "team"
+	visibilityPublic  = "public"
+	visibilityPrivate = "private"
+)
+
+// maxPageSize caps the limit query parameter the way Quay does.
+const maxPageSize = 100
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithUsername sets the name of the authenticated user.
+func WithUsername(name string) Option {
+	return func(s *Server) {
+		s.user = name
+	}
+}
+
+// WithClock replaces time.Now for timestamps on tags, builds and robots.
+func WithClock(now func() time.Time) Option {
+	return func(s *Server) {
+		s.now = now
+	}
+}
+
+// Server is an in-memory Quay API. All methods are safe for concurrent use.
+type Server struct {
+	// URL is the API base URL (including /api/v1) for lib.NewClientWithURL.
+	URL string
+
+	srv  *httptest.Server
+	user string
+	now  func() time.Time
+
+	mu     sync.Mutex
+	seq    int
+	orgs   map[string]*organization
+	repos  map[string]*repository
+	robots map[string]*robot // the user's own robots, keyed by short name
+}
+
+type organization struct {
+	info       lib.Organization
+	members    map[string]bool
+	teams      map[string]*team
+	robots     map[string]*robot
+	prototypes []lib.Prototype
+	policies   []lib.AutoPrunePolicy
+	quota      *lib.Quota
+}
+
+type team struct {
+	info    lib.Team
+	members []string
+	invites []string
+}
+
+type robot struct {
+	info       lib.RobotAccount
+	federation []lib.RobotFederationConfig
+}
+
+type repository struct {
+	info          lib.Repository
+	tags          []lib.Tag // every tag revision, oldest first
+	manifests     map[string]*manifest
+	userPerms     map[string]string
+	teamPerms     map[string]string
+	notifications []lib.RepositoryNotification
+	builds        []*build
+}
+
+type manifest struct {
+	info   lib.Manifest
+	labels []lib.ManifestLabel
+	scan   *lib.SecurityScan
+}
+
+type build struct {
+	info lib.Build
+	logs []lib.BuildLogEntry
+}
+
+// New starts a Server. Call Close when done.
+func New(opts ...Option) *Server {
+	s := &Server{
+		user:   DefaultUsername,
+		now:    time.Now,
+		orgs:   map[string]*organization{},
+		repos:  map[string]*repository{},
+		robots: map[string]*robot{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	mux := http.NewServeMux()
+	s.routes(mux)
+	s.srv = httptest.NewServer(authenticate(mux))
+	s.URL = s.srv.URL + apiPrefix
+	return s
+}
+
+// Close shuts the server down.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Client returns a lib.Client pointed at the server.
+func (s *Server) Client(opts ...lib.ClientOption) *lib.Client {
+	client, _ := lib.NewClientWithURL(Token, s.URL, opts...)
+	return client
+}
+
+// PushManifest stores a manifest in a repository, creating the repository if
+// needed, and points tag at it when tag is non-empty. An empty Digest is
+// derived from the manifest contents and an empty Size from its layers. It
+// returns the manifest digest.
+func (s *Server) PushManifest(namespace, repository, tag string, m lib.Manifest) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.namespaceExists(namespace) {
+		return "", fmt.Errorf("quaytest: namespace %q does not exist", namespace)
+	}
+	repo := s.repos[repoKey(namespace, repository)]
+	if repo == nil {
+		repo = s.addRepository(namespace, repository, false, "")
+	}
+
+	if m.Size == 0 {
+		m.Size = m.Config.Size
+		for _, layer := range m.Layers {
+			m.Size += layer.Size
+		}
+	}
+	if m.Digest == "" {
+		data, err := json.Marshal(m)
+		if err != nil {
+			return "", fmt.Errorf("quaytest: encode manifest: %w", err)
+		}
+		sum := sha256.Sum256(data)
+		m.Digest = "sha256:" + hex.EncodeToString(sum[:])
+	}
+
+	if existing := repo.manifests[m.Digest]; existing != nil {
+		existing.info = m
+	} else {
+		repo.manifests[m.Digest] = &manifest{info: m}
+	}
+	if tag != "" {
+		s.setTag(repo, tag, m.Digest, false)
+	}
+	return m.Digest, nil
+}
+
+// SetSecurityScan sets the security report served for a manifest. Manifests
+// without one report status "queued".
+func (s *Server) SetSecurityScan(namespace, repository, digest string, scan lib.SecurityScan) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, err := s.seedManifest(namespace, repository, digest)
+	if err != nil {
+		return err
+	}
+	m.scan = &scan
+	return nil
+}
+
+// UpdateBuild applies update to a build, e.g. to move it to another phase.
+func (s *Server) UpdateBuild(namespace, repository, id string, update func(*lib.Build)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, err := s.seedBuild(namespace, repository, id)
+	if err != nil {
+		return err
+	}
+	update(&b.info)
+	return nil
+}
+
+// AppendBuildLogs adds entries to a build's log.
+func (s *Server) AppendBuildLogs(namespace, repository, id string, entries ...lib.BuildLogEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, err := s.seedBuild(namespace, repository, id)
+	if err != nil {
+		return err
+	}
+	b.logs = append(b.logs, entries...)
+	return nil
+}
+
+func (s *Server) seedManifest(namespace, repository, digest string) (*manifest, error) {
+	repo := s.repos[repoKey(namespace, repository)]
+	if repo == nil {
+		return nil, fmt.Errorf("quaytest: repository %s/%s does not exist", namespace, repository)
+	}
+	m := repo.manifests[digest]
+	if m == nil {
+		return nil, fmt.Errorf("quaytest: manifest %s does not exist in %s/%s", digest, namespace, repository)
+	}
+	return m, nil
+}
+
+func (s *Server) seedBuild(namespace, repository, id string) (*build, error) {
+	repo := s.repos[repoKey(namespace, repository)]
+	if repo == nil {
+		return nil, fmt.Errorf("quaytest: repository %s/%s does not exist", namespace, repository)
+	}
+	b := repo.findBuild(id)
+	if b == nil {
+		return nil, fmt.Errorf("quaytest: build %s does not exist in %s/%s", id, namespace, repository)
+	}
+	return b, nil
+}
+
+func (s *Server) routes(mux *http.ServeMux) {
+	route := func(method, path string, h http.HandlerFunc) {
+		mux.HandleFunc(method+" "+apiPrefix+path, func(w http.ResponseWriter, r *http.Request) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			h(w, r)
+		})
+	}
+
+	route(http.MethodGet, "/user", s.getUser)
+
+	// Repositories
+	route(http.MethodPost, "/repository", s.createRepository)
+	route(http.MethodGet, "/repository", s.listRepositories)
+	route(http.MethodGet, "/repository/{namespace}/{repository}", s.getRepository)
+	route(http.MethodPut, "/repository/{namespace}/{repository}", s.updateRepository)
+	route(http.MethodDelete, "/repository/{namespace}/{repository}", s.deleteRepository)
+	route(http.MethodPost, "/repository/{namespace}/{repository}/changevisibility", s.changeVisibility)
+
+	// Tags
+	route(http.MethodGet, "/repository/{namespace}/{repository}/tag/{$}", s.listTags)
+	route(http.MethodGet, "/repository/{namespace}/{repository}/tag/{tag}", s.getTag)
+	route(http.MethodPut, "/repository/{namespace}/{repository}/tag/{tag}", s.putTag)
+	route(http.MethodDelete, "/repository/{namespace}/{repository}/tag/{tag}", s.deleteTag)
+	route(http.MethodGet, "/repository/{namespace}/{repository}/tag/{tag}/history", s.tagHistory)
+	route(http.MethodPost, "/repository/{namespace}/{repository}/tag/{tag}/revert", s.revertTag)
+	route(http.MethodPost, "/repository/{namespace}/{repository}/tag/{tag}/restore", s.revertTag)
+
+	// Manifests
+	route(http.MethodGet, "/repository/{namespace}/{repository}/manifest/{ref}", s.getManifest)
+	route(http.MethodDelete, "/repository/{namespace}/{repository}/manifest/{ref}", s.deleteManifest)
+	route(http.MethodGet, "/repository/{namespace}/{repository}/manifest/{ref}/labels", s.listLabels)
+	route(http.MethodPost, "/repository/{namespace}/{repository}/manifest/{ref}/labels", s.addLabel)
+	route(http.MethodGet, "/repository/{namespace}/{repository}/manifest/{ref}/labels/{id}", s.getLabel)
+	route(http.MethodDelete, "/repository/{namespace}/{repository}/manifest/{ref}/labels/{id}", s.deleteLabel)
+	route(http.MethodGet, "/repository/{namespace}/{repository}/manifest/{ref}/security", s.getSecurity)
+
+	// Repository permissions
+	route(http.MethodGet, "/repository/{namespace}/{repository}/permissions", s.listPermissions)
+	route(http.MethodPut, "/repository/{namespace}/{repository}/permissions/{user}", s.setUserPermission)
+	route(http.MethodDelete, "/repository/{namespace}/{repository}/permissions/{user}", s.deleteUserPermission)
+	route(http.MethodGet, "/repository/{namespace}/{repository}/permissions/user/{$}", s.listUserPermissions)
+	route(http.MethodGet, "/repository/{namespace}/{repository}/permissions/user/{user}", s.getUserPermission)
+	route(http.MethodPut, "/repository/{namespace}/{repository}/permissions/user/{user}", s.setUserPermission)
+	route(http.MethodDelete, "/repository/{namespace}/{repository}/permissions/user/{user}", s.deleteUserPermission)
+	route(http.MethodGet, "/repository/{namespace}/{repository}/permissions/user/{user}/transitive", s.getTransitivePermission)
+	route(http.MethodGet, "/repository/{namespace}/{repository}/permissions/team/{$}", s.listTeamPermissions)
+	route(http.MethodGet, "/repository/{namespace}/{repository}/permissions/team/{team}", s.getTeamPermission)
+	route(http.MethodPut, "/repository/{namespace}/{repository}/permissions/team/{team}", s.setTeamPermission)
+	route(http.MethodDelete, "/repository/{namespace}/{repository}/permissions/team/{team}", s.deleteTeamPermission)
+
+	// Notifications
+	route(http.MethodGet, "/repository/{namespace}/{repository}/notification/{$}", s.listNotifications)
+	route(http.MethodPost, "/repository/{namespace}/{repository}/notification/{$}", s.createNotification)
+	route(http.MethodGet, "/repository/{namespace}/{repository}/notification/{uuid}", s.getNotification)
+	route(http.MethodPost, "/repository/{namespace}/{repository}/notification/{uuid}", s.updateNotification)
+	route(http.MethodDelete, "/repository/{namespace}/{repository}/notification/{uuid}", s.deleteNotification)
+	route(http.MethodPost, "/repository/{namespace}/{repository}/notification/{uuid}/test", s.testNotification)
+	route(http.MethodPost, "/repository/{namespace}/{repository}/notification/{uuid}/reset", s.resetNotification)
+
+	// Builds
+	route(http.MethodGet, "/repository/{namespace}/{repository}/build/{$}", s.listBuilds)
+	route(http.MethodPost, "/repository/{namespace}/{repository}/build/{$}", s.requestBuild)
+	route(http.MethodGet, "/repository/{namespace}/{repository}/build/{uuid}", s.getBuild)
+	route(http.MethodDelete, "/repository/{namespace}/{repository}/build/{uuid}", s.cancelBuild)
+	route(http.MethodGet, "/repository/{namespace}/{repository}/build/{uuid}/status", s.getBuildStatus)
+	route(http.MethodGet, "/repository/{namespace}/{repository}/build/{uuid}/logs", s.getBuildLogs)
+
+	// Organizations
+	route(http.MethodPost, "/organization/{$}", s.createOrganization)
+	route(http.MethodGet, "/organization/{org}", s.getOrganization)
+	route(http.MethodPut, "/organization/{org}", s.updateOrganization)
+	route(http.MethodDelete, "/organization/{org}", s.deleteOrganization)
+	route(http.MethodGet, "/organization/{org}/members", s.listMembers)
+	route(http.MethodGet, "/organization/{org}/members/{member}", s.getMember)
+	route(http.MethodPut, "/organization/{org}/members/{member}", s.addMember)
+	route(http.MethodDelete, "/organization/{org}/members/{member}", s.removeMember)
+	route(http.MethodGet, "/organization/{org}/repositories", s.listOrganizationRepositories)
+	route(http.MethodGet, "/organization/{org}/collaborators", s.listCollaborators)
+
+	// Teams
+	route(http.MethodGet, "/organization/{org}/teams", s.listTeams)
+	route(http.MethodGet, "/organization/{org}/team/{team}", s.getTeam)
+	route(http.MethodPut, "/organization/{org}/team/{team}", s.putTeam)
+	route(http.MethodDelete, "/organization/{org}/team/{team}", s.deleteTeam)
+	route(http.MethodGet, "/organization/{org}/team/{team}/members", s.listTeamMembers)
+	route(http.MethodPut, "/organization/{org}/team/{team}/members/{member}", s.addTeamMember)
+	route(http.MethodDelete, "/organization/{org}/team/{team}/members/{member}", s.removeTeamMember)
+	route(http.MethodPut, "/organization/{org}/team/{team}/invite/{email}", s.inviteTeamMember)
+	route(http.MethodDelete, "/organization/{org}/team/{team}/invite/{email}", s.deleteTeamInvite)
+	route(http.MethodGet, "/organization/{org}/team/{team}/permissions", s.listTeamRepositories)
+	route(http.MethodPut, "/organization/{org}/team/{team}/permissions/{repository}", s.setTeamRepository)
+	route(http.MethodDelete, "/organization/{org}/team/{team}/permissions/{repository}", s.removeTeamRepository)
+
+	// Robots
+	for prefix, scope := range map[string]robotScope{
+		"/organization/{org}/robots": s.orgRobots,
+		"/user/robots":               s.userRobots,
+	} {
+		route(http.MethodGet, prefix, s.listRobots(scope))
+		route(http.MethodPut, prefix+"/{robot}", s.createRobot(scope))
+		route(http.MethodGet, prefix+"/{robot}", s.getRobot(scope))
+		route(http.MethodDelete, prefix+"/{robot}", s.deleteRobot(scope))
+		route(http.MethodPost, prefix+"/{robot}/regenerate", s.regenerateRobot(scope))
+		route(http.MethodGet, prefix+"/{robot}/permissions", s.listRobotPermissions(scope))
+		route(http.MethodGet, prefix+"/{robot}/federation", s.getFederation(scope))
+		route(http.MethodPost, prefix+"/{robot}/federation", s.setFederation(scope))
+		route(http.MethodDelete, prefix+"/{robot}/federation", s.deleteFederation(scope))
+	}
+	route(http.MethodPut, "/organization/{org}/robots/{robot}/permissions/{repository}", s.setRobotPermission)
+	route(http.MethodDelete, "/organization/{org}/robots/{robot}/permissions/{repository}", s.removeRobotPermission)
+
+	// Quota, auto-prune and prototypes
+	route(http.MethodGet, "/organization/{org}/quota", s.getQuota)
+	route(http.MethodPost, "/organization/{org}/quota", s.createQuota)
+	route(http.MethodPut, "/organization/{org}/quota", s.updateQuota)
+	route(http.MethodDelete, "/organization/{org}/quota", s.deleteQuota)
+	route(http.MethodGet, "/organization/{org}/autoprunepolicy", s.listAutoPrune)
+	route(http.MethodPost, "/organization/{org}/autoprunepolicy", s.createAutoPrune)
+	route(http.MethodGet, "/organization/{org}/autoprunepolicy/{uuid}", s.getAutoPrune)
+	route(http.MethodPut, "/organization/{org}/autoprunepolicy/{uuid}", s.updateAutoPrune)
+	route(http.MethodDelete, "/organization/{org}/autoprunepolicy/{uuid}", s.deleteAutoPrune)
+	route(http.MethodGet, "/organization/{org}/prototypes", s.listPrototypes)
+	route(http.MethodPost, "/organization/{org}/prototypes", s.createPrototype)
+	route(http.MethodGet, "/organization/{org}/prototypes/{uuid}", s.getPrototype)
+	route(http.MethodPut, "/organization/{org}/prototypes/{uuid}", s.updatePrototype)
+	route(http.MethodDelete, "/organization/{org}/prototypes/{uuid}", s.deletePrototype)
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("quaytest does not implement %s %s", r.Method, r.URL.Path))
+	})
+}
+
+func authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+			writeError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) getUser(w http.ResponseWriter, _ *http.Request) {
+	details := lib.UserDetails{
+		Username:      s.user,
+		Verified:      true,
+		CanCreateRepo: true,
+	}
+	for _, name := range slices.Sorted(maps.Keys(s.orgs)) {
+		details.Organizations = append(details.Organizations, lib.User{Name: name, Kind: "org"})
+	}
+	writeJSON(w, http.StatusOK, details)
+}
+
+// nextID returns a UUID-shaped identifier that is unique within the server.
+func (s *Server) nextID() string {
+	s.seq++
+	return fmt.Sprintf("00000000-0000-4000-8000-%012d", s.seq)
+}
+
+func (s *Server) timestamp() string {
+	return s.now().UTC().Format(time.RFC1123Z)
+}
+
+func (s *Server) namespaceExists(namespace string) bool {
+	return namespace == s.user || s.orgs[namespace] != nil
+}
+
+func repoKey(namespace, repository string) string {
+	return namespace + "/" + repository
+}
+
+func randomToken() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return strings.ToUpper(hex.EncodeToString(b))
+}
+
+var validRoles = map[string]bool{"read": true, "write": true, "admin": true}
+
+var roleRank = map[string]int{"read": 1, "write": 2, "admin": 3}
+
+func validVisibility(v string) bool {
+	return v == visibilityPublic || v == visibilityPrivate
+}
+
+// paginate slices items according to the page and limit query parameters.
+func paginate[T any](items []T, q url.Values, defaultLimit int) (page []T, number int, more bool) {
+	limit := defaultLimit
+	if n, err := strconv.Atoi(q.Get("limit")); err == nil && n > 0 {
+		limit = min(n, maxPageSize)
+	}
+	number = 1
+	if n, err := strconv.Atoi(q.Get("page")); err == nil && n > 0 {
+		number = n
+	}
+
+	start := (number - 1) * limit
+	if start >= len(items) {
+		return nil, number, false
+	}
+	end := min(start+limit, len(items))
+	return items[start:end], number, end < len(items)
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, lib.QuayError{
+		Status:  status,
+		Message: message,
+	})
+}
+
+func writeNotFound(w http.ResponseWriter, kind, name string) {
+	writeError(w, http.StatusNotFound, fmt.Sprintf("%s %q not found", kind, name))
+}
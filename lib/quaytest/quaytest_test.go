@@ -0,0 +1,483 @@
+package quaytest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sebrandon1/go-quay/lib"
+)
+
+func newFixture(t *testing.T) (*Server, *lib.Client) {
+	t.Helper()
+	srv := New()
+	t.Cleanup(srv.Close)
+	client := srv.Client()
+	if _, err := client.CreateOrganization(context.Background(), "acme", "ops@acme.test"); err != nil {
+		t.Fatalf("CreateOrganization: %v", err)
+	}
+	return srv, client
+}
+
+func requireStatus(t *testing.T, err error, status int) {
+	t.Helper()
+	var quayErr *lib.QuayError
+	if !errors.As(err, &quayErr) {
+		t.Fatalf("expected *lib.QuayError, got %v", err)
+	}
+	if quayErr.Status != status {
+		t.Fatalf("expected status %d, got %d (%v)", status, quayErr.Status, err)
+	}
+}
+
+func TestRepositoryWorkflow(t *testing.T) {
+	srv, client := newFixture(t)
+	ctx := context.Background()
+
+	if _, err := client.CreateRepository(ctx, "acme", "api", "private", "API server"); err != nil {
+		t.Fatalf("CreateRepository: %v", err)
+	}
+	digest, err := srv.PushManifest("acme", "api", "v1", lib.Manifest{
+		Layers: []lib.ManifestLayer{{Digest: "sha256:aaa", Size: 100}, {Digest: "sha256:bbb", Size: 50}},
+	})
+	if err != nil {
+		t.Fatalf("PushManifest: %v", err)
+	}
+	if err := client.ChangeTag(ctx, "acme", "api", "latest", digest); err != nil {
+		t.Fatalf("ChangeTag: %v", err)
+	}
+	if _, err := client.CreateTeam(ctx, "acme", "devs", "Developers", "member"); err != nil {
+		t.Fatalf("CreateTeam: %v", err)
+	}
+	if err := client.SetTeamPermission(ctx, "acme", "api", "devs", "write"); err != nil {
+		t.Fatalf("SetTeamPermission: %v", err)
+	}
+
+	repo, err := client.GetRepository(ctx, "acme", "api")
+	if err != nil {
+		t.Fatalf("GetRepository: %v", err)
+	}
+	if repo.Description != "API server" || repo.IsPublic || !repo.IsOrganization {
+		t.Errorf("unexpected repository: %+v", repo.Repository)
+	}
+	if len(repo.Tags.Tags) != 2 {
+		t.Fatalf("expected 2 tags, got %d", len(repo.Tags.Tags))
+	}
+	for _, tag := range repo.Tags.Tags {
+		if tag.ManifestDigest != digest || tag.Size != 150 {
+			t.Errorf("unexpected tag: %+v", tag)
+		}
+	}
+
+	teamPerms, err := client.GetTeamPermissions(ctx, "acme", "devs")
+	if err != nil {
+		t.Fatalf("GetTeamPermissions: %v", err)
+	}
+	if len(teamPerms.Permissions) != 1 || teamPerms.Permissions[0].Repository.Name != "api" || teamPerms.Permissions[0].Role != "write" {
+		t.Errorf("unexpected team permissions: %+v", teamPerms.Permissions)
+	}
+	team, err := client.GetTeam(ctx, "acme", "devs")
+	if err != nil {
+		t.Fatalf("GetTeam: %v", err)
+	}
+	if team.RepoCount != 1 {
+		t.Errorf("expected repo count 1, got %d", team.RepoCount)
+	}
+
+	repos, err := client.ListRepositories(ctx, "acme", false, false, false, 0, 0)
+	if err != nil {
+		t.Fatalf("ListRepositories: %v", err)
+	}
+	if len(repos.Repositories) != 1 || repos.Repositories[0].TagsCount != 2 {
+		t.Errorf("unexpected repositories: %+v", repos.Repositories)
+	}
+
+	if err := client.DeleteRepository(ctx, "acme", "api"); err != nil {
+		t.Fatalf("DeleteRepository: %v", err)
+	}
+	_, err = client.GetTag(ctx, "acme", "api", "latest")
+	requireStatus(t, err, http.StatusNotFound)
+}
+
+func TestTagHistoryAndRevert(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	srv := New(WithClock(func() time.Time { return now }))
+	t.Cleanup(srv.Close)
+	client := srv.Client()
+	ctx := context.Background()
+
+	first, _ := srv.PushManifest(DefaultUsername, "app", "latest", lib.Manifest{Digest: "sha256:one"})
+	now = now.Add(time.Hour)
+	if _, err := srv.PushManifest(DefaultUsername, "app", "latest", lib.Manifest{Digest: "sha256:two"}); err != nil {
+		t.Fatalf("PushManifest: %v", err)
+	}
+
+	history, err := client.GetTagHistory(ctx, DefaultUsername, "app", "latest")
+	if err != nil {
+		t.Fatalf("GetTagHistory: %v", err)
+	}
+	if len(history.Tags) != 2 || history.Tags[0].ManifestDigest != "sha256:two" || history.Tags[1].EndTs != now.Unix() {
+		t.Fatalf("unexpected history: %+v", history.Tags)
+	}
+
+	now = now.Add(time.Hour)
+	reverted, err := client.RevertTag(ctx, DefaultUsername, "app", "latest", first)
+	if err != nil {
+		t.Fatalf("RevertTag: %v", err)
+	}
+	if !reverted.Reversion || reverted.ManifestDigest != first {
+		t.Errorf("unexpected reverted tag: %+v", reverted)
+	}
+
+	active, err := client.ListTags(ctx, DefaultUsername, "app", 0, true)
+	if err != nil {
+		t.Fatalf("ListTags: %v", err)
+	}
+	if len(active.Tags) != 1 || active.Tags[0].ManifestDigest != first {
+		t.Errorf("unexpected active tags: %+v", active.Tags)
+	}
+	all, err := client.ListTags(ctx, DefaultUsername, "app", 0, false)
+	if err != nil {
+		t.Fatalf("ListTags: %v", err)
+	}
+	if len(all.Tags) != 3 {
+		t.Errorf("expected 3 tag revisions, got %d", len(all.Tags))
+	}
+
+	if err := client.DeleteTag(ctx, DefaultUsername, "app", "latest"); err != nil {
+		t.Fatalf("DeleteTag: %v", err)
+	}
+	_, err = client.GetTag(ctx, DefaultUsername, "app", "latest")
+	requireStatus(t, err, http.StatusNotFound)
+
+	err = client.ChangeTag(ctx, DefaultUsername, "app", "latest", "sha256:missing")
+	requireStatus(t, err, http.StatusNotFound)
+}
+
+func TestManifestLabelsAndSecurity(t *testing.T) {
+	srv, client := newFixture(t)
+	ctx := context.Background()
+
+	digest, _ := srv.PushManifest("acme", "api", "latest", lib.Manifest{})
+	label, err := client.AddManifestLabel(ctx, "acme", "api", digest, "team", "platform", "")
+	if err != nil {
+		t.Fatalf("AddManifestLabel: %v", err)
+	}
+	labels, err := client.GetManifestLabels(ctx, "acme", "api", digest)
+	if err != nil {
+		t.Fatalf("GetManifestLabels: %v", err)
+	}
+	if len(labels.Labels) != 1 || labels.Labels[0].ID != label.ID || labels.Labels[0].MediaType != "text/plain" {
+		t.Errorf("unexpected labels: %+v", labels.Labels)
+	}
+	if err := client.DeleteManifestLabel(ctx, "acme", "api", digest, label.ID); err != nil {
+		t.Fatalf("DeleteManifestLabel: %v", err)
+	}
+
+	scan, err := client.GetManifestSecurity(ctx, "acme", "api", digest, true)
+	if err != nil {
+		t.Fatalf("GetManifestSecurity: %v", err)
+	}
+	if scan.Status != "queued" {
+		t.Errorf("expected queued before a report is seeded, got %q", scan.Status)
+	}
+
+	err = srv.SetSecurityScan("acme", "api", digest, lib.SecurityScan{
+		Status: "scanned",
+		Data: &lib.SecurityData{Layer: &lib.SecurityLayer{Features: []lib.SecurityFeature{{
+			Name:            "openssl",
+			Vulnerabilities: []lib.SecurityVulnerability{{Name: "CVE-2024-0001", Severity: "High"}},
+		}}}},
+	})
+	if err != nil {
+		t.Fatalf("SetSecurityScan: %v", err)
+	}
+	withVulns, _ := client.GetManifestSecurity(ctx, "acme", "api", digest, true)
+	if got := len(withVulns.Data.Layer.Features[0].Vulnerabilities); got != 1 {
+		t.Errorf("expected 1 vulnerability, got %d", got)
+	}
+	withoutVulns, _ := client.GetManifestSecurity(ctx, "acme", "api", digest, false)
+	if got := len(withoutVulns.Data.Layer.Features[0].Vulnerabilities); got != 0 {
+		t.Errorf("expected vulnerabilities to be omitted, got %d", got)
+	}
+
+	if err := client.DeleteManifest(ctx, "acme", "api", digest); err != nil {
+		t.Fatalf("DeleteManifest: %v", err)
+	}
+	_, err = client.GetTag(ctx, "acme", "api", "latest")
+	requireStatus(t, err, http.StatusNotFound)
+}
+
+func TestRobotLifecycle(t *testing.T) {
+	_, client := newFixture(t)
+	ctx := context.Background()
+
+	if _, err := client.CreateRepository(ctx, "acme", "api", "private", ""); err != nil {
+		t.Fatalf("CreateRepository: %v", err)
+	}
+	robot, err := client.CreateRobotAccount(ctx, "acme", "deployer", "CI deploys", nil)
+	if err != nil {
+		t.Fatalf("CreateRobotAccount: %v", err)
+	}
+	if robot.Name != "acme+deployer" || robot.Token == "" {
+		t.Fatalf("unexpected robot: %+v", robot)
+	}
+	if err := client.SetRobotRepositoryPermission(ctx, "acme", "deployer", "api", "write"); err != nil {
+		t.Fatalf("SetRobotRepositoryPermission: %v", err)
+	}
+
+	perms, err := client.GetRepositoryPermissions(ctx, "acme", "api")
+	if err != nil {
+		t.Fatalf("GetRepositoryPermissions: %v", err)
+	}
+	if len(perms.Permissions) != 1 || perms.Permissions[0].Name != "acme+deployer" || !perms.Permissions[0].IsRobot {
+		t.Errorf("unexpected permissions: %+v", perms.Permissions)
+	}
+
+	regenerated, err := client.RegenerateRobotToken(ctx, "acme", "deployer")
+	if err != nil {
+		t.Fatalf("RegenerateRobotToken: %v", err)
+	}
+	if regenerated.Token == robot.Token {
+		t.Error("expected a new token")
+	}
+
+	_, err = client.CreateRobotAccount(ctx, "acme", "deployer", "", nil)
+	requireStatus(t, err, http.StatusBadRequest)
+
+	if err := client.DeleteRobotAccount(ctx, "acme", "deployer"); err != nil {
+		t.Fatalf("DeleteRobotAccount: %v", err)
+	}
+	perms, _ = client.GetRepositoryPermissions(ctx, "acme", "api")
+	if len(perms.Permissions) != 0 {
+		t.Errorf("expected robot permission to be removed, got %+v", perms.Permissions)
+	}
+}
+
+func TestTeamsMembersAndPrototypes(t *testing.T) {
+	_, client := newFixture(t)
+	ctx := context.Background()
+
+	if _, err := client.CreateTeam(ctx, "acme", "readers", "", "member"); err != nil {
+		t.Fatalf("CreateTeam: %v", err)
+	}
+	if err := client.AddTeamMember(ctx, "acme", "readers", "alice"); err != nil {
+		t.Fatalf("AddTeamMember: %v", err)
+	}
+	if _, err := client.CreatePrototype(ctx, "acme", &lib.CreatePrototypeRequest{
+		Delegate: lib.PrototypeDelegateRequest{Name: "readers", Kind: "team"},
+		Role:     "read",
+	}); err != nil {
+		t.Fatalf("CreatePrototype: %v", err)
+	}
+
+	if _, err := client.CreateRepository(ctx, "acme", "web", "public", ""); err != nil {
+		t.Fatalf("CreateRepository: %v", err)
+	}
+	perm, err := client.GetTeamPermission(ctx, "acme", "web", "readers")
+	if err != nil {
+		t.Fatalf("GetTeamPermission: %v", err)
+	}
+	if perm.Role != "read" {
+		t.Errorf("expected prototype to grant read, got %q", perm.Role)
+	}
+	transitive, err := client.GetUserTransitivePermission(ctx, "acme", "web", "alice")
+	if err != nil {
+		t.Fatalf("GetUserTransitivePermission: %v", err)
+	}
+	if transitive.Role != "read" {
+		t.Errorf("expected transitive read, got %q", transitive.Role)
+	}
+
+	members, err := client.GetOrganizationMembers(ctx, "acme")
+	if err != nil {
+		t.Fatalf("GetOrganizationMembers: %v", err)
+	}
+	if len(members.Members) != 2 || members.Members[0].Name != "alice" || members.Members[1].Name != DefaultUsername {
+		t.Errorf("unexpected members: %+v", members.Members)
+	}
+
+	if err := client.DeleteTeam(ctx, "acme", "readers"); err != nil {
+		t.Fatalf("DeleteTeam: %v", err)
+	}
+	_, err = client.GetTeamPermission(ctx, "acme", "web", "readers")
+	requireStatus(t, err, http.StatusNotFound)
+	prototypes, _ := client.GetPrototypes(ctx, "acme")
+	if len(prototypes.Prototypes) != 0 {
+		t.Errorf("expected prototype to be removed with its team, got %+v", prototypes.Prototypes)
+	}
+}
+
+func TestBuilds(t *testing.T) {
+	srv, client := newFixture(t)
+	ctx := context.Background()
+
+	if _, err := client.CreateRepository(ctx, "acme", "api", "private", ""); err != nil {
+		t.Fatalf("CreateRepository: %v", err)
+	}
+	build, err := client.RequestBuild(ctx, "acme", "api", &lib.RequestBuildRequest{ArchiveURL: "https://example.test/src.tgz"})
+	if err != nil {
+		t.Fatalf("RequestBuild: %v", err)
+	}
+	if build.Phase != "waiting" || len(build.Tags) != 1 || build.Tags[0] != "latest" {
+		t.Fatalf("unexpected build: %+v", build)
+	}
+
+	_ = srv.AppendBuildLogs("acme", "api", build.ID,
+		lib.BuildLogEntry{Type: "phase", Message: "building"},
+		lib.BuildLogEntry{Message: "Step 1/2"},
+		lib.BuildLogEntry{Message: "Step 2/2"},
+	)
+	if err := srv.UpdateBuild("acme", "api", build.ID, func(b *lib.Build) { b.Phase = "complete" }); err != nil {
+		t.Fatalf("UpdateBuild: %v", err)
+	}
+
+	logs, err := client.GetBuildLogs(ctx, "acme", "api", build.ID)
+	if err != nil {
+		t.Fatalf("GetBuildLogs: %v", err)
+	}
+	if logs.Total != 3 || len(logs.Logs) != 3 {
+		t.Errorf("unexpected logs: %+v", logs)
+	}
+	status, err := client.GetBuildStatus(ctx, "acme", "api", build.ID)
+	if err != nil {
+		t.Fatalf("GetBuildStatus: %v", err)
+	}
+	if status.Phase != "complete" {
+		t.Errorf("expected complete, got %q", status.Phase)
+	}
+	requireStatus(t, client.CancelBuild(ctx, "acme", "api", build.ID), http.StatusBadRequest)
+
+	_, err = client.RequestBuild(ctx, "acme", "api", &lib.RequestBuildRequest{})
+	requireStatus(t, err, http.StatusBadRequest)
+}
+
+func TestOrganizationSettings(t *testing.T) {
+	srv, client := newFixture(t)
+	ctx := context.Background()
+
+	_, err := client.GetQuota(ctx, "acme")
+	requireStatus(t, err, http.StatusNotFound)
+	if _, err := client.CreateQuota(ctx, "acme", 1000); err != nil {
+		t.Fatalf("CreateQuota: %v", err)
+	}
+	if _, err := client.UpdateQuota(ctx, "acme", 2000); err != nil {
+		t.Fatalf("UpdateQuota: %v", err)
+	}
+	_, _ = srv.PushManifest("acme", "api", "latest", lib.Manifest{Size: 300})
+	org, err := client.GetOrganization(ctx, "acme")
+	if err != nil {
+		t.Fatalf("GetOrganization: %v", err)
+	}
+	if org.QuotaReport == nil || org.QuotaReport.ConfiguredQuota != 2000 || org.QuotaReport.QuotaBytes != 300 {
+		t.Errorf("unexpected quota report: %+v", org.QuotaReport)
+	}
+
+	policy, err := client.CreateAutoPrunePolicy(ctx, "acme", "number_of_tags", 10, "")
+	if err != nil {
+		t.Fatalf("CreateAutoPrunePolicy: %v", err)
+	}
+	if _, err := client.UpdateAutoPrunePolicy(ctx, "acme", policy.UUID, "number_of_tags", 20, "^v"); err != nil {
+		t.Fatalf("UpdateAutoPrunePolicy: %v", err)
+	}
+	policies, err := client.GetAutoPrunePolicies(ctx, "acme")
+	if err != nil {
+		t.Fatalf("GetAutoPrunePolicies: %v", err)
+	}
+	if len(policies.Policies) != 1 || policies.Policies[0].Value != 20 || policies.Policies[0].TagPattern != "^v" {
+		t.Errorf("unexpected policies: %+v", policies.Policies)
+	}
+	_, err = client.CreateAutoPrunePolicy(ctx, "acme", "bogus", 1, "")
+	requireStatus(t, err, http.StatusBadRequest)
+
+	if err := client.DeleteOrganization(ctx, "acme"); err != nil {
+		t.Fatalf("DeleteOrganization: %v", err)
+	}
+	_, err = client.GetRepository(ctx, "acme", "api")
+	requireStatus(t, err, http.StatusNotFound)
+}
+
+func TestNotifications(t *testing.T) {
+	_, client := newFixture(t)
+	ctx := context.Background()
+
+	if _, err := client.CreateRepository(ctx, "acme", "api", "private", ""); err != nil {
+		t.Fatalf("CreateRepository: %v", err)
+	}
+	created, err := client.CreateNotification(ctx, "acme", "api", &lib.CreateNotificationRequest{
+		Event:  "repo_push",
+		Method: "webhook",
+		Config: map[string]any{"url": "https://hooks.example.test"},
+		Title:  "push hook",
+	})
+	if err != nil {
+		t.Fatalf("CreateNotification: %v", err)
+	}
+	if err := client.TestNotification(ctx, "acme", "api", created.UUID); err != nil {
+		t.Fatalf("TestNotification: %v", err)
+	}
+	list, err := client.GetNotifications(ctx, "acme", "api")
+	if err != nil {
+		t.Fatalf("GetNotifications: %v", err)
+	}
+	if len(list.Notifications) != 1 || list.Notifications[0].Title != "push hook" {
+		t.Errorf("unexpected notifications: %+v", list.Notifications)
+	}
+	if err := client.DeleteNotification(ctx, "acme", "api", created.UUID); err != nil {
+		t.Fatalf("DeleteNotification: %v", err)
+	}
+	_, err = client.GetNotification(ctx, "acme", "api", created.UUID)
+	requireStatus(t, err, http.StatusNotFound)
+}
+
+func TestListAllRepositoriesPaginates(t *testing.T) {
+	srv, client := newFixture(t)
+	for i := range 120 {
+		if _, err := srv.PushManifest("acme", fmt.Sprintf("repo-%03d", i), "", lib.Manifest{}); err != nil {
+			t.Fatalf("PushManifest: %v", err)
+		}
+	}
+
+	first, err := client.ListRepositories(context.Background(), "acme", false, false, false, 1, 100)
+	if err != nil {
+		t.Fatalf("ListRepositories: %v", err)
+	}
+	if len(first.Repositories) != 100 || !first.HasAdditional {
+		t.Errorf("expected a full first page with more, got %d (more=%v)", len(first.Repositories), first.HasAdditional)
+	}
+	all, err := client.ListAllRepositories(context.Background(), "acme", false, false, false)
+	if err != nil {
+		t.Fatalf("ListAllRepositories: %v", err)
+	}
+	if len(all) != 120 {
+		t.Errorf("expected 120 repositories, got %d", len(all))
+	}
+}
+
+func TestRequestsRequireToken(t *testing.T) {
+	srv := New()
+	t.Cleanup(srv.Close)
+
+	client, _ := lib.NewClientWithURL("", srv.URL)
+	_, err := client.GetUser(context.Background())
+	requireStatus(t, err, http.StatusUnauthorized)
+
+	user, err := srv.Client().GetUser(context.Background())
+	if err != nil {
+		t.Fatalf("GetUser: %v", err)
+	}
+	if user.Username != DefaultUsername {
+		t.Errorf("expected %q, got %q", DefaultUsername, user.Username)
+	}
+}
+
+func TestUnknownEndpoint(t *testing.T) {
+	srv := New()
+	t.Cleanup(srv.Close)
+
+	_, err := srv.Client().GetMessages(context.Background())
+	requireStatus(t, err, http.StatusNotFound)
+}
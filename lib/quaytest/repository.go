@@ -0,0 +1,860 @@
+package quaytest
+
+import (
+	"cmp"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/sebrandon1/go-quay/lib"
+)
+
+// Repositories
+
+func (s *Server) lookupRepo(w http.ResponseWriter, r *http.Request) (*repository, bool) {
+	key := repoKey(r.PathValue("namespace"), r.PathValue("repository"))
+	repo := s.repos[key]
+	if repo == nil {
+		writeNotFound(w, "repository", key)
+		return nil, false
+	}
+	return repo, true
+}
+
+// addRepository creates a repository and applies the namespace's default
+// permissions (prototypes).
+func (s *Server) addRepository(namespace, name string, public bool, description string) *repository {
+	repo := &repository{
+		info: lib.Repository{
+			Namespace:      namespace,
+			Name:           name,
+			Kind:           "image",
+			Description:    description,
+			IsPublic:       public,
+			IsOrganization: s.orgs[namespace] != nil,
+			State:          "NORMAL",
+			CanWrite:       true,
+			CanAdmin:       true,
+		},
+		manifests: map[string]*manifest{},
+		userPerms: map[string]string{},
+		teamPerms: map[string]string{},
+	}
+	if org := s.orgs[namespace]; org != nil {
+		for _, p := range org.prototypes {
+			if p.ActivatingUser != nil && p.ActivatingUser.Name != s.user {
+				continue
+			}
+			if p.Delegate.Kind == kindTeam {
+				repo.teamPerms[p.Delegate.Name] = p.Role
+			} else {
+				repo.userPerms[p.Delegate.Name] = p.Role
+			}
+		}
+	}
+	s.repos[repoKey(namespace, name)] = repo
+	return repo
+}
+
+func (s *Server) organizationRepository(repo *repository) lib.OrganizationRepository {
+	lastModified := ""
+	if n := len(repo.tags); n > 0 {
+		lastModified = repo.tags[n-1].LastModified
+	}
+	return lib.OrganizationRepository{
+		Name:         repo.info.Name,
+		Description:  repo.info.Description,
+		IsPublic:     repo.info.IsPublic,
+		Kind:         repo.info.Kind,
+		Namespace:    repo.info.Namespace,
+		LastModified: lastModified,
+		TagsCount:    len(repo.activeTags()),
+	}
+}
+
+// sortedRepos returns repositories in namespace (or all when empty) ordered by name.
+func (s *Server) sortedRepos(namespace string) []*repository {
+	var repos []*repository
+	for _, key := range slices.Sorted(maps.Keys(s.repos)) {
+		if repo := s.repos[key]; namespace == "" || repo.info.Namespace == namespace {
+			repos = append(repos, repo)
+		}
+	}
+	return repos
+}
+
+func (s *Server) createRepository(w http.ResponseWriter, r *http.Request) {
+	var req lib.CreateRepositoryRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.Repository == "" {
+		writeError(w, http.StatusBadRequest, "repository name is required")
+		return
+	}
+	if req.Visibility != "" && !validVisibility(req.Visibility) {
+		writeError(w, http.StatusBadRequest, "visibility must be public or private")
+		return
+	}
+	namespace := cmp.Or(req.Namespace, s.user)
+	if !s.namespaceExists(namespace) {
+		writeError(w, http.StatusForbidden, "Unauthorized")
+		return
+	}
+	if s.repos[repoKey(namespace, req.Repository)] != nil {
+		writeError(w, http.StatusBadRequest, "Repository already exists")
+		return
+	}
+
+	repo := s.addRepository(namespace, req.Repository, req.Visibility == visibilityPublic, req.Description)
+	writeJSON(w, http.StatusCreated, repo.info)
+}
+
+func (s *Server) listRepositories(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var all []lib.OrganizationRepository
+	for _, repo := range s.sortedRepos(q.Get("namespace")) {
+		all = append(all, s.organizationRepository(repo))
+	}
+
+	page, number, more := paginate(all, q, maxPageSize)
+	list := lib.RepositoryList{Repositories: page, HasAdditional: more}
+	if more {
+		list.NextPage = strconv.Itoa(number + 1)
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) getRepository(w http.ResponseWriter, r *http.Request) {
+	repo, ok := s.lookupRepo(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, repo.info)
+}
+
+func (s *Server) updateRepository(w http.ResponseWriter, r *http.Request) {
+	repo, ok := s.lookupRepo(w, r)
+	if !ok {
+		return
+	}
+	var req lib.UpdateRepositoryRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.Description != "" {
+		repo.info.Description = req.Description
+	}
+	switch req.Visibility {
+	case "":
+	case visibilityPublic, visibilityPrivate:
+		repo.info.IsPublic = req.Visibility == visibilityPublic
+	default:
+		writeError(w, http.StatusBadRequest, "visibility must be public or private")
+		return
+	}
+	writeJSON(w, http.StatusOK, repo.info)
+}
+
+func (s *Server) deleteRepository(w http.ResponseWriter, r *http.Request) {
+	repo, ok := s.lookupRepo(w, r)
+	if !ok {
+		return
+	}
+	delete(s.repos, repoKey(repo.info.Namespace, repo.info.Name))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) changeVisibility(w http.ResponseWriter, r *http.Request) {
+	repo, ok := s.lookupRepo(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Visibility string `json:"visibility"`
+	}
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if !validVisibility(req.Visibility) {
+		writeError(w, http.StatusBadRequest, "visibility must be public or private")
+		return
+	}
+	repo.info.IsPublic = req.Visibility == visibilityPublic
+	writeJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// Tags
+
+func (repo *repository) activeTag(name string) *lib.Tag {
+	for i := len(repo.tags) - 1; i >= 0; i-- {
+		if t := &repo.tags[i]; t.Name == name && t.EndTs == 0 {
+			return t
+		}
+	}
+	return nil
+}
+
+func (repo *repository) activeTags() []lib.Tag {
+	var active []lib.Tag
+	for _, t := range repo.tags {
+		if t.EndTs == 0 {
+			active = append(active, t)
+		}
+	}
+	return active
+}
+
+// setTag points name at digest, closing the tag's current revision.
+func (s *Server) setTag(repo *repository, name, digest string, reversion bool) lib.Tag {
+	now := s.now()
+	if current := repo.activeTag(name); current != nil {
+		current.EndTs = now.Unix()
+	}
+	m := repo.manifests[digest].info
+	tag := lib.Tag{
+		Name:           name,
+		Reversion:      reversion,
+		StartTs:        now.Unix(),
+		ManifestDigest: digest,
+		IsManifestList: m.IsManifestList,
+		Size:           m.Size,
+		LastModified:   s.timestamp(),
+	}
+	repo.tags = append(repo.tags, tag)
+	return tag
+}
+
+func (s *Server) listTags(w http.ResponseWriter, r *http.Request) {
+	repo, ok := s.lookupRepo(w, r)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	onlyActive := q.Get("onlyActiveTags") == "true"
+
+	var tags []lib.Tag
+	for i := len(repo.tags) - 1; i >= 0; i-- {
+		if t := repo.tags[i]; !onlyActive || t.EndTs == 0 {
+			tags = append(tags, t)
+		}
+	}
+
+	page, number, more := paginate(tags, q, 50)
+	writeJSON(w, http.StatusOK, lib.RepositoryTags{Tags: page, Page: number, HasAdditional: more})
+}
+
+func (s *Server) getTag(w http.ResponseWriter, r *http.Request) {
+	repo, ok := s.lookupRepo(w, r)
+	if !ok {
+		return
+	}
+	tag := repo.activeTag(r.PathValue("tag"))
+	if tag == nil {
+		writeNotFound(w, "tag", r.PathValue("tag"))
+		return
+	}
+	writeJSON(w, http.StatusOK, tag)
+}
+
+func (s *Server) putTag(w http.ResponseWriter, r *http.Request) {
+	repo, ok := s.lookupRepo(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		ManifestDigest string  `json:"manifest_digest"`
+		Expiration     *string `json:"expiration"`
+	}
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	name := r.PathValue("tag")
+
+	switch {
+	case req.ManifestDigest != "":
+		if repo.manifests[req.ManifestDigest] == nil {
+			writeNotFound(w, "manifest", req.ManifestDigest)
+			return
+		}
+		s.setTag(repo, name, req.ManifestDigest, false)
+	case req.Expiration == nil:
+		writeError(w, http.StatusBadRequest, "manifest_digest or expiration is required")
+		return
+	}
+
+	tag := repo.activeTag(name)
+	if tag == nil {
+		writeNotFound(w, "tag", name)
+		return
+	}
+	if req.Expiration != nil {
+		tag.Expiration = *req.Expiration
+	}
+	writeJSON(w, http.StatusOK, tag)
+}
+
+func (s *Server) deleteTag(w http.ResponseWriter, r *http.Request) {
+	repo, ok := s.lookupRepo(w, r)
+	if !ok {
+		return
+	}
+	tag := repo.activeTag(r.PathValue("tag"))
+	if tag == nil {
+		writeNotFound(w, "tag", r.PathValue("tag"))
+		return
+	}
+	tag.EndTs = s.now().Unix()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) tagHistory(w http.ResponseWriter, r *http.Request) {
+	repo, ok := s.lookupRepo(w, r)
+	if !ok {
+		return
+	}
+	name := r.PathValue("tag")
+	var history lib.TagHistory
+	for i := len(repo.tags) - 1; i >= 0; i-- {
+		if repo.tags[i].Name == name {
+			history.Tags = append(history.Tags, repo.tags[i])
+		}
+	}
+	if len(history.Tags) == 0 {
+		writeNotFound(w, "tag", name)
+		return
+	}
+	writeJSON(w, http.StatusOK, history)
+}
+
+// revertTag serves both revert and restore, which differ only in name.
+func (s *Server) revertTag(w http.ResponseWriter, r *http.Request) {
+	repo, ok := s.lookupRepo(w, r)
+	if !ok {
+		return
+	}
+	var req lib.RevertTagRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if repo.manifests[req.ManifestDigest] == nil {
+		writeNotFound(w, "manifest", req.ManifestDigest)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.setTag(repo, r.PathValue("tag"), req.ManifestDigest, true))
+}
+
+// Manifests
+
+func (s *Server) lookupManifest(w http.ResponseWriter, r *http.Request) (*repository, *manifest, bool) {
+	repo, ok := s.lookupRepo(w, r)
+	if !ok {
+		return nil, nil, false
+	}
+	m := repo.manifests[r.PathValue("ref")]
+	if m == nil {
+		writeNotFound(w, "manifest", r.PathValue("ref"))
+		return nil, nil, false
+	}
+	return repo, m, true
+}
+
+func (s *Server) getManifest(w http.ResponseWriter, r *http.Request) {
+	_, m, ok := s.lookupManifest(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, m.info)
+}
+
+// deleteManifest removes the manifest and expires every tag pointing at it.
+func (s *Server) deleteManifest(w http.ResponseWriter, r *http.Request) {
+	repo, m, ok := s.lookupManifest(w, r)
+	if !ok {
+		return
+	}
+	now := s.now().Unix()
+	for i := range repo.tags {
+		if t := &repo.tags[i]; t.ManifestDigest == m.info.Digest && t.EndTs == 0 {
+			t.EndTs = now
+		}
+	}
+	delete(repo.manifests, m.info.Digest)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listLabels(w http.ResponseWriter, r *http.Request) {
+	_, m, ok := s.lookupManifest(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, lib.ManifestLabels{Labels: m.labels})
+}
+
+func (s *Server) addLabel(w http.ResponseWriter, r *http.Request) {
+	_, m, ok := s.lookupManifest(w, r)
+	if !ok {
+		return
+	}
+	var req lib.AddManifestLabelRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.Key == "" {
+		writeError(w, http.StatusBadRequest, "label key is required")
+		return
+	}
+	label := lib.ManifestLabel{
+		ID:         s.nextID(),
+		Key:        req.Key,
+		Value:      req.Value,
+		SourceType: "api",
+		MediaType:  cmp.Or(req.MediaType, "text/plain"),
+	}
+	m.labels = append(m.labels, label)
+	writeJSON(w, http.StatusCreated, label)
+}
+
+func (s *Server) getLabel(w http.ResponseWriter, r *http.Request) {
+	_, m, ok := s.lookupManifest(w, r)
+	if !ok {
+		return
+	}
+	i := slices.IndexFunc(m.labels, func(l lib.ManifestLabel) bool { return l.ID == r.PathValue("id") })
+	if i < 0 {
+		writeNotFound(w, "label", r.PathValue("id"))
+		return
+	}
+	writeJSON(w, http.StatusOK, m.labels[i])
+}
+
+func (s *Server) deleteLabel(w http.ResponseWriter, r *http.Request) {
+	_, m, ok := s.lookupManifest(w, r)
+	if !ok {
+		return
+	}
+	i := slices.IndexFunc(m.labels, func(l lib.ManifestLabel) bool { return l.ID == r.PathValue("id") })
+	if i < 0 {
+		writeNotFound(w, "label", r.PathValue("id"))
+		return
+	}
+	m.labels = slices.Delete(m.labels, i, i+1)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getSecurity serves the seeded report, dropping vulnerabilities unless the
+// caller asked for them as Quay does.
+func (s *Server) getSecurity(w http.ResponseWriter, r *http.Request) {
+	_, m, ok := s.lookupManifest(w, r)
+	if !ok {
+		return
+	}
+	if m.scan == nil {
+		writeJSON(w, http.StatusOK, lib.SecurityScan{Status: "queued"})
+		return
+	}
+
+	scan := *m.scan
+	if r.URL.Query().Get("vulnerabilities") != "true" && scan.Data != nil && scan.Data.Layer != nil {
+		layer := *scan.Data.Layer
+		layer.Features = slices.Clone(layer.Features)
+		for i := range layer.Features {
+			layer.Features[i].Vulnerabilities = nil
+		}
+		scan.Data = &lib.SecurityData{Layer: &layer}
+	}
+	writeJSON(w, http.StatusOK, scan)
+}
+
+// Repository permissions
+
+func userPermission(name, role string) lib.RepositoryPermission {
+	return lib.RepositoryPermission{Name: name, Kind: kindUser, Role: role, IsRobot: isRobot(name)}
+}
+
+func teamPermission(name, role string) lib.RepositoryPermission {
+	return lib.RepositoryPermission{Name: name, Kind: kindTeam, Role: role}
+}
+
+func (repo *repository) permissions(users, teams bool) lib.RepositoryPermissions {
+	var perms lib.RepositoryPermissions
+	if users {
+		for _, name := range slices.Sorted(maps.Keys(repo.userPerms)) {
+			perms.Permissions = append(perms.Permissions, userPermission(name, repo.userPerms[name]))
+		}
+	}
+	if teams {
+		for _, name := range slices.Sorted(maps.Keys(repo.teamPerms)) {
+			perms.Permissions = append(perms.Permissions, teamPermission(name, repo.teamPerms[name]))
+		}
+	}
+	return perms
+}
+
+func decodeRole(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var req lib.SetRepositoryPermissionRequest
+	if !decodeBody(w, r, &req) {
+		return "", false
+	}
+	if !validRoles[req.Role] {
+		writeError(w, http.StatusBadRequest, "role must be read, write or admin")
+		return "", false
+	}
+	return req.Role, true
+}
+
+func (s *Server) listPermissions(w http.ResponseWriter, r *http.Request) {
+	if repo, ok := s.lookupRepo(w, r); ok {
+		writeJSON(w, http.StatusOK, repo.permissions(true, true))
+	}
+}
+
+func (s *Server) listUserPermissions(w http.ResponseWriter, r *http.Request) {
+	if repo, ok := s.lookupRepo(w, r); ok {
+		writeJSON(w, http.StatusOK, repo.permissions(true, false))
+	}
+}
+
+func (s *Server) listTeamPermissions(w http.ResponseWriter, r *http.Request) {
+	if repo, ok := s.lookupRepo(w, r); ok {
+		writeJSON(w, http.StatusOK, repo.permissions(false, true))
+	}
+}
+
+func (s *Server) getUserPermission(w http.ResponseWriter, r *http.Request) {
+	repo, ok := s.lookupRepo(w, r)
+	if !ok {
+		return
+	}
+	name := r.PathValue("user")
+	role, ok := repo.userPerms[name]
+	if !ok {
+		writeNotFound(w, "permission for user", name)
+		return
+	}
+	writeJSON(w, http.StatusOK, userPermission(name, role))
+}
+
+func (s *Server) setUserPermission(w http.ResponseWriter, r *http.Request) {
+	repo, ok := s.lookupRepo(w, r)
+	if !ok {
+		return
+	}
+	role, ok := decodeRole(w, r)
+	if !ok {
+		return
+	}
+	name := r.PathValue("user")
+	repo.userPerms[name] = role
+	writeJSON(w, http.StatusOK, userPermission(name, role))
+}
+
+func (s *Server) deleteUserPermission(w http.ResponseWriter, r *http.Request) {
+	repo, ok := s.lookupRepo(w, r)
+	if !ok {
+		return
+	}
+	name := r.PathValue("user")
+	if _, ok := repo.userPerms[name]; !ok {
+		writeNotFound(w, "permission for user", name)
+		return
+	}
+	delete(repo.userPerms, name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getTransitivePermission reports the strongest role a user holds directly or
+// through team membership.
+func (s *Server) getTransitivePermission(w http.ResponseWriter, r *http.Request) {
+	repo, ok := s.lookupRepo(w, r)
+	if !ok {
+		return
+	}
+	name := r.PathValue("user")
+	role := repo.userPerms[name]
+	if org := s.orgs[repo.info.Namespace]; org != nil {
+		for teamName, teamRole := range repo.teamPerms {
+			if t := org.teams[teamName]; t != nil && slices.Contains(t.members, name) && roleRank[teamRole] > roleRank[role] {
+				role = teamRole
+			}
+		}
+	}
+	if role == "" {
+		writeNotFound(w, "permission for user", name)
+		return
+	}
+	writeJSON(w, http.StatusOK, userPermission(name, role))
+}
+
+// lookupRepoTeam resolves the {team} path value against the repository's organization.
+func (s *Server) lookupRepoTeam(w http.ResponseWriter, repo *repository, name string) bool {
+	org := s.orgs[repo.info.Namespace]
+	if org == nil || org.teams[name] == nil {
+		writeNotFound(w, "team", name)
+		return false
+	}
+	return true
+}
+
+func (s *Server) getTeamPermission(w http.ResponseWriter, r *http.Request) {
+	repo, ok := s.lookupRepo(w, r)
+	if !ok {
+		return
+	}
+	name := r.PathValue("team")
+	role, ok := repo.teamPerms[name]
+	if !ok {
+		writeNotFound(w, "permission for team", name)
+		return
+	}
+	writeJSON(w, http.StatusOK, teamPermission(name, role))
+}
+
+func (s *Server) setTeamPermission(w http.ResponseWriter, r *http.Request) {
+	repo, ok := s.lookupRepo(w, r)
+	if !ok {
+		return
+	}
+	name := r.PathValue("team")
+	if !s.lookupRepoTeam(w, repo, name) {
+		return
+	}
+	role, ok := decodeRole(w, r)
+	if !ok {
+		return
+	}
+	repo.teamPerms[name] = role
+	writeJSON(w, http.StatusOK, teamPermission(name, role))
+}
+
+func (s *Server) deleteTeamPermission(w http.ResponseWriter, r *http.Request) {
+	repo, ok := s.lookupRepo(w, r)
+	if !ok {
+		return
+	}
+	name := r.PathValue("team")
+	if _, ok := repo.teamPerms[name]; !ok {
+		writeNotFound(w, "permission for team", name)
+		return
+	}
+	delete(repo.teamPerms, name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Notifications
+
+func (s *Server) lookupNotification(w http.ResponseWriter, r *http.Request) (*repository, int, bool) {
+	repo, ok := s.lookupRepo(w, r)
+	if !ok {
+		return nil, 0, false
+	}
+	uuid := r.PathValue("uuid")
+	i := slices.IndexFunc(repo.notifications, func(n lib.RepositoryNotification) bool { return n.UUID == uuid })
+	if i < 0 {
+		writeNotFound(w, "notification", uuid)
+		return nil, 0, false
+	}
+	return repo, i, true
+}
+
+func decodeNotification(w http.ResponseWriter, r *http.Request) (lib.CreateNotificationRequest, bool) {
+	var req lib.CreateNotificationRequest
+	if !decodeBody(w, r, &req) {
+		return req, false
+	}
+	if req.Event == "" || req.Method == "" {
+		writeError(w, http.StatusBadRequest, "event and method are required")
+		return req, false
+	}
+	return req, true
+}
+
+func (s *Server) listNotifications(w http.ResponseWriter, r *http.Request) {
+	if repo, ok := s.lookupRepo(w, r); ok {
+		writeJSON(w, http.StatusOK, lib.RepositoryNotifications{Notifications: repo.notifications})
+	}
+}
+
+func (s *Server) createNotification(w http.ResponseWriter, r *http.Request) {
+	repo, ok := s.lookupRepo(w, r)
+	if !ok {
+		return
+	}
+	req, ok := decodeNotification(w, r)
+	if !ok {
+		return
+	}
+	notification := lib.RepositoryNotification{
+		UUID:        s.nextID(),
+		Title:       req.Title,
+		Event:       req.Event,
+		Method:      req.Method,
+		Config:      req.Config,
+		EventConfig: req.EventConfig,
+	}
+	repo.notifications = append(repo.notifications, notification)
+	writeJSON(w, http.StatusCreated, notification)
+}
+
+func (s *Server) getNotification(w http.ResponseWriter, r *http.Request) {
+	if repo, i, ok := s.lookupNotification(w, r); ok {
+		writeJSON(w, http.StatusOK, repo.notifications[i])
+	}
+}
+
+func (s *Server) updateNotification(w http.ResponseWriter, r *http.Request) {
+	repo, i, ok := s.lookupNotification(w, r)
+	if !ok {
+		return
+	}
+	req, ok := decodeNotification(w, r)
+	if !ok {
+		return
+	}
+	n := &repo.notifications[i]
+	n.Title, n.Event, n.Method, n.Config, n.EventConfig = req.Title, req.Event, req.Method, req.Config, req.EventConfig
+	writeJSON(w, http.StatusOK, n)
+}
+
+func (s *Server) deleteNotification(w http.ResponseWriter, r *http.Request) {
+	if repo, i, ok := s.lookupNotification(w, r); ok {
+		repo.notifications = slices.Delete(repo.notifications, i, i+1)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *Server) testNotification(w http.ResponseWriter, r *http.Request) {
+	if _, _, ok := s.lookupNotification(w, r); ok {
+		writeJSON(w, http.StatusOK, struct{}{})
+	}
+}
+
+func (s *Server) resetNotification(w http.ResponseWriter, r *http.Request) {
+	if repo, i, ok := s.lookupNotification(w, r); ok {
+		repo.notifications[i].NumberOfFailures = 0
+		writeJSON(w, http.StatusOK, struct{}{})
+	}
+}
+
+// Builds
+
+// terminalPhases are build phases that can no longer be cancelled.
+var terminalPhases = map[string]bool{
+	"complete":      true,
+	"error":         true,
+	"internalerror": true,
+	"cancelled":     true,
+	"expired":       true,
+}
+
+func (repo *repository) findBuild(id string) *build {
+	for _, b := range repo.builds {
+		if b.info.ID == id {
+			return b
+		}
+	}
+	return nil
+}
+
+func (s *Server) lookupBuild(w http.ResponseWriter, r *http.Request) (*repository, *build, bool) {
+	repo, ok := s.lookupRepo(w, r)
+	if !ok {
+		return nil, nil, false
+	}
+	b := repo.findBuild(r.PathValue("uuid"))
+	if b == nil {
+		writeNotFound(w, "build", r.PathValue("uuid"))
+		return nil, nil, false
+	}
+	return repo, b, true
+}
+
+func (s *Server) listBuilds(w http.ResponseWriter, r *http.Request) {
+	repo, ok := s.lookupRepo(w, r)
+	if !ok {
+		return
+	}
+	var builds []lib.Build
+	for i := len(repo.builds) - 1; i >= 0; i-- {
+		builds = append(builds, repo.builds[i].info)
+	}
+	page, _, _ := paginate(builds, r.URL.Query(), 5)
+	writeJSON(w, http.StatusOK, lib.Builds{Builds: page})
+}
+
+func (s *Server) requestBuild(w http.ResponseWriter, r *http.Request) {
+	repo, ok := s.lookupRepo(w, r)
+	if !ok {
+		return
+	}
+	var req lib.RequestBuildRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.FileID == "" && req.ArchiveURL == "" {
+		writeError(w, http.StatusBadRequest, "file_id or archive_url is required")
+		return
+	}
+
+	id := s.nextID()
+	info := lib.Build{
+		ID:           id,
+		Phase:        "waiting",
+		Started:      s.timestamp(),
+		DisplayName:  id[len(id)-7:],
+		Subdirectory: req.Subdirectory,
+		Dockerfile:   req.DockerfilePath,
+		Context:      req.Context,
+		IsWriter:     true,
+		ResourceKey:  req.FileID,
+		Archive:      req.ArchiveURL,
+		Repository:   &lib.BuildRepository{Namespace: repo.info.Namespace, Name: repo.info.Name},
+		ManualUser:   s.user,
+		Tags:         req.Tags,
+	}
+	if len(info.Tags) == 0 {
+		info.Tags = []string{"latest"}
+	}
+	if req.PullRobot != "" {
+		info.Pull = &lib.BuildPullRobot{Name: req.PullRobot, Kind: kindUser, IsRobot: true}
+	}
+	repo.builds = append(repo.builds, &build{info: info})
+	writeJSON(w, http.StatusCreated, info)
+}
+
+func (s *Server) getBuild(w http.ResponseWriter, r *http.Request) {
+	if _, b, ok := s.lookupBuild(w, r); ok {
+		writeJSON(w, http.StatusOK, b.info)
+	}
+}
+
+func (s *Server) cancelBuild(w http.ResponseWriter, r *http.Request) {
+	_, b, ok := s.lookupBuild(w, r)
+	if !ok {
+		return
+	}
+	if terminalPhases[b.info.Phase] {
+		writeError(w, http.StatusBadRequest, "Build cannot be cancelled in phase "+b.info.Phase)
+		return
+	}
+	b.info.Phase = "cancelled"
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) getBuildStatus(w http.ResponseWriter, r *http.Request) {
+	if _, b, ok := s.lookupBuild(w, r); ok {
+		writeJSON(w, http.StatusOK, lib.BuildStatus{ID: b.info.ID, Phase: b.info.Phase, Error: b.info.Error})
+	}
+}
+
+// getBuildLogs honors Quay's start parameter, the index of the first entry to return.
+func (s *Server) getBuildLogs(w http.ResponseWriter, r *http.Request) {
+	_, b, ok := s.lookupBuild(w, r)
+	if !ok {
+		return
+	}
+	start, _ := strconv.Atoi(r.URL.Query().Get("start"))
+	start = min(max(start, 0), len(b.logs))
+	writeJSON(w, http.StatusOK, lib.BuildLogs{Start: start, Total: len(b.logs), Logs: b.logs[start:]})
+}
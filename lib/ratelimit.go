@@ -0,0 +1,158 @@
+/*
+Package lib provides Quay.io API client functionality.
+
+This file covers CLIENT-SIDE RATE LIMITING:
+
+Rate Limiter Setup:
+  - NewRateLimiter(cfg RateLimitConfig) *RateLimiter - Token bucket plus max-in-flight semaphore
+
+Rate Limiter Methods:
+  - (*RateLimiter).Acquire(ctx) (release func(), error) - Block until a request may start
+  - (*RateLimiter).Pause(d)                             - Hold every caller for d (Retry-After)
+
+Assign a *RateLimiter to Client.RateLimiter to pace every request the client
+makes. A single limiter may be shared by many goroutines and clients; when any
+of them receives a Retry-After header, all callers slow down together instead
+of retrying independently.
+*/
+package lib
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimitConfig controls client-side request pacing.
+type RateLimitConfig struct {
+	// RequestsPerSecond is the sustained request rate. Zero disables the token bucket.
+	RequestsPerSecond float64
+	// Burst is the number of requests that may start back-to-back. Defaults to 1.
+	Burst int
+	// MaxInFlight caps concurrent requests. Zero means unlimited.
+	MaxInFlight int
+}
+
+// RateLimiter is a token bucket combined with a concurrency semaphore.
+// It is safe for concurrent use.
+type RateLimiter struct {
+	mu          sync.Mutex
+	rate        float64
+	burst       float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+	sem         chan struct{}
+}
+
+// NewRateLimiter creates a RateLimiter from cfg. The bucket starts full.
+func NewRateLimiter(cfg RateLimitConfig) *RateLimiter {
+	burst := cfg.Burst
+	if burst < 1 {
+		burst = 1
+	}
+
+	l := &RateLimiter{
+		rate:   cfg.RequestsPerSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+	if cfg.MaxInFlight > 0 {
+		l.sem = make(chan struct{}, cfg.MaxInFlight)
+	}
+	return l
+}
+
+// Acquire blocks until a request may start, honoring the in-flight limit, the
+// token bucket and any active pause. The returned release func must be called
+// once the request has finished.
+func (l *RateLimiter) Acquire(ctx context.Context) (func(), error) {
+	if l.sem != nil {
+		select {
+		case l.sem <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	release := func() {
+		if l.sem != nil {
+			<-l.sem
+		}
+	}
+
+	wait := l.reserve()
+	if wait <= 0 {
+		return release, nil
+	}
+
+	t := time.NewTimer(wait)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		l.cancelReservation()
+		release()
+		return nil, ctx.Err()
+	case <-t.C:
+		return release, nil
+	}
+}
+
+// Pause holds every caller of Acquire for at least d from now. Shorter pauses
+// never shorten one already in effect.
+func (l *RateLimiter) Pause(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	until := time.Now().Add(d)
+	if until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
+
+// reserve takes one token (possibly going into debt) and reports how long the
+// caller must wait before starting.
+func (l *RateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	var wait time.Duration
+
+	if l.rate > 0 {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+		l.last = now
+
+		l.tokens--
+		if l.tokens < 0 {
+			wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+		}
+	}
+
+	if pause := l.pausedUntil.Sub(now); pause > wait {
+		wait = pause
+	}
+	return wait
+}
+
+func (l *RateLimiter) cancelReservation() {
+	if l.rate <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.tokens++
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+}
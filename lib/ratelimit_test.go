@@ -0,0 +1,174 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterTokenBucket(t *testing.T) {
+	l := NewRateLimiter(RateLimitConfig{RequestsPerSecond: 50, Burst: 1})
+
+	start := time.Now()
+	for range 4 {
+		release, err := l.Acquire(context.Background())
+		if err != nil {
+			t.Fatalf("Acquire returned error: %v", err)
+		}
+		release()
+	}
+
+	// First token is free, the remaining three need 20ms each.
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Expected pacing of at least 50ms, got %v", elapsed)
+	}
+}
+
+func TestRateLimiterBurst(t *testing.T) {
+	l := NewRateLimiter(RateLimitConfig{RequestsPerSecond: 1, Burst: 5})
+
+	start := time.Now()
+	for range 5 {
+		release, err := l.Acquire(context.Background())
+		if err != nil {
+			t.Fatalf("Acquire returned error: %v", err)
+		}
+		release()
+	}
+
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("Expected burst of 5 to start immediately, took %v", elapsed)
+	}
+}
+
+func TestRateLimiterPause(t *testing.T) {
+	l := NewRateLimiter(RateLimitConfig{})
+	l.Pause(60 * time.Millisecond)
+	l.Pause(time.Millisecond) // must not shorten the active pause
+
+	start := time.Now()
+	release, err := l.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Acquire returned error: %v", err)
+	}
+	release()
+
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Expected Acquire to wait for pause, returned after %v", elapsed)
+	}
+}
+
+func TestRateLimiterAcquireCanceled(t *testing.T) {
+	l := NewRateLimiter(RateLimitConfig{MaxInFlight: 1})
+
+	release, err := l.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Acquire returned error: %v", err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := l.Acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestClientRateLimiterMaxInFlight(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n := inFlight.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		inFlight.Add(-1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.RateLimiter = NewRateLimiter(RateLimitConfig{MaxInFlight: 2})
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			req, err := newRequest(context.Background(), httpMethodGet, server.URL+"/api/v1/test", nil)
+			if err != nil {
+				t.Errorf("Failed to create request: %v", err)
+				return
+			}
+			if err := client.get(req, nil); err != nil {
+				t.Errorf("get returned error: %v", err)
+			}
+		})
+	}
+	wg.Wait()
+
+	if peak.Load() > 2 {
+		t.Errorf("Expected at most 2 concurrent requests, saw %d", peak.Load())
+	}
+}
+
+func TestClientRateLimiterSharedRetryAfter(t *testing.T) {
+	var limited atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/limited" && limited.CompareAndSwap(false, true) {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error":"rate_limited"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	limiter := NewRateLimiter(RateLimitConfig{})
+	first, err := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	first.RateLimiter = limiter
+	second, err := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	second.RateLimiter = limiter
+
+	req, err := newRequest(context.Background(), httpMethodGet, server.URL+"/api/v1/limited", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	if err := first.get(req, nil); err == nil {
+		t.Fatal("Expected 429 error without retry config, got nil")
+	}
+
+	start := time.Now()
+	req, err = newRequest(context.Background(), httpMethodGet, server.URL+"/api/v1/other", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	if err := second.get(req, nil); err != nil {
+		t.Fatalf("get returned error: %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Errorf("Expected second client to honor shared Retry-After, waited only %v", elapsed)
+	}
+}
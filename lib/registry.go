@@ -0,0 +1,687 @@
+/*
+Package lib provides Quay.io API client functionality.
+
+This file covers the DOCKER REGISTRY v2 / OCI DISTRIBUTION API:
+
+Registry Setup:
+  - (*Client).Registry() *RegistryClient - Registry client sharing the API client's credentials and transport, without its overall timeout
+
+Authentication:
+  - GET  /v2/auth - Bearer token per scope, fetched with basic auth ($oauthtoken:<token>) and cached until expiry
+
+Manifests:
+  - GET  /v2/{namespace}/{repository}/manifests/{reference} - GetManifest()
+  - HEAD /v2/{namespace}/{repository}/manifests/{reference} - HeadManifest()
+  - PUT  /v2/{namespace}/{repository}/manifests/{reference} - PutManifest()
+
+Blobs:
+  - GET  /v2/{namespace}/{repository}/blobs/{digest}  - GetBlob(), DownloadBlob()
+  - HEAD /v2/{namespace}/{repository}/blobs/{digest}  - HeadBlob()
+  - POST /v2/{namespace}/{repository}/blobs/uploads/  - UploadBlob(), MountBlob()
+  - PUT  {upload location}?digest={digest}            - UploadBlob()
+
+Listing:
+  - GET  /v2/_catalog                               - Catalog(), CatalogSeq()
+  - GET  /v2/{namespace}/{repository}/tags/list     - ListTags(), TagsSeq()
+
+Requests go through the same pipeline as the REST API, so Retry, Middleware,
+RateLimiter and Telemetry apply to registry calls too. Manifest and blob
+content is verified against its digest whenever the digest is known.
+*/
+package lib
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Manifest media types understood by the registry client.
+const (
+	MediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	MediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	MediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+)
+
+// RegistryTokenUsername is the username Quay expects when an OAuth or API
+// token is used as the registry password.
+const RegistryTokenUsername = "$oauthtoken"
+
+const (
+	// maxManifestSize bounds manifest bodies, matching the OCI recommended limit.
+	maxManifestSize = 4 << 20
+	// defaultRegistryTokenTTL applies when the token response omits expires_in.
+	defaultRegistryTokenTTL = 60 * time.Second
+	// registryTokenLeeway renews tokens shortly before they expire.
+	registryTokenLeeway = 5 * time.Second
+
+	headerContentDigest = "Docker-Content-Digest"
+	headerContentType   = "Content-Type"
+	headerLocation      = "Location"
+	scopePull           = "pull"
+	scopePush           = "pull,push"
+	scopeCatalog        = "registry:catalog:*"
+)
+
+// manifestAccept lists every manifest type the client can handle, so the
+// registry returns manifests as pushed instead of converting them.
+var manifestAccept = strings.Join([]string{
+	MediaTypeOCIIndex,
+	MediaTypeOCIManifest,
+	MediaTypeDockerManifestList,
+	MediaTypeDockerManifest,
+}, ", ")
+
+// RegistryClient talks to the registry v2 API of the Quay instance behind a
+// Client. It is safe for concurrent use; reuse it to share cached tokens.
+type RegistryClient struct {
+	// BaseURL is the registry root, e.g. https://quay.io.
+	BaseURL string
+	// Service is the token service name, normally the registry host.
+	Service string
+	// Username and Password authenticate against /v2/auth. They default to
+	// RegistryTokenUsername and the client's BearerToken; set them to robot
+	// credentials to act as a robot. Empty values request anonymous tokens.
+	Username string
+	Password string
+
+	client *Client
+	mu     sync.Mutex
+	tokens map[string]registryToken
+}
+
+type registryToken struct {
+	value   string
+	expires time.Time
+}
+
+type registryTokenResponse struct {
+	Token       string `json:"token"`
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// Registry returns a registry v2 client for the Quay instance at c.BaseURL,
+// authenticated with the same token. It sends requests through a copy of c
+// whose HTTP client has no overall Timeout, since a blob can take longer to
+// transfer; cancel ctx to bound a transfer.
+func (c *Client) Registry() *RegistryClient {
+	api := *c
+	api.HTTPClient = streamingHTTPClient(c.HTTPClient)
+	base := strings.TrimSuffix(strings.TrimSuffix(c.BaseURL, "/"), "/api/v1")
+	service := base
+	if u, err := url.Parse(base); err == nil && u.Host != "" {
+		service = u.Host
+	}
+	return &RegistryClient{
+		BaseURL:  base,
+		Service:  service,
+		Username: RegistryTokenUsername,
+		Password: c.BearerToken,
+		client:   &api,
+		tokens:   make(map[string]registryToken),
+	}
+}
+
+// IsIndex reports whether the manifest is an OCI index or Docker manifest list.
+func (m *RegistryManifest) IsIndex() bool {
+	return m.MediaType == MediaTypeOCIIndex || m.MediaType == MediaTypeDockerManifestList
+}
+
+// Image decodes the manifest content.
+func (m *RegistryManifest) Image() (*ImageManifest, error) {
+	var image ImageManifest
+	if err := json.Unmarshal(m.Content, &image); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest %s: %w", m.Digest, err)
+	}
+	return &image, nil
+}
+
+// GetManifest fetches a manifest by tag or digest.
+func (r *RegistryClient) GetManifest(ctx context.Context, namespace, repository, reference string) (*RegistryManifest, error) {
+	if err := validateRegistryRef(namespace, repository, reference); err != nil {
+		return nil, err
+	}
+
+	req, err := newAPIRequest(ctx, http.MethodGet, r.buildURL("/v2/{namespace}/{repository}/manifests/{reference}", namespace, repository, reference), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create get manifest request: %w", err)
+	}
+	req.Header.Set("Accept", manifestAccept)
+
+	var manifest RegistryManifest
+	handle := responseFunc(func(resp *http.Response) error {
+		content, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestSize+1))
+		if err != nil {
+			return err
+		}
+		if len(content) > maxManifestSize {
+			return fmt.Errorf("manifest exceeds %d bytes", maxManifestSize)
+		}
+		manifest = RegistryManifest{
+			MediaType: mediaTypeOf(resp.Header.Get(headerContentType), content),
+			Digest:    resp.Header.Get(headerContentDigest),
+			Size:      int64(len(content)),
+			Content:   content,
+		}
+		return nil
+	})
+	if err := r.do(req, handle, repositoryScope(namespace, repository, scopePull), http.StatusOK); err != nil {
+		return nil, fmt.Errorf("failed to get manifest: %w", err)
+	}
+
+	expected := manifest.Digest
+	if isDigest(reference) {
+		expected = reference
+	}
+	if expected == "" {
+		manifest.Digest = digestOf(manifest.Content)
+		return &manifest, nil
+	}
+	if err := verifyDigest(expected, manifest.Content); err != nil {
+		return nil, fmt.Errorf("failed to get manifest: %w", err)
+	}
+	manifest.Digest = expected
+
+	return &manifest, nil
+}
+
+// HeadManifest returns the descriptor of a manifest without fetching it. A
+// missing manifest is reported as a *RegistryError with status 404.
+func (r *RegistryClient) HeadManifest(ctx context.Context, namespace, repository, reference string) (*RegistryDescriptor, error) {
+	if err := validateRegistryRef(namespace, repository, reference); err != nil {
+		return nil, err
+	}
+
+	req, err := newAPIRequest(ctx, http.MethodHead, r.buildURL("/v2/{namespace}/{repository}/manifests/{reference}", namespace, repository, reference), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create head manifest request: %w", err)
+	}
+	req.Header.Set("Accept", manifestAccept)
+
+	desc, err := r.head(req, repositoryScope(namespace, repository, scopePull), "MANIFEST_UNKNOWN")
+	if err != nil {
+		return nil, fmt.Errorf("failed to head manifest: %w", err)
+	}
+	return desc, nil
+}
+
+// PutManifest uploads a manifest under reference (a tag or its digest) and
+// returns its digest. Every blob and child manifest it references must already
+// exist in the repository.
+func (r *RegistryClient) PutManifest(ctx context.Context, namespace, repository, reference, mediaType string, content []byte) (string, error) {
+	if err := validateRegistryRef(namespace, repository, reference); err != nil {
+		return "", err
+	}
+	if mediaType == "" {
+		return "", fmt.Errorf("mediaType is required")
+	}
+	if isDigest(reference) {
+		if err := verifyDigest(reference, content); err != nil {
+			return "", fmt.Errorf("failed to put manifest: %w", err)
+		}
+	}
+
+	req, err := newAPIRequest(ctx, http.MethodPut, r.buildURL("/v2/{namespace}/{repository}/manifests/{reference}", namespace, repository, reference), bytes.NewReader(content))
+	if err != nil {
+		return "", fmt.Errorf("failed to create put manifest request: %w", err)
+	}
+	req.Header.Set(headerContentType, mediaType)
+
+	var digest string
+	handle := responseFunc(func(resp *http.Response) error {
+		digest = resp.Header.Get(headerContentDigest)
+		return nil
+	})
+	if err := r.do(req, handle, repositoryScope(namespace, repository, scopePush), http.StatusCreated, http.StatusOK); err != nil {
+		return "", fmt.Errorf("failed to put manifest: %w", err)
+	}
+
+	if digest == "" {
+		digest = digestOf(content)
+	}
+	return digest, nil
+}
+
+// HeadBlob returns the descriptor of a blob without fetching it. A missing
+// blob is reported as a *RegistryError with status 404.
+func (r *RegistryClient) HeadBlob(ctx context.Context, namespace, repository, digest string) (*RegistryDescriptor, error) {
+	if err := validateRegistryRef(namespace, repository, digest); err != nil {
+		return nil, err
+	}
+
+	req, err := newAPIRequest(ctx, http.MethodHead, r.buildURL("/v2/{namespace}/{repository}/blobs/{digest}", namespace, repository, digest), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create head blob request: %w", err)
+	}
+
+	desc, err := r.head(req, repositoryScope(namespace, repository, scopePull), "BLOB_UNKNOWN")
+	if err != nil {
+		return nil, fmt.Errorf("failed to head blob: %w", err)
+	}
+	if desc.Digest == "" {
+		desc.Digest = digest
+	}
+	return desc, nil
+}
+
+// GetBlob fetches a small blob, such as an image config, into memory. Use
+// DownloadBlob for layers.
+func (r *RegistryClient) GetBlob(ctx context.Context, namespace, repository, digest string) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := r.DownloadBlob(ctx, namespace, repository, digest, &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DownloadBlob streams a blob to w and returns the number of bytes written.
+// The content is verified against digest; on mismatch an error is returned
+// after w has received the data.
+func (r *RegistryClient) DownloadBlob(ctx context.Context, namespace, repository, digest string, w io.Writer) (int64, error) {
+	if err := validateRegistryRef(namespace, repository, digest); err != nil {
+		return 0, err
+	}
+	hasher, err := newDigester(digest)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := newAPIRequest(ctx, http.MethodGet, r.buildURL("/v2/{namespace}/{repository}/blobs/{digest}", namespace, repository, digest), nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create get blob request: %w", err)
+	}
+
+	var written int64
+	handle := responseFunc(func(resp *http.Response) error {
+		hasher.Reset()
+		n, copyErr := io.Copy(io.MultiWriter(w, hasher), resp.Body)
+		written += n
+		return copyErr
+	})
+	if err := r.do(req, handle, repositoryScope(namespace, repository, scopePull), http.StatusOK); err != nil {
+		return written, fmt.Errorf("failed to get blob: %w", err)
+	}
+
+	if actual := digestPrefix(digest) + hex.EncodeToString(hasher.Sum(nil)); actual != digest {
+		return written, fmt.Errorf("failed to get blob: digest mismatch: expected %s, got %s", digest, actual)
+	}
+	return written, nil
+}
+
+// UploadBlob uploads size bytes from content as a blob with the given digest,
+// using a monolithic upload. The registry rejects the upload if the content
+// does not match digest. The PUT is retried only when content is a
+// *bytes.Reader, *bytes.Buffer or *strings.Reader, which can be sent again.
+func (r *RegistryClient) UploadBlob(ctx context.Context, namespace, repository, digest string, content io.Reader, size int64) error {
+	if err := validateRegistryRef(namespace, repository, digest); err != nil {
+		return err
+	}
+
+	location, _, err := r.startUpload(ctx, namespace, repository, nil)
+	if err != nil {
+		return fmt.Errorf("failed to upload blob: %w", err)
+	}
+
+	req, err := newAPIRequest(ctx, http.MethodPut, apiURL{url: location, route: "/v2/{namespace}/{repository}/blobs/uploads/{uuid}"}, content)
+	if err != nil {
+		return fmt.Errorf("failed to create put blob request: %w", err)
+	}
+	req.ContentLength = size
+	req.Header.Set(headerContentType, "application/octet-stream")
+	q := req.URL.Query()
+	q.Set("digest", digest)
+	req.URL.RawQuery = q.Encode()
+
+	if err := r.do(req, nil, repositoryScope(namespace, repository, scopePush), http.StatusCreated, http.StatusNoContent); err != nil {
+		return fmt.Errorf("failed to upload blob: %w", err)
+	}
+	return nil
+}
+
+// MountBlob asks the registry to link an existing blob from another repository
+// on the same registry instead of uploading it. It reports false when the
+// registry declined, in which case the caller should fall back to UploadBlob.
+func (r *RegistryClient) MountBlob(ctx context.Context, namespace, repository, digest, fromNamespace, fromRepository string) (bool, error) {
+	if err := validateRegistryRef(namespace, repository, digest); err != nil {
+		return false, err
+	}
+	if fromNamespace == "" || fromRepository == "" {
+		return false, fmt.Errorf("source repository is required")
+	}
+
+	params := map[string]string{
+		"mount": digest,
+		"from":  fromNamespace + "/" + fromRepository,
+	}
+	scopes := slices.Concat(
+		repositoryScope(namespace, repository, scopePush),
+		repositoryScope(fromNamespace, fromRepository, scopePull),
+	)
+	_, status, err := r.startUpload(ctx, namespace, repository, params, scopes...)
+	if err != nil {
+		return false, fmt.Errorf("failed to mount blob: %w", err)
+	}
+	return status == http.StatusCreated, nil
+}
+
+// Catalog returns one page of up to n repository names after last. Pass
+// n <= 0 for the registry default and last "" for the first page.
+func (r *RegistryClient) Catalog(ctx context.Context, n int, last string) (*RegistryCatalog, error) {
+	req, err := newAPIRequest(ctx, http.MethodGet, r.buildURL("/v2/_catalog"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create catalog request: %w", err)
+	}
+	addPageParams(req, n, last)
+
+	var catalog RegistryCatalog
+	handle := responseFunc(func(resp *http.Response) error {
+		catalog.Next = nextLinkCursor(resp.Header.Get("Link"))
+		return decodeJSON(resp.Body, &catalog)
+	})
+	if err := r.do(req, handle, []string{scopeCatalog}, http.StatusOK); err != nil {
+		return nil, fmt.Errorf("failed to get catalog: %w", err)
+	}
+
+	return &catalog, nil
+}
+
+// ListTags returns one page of up to n tag names after last. Pass n <= 0 for
+// the registry default and last "" for the first page.
+func (r *RegistryClient) ListTags(ctx context.Context, namespace, repository string, n int, last string) (*RegistryTagList, error) {
+	if namespace == "" {
+		return nil, fmt.Errorf("namespace is required")
+	}
+	if repository == "" {
+		return nil, fmt.Errorf("repository is required")
+	}
+
+	req, err := newAPIRequest(ctx, http.MethodGet, r.buildURL("/v2/{namespace}/{repository}/tags/list", namespace, repository), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create list tags request: %w", err)
+	}
+	addPageParams(req, n, last)
+
+	var tags RegistryTagList
+	handle := responseFunc(func(resp *http.Response) error {
+		tags.Next = nextLinkCursor(resp.Header.Get("Link"))
+		return decodeJSON(resp.Body, &tags)
+	})
+	if err := r.do(req, handle, repositoryScope(namespace, repository, scopePull), http.StatusOK); err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	return &tags, nil
+}
+
+// CatalogSeq iterates over every repository in the catalog, fetching pages lazily.
+func (r *RegistryClient) CatalogSeq(ctx context.Context) iter.Seq2[string, error] {
+	return seqPages(ctx, func(ctx context.Context, cursor string) ([]string, string, error) {
+		catalog, err := r.Catalog(ctx, seqPageSize, cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		return catalog.Repositories, catalog.Next, nil
+	})
+}
+
+// TagsSeq iterates over every tag name of a repository, fetching pages lazily.
+func (r *RegistryClient) TagsSeq(ctx context.Context, namespace, repository string) iter.Seq2[string, error] {
+	return seqPages(ctx, func(ctx context.Context, cursor string) ([]string, string, error) {
+		tags, err := r.ListTags(ctx, namespace, repository, seqPageSize, cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		return tags.Tags, tags.Next, nil
+	})
+}
+
+// do authorizes req for scopes and sends it through the client pipeline.
+func (r *RegistryClient) do(req *http.Request, v any, scopes []string, acceptedStatuses ...int) error {
+	token, err := r.token(req.Context(), scopes)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return r.client.do(req, v, acceptedStatuses...)
+}
+
+// head sends a HEAD request and converts a 404 into a *RegistryError with code,
+// since HEAD responses carry no error body.
+func (r *RegistryClient) head(req *http.Request, scopes []string, code string) (*RegistryDescriptor, error) {
+	var desc RegistryDescriptor
+	handle := responseFunc(func(resp *http.Response) error {
+		if resp.StatusCode == http.StatusNotFound {
+			return &RegistryError{Status: resp.StatusCode, Errors: []RegistryErrorDetail{{Code: code}}}
+		}
+		desc = RegistryDescriptor{
+			MediaType: mediaTypeOf(resp.Header.Get(headerContentType), nil),
+			Digest:    resp.Header.Get(headerContentDigest),
+			Size:      resp.ContentLength,
+		}
+		return nil
+	})
+	if err := r.do(req, handle, scopes, http.StatusOK, http.StatusNotFound); err != nil {
+		return nil, err
+	}
+	return &desc, nil
+}
+
+// startUpload opens an upload session, or mounts a blob when params request
+// it, and returns the absolute upload location and response status.
+func (r *RegistryClient) startUpload(ctx context.Context, namespace, repository string, params map[string]string, scopes ...string) (string, int, error) {
+	req, err := newAPIRequest(ctx, http.MethodPost, r.buildURL("/v2/{namespace}/{repository}/blobs/uploads/", namespace, repository), nil)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create upload request: %w", err)
+	}
+	if params != nil {
+		addQueryParams(req, params)
+	}
+	if len(scopes) == 0 {
+		scopes = repositoryScope(namespace, repository, scopePush)
+	}
+
+	var location string
+	var status int
+	handle := responseFunc(func(resp *http.Response) error {
+		status = resp.StatusCode
+		loc, err := req.URL.Parse(resp.Header.Get(headerLocation))
+		if err != nil {
+			return fmt.Errorf("invalid upload location: %w", err)
+		}
+		location = loc.String()
+		return nil
+	})
+	if err := r.do(req, handle, scopes, http.StatusAccepted, http.StatusCreated); err != nil {
+		return "", 0, err
+	}
+	return location, status, nil
+}
+
+// token returns a bearer token for scopes, fetching one from /v2/auth when
+// none is cached.
+func (r *RegistryClient) token(ctx context.Context, scopes []string) (string, error) {
+	key := strings.Join(scopes, " ")
+	r.mu.Lock()
+	cached, ok := r.tokens[key]
+	r.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.value, nil
+	}
+
+	req, err := newAPIRequest(ctx, http.MethodGet, r.buildURL("/v2/auth"), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create registry token request: %w", err)
+	}
+	q := req.URL.Query()
+	q.Set("service", r.Service)
+	for _, scope := range scopes {
+		q.Add("scope", scope)
+	}
+	req.URL.RawQuery = q.Encode()
+	client := r.client
+	if r.Username != "" || r.Password != "" {
+		req.SetBasicAuth(r.Username, r.Password)
+	} else {
+		// An anonymous token request must not carry the API token.
+		anonymous := *r.client
+		anonymous.BearerToken = ""
+		client = &anonymous
+	}
+
+	var resp registryTokenResponse
+	if err := client.get(req, &resp); err != nil {
+		return "", fmt.Errorf("failed to get registry token: %w", err)
+	}
+
+	value := resp.Token
+	if value == "" {
+		value = resp.AccessToken
+	}
+	ttl := defaultRegistryTokenTTL
+	if resp.ExpiresIn > 0 {
+		ttl = time.Duration(resp.ExpiresIn) * time.Second
+	}
+
+	r.mu.Lock()
+	r.tokens[key] = registryToken{value: value, expires: time.Now().Add(ttl - registryTokenLeeway)}
+	r.mu.Unlock()
+
+	return value, nil
+}
+
+// repositoryScope returns the token scope granting actions on a repository.
+func repositoryScope(namespace, repository, actions string) []string {
+	return []string{"repository:" + namespace + "/" + repository + ":" + actions}
+}
+
+// buildURL fills each {placeholder} in route with the next escaped arg and
+// keeps route as the telemetry template.
+func (r *RegistryClient) buildURL(route string, args ...string) apiURL {
+	var path strings.Builder
+	rest := route
+	for _, arg := range args {
+		before, after, ok := strings.Cut(rest, "{")
+		if !ok {
+			break
+		}
+		_, after, _ = strings.Cut(after, "}")
+		path.WriteString(before)
+		path.WriteString(url.PathEscape(arg))
+		rest = after
+	}
+	path.WriteString(rest)
+	return apiURL{url: r.BaseURL + path.String(), route: route}
+}
+
+func addPageParams(req *http.Request, n int, last string) {
+	params := map[string]string{}
+	if n > 0 {
+		params["n"] = strconv.Itoa(n)
+	}
+	if last != "" {
+		params["last"] = last
+	}
+	addQueryParams(req, params)
+}
+
+// nextLinkCursor extracts the "last" parameter from an RFC 5988 Link header
+// with rel="next", or returns "" when there is no next page.
+func nextLinkCursor(link string) string {
+	for part := range strings.SplitSeq(link, ",") {
+		target, params, ok := strings.Cut(part, ";")
+		if !ok || !strings.Contains(params, `rel="next"`) {
+			continue
+		}
+		u, err := url.Parse(strings.Trim(strings.TrimSpace(target), "<>"))
+		if err != nil {
+			return ""
+		}
+		return u.Query().Get("last")
+	}
+	return ""
+}
+
+func validateRegistryRef(namespace, repository, reference string) error {
+	if namespace == "" {
+		return fmt.Errorf("namespace is required")
+	}
+	if repository == "" {
+		return fmt.Errorf("repository is required")
+	}
+	if reference == "" {
+		return fmt.Errorf("reference is required")
+	}
+	return nil
+}
+
+// mediaTypeOf returns the media type from a Content-Type header, falling back
+// to the mediaType field of a manifest body.
+func mediaTypeOf(contentType string, content []byte) string {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.TrimSpace(mediaType)
+	if len(content) == 0 || (mediaType != "" && mediaType != "application/json") {
+		return mediaType
+	}
+	var body struct {
+		MediaType string `json:"mediaType"`
+	}
+	if json.Unmarshal(content, &body) == nil && body.MediaType != "" {
+		return body.MediaType
+	}
+	return mediaType
+}
+
+func isDigest(reference string) bool {
+	return strings.Contains(reference, ":")
+}
+
+func digestOf(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func digestPrefix(digest string) string {
+	algorithm, _, _ := strings.Cut(digest, ":")
+	return algorithm + ":"
+}
+
+func newDigester(digest string) (hash.Hash, error) {
+	switch digestPrefix(digest) {
+	case "sha256:":
+		return sha256.New(), nil
+	case "sha512:":
+		return sha512.New(), nil
+	default:
+		return nil, fmt.Errorf("unsupported digest %q", digest)
+	}
+}
+
+func verifyDigest(expected string, content []byte) error {
+	hasher, err := newDigester(expected)
+	if err != nil {
+		return err
+	}
+	hasher.Write(content)
+	if actual := digestPrefix(expected) + hex.EncodeToString(hasher.Sum(nil)); actual != expected {
+		return fmt.Errorf("digest mismatch: expected %s, got %s", expected, actual)
+	}
+	return nil
+}
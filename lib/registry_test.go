@@ -0,0 +1,582 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testRegistryToken = "registry-token"
+
+// fakeRegistry is a minimal registry v2 server storing manifests and blobs per
+// repository. It also serves the REST tag endpoint used by ChangeTag.
+type fakeRegistry struct {
+	t         *testing.T
+	mu        sync.Mutex
+	repos     map[string]*fakeRegistryRepo
+	authCalls atomic.Int32
+	scopes    []string
+	uploads   int
+	// omitDigest leaves Docker-Content-Digest off manifest responses and
+	// rejectUploads fails blob uploads without reading them.
+	omitDigest    bool
+	rejectUploads bool
+}
+
+type fakeRegistryRepo struct {
+	manifests  map[string][]byte
+	mediaTypes map[string]string
+	tags       map[string]string
+	blobs      map[string][]byte
+}
+
+func newFakeRegistry(t *testing.T) (*fakeRegistry, *RegistryClient) {
+	t.Helper()
+	f, client := newFakeRegistryClient(t)
+	return f, client.Registry()
+}
+
+func newFakeRegistryClient(t *testing.T) (*fakeRegistry, *Client) {
+	t.Helper()
+	f := &fakeRegistry{t: t, repos: map[string]*fakeRegistryRepo{}}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+
+	client, err := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	return f, client
+}
+
+// repo returns the named repository, creating it if needed. Callers that run
+// concurrently with requests must hold f.mu.
+func (f *fakeRegistry) repo(namespace, repository string) *fakeRegistryRepo {
+	name := namespace + "/" + repository
+	if f.repos[name] == nil {
+		f.repos[name] = &fakeRegistryRepo{
+			manifests:  map[string][]byte{},
+			mediaTypes: map[string]string{},
+			tags:       map[string]string{},
+			blobs:      map[string][]byte{},
+		}
+	}
+	return f.repos[name]
+}
+
+// pushManifest stores content in a repository and returns its digest.
+func (f *fakeRegistry) pushManifest(namespace, repository, tag, mediaType string, content []byte) string {
+	repo := f.repo(namespace, repository)
+	digest := digestOf(content)
+	repo.manifests[digest] = content
+	repo.mediaTypes[digest] = mediaType
+	if tag != "" {
+		repo.tags[tag] = digest
+	}
+	return digest
+}
+
+func (f *fakeRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.URL.Path == "/v2/auth" {
+		f.authCalls.Add(1)
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != RegistryTokenUsername || pass != testTokenValue {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		f.scopes = r.URL.Query()["scope"]
+		fmt.Fprintf(w, `{"token": %q, "expires_in": 300}`, testRegistryToken)
+		return
+	}
+	if strings.HasPrefix(r.URL.Path, "/api/v1/repository/") {
+		f.serveChangeTag(w, r)
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer "+testRegistryToken {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"errors": [{"code": "UNAUTHORIZED", "message": "access to the requested resource is not authorized"}]}`)
+		return
+	}
+	if r.URL.Path == "/v2/_catalog" {
+		f.serveCatalog(w, r)
+		return
+	}
+
+	// /v2/{namespace}/{repository}/{kind}/{rest}
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/v2/"), "/", 4)
+	if len(parts) < 3 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	repo := f.repo(parts[0], parts[1])
+	rest := ""
+	if len(parts) == 4 {
+		rest = parts[3]
+	}
+	switch {
+	case parts[2] == "tags" && rest == "list":
+		fmt.Fprintf(w, `{"name": "%s/%s", "tags": ["latest"]}`, parts[0], parts[1])
+	case parts[2] == "manifests":
+		f.serveManifest(w, r, repo, rest)
+	case parts[2] == "blobs" && rest == "uploads/":
+		f.startUpload(w, r, repo)
+	case parts[2] == "blobs" && strings.HasPrefix(rest, "uploads/"):
+		f.finishUpload(w, r, repo)
+	case parts[2] == "blobs":
+		f.serveBlob(w, r, repo, rest)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeRegistry) serveChangeTag(w http.ResponseWriter, r *http.Request) {
+	// /api/v1/repository/{namespace}/{repository}/tag/{tag}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/v1/repository/"), "/")
+	if r.Method != http.MethodPut || len(parts) != 4 || parts[2] != "tag" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	var body struct {
+		ManifestDigest string `json:"manifest_digest"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	repo := f.repo(parts[0], parts[1])
+	if _, ok := repo.manifests[body.ManifestDigest]; !ok {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error": "manifest not found"}`)
+		return
+	}
+	repo.tags[parts[3]] = body.ManifestDigest
+	fmt.Fprint(w, `"Updated"`)
+}
+
+func (f *fakeRegistry) serveCatalog(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("last") == "" {
+		w.Header().Set("Link", `</v2/_catalog?last=a%2Fone&n=2>; rel="next"`)
+		fmt.Fprint(w, `{"repositories": ["a/one"]}`)
+		return
+	}
+	fmt.Fprint(w, `{"repositories": ["b/two", "c/three"]}`)
+}
+
+func (f *fakeRegistry) serveManifest(w http.ResponseWriter, r *http.Request, repo *fakeRegistryRepo, reference string) {
+	if r.Method == http.MethodPut {
+		content, _ := io.ReadAll(r.Body)
+		image, _ := (&RegistryManifest{Content: content}).Image()
+		if image != nil && image.Config != nil {
+			for _, desc := range append([]RegistryDescriptor{*image.Config}, image.Layers...) {
+				if _, ok := repo.blobs[desc.Digest]; !ok {
+					w.WriteHeader(http.StatusBadRequest)
+					fmt.Fprintf(w, `{"errors": [{"code": "MANIFEST_BLOB_UNKNOWN", "message": "blob unknown to registry", "detail": %q}]}`, desc.Digest)
+					return
+				}
+			}
+		}
+		tag := reference
+		if isDigest(reference) {
+			tag = ""
+		}
+		digest := digestOf(content)
+		repo.manifests[digest] = content
+		repo.mediaTypes[digest] = r.Header.Get("Content-Type")
+		if tag != "" {
+			repo.tags[tag] = digest
+		}
+		w.Header().Set(headerContentDigest, digest)
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+
+	if !strings.Contains(r.Header.Get("Accept"), MediaTypeOCIIndex) {
+		f.t.Errorf("Expected Accept to list manifest types, got %q", r.Header.Get("Accept"))
+	}
+	digest := reference
+	if tagged, ok := repo.tags[reference]; ok {
+		digest = tagged
+	}
+	content, ok := repo.manifests[digest]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		if r.Method == http.MethodGet {
+			fmt.Fprint(w, `{"errors": [{"code": "MANIFEST_UNKNOWN", "message": "manifest unknown"}]}`)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", repo.mediaTypes[digest])
+	if !f.omitDigest {
+		w.Header().Set(headerContentDigest, digest)
+	}
+	w.Header().Set("Content-Length", fmt.Sprint(len(content)))
+	if r.Method == http.MethodGet {
+		w.Write(content)
+	}
+}
+
+func (f *fakeRegistry) serveBlob(w http.ResponseWriter, r *http.Request, repo *fakeRegistryRepo, digest string) {
+	content, ok := repo.blobs[digest]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set(headerContentDigest, digest)
+	w.Header().Set("Content-Length", fmt.Sprint(len(content)))
+	if r.Method == http.MethodGet {
+		w.Write(content)
+	}
+}
+
+func (f *fakeRegistry) startUpload(w http.ResponseWriter, r *http.Request, repo *fakeRegistryRepo) {
+	if mount := r.URL.Query().Get("mount"); mount != "" {
+		if from, ok := f.repos[r.URL.Query().Get("from")]; ok && from.blobs[mount] != nil {
+			repo.blobs[mount] = from.blobs[mount]
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+	}
+	f.uploads++
+	w.Header().Set("Location", fmt.Sprintf("%s%d?_state=abc", r.URL.Path, f.uploads))
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (f *fakeRegistry) finishUpload(w http.ResponseWriter, r *http.Request, repo *fakeRegistryRepo) {
+	if r.URL.Query().Get("_state") != "abc" {
+		f.t.Errorf("Expected upload location query to be preserved, got %q", r.URL.RawQuery)
+	}
+	if f.rejectUploads {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"errors": [{"code": "BLOB_UPLOAD_INVALID", "message": "blob upload invalid"}]}`)
+		return
+	}
+	content, _ := io.ReadAll(r.Body)
+	digest := r.URL.Query().Get("digest")
+	if digestOf(content) != digest {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"errors": [{"code": "DIGEST_INVALID", "message": "provided digest did not match uploaded content"}]}`)
+		return
+	}
+	repo.blobs[digest] = content
+	w.WriteHeader(http.StatusCreated)
+}
+
+func TestRegistryURL(t *testing.T) {
+	client, _ := NewClientWithURL(testTokenValue, DefaultQuayURL)
+	registry := client.Registry()
+	if registry.BaseURL != "https://quay.io" {
+		t.Errorf("Expected registry URL https://quay.io, got %s", registry.BaseURL)
+	}
+	if registry.Service != "quay.io" {
+		t.Errorf("Expected service quay.io, got %s", registry.Service)
+	}
+}
+
+func TestRegistryManifestRoundTrip(t *testing.T) {
+	fake, registry := newFakeRegistry(t)
+	ctx := context.Background()
+	content := []byte(`{"schemaVersion": 2, "mediaType": "` + MediaTypeOCIManifest + `", "config": {"digest": "sha256:c0", "size": 2}, "layers": [{"digest": "sha256:l1", "size": 10}]}`)
+	repo := fake.repo(testNamespace, testRepository)
+	repo.blobs["sha256:c0"] = []byte("{}")
+	repo.blobs["sha256:l1"] = []byte("layer data")
+
+	digest, err := registry.PutManifest(ctx, testNamespace, testRepository, "v1", MediaTypeOCIManifest, content)
+	if err != nil {
+		t.Fatalf("PutManifest returned error: %v", err)
+	}
+	if digest != digestOf(content) {
+		t.Errorf("Expected digest %s, got %s", digestOf(content), digest)
+	}
+
+	manifest, err := registry.GetManifest(ctx, testNamespace, testRepository, "v1")
+	if err != nil {
+		t.Fatalf("GetManifest returned error: %v", err)
+	}
+	if manifest.MediaType != MediaTypeOCIManifest || manifest.Digest != digest || !bytes.Equal(manifest.Content, content) {
+		t.Errorf("Unexpected manifest %+v", manifest)
+	}
+	image, err := manifest.Image()
+	if err != nil {
+		t.Fatalf("Image returned error: %v", err)
+	}
+	if manifest.IsIndex() || image.Config.Digest != "sha256:c0" || len(image.Layers) != 1 {
+		t.Errorf("Unexpected decoded manifest %+v", image)
+	}
+
+	desc, err := registry.HeadManifest(ctx, testNamespace, testRepository, digest)
+	if err != nil {
+		t.Fatalf("HeadManifest returned error: %v", err)
+	}
+	if desc.Digest != digest || desc.Size != int64(len(content)) || desc.MediaType != MediaTypeOCIManifest {
+		t.Errorf("Unexpected descriptor %+v", desc)
+	}
+
+	if fake.authCalls.Load() != 2 {
+		t.Errorf("Expected one token per scope (2), got %d token requests", fake.authCalls.Load())
+	}
+}
+
+func TestRegistryGetManifestDigestMismatch(t *testing.T) {
+	fake, registry := newFakeRegistry(t)
+	repo := fake.repo(testNamespace, testRepository)
+	repo.manifests["sha256:0000"] = []byte(`{"schemaVersion": 2}`)
+	repo.mediaTypes["sha256:0000"] = MediaTypeDockerManifest
+
+	_, err := registry.GetManifest(context.Background(), testNamespace, testRepository, "sha256:0000")
+	if err == nil || !strings.Contains(err.Error(), "digest mismatch") {
+		t.Errorf("Expected digest mismatch error, got %v", err)
+	}
+}
+
+func TestRegistryManifestNotFound(t *testing.T) {
+	_, registry := newFakeRegistry(t)
+	ctx := context.Background()
+
+	_, err := registry.GetManifest(ctx, testNamespace, testRepository, "missing")
+	var registryErr *RegistryError
+	if !errors.As(err, &registryErr) || registryErr.Status != http.StatusNotFound || registryErr.Errors[0].Code != "MANIFEST_UNKNOWN" {
+		t.Errorf("Expected MANIFEST_UNKNOWN registry error, got %v", err)
+	}
+
+	_, err = registry.HeadManifest(ctx, testNamespace, testRepository, "missing")
+	if !errors.As(err, &registryErr) || registryErr.StatusCode() != http.StatusNotFound {
+		t.Errorf("Expected 404 registry error from HEAD, got %v", err)
+	}
+}
+
+func TestRegistryBlobRoundTrip(t *testing.T) {
+	_, registry := newFakeRegistry(t)
+	ctx := context.Background()
+	content := []byte("layer content")
+	digest := digestOf(content)
+
+	if err := registry.UploadBlob(ctx, testNamespace, testRepository, digest, bytes.NewReader(content), int64(len(content))); err != nil {
+		t.Fatalf("UploadBlob returned error: %v", err)
+	}
+
+	desc, err := registry.HeadBlob(ctx, testNamespace, testRepository, digest)
+	if err != nil {
+		t.Fatalf("HeadBlob returned error: %v", err)
+	}
+	if desc.Size != int64(len(content)) {
+		t.Errorf("Expected size %d, got %d", len(content), desc.Size)
+	}
+
+	var buf bytes.Buffer
+	n, err := registry.DownloadBlob(ctx, testNamespace, testRepository, digest, &buf)
+	if err != nil {
+		t.Fatalf("DownloadBlob returned error: %v", err)
+	}
+	if n != int64(len(content)) || buf.String() != string(content) {
+		t.Errorf("Unexpected blob %q (%d bytes)", buf.String(), n)
+	}
+}
+
+// slowReader returns one byte per read, after a delay.
+type slowReader struct {
+	data  []byte
+	delay time.Duration
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	time.Sleep(r.delay)
+	n := copy(p[:1], r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+// slowResponseWriter sends the body one flushed byte at a time, after a delay.
+type slowResponseWriter struct {
+	http.ResponseWriter
+	delay time.Duration
+}
+
+func (w *slowResponseWriter) Write(p []byte) (int, error) {
+	for i := range p {
+		time.Sleep(w.delay)
+		if _, err := w.ResponseWriter.Write(p[i : i+1]); err != nil {
+			return i, err
+		}
+		w.ResponseWriter.(http.Flusher).Flush()
+	}
+	return len(p), nil
+}
+
+// newSlowFakeRegistryClient serves blob downloads one byte per delay, to a
+// client whose HTTP timeout is only five delays.
+func newSlowFakeRegistryClient(t *testing.T, delay time.Duration) (*fakeRegistry, *Client) {
+	t.Helper()
+	f := &fakeRegistry{t: t, repos: map[string]*fakeRegistryRepo{}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && strings.Contains(r.URL.Path, "/blobs/") {
+			w = &slowResponseWriter{ResponseWriter: w, delay: delay}
+		}
+		f.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.HTTPClient.Timeout = 5 * delay
+	return f, client
+}
+
+func TestRegistryStreamsSlowBlobs(t *testing.T) {
+	const delay = 20 * time.Millisecond
+	_, client := newSlowFakeRegistryClient(t, delay)
+	registry := client.Registry()
+	ctx := context.Background()
+
+	// Both transfers take twice the client timeout.
+	content := []byte("slow layer")
+	digest := digestOf(content)
+	if err := registry.UploadBlob(ctx, testNamespace, testRepository, digest, &slowReader{data: content, delay: delay}, int64(len(content))); err != nil {
+		t.Fatalf("UploadBlob returned error: %v", err)
+	}
+	var buf bytes.Buffer
+	if _, err := registry.DownloadBlob(ctx, testNamespace, testRepository, digest, &buf); err != nil || buf.String() != string(content) {
+		t.Fatalf("Expected the slow blob to download, got %q, %v", buf.String(), err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 2*delay)
+	defer cancel()
+	if _, err := registry.DownloadBlob(ctx, testNamespace, testRepository, digest, io.Discard); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the context to bound the download, got %v", err)
+	}
+}
+
+func TestRegistryUploadBlobDigestInvalid(t *testing.T) {
+	_, registry := newFakeRegistry(t)
+	content := []byte("layer content")
+
+	err := registry.UploadBlob(context.Background(), testNamespace, testRepository, digestOf([]byte("other")), bytes.NewReader(content), int64(len(content)))
+	var registryErr *RegistryError
+	if !errors.As(err, &registryErr) || registryErr.Errors[0].Code != "DIGEST_INVALID" {
+		t.Errorf("Expected DIGEST_INVALID registry error, got %v", err)
+	}
+}
+
+func TestRegistryGetBlobDigestMismatch(t *testing.T) {
+	fake, registry := newFakeRegistry(t)
+	fake.repo(testNamespace, testRepository).blobs["sha256:0000"] = []byte("tampered")
+
+	_, err := registry.GetBlob(context.Background(), testNamespace, testRepository, "sha256:0000")
+	if err == nil || !strings.Contains(err.Error(), "digest mismatch") {
+		t.Errorf("Expected digest mismatch error, got %v", err)
+	}
+}
+
+func TestRegistryMountBlob(t *testing.T) {
+	fake, registry := newFakeRegistry(t)
+	ctx := context.Background()
+	fake.repo("other", "source").blobs["sha256:abc"] = []byte("mounted")
+
+	mounted, err := registry.MountBlob(ctx, testNamespace, testRepository, "sha256:abc", "other", "source")
+	if err != nil {
+		t.Fatalf("MountBlob returned error: %v", err)
+	}
+	if !mounted {
+		t.Error("Expected blob to be mounted")
+	}
+	if len(fake.scopes) != 2 || fake.scopes[1] != "repository:other/source:pull" {
+		t.Errorf("Expected push and source pull scopes, got %v", fake.scopes)
+	}
+
+	mounted, err = registry.MountBlob(ctx, testNamespace, testRepository, "sha256:abc", "elsewhere", "source")
+	if err != nil {
+		t.Fatalf("MountBlob returned error: %v", err)
+	}
+	if mounted {
+		t.Error("Expected mount to be declined")
+	}
+}
+
+func TestRegistryCatalogSeq(t *testing.T) {
+	_, registry := newFakeRegistry(t)
+
+	var repos []string
+	for repo, err := range registry.CatalogSeq(context.Background()) {
+		if err != nil {
+			t.Fatalf("CatalogSeq returned error: %v", err)
+		}
+		repos = append(repos, repo)
+	}
+	if fmt.Sprint(repos) != "[a/one b/two c/three]" {
+		t.Errorf("Expected repositories across Link pages, got %v", repos)
+	}
+}
+
+func TestRegistryListTags(t *testing.T) {
+	_, registry := newFakeRegistry(t)
+
+	tags, err := registry.ListTags(context.Background(), testNamespace, testRepository, 0, "")
+	if err != nil {
+		t.Fatalf("ListTags returned error: %v", err)
+	}
+	if len(tags.Tags) != 1 || tags.Tags[0] != "latest" || tags.Next != "" {
+		t.Errorf("Unexpected tag list %+v", tags)
+	}
+}
+
+func TestRegistryTokenRejected(t *testing.T) {
+	_, registry := newFakeRegistry(t)
+	registry.Password = "wrong"
+
+	_, err := registry.ListTags(context.Background(), testNamespace, testRepository, 0, "")
+	if err == nil || !strings.Contains(err.Error(), "failed to get registry token") {
+		t.Errorf("Expected token error, got %v", err)
+	}
+}
+
+func TestRegistryAnonymousToken(t *testing.T) {
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		fmt.Fprint(w, `{"token": "anonymous"}`)
+	}))
+	defer server.Close()
+	client, err := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	registry := client.Registry()
+	registry.Username, registry.Password = "", ""
+
+	token, err := registry.token(context.Background(), repositoryScope(testNamespace, testRepository, scopePull))
+	if err != nil || token != "anonymous" {
+		t.Fatalf("Expected an anonymous token, got %q, %v", token, err)
+	}
+	if authorization != "" {
+		t.Errorf("Expected no credentials on an anonymous token request, got %q", authorization)
+	}
+}
+
+func TestNextLinkCursor(t *testing.T) {
+	tests := map[string]string{
+		"": "",
+		`</v2/_catalog?last=ns%2Frepo&n=100>; rel="next"`:                                     "ns/repo",
+		`</v2/ns/repo/tags/list?n=50&last=v1.2>; rel="next"`:                                  "v1.2",
+		`<https://quay.io/v2/_catalog?last=x>; rel="prev"`:                                    "",
+		`<https://quay.io/v2/_catalog?last=x>; rel="prev", </v2/_catalog?last=y>; rel="next"`: "y",
+	}
+	for link, want := range tests {
+		if got := nextLinkCursor(link); got != want {
+			t.Errorf("nextLinkCursor(%q) = %q, want %q", link, got, want)
+		}
+	}
+}
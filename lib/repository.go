@@ -59,8 +59,7 @@ func (c *Client) GetRepository(ctx context.Context, namespace, repository string
 		return RepositoryWithTags{}, fmt.Errorf("repository is required")
 	}
 
-	repoURL := c.buildURL("/repository/%s/%s", namespace, repository)
-	req, err := newRequest(ctx, http.MethodGet, repoURL, nil)
+	req, err := newAPIRequest(ctx, http.MethodGet, c.buildURL("/repository/%s/%s", namespace, repository), nil)
 	if err != nil {
 		return RepositoryWithTags{}, fmt.Errorf("failed to create request for repository: %w", err)
 	}
@@ -93,7 +92,7 @@ func (c *Client) CreateRepository(ctx context.Context, namespace, repository, vi
 		return nil, fmt.Errorf("visibility is required")
 	}
 
-	req, err := newRequestWithBody(ctx, http.MethodPost, c.buildURL("/repository"), CreateRepositoryRequest{
+	req, err := newAPIRequestWithBody(ctx, http.MethodPost, c.buildURL("/repository"), CreateRepositoryRequest{
 		Repository:  repository,
 		Namespace:   namespace,
 		Visibility:  visibility,
@@ -130,7 +129,7 @@ func (c *Client) UpdateRepository(ctx context.Context, namespace, repository, de
 		updateReq.Visibility = visibility
 	}
 
-	req, err := newRequestWithBody(ctx, http.MethodPut, c.buildURL("/repository/%s/%s", namespace, repository), updateReq)
+	req, err := newAPIRequestWithBody(ctx, http.MethodPut, c.buildURL("/repository/%s/%s", namespace, repository), updateReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create update repository request: %w", err)
 	}
@@ -152,7 +151,7 @@ func (c *Client) DeleteRepository(ctx context.Context, namespace, repository str
 		return fmt.Errorf("repository is required")
 	}
 
-	req, err := newRequest(ctx, http.MethodDelete, c.buildURL("/repository/%s/%s", namespace, repository), nil)
+	req, err := newAPIRequest(ctx, http.MethodDelete, c.buildURL("/repository/%s/%s", namespace, repository), nil)
 	if err != nil {
 		return fmt.Errorf("failed to create delete repository request: %w", err)
 	}
@@ -166,7 +165,7 @@ func (c *Client) DeleteRepository(ctx context.Context, namespace, repository str
 
 // ListRepositories lists all repositories visible to the user
 func (c *Client) ListRepositories(ctx context.Context, namespace string, public, starred, popularity bool, page, limit int) (*RepositoryList, error) {
-	req, err := newRequest(ctx, http.MethodGet, c.buildURL("/repository"), nil)
+	req, err := newAPIRequest(ctx, http.MethodGet, c.buildURL("/repository"), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create list repositories request: %w", err)
 	}
@@ -201,56 +200,15 @@ func (c *Client) ListRepositories(ctx context.Context, namespace string, public,
 }
 
 // ListAllRepositories fetches all repositories by following pagination automatically.
+// Use RepositoriesSeq to stream large result sets instead of buffering them.
 func (c *Client) ListAllRepositories(ctx context.Context, namespace string, public, starred, popularity bool) ([]OrganizationRepository, error) {
-	var all []OrganizationRepository
-	page := 1
-	const pageSize = 100
-
-	for {
-		repos, err := c.ListRepositories(ctx, namespace, public, starred, popularity, page, pageSize)
-		if err != nil {
-			return nil, err
-		}
-
-		all = append(all, repos.Repositories...)
-
-		if !repos.HasAdditional {
-			break
-		}
-		page++
-	}
-
-	return all, nil
+	return collectSeq(c.RepositoriesSeq(ctx, namespace, public, starred, popularity))
 }
 
 // ListAllTags fetches all tags for a repository by following pagination automatically.
+// Use TagsSeq to stream large result sets instead of buffering them.
 func (c *Client) ListAllTags(ctx context.Context, namespace, repository string, onlyActive bool) ([]Tag, error) {
-	if namespace == "" {
-		return nil, fmt.Errorf("namespace is required")
-	}
-	if repository == "" {
-		return nil, fmt.Errorf("repository is required")
-	}
-
-	var all []Tag
-	page := 1
-	const pageSize = 100
-
-	for {
-		tags, err := c.ListTagsPage(ctx, namespace, repository, pageSize, page, onlyActive)
-		if err != nil {
-			return nil, err
-		}
-
-		all = append(all, tags.Tags...)
-
-		if !tags.HasAdditional {
-			break
-		}
-		page++
-	}
-
-	return all, nil
+	return collectSeq(c.TagsSeq(ctx, namespace, repository, onlyActive))
 }
 
 // ChangeRepositoryVisibility changes the visibility (public/private) of a repository
@@ -270,7 +228,7 @@ func (c *Client) ChangeRepositoryVisibility(ctx context.Context, namespace, repo
 	}{
 		Visibility: visibility,
 	}
-	req, err := newRequestWithBody(ctx, http.MethodPost, c.buildURL("/repository/%s/%s/changevisibility", namespace, repository), body)
+	req, err := newAPIRequestWithBody(ctx, http.MethodPost, c.buildURL("/repository/%s/%s/changevisibility", namespace, repository), body)
 	if err != nil {
 		return fmt.Errorf("failed to create change visibility request: %w", err)
 	}
@@ -291,7 +249,7 @@ func (c *Client) ListTagsPage(ctx context.Context, namespace, repository string,
 		return nil, fmt.Errorf("repository is required")
 	}
 
-	req, err := newRequest(ctx, http.MethodGet, c.buildURL("/repository/%s/%s/tag/", namespace, repository), nil)
+	req, err := newAPIRequest(ctx, http.MethodGet, c.buildURL("/repository/%s/%s/tag/", namespace, repository), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create list tags request: %w", err)
 	}
@@ -325,7 +283,7 @@ func (c *Client) ListTags(ctx context.Context, namespace, repository string, lim
 		return nil, fmt.Errorf("repository is required")
 	}
 
-	req, err := newRequest(ctx, http.MethodGet, c.buildURL("/repository/%s/%s/tag/", namespace, repository), nil)
+	req, err := newAPIRequest(ctx, http.MethodGet, c.buildURL("/repository/%s/%s/tag/", namespace, repository), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create list tags request: %w", err)
 	}
@@ -32,7 +32,7 @@ func (c *Client) GetRepoTokens(ctx context.Context, namespace, repository string
 		return nil, fmt.Errorf("repository is required")
 	}
 
-	req, err := newRequest(ctx, http.MethodGet, c.buildURL("/repository/%s/%s/tokens", namespace, repository), nil)
+	req, err := newAPIRequest(ctx, http.MethodGet, c.buildURL("/repository/%s/%s/tokens", namespace, repository), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create get repo tokens request: %w", err)
 	}
@@ -56,7 +56,7 @@ func (c *Client) CreateRepoToken(ctx context.Context, namespace, repository stri
 		return nil, fmt.Errorf("repository is required")
 	}
 
-	req, err := newRequestWithBody(ctx, http.MethodPost, c.buildURL("/repository/%s/%s/tokens", namespace, repository), createReq)
+	req, err := newAPIRequestWithBody(ctx, http.MethodPost, c.buildURL("/repository/%s/%s/tokens", namespace, repository), createReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create repo token request: %w", err)
 	}
@@ -83,7 +83,7 @@ func (c *Client) GetRepoToken(ctx context.Context, namespace, repository, code s
 		return nil, fmt.Errorf("code is required")
 	}
 
-	req, err := newRequest(ctx, http.MethodGet, c.buildURL("/repository/%s/%s/tokens/%s", namespace, repository, code), nil)
+	req, err := newAPIRequest(ctx, http.MethodGet, c.buildURL("/repository/%s/%s/tokens/%s", namespace, repository, code), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create get repo token request: %w", err)
 	}
@@ -110,7 +110,7 @@ func (c *Client) UpdateRepoToken(ctx context.Context, namespace, repository, cod
 		return nil, fmt.Errorf("code is required")
 	}
 
-	req, err := newRequestWithBody(ctx, http.MethodPut, c.buildURL("/repository/%s/%s/tokens/%s", namespace, repository, code), updateReq)
+	req, err := newAPIRequestWithBody(ctx, http.MethodPut, c.buildURL("/repository/%s/%s/tokens/%s", namespace, repository, code), updateReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create update repo token request: %w", err)
 	}
@@ -137,7 +137,7 @@ func (c *Client) DeleteRepoToken(ctx context.Context, namespace, repository, cod
 		return fmt.Errorf("code is required")
 	}
 
-	req, err := newRequest(ctx, http.MethodDelete, c.buildURL("/repository/%s/%s/tokens/%s", namespace, repository, code), nil)
+	req, err := newAPIRequest(ctx, http.MethodDelete, c.buildURL("/repository/%s/%s/tokens/%s", namespace, repository, code), nil)
 	if err != nil {
 		return fmt.Errorf("failed to create delete repo token request: %w", err)
 	}
@@ -0,0 +1,255 @@
+/*
+Package lib provides Quay.io API client functionality.
+
+This file covers SBOM generation from security scan data:
+
+SBOM Generation:
+  - SecurityScanSPDX(scan, info) (*SPDXDocument, error)           - SPDX 2.3 document
+  - SecurityScanCycloneDXSBOM(scan, info) (*CycloneDXBOM, error)  - CycloneDX 1.5 BOM
+  - WriteSBOM(w, format, scan, info) error                        - Encode a scan as an SBOM
+  - (*Client).WriteManifestSBOM(ctx, w, format, ns, repo, digest) error - Fetch scan data and write an SBOM
+
+The package inventory is the Features list Clair reports for a manifest. Each
+package records the layer that introduced it: SPDX documents model layers as
+packages that CONTAIN their packages, and CycloneDX components carry a
+quay:layer:addedBy property.
+*/
+package lib
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// SBOM formats accepted by WriteSBOM.
+const (
+	SBOMFormatSPDX      = "spdx"
+	SBOMFormatCycloneDX = ReportFormatCycloneDX
+)
+
+const (
+	spdxVersion     = "SPDX-2.3"
+	spdxDataLicense = "CC0-1.0"
+	spdxDocumentID  = "SPDXRef-DOCUMENT"
+	spdxImageID     = "SPDXRef-Image"
+	spdxNoAssertion = "NOASSERTION"
+
+	spdxRelationshipDescribes = "DESCRIBES"
+	spdxRelationshipContains  = "CONTAINS"
+)
+
+var spdxNameUnsafe = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+// SPDX Structures
+
+// SPDXDocument is an SPDX 2.3 JSON document.
+type SPDXDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      SPDXCreationInfo   `json:"creationInfo"`
+	Packages          []SPDXPackage      `json:"packages"`
+	Relationships     []SPDXRelationship `json:"relationships"`
+}
+
+// SPDXCreationInfo records when and by what a document was created.
+type SPDXCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+// SPDXPackage is the image, one of its layers, or a package in it.
+type SPDXPackage struct {
+	SPDXID                string            `json:"SPDXID"`
+	Name                  string            `json:"name"`
+	VersionInfo           string            `json:"versionInfo,omitempty"`
+	DownloadLocation      string            `json:"downloadLocation"`
+	FilesAnalyzed         bool              `json:"filesAnalyzed"`
+	LicenseConcluded      string            `json:"licenseConcluded,omitempty"`
+	LicenseDeclared       string            `json:"licenseDeclared,omitempty"`
+	CopyrightText         string            `json:"copyrightText,omitempty"`
+	PrimaryPackagePurpose string            `json:"primaryPackagePurpose,omitempty"`
+	Checksums             []SPDXChecksum    `json:"checksums,omitempty"`
+	ExternalRefs          []SPDXExternalRef `json:"externalRefs,omitempty"`
+	Comment               string            `json:"comment,omitempty"`
+}
+
+// SPDXChecksum is a digest of a package.
+type SPDXChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+// SPDXExternalRef identifies a package elsewhere, e.g. by purl.
+type SPDXExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+// SPDXRelationship links two SPDX elements.
+type SPDXRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// WriteSBOM converts the packages of scan to format (spdx or cyclonedx) and
+// writes the JSON document to w.
+func WriteSBOM(w io.Writer, format string, scan *SecurityScan, info ScanReportInfo) error {
+	var doc any
+	var err error
+	switch format {
+	case SBOMFormatSPDX:
+		doc, err = SecurityScanSPDX(scan, info)
+	case SBOMFormatCycloneDX:
+		doc, err = SecurityScanCycloneDXSBOM(scan, info)
+	default:
+		return fmt.Errorf("unsupported SBOM format %q (want %s or %s)", format, SBOMFormatSPDX, SBOMFormatCycloneDX)
+	}
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(doc)
+}
+
+// WriteManifestSBOM fetches the scan data of a manifest and writes its SBOM
+// to w. The image is named after the host of the client's BaseURL.
+func (c *Client) WriteManifestSBOM(ctx context.Context, w io.Writer, format, namespace, repository, digest string) error {
+	scan, err := c.GetManifestSecurity(ctx, namespace, repository, digest, false)
+	if err != nil {
+		return err
+	}
+
+	image := namespace + "/" + repository
+	if u, err := url.Parse(c.BaseURL); err == nil && u.Host != "" {
+		image = u.Host + "/" + image
+	}
+	return WriteSBOM(w, format, scan, ScanReportInfo{Image: image, Digest: digest, ToolVersion: c.Version})
+}
+
+// SecurityScanSPDX converts the packages of a completed scan into an SPDX 2.3
+// document. The document DESCRIBES the image, which CONTAINS one package per
+// layer, which in turn CONTAINS the packages that layer introduced. Packages
+// without a known layer are contained by the image directly.
+func SecurityScanSPDX(scan *SecurityScan, info ScanReportInfo) (*SPDXDocument, error) {
+	if err := requireScanned(scan); err != nil {
+		return nil, err
+	}
+
+	name := info.reference()
+	doc := &SPDXDocument{
+		SPDXVersion:       spdxVersion,
+		DataLicense:       spdxDataLicense,
+		SPDXID:            spdxDocumentID,
+		Name:              name,
+		DocumentNamespace: fmt.Sprintf("%s/spdxdocs/%s-%s", reportToolURI, spdxNameUnsafe.ReplaceAllString(info.Image, "-"), newUUID()),
+		CreationInfo: SPDXCreationInfo{
+			Created:  info.timestamp(),
+			Creators: []string{"Tool: " + strings.TrimSuffix(reportToolName+"-"+info.ToolVersion, "-")},
+		},
+		Packages:      []SPDXPackage{spdxImagePackage(info)},
+		Relationships: []SPDXRelationship{{SPDXElementID: spdxDocumentID, RelationshipType: spdxRelationshipDescribes, RelatedSPDXElement: spdxImageID}},
+	}
+
+	layers := make(map[string]string)
+	for i, feature := range scan.Data.Layer.Features {
+		parent := spdxImageID
+		if feature.AddedBy != "" {
+			parent = doc.addLayer(layers, feature.AddedBy)
+		}
+		pkg := spdxFeaturePackage(feature, fmt.Sprintf("SPDXRef-Package-%d", i+1))
+		doc.Packages = append(doc.Packages, pkg)
+		doc.Relationships = append(doc.Relationships, SPDXRelationship{SPDXElementID: parent, RelationshipType: spdxRelationshipContains, RelatedSPDXElement: pkg.SPDXID})
+	}
+	return doc, nil
+}
+
+// addLayer returns the SPDX ID of a layer package, adding it on first use.
+func (d *SPDXDocument) addLayer(layers map[string]string, digest string) string {
+	if id, ok := layers[digest]; ok {
+		return id
+	}
+	id := fmt.Sprintf("SPDXRef-Layer-%d", len(layers)+1)
+	layers[digest] = id
+	d.Packages = append(d.Packages, SPDXPackage{
+		SPDXID:           id,
+		Name:             digest,
+		DownloadLocation: spdxNoAssertion,
+		Checksums:        spdxChecksums(digest),
+		Comment:          "Image layer",
+	})
+	d.Relationships = append(d.Relationships, SPDXRelationship{SPDXElementID: spdxImageID, RelationshipType: spdxRelationshipContains, RelatedSPDXElement: id})
+	return id
+}
+
+func spdxImagePackage(info ScanReportInfo) SPDXPackage {
+	return SPDXPackage{
+		SPDXID:                spdxImageID,
+		Name:                  info.Image,
+		VersionInfo:           info.Digest,
+		DownloadLocation:      spdxNoAssertion,
+		PrimaryPackagePurpose: "CONTAINER",
+		Checksums:             spdxChecksums(info.Digest),
+	}
+}
+
+func spdxFeaturePackage(feature SecurityFeature, id string) SPDXPackage {
+	pkg := SPDXPackage{
+		SPDXID:           id,
+		Name:             feature.Name,
+		VersionInfo:      feature.Version,
+		DownloadLocation: spdxNoAssertion,
+		LicenseConcluded: spdxNoAssertion,
+		LicenseDeclared:  spdxNoAssertion,
+		CopyrightText:    spdxNoAssertion,
+		ExternalRefs:     []SPDXExternalRef{{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl", ReferenceLocator: packageURL(feature)}},
+	}
+	if feature.AddedBy != "" {
+		pkg.Comment = "Introduced by layer " + feature.AddedBy
+	}
+	return pkg
+}
+
+// spdxChecksums converts an "algorithm:hex" digest into an SPDX checksum.
+func spdxChecksums(digest string) []SPDXChecksum {
+	algorithm, value, ok := strings.Cut(digest, ":")
+	if !ok {
+		return nil
+	}
+	switch algorithm {
+	case "sha256", "sha512":
+		return []SPDXChecksum{{Algorithm: strings.ToUpper(algorithm), ChecksumValue: value}}
+	}
+	return nil
+}
+
+// SecurityScanCycloneDXSBOM converts the packages of a completed scan into a
+// CycloneDX 1.5 BOM. The image is the metadata component and depends on every
+// package; each package carries the layer that introduced it as a
+// quay:layer:addedBy property.
+func SecurityScanCycloneDXSBOM(scan *SecurityScan, info ScanReportInfo) (*CycloneDXBOM, error) {
+	if err := requireScanned(scan); err != nil {
+		return nil, err
+	}
+
+	bom := newCycloneDXBOM(info)
+	bom.Components = featureComponents(scan.Data.Layer.Features)
+
+	image := CycloneDXDependency{Ref: bom.Metadata.Component.BOMRef}
+	for _, component := range bom.Components {
+		image.DependsOn = append(image.DependsOn, component.BOMRef)
+		bom.Dependencies = append(bom.Dependencies, CycloneDXDependency{Ref: component.BOMRef})
+	}
+	bom.Dependencies = append([]CycloneDXDependency{image}, bom.Dependencies...)
+	return bom, nil
+}
@@ -0,0 +1,100 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSecurityScanSPDX(t *testing.T) {
+	scan := newTestReportScan(t)
+	scan.Data.Layer.Features[2].AddedBy = "sha256:layer2"
+
+	doc, err := SecurityScanSPDX(scan, testReportInfo)
+	if err != nil {
+		t.Fatalf("SecurityScanSPDX returned error: %v", err)
+	}
+
+	if doc.SPDXVersion != "SPDX-2.3" || doc.CreationInfo.Creators[0] != "Tool: go-quay-1.2.3" || !strings.Contains(doc.DocumentNamespace, "quay.io-testorg-testrepo-") {
+		t.Errorf("Unexpected document header: %+v", doc)
+	}
+	// Image, 2 layers and 4 packages.
+	if len(doc.Packages) != 7 {
+		t.Fatalf("Expected 7 packages, got %d", len(doc.Packages))
+	}
+	image := doc.Packages[0]
+	if image.PrimaryPackagePurpose != "CONTAINER" || image.Checksums[0].Algorithm != "SHA256" {
+		t.Errorf("Unexpected image package %+v", image)
+	}
+
+	parents := map[string]string{}
+	for _, rel := range doc.Relationships {
+		parents[rel.RelatedSPDXElement] = rel.SPDXElementID
+	}
+	ids := map[string]string{}
+	for _, pkg := range doc.Packages {
+		ids[pkg.Name] = pkg.SPDXID
+	}
+	if parents[ids["openssl"]] != ids["sha256:layer1"] || parents[ids["bash"]] != ids["sha256:layer2"] {
+		t.Errorf("Expected packages to be contained by the layer that added them, got %v", parents)
+	}
+	if parents[ids["zlib"]] != spdxImageID || parents[ids["sha256:layer1"]] != spdxImageID || parents[spdxImageID] != spdxDocumentID {
+		t.Errorf("Expected image to contain layers and zlib, got %v", parents)
+	}
+	if ref := doc.Packages[2].ExternalRefs[0]; ref.ReferenceType != "purl" || ref.ReferenceLocator != "pkg:rpm/openssl@1.1.1k?distro=rhel-8" {
+		t.Errorf("Unexpected purl reference %+v", ref)
+	}
+}
+
+func TestSecurityScanCycloneDXSBOM(t *testing.T) {
+	bom, err := SecurityScanCycloneDXSBOM(newTestReportScan(t), testReportInfo)
+	if err != nil {
+		t.Fatalf("SecurityScanCycloneDXSBOM returned error: %v", err)
+	}
+
+	if len(bom.Components) != 4 || len(bom.Vulnerabilities) != 0 {
+		t.Errorf("Expected 4 components and no vulnerabilities, got %+v", bom)
+	}
+	if props := bom.Components[0].Properties; len(props) == 0 || props[0].Name != "quay:layer:addedBy" || props[0].Value != "sha256:layer1" {
+		t.Errorf("Expected layer property, got %+v", props)
+	}
+	if root := bom.Dependencies[0]; root.Ref != bom.Metadata.Component.BOMRef || len(root.DependsOn) != 4 {
+		t.Errorf("Expected image to depend on every package, got %+v", root)
+	}
+}
+
+func TestWriteManifestSBOM(t *testing.T) {
+	scan := newTestReportScan(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Has("vulnerabilities") {
+			t.Error("Expected features without vulnerability details")
+		}
+		data, _ := json.Marshal(scan)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	client, _ := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	var buf bytes.Buffer
+	if err := client.WriteManifestSBOM(context.Background(), &buf, SBOMFormatSPDX, testNamespace, testRepository, testDigestSHA256); err != nil {
+		t.Fatalf("WriteManifestSBOM returned error: %v", err)
+	}
+
+	var doc SPDXDocument
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Failed to decode SPDX output: %v", err)
+	}
+	host := strings.TrimPrefix(server.URL, "http://")
+	if doc.Packages[0].Name != host+"/"+testNamespace+"/"+testRepository {
+		t.Errorf("Expected image named after the API host, got %q", doc.Packages[0].Name)
+	}
+
+	if err := client.WriteManifestSBOM(context.Background(), &buf, "swid", testNamespace, testRepository, testDigestSHA256); err == nil {
+		t.Error("Expected unsupported format error")
+	}
+}
@@ -0,0 +1,193 @@
+/*
+Package lib provides Quay.io API client functionality.
+
+This file covers SECURITY SCAN DIFFS between two manifests:
+
+Scan Diffs:
+  - DiffSecurityScans(from, to *SecurityScan) (*ScanDiff, error)   - Compare two completed scans
+  - (*Client).DiffImageScans(ctx, from, to ImageRef) (*ScanDiff, error) - Resolve tags, fetch both scans and compare them
+
+A vulnerability is identified by its ID and the package it affects. It is
+added if only the newer scan reports it, removed (fixed) if only the older
+scan does, changed if its severity or fixed version differ, and unchanged
+otherwise. Package changes are reported separately, keyed by package name.
+*/
+package lib
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// Package change kinds reported in PackageChange.Change.
+const (
+	PackageAdded   = "added"
+	PackageRemoved = "removed"
+	PackageUpdated = "updated"
+)
+
+// ScanDiff is the difference between two security scans.
+type ScanDiff struct {
+	From       string `json:"from,omitempty"`
+	To         string `json:"to,omitempty"`
+	FromDigest string `json:"from_digest,omitempty"`
+	ToDigest   string `json:"to_digest,omitempty"`
+	// Added vulnerabilities appear only in the newer scan.
+	Added []VulnerabilityFinding `json:"added"`
+	// Removed vulnerabilities appear only in the older scan, i.e. were fixed.
+	Removed []VulnerabilityFinding `json:"removed"`
+	// Changed vulnerabilities differ in severity or fixed version.
+	Changed   []VulnerabilityChange  `json:"changed"`
+	Unchanged []VulnerabilityFinding `json:"unchanged"`
+	Packages  []PackageChange        `json:"packages"`
+}
+
+// VulnerabilityChange is a vulnerability present in both scans whose details differ.
+type VulnerabilityChange struct {
+	From VulnerabilityFinding `json:"from"`
+	To   VulnerabilityFinding `json:"to"`
+}
+
+// PackageChange is a package added, removed or updated between two scans.
+type PackageChange struct {
+	Name        string `json:"name"`
+	Change      string `json:"change"`
+	FromVersion string `json:"from_version,omitempty"`
+	ToVersion   string `json:"to_version,omitempty"`
+}
+
+// DiffSecurityScans compares the vulnerabilities and packages of two
+// completed scans. Findings are ordered from most to least severe.
+func DiffSecurityScans(from, to *SecurityScan) (*ScanDiff, error) {
+	if err := requireScanned(from); err != nil {
+		return nil, fmt.Errorf("from: %w", err)
+	}
+	if err := requireScanned(to); err != nil {
+		return nil, fmt.Errorf("to: %w", err)
+	}
+
+	diff := &ScanDiff{
+		Added:     []VulnerabilityFinding{},
+		Removed:   []VulnerabilityFinding{},
+		Changed:   []VulnerabilityChange{},
+		Unchanged: []VulnerabilityFinding{},
+	}
+	before := findingsByKey(from)
+	after := findingsByKey(to)
+	for key, finding := range after {
+		old, ok := before[key]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, finding)
+		case old.Severity != finding.Severity || old.FixedBy != finding.FixedBy:
+			diff.Changed = append(diff.Changed, VulnerabilityChange{From: old, To: finding})
+		default:
+			diff.Unchanged = append(diff.Unchanged, finding)
+		}
+	}
+	for key, finding := range before {
+		if _, ok := after[key]; !ok {
+			diff.Removed = append(diff.Removed, finding)
+		}
+	}
+
+	sortFindings(diff.Added)
+	sortFindings(diff.Removed)
+	sortFindings(diff.Unchanged)
+	slices.SortFunc(diff.Changed, func(a, b VulnerabilityChange) int { return compareFindings(a.To, b.To) })
+	diff.Packages = diffPackages(from.Data.Layer.Features, to.Data.Layer.Features)
+	return diff, nil
+}
+
+// DiffImageScans resolves from and to to manifest digests, fetches both
+// security scans with vulnerabilities, and compares them.
+func (c *Client) DiffImageScans(ctx context.Context, from, to ImageRef) (*ScanDiff, error) {
+	var scans [2]*SecurityScan
+	var digests [2]string
+	var errs [2]error
+	var wg sync.WaitGroup
+	for i, ref := range []ImageRef{from, to} {
+		wg.Go(func() {
+			digests[i], errs[i] = c.ResolveImageDigest(ctx, ref)
+			if errs[i] == nil {
+				scans[i], errs[i] = c.GetManifestSecurity(ctx, ref.Namespace, ref.Repository, digests[i], true)
+			}
+		})
+	}
+	wg.Wait()
+	if errs[0] != nil {
+		return nil, fmt.Errorf("%s: %w", from, errs[0])
+	}
+	if errs[1] != nil {
+		return nil, fmt.Errorf("%s: %w", to, errs[1])
+	}
+
+	diff, err := DiffSecurityScans(scans[0], scans[1])
+	if err != nil {
+		return nil, err
+	}
+	diff.From, diff.To = from.String(), to.String()
+	diff.FromDigest, diff.ToDigest = digests[0], digests[1]
+	return diff, nil
+}
+
+// findingsByKey indexes findings by vulnerability ID and package name,
+// keeping the first occurrence.
+func findingsByKey(scan *SecurityScan) map[string]VulnerabilityFinding {
+	findings := make(map[string]VulnerabilityFinding)
+	for _, finding := range scan.Findings() {
+		key := finding.ID + "\x00" + finding.Package
+		if _, ok := findings[key]; !ok {
+			findings[key] = finding
+		}
+	}
+	return findings
+}
+
+func diffPackages(from, to []SecurityFeature) []PackageChange {
+	before := featureVersions(from)
+	after := featureVersions(to)
+	changes := []PackageChange{}
+	for name, version := range after {
+		old, ok := before[name]
+		switch {
+		case !ok:
+			changes = append(changes, PackageChange{Name: name, Change: PackageAdded, ToVersion: version})
+		case old != version:
+			changes = append(changes, PackageChange{Name: name, Change: PackageUpdated, FromVersion: old, ToVersion: version})
+		}
+	}
+	for name, version := range before {
+		if _, ok := after[name]; !ok {
+			changes = append(changes, PackageChange{Name: name, Change: PackageRemoved, FromVersion: version})
+		}
+	}
+	slices.SortFunc(changes, func(a, b PackageChange) int { return cmp.Compare(a.Name, b.Name) })
+	return changes
+}
+
+func featureVersions(features []SecurityFeature) map[string]string {
+	versions := make(map[string]string, len(features))
+	for _, feature := range features {
+		if _, ok := versions[feature.Name]; !ok {
+			versions[feature.Name] = feature.Version
+		}
+	}
+	return versions
+}
+
+func sortFindings(findings []VulnerabilityFinding) {
+	slices.SortFunc(findings, compareFindings)
+}
+
+// compareFindings orders by descending severity, then ID and package.
+func compareFindings(a, b VulnerabilityFinding) int {
+	return cmp.Or(
+		cmp.Compare(SeverityRank(b.Severity), SeverityRank(a.Severity)),
+		cmp.Compare(a.ID, b.ID),
+		cmp.Compare(a.Package, b.Package),
+	)
+}
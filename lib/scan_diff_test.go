@@ -0,0 +1,116 @@
+package lib
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDiffSecurityScans(t *testing.T) {
+	from := newTestScan(t,
+		"openssl@1.1.1k:CVE-2021-3712:High:1.1.1l",
+		"openssl@1.1.1k:CVE-2021-3711:Critical:1.1.1l",
+		"bash@5.0:CVE-2019-18276:Low",
+		"zlib@1.2.11:CVE-2018-25032:High",
+	)
+	to := newTestScan(t,
+		"openssl@1.1.1l:CVE-2023-0286:High:1.1.1t",
+		"bash@5.0:CVE-2019-18276:Low",
+		"zlib@1.2.11:CVE-2018-25032:Medium:1.2.12",
+		"curl@7.61.1:CVE-2023-38545:Critical:7.61.1-34",
+	)
+
+	diff, err := DiffSecurityScans(from, to)
+	if err != nil {
+		t.Fatalf("DiffSecurityScans returned error: %v", err)
+	}
+
+	if len(diff.Added) != 2 || diff.Added[0].ID != "CVE-2023-38545" || diff.Added[1].ID != "CVE-2023-0286" {
+		t.Errorf("Expected added findings ordered Critical first, got %+v", diff.Added)
+	}
+	if len(diff.Removed) != 2 || diff.Removed[0].ID != "CVE-2021-3711" {
+		t.Errorf("Expected both openssl 1.1.1k CVEs removed, got %+v", diff.Removed)
+	}
+	if len(diff.Changed) != 1 || diff.Changed[0].From.Severity != SeverityHigh || diff.Changed[0].To.FixedBy != "1.2.12" {
+		t.Errorf("Expected zlib CVE to change severity and gain a fix, got %+v", diff.Changed)
+	}
+	if len(diff.Unchanged) != 1 || diff.Unchanged[0].Package != "bash" {
+		t.Errorf("Expected bash CVE unchanged, got %+v", diff.Unchanged)
+	}
+
+	want := []PackageChange{
+		{Name: "curl", Change: PackageAdded, ToVersion: "7.61.1"},
+		{Name: "openssl", Change: PackageUpdated, FromVersion: "1.1.1k", ToVersion: "1.1.1l"},
+	}
+	if len(diff.Packages) != len(want) || diff.Packages[0] != want[0] || diff.Packages[1] != want[1] {
+		t.Errorf("Expected package changes %+v, got %+v", want, diff.Packages)
+	}
+}
+
+func TestDiffSecurityScansIncomplete(t *testing.T) {
+	_, err := DiffSecurityScans(newTestScan(t), &SecurityScan{Status: ScanStatusQueued})
+	if err == nil || !strings.HasPrefix(err.Error(), "to:") {
+		t.Errorf("Expected error for the incomplete newer scan, got %v", err)
+	}
+}
+
+func TestDiffImageScans(t *testing.T) {
+	scans := map[string]*SecurityScan{
+		"sha256:old": newTestScan(t, "openssl@1.1.1k:CVE-2021-3712:High:1.1.1l"),
+		"sha256:new": newTestScan(t, "openssl@1.1.1l:CVE-2023-0286:High"),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/tag/1.2"):
+			w.Write([]byte(`{"name": "1.2", "manifest_digest": "sha256:old"}`))
+		case strings.HasSuffix(r.URL.Path, "/security"):
+			digest := strings.Split(r.URL.Path, "/")[7]
+			data, _ := json.Marshal(scans[digest])
+			w.Write(data)
+		default:
+			t.Errorf("Unexpected request %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, _ := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	diff, err := client.DiffImageScans(context.Background(),
+		ImageRef{Namespace: testNamespace, Repository: testRepository, Reference: "1.2"},
+		ImageRef{Namespace: testNamespace, Repository: testRepository, Reference: "sha256:new"})
+	if err != nil {
+		t.Fatalf("DiffImageScans returned error: %v", err)
+	}
+	if diff.FromDigest != "sha256:old" || diff.To != "testorg/testrepo@sha256:new" {
+		t.Errorf("Unexpected diff header %+v", diff)
+	}
+	if len(diff.Added) != 1 || len(diff.Removed) != 1 {
+		t.Errorf("Expected one added and one removed CVE, got %+v", diff)
+	}
+}
+
+func TestResolveImageDigest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/tag/latest") {
+			t.Errorf("Expected latest tag lookup, got %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name": "latest", "manifest_digest": "` + testDigestSHA256 + `"}`))
+	}))
+	defer server.Close()
+
+	client, _ := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	digest, err := client.ResolveImageDigest(context.Background(), ImageRef{Namespace: testNamespace, Repository: testRepository})
+	if err != nil || digest != testDigestSHA256 {
+		t.Errorf("Expected %s, got %q (%v)", testDigestSHA256, digest, err)
+	}
+
+	digest, err = client.ResolveImageDigest(context.Background(), ImageRef{Namespace: testNamespace, Repository: testRepository, Reference: "sha256:abc"})
+	if err != nil || digest != "sha256:abc" {
+		t.Errorf("Expected digest to be returned as-is, got %q (%v)", digest, err)
+	}
+}
@@ -0,0 +1,300 @@
+/*
+Package lib provides Quay.io API client functionality.
+
+This file covers VULNERABILITY POLICY evaluation:
+
+Policy Evaluation:
+  - (*VulnerabilityPolicy).Validate() error                                 - Check severities, counts and expiry dates
+  - (*VulnerabilityPolicy).Evaluate(scan, now) (*PolicyResult, error)       - Decide pass/fail for a scan
+  - (*Client).CheckManifestPolicy(ctx, ns, repo, ref, policy) (*PolicyResult, error) - Fetch a scan and evaluate it
+
+A policy first drops findings matched by ignore_packages or fixable_only,
+then those covered by unexpired allowlist entries. The remaining findings are
+checked against max_severity and the per-severity max_counts. Expired
+allowlist entries no longer suppress anything and are reported so they can
+be renewed or removed.
+*/
+package lib
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Policy rules reported in PolicyViolation.Rule.
+const (
+	PolicyRuleMaxSeverity = "max_severity"
+	PolicyRuleMaxCount    = "max_count"
+)
+
+// VulnerabilityPolicy describes which vulnerabilities an image may ship with.
+// The zero value allows everything.
+type VulnerabilityPolicy struct {
+	// MaxSeverity is the highest severity allowed, e.g. "Medium". Findings
+	// above it are violations. Empty allows every severity.
+	MaxSeverity string `json:"max_severity,omitempty" yaml:"max_severity,omitempty"`
+	// MaxCounts caps the number of findings per severity, e.g. {"High": 5}.
+	MaxCounts map[string]int `json:"max_counts,omitempty" yaml:"max_counts,omitempty"`
+	// FixableOnly ignores findings that have no fixed version yet.
+	FixableOnly bool `json:"fixable_only,omitempty" yaml:"fixable_only,omitempty"`
+	// Allowlist accepts specific vulnerabilities, optionally until a date.
+	Allowlist []PolicyAllowlistEntry `json:"allowlist,omitempty" yaml:"allowlist,omitempty"`
+	// IgnorePackages drops every finding in matching packages.
+	IgnorePackages []PolicyPackageRule `json:"ignore_packages,omitempty" yaml:"ignore_packages,omitempty"`
+}
+
+// PolicyAllowlistEntry accepts a vulnerability by ID.
+type PolicyAllowlistEntry struct {
+	// ID is the vulnerability name, e.g. CVE-2024-1234 (case-insensitive).
+	ID string `json:"id" yaml:"id"`
+	// Package limits the entry to packages matching this glob. Empty matches all.
+	Package string `json:"package,omitempty" yaml:"package,omitempty"`
+	// Expires is the last day (YYYY-MM-DD, UTC) the entry applies. Empty never expires.
+	Expires string `json:"expires,omitempty" yaml:"expires,omitempty"`
+	Reason  string `json:"reason,omitempty" yaml:"reason,omitempty"`
+}
+
+// PolicyPackageRule ignores findings in packages matching Name and, if set,
+// Version. Both are path.Match globs.
+type PolicyPackageRule struct {
+	Name    string `json:"name" yaml:"name"`
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+	Reason  string `json:"reason,omitempty" yaml:"reason,omitempty"`
+}
+
+// PolicyResult is the outcome of evaluating a policy against a scan.
+type PolicyResult struct {
+	Passed bool `json:"passed"`
+	// Counts holds the number of evaluated findings per severity, after
+	// ignore rules and the allowlist.
+	Counts           map[string]int         `json:"counts"`
+	Violations       []PolicyViolation      `json:"violations,omitempty"`
+	Allowed          []VulnerabilityFinding `json:"allowed,omitempty"`
+	Ignored          []VulnerabilityFinding `json:"ignored,omitempty"`
+	ExpiredAllowlist []PolicyAllowlistEntry `json:"expired_allowlist,omitempty"`
+}
+
+// PolicyViolation is one failed policy rule.
+type PolicyViolation struct {
+	Rule     string                 `json:"rule"`
+	Severity string                 `json:"severity"`
+	Message  string                 `json:"message"`
+	Findings []VulnerabilityFinding `json:"findings,omitempty"`
+}
+
+// Validate reports malformed or duplicated severities, negative counts,
+// invalid globs and unparsable expiry dates.
+func (p *VulnerabilityPolicy) Validate() error {
+	if p.MaxSeverity != "" && !isKnownSeverity(p.MaxSeverity) {
+		return fmt.Errorf("max_severity: unknown severity %q", p.MaxSeverity)
+	}
+	seen := map[string]string{}
+	for _, severity := range slices.Sorted(maps.Keys(p.MaxCounts)) {
+		if !isKnownSeverity(severity) {
+			return fmt.Errorf("max_counts: unknown severity %q", severity)
+		}
+		if p.MaxCounts[severity] < 0 {
+			return fmt.Errorf("max_counts: %s must not be negative", severity)
+		}
+		normalized := NormalizeSeverity(severity)
+		if other, ok := seen[normalized]; ok {
+			return fmt.Errorf("max_counts: %q and %q are the same severity", other, severity)
+		}
+		seen[normalized] = severity
+	}
+	for i, entry := range p.Allowlist {
+		if err := entry.validate(); err != nil {
+			return fmt.Errorf("allowlist[%d]: %w", i, err)
+		}
+	}
+	for i, rule := range p.IgnorePackages {
+		if rule.Name == "" {
+			return fmt.Errorf("ignore_packages[%d]: name is required", i)
+		}
+		if _, err := path.Match(rule.Name, ""); err != nil {
+			return fmt.Errorf("ignore_packages[%d]: invalid name pattern %q", i, rule.Name)
+		}
+		if _, err := path.Match(rule.Version, ""); err != nil {
+			return fmt.Errorf("ignore_packages[%d]: invalid version pattern %q", i, rule.Version)
+		}
+	}
+	return nil
+}
+
+func (e PolicyAllowlistEntry) validate() error {
+	if e.ID == "" {
+		return fmt.Errorf("id is required")
+	}
+	if _, err := path.Match(e.Package, ""); err != nil {
+		return fmt.Errorf("invalid package pattern %q", e.Package)
+	}
+	if e.Expires != "" {
+		if _, err := time.Parse(time.DateOnly, e.Expires); err != nil {
+			return fmt.Errorf("expires must be YYYY-MM-DD: %w", err)
+		}
+	}
+	return nil
+}
+
+// Evaluate applies the policy to a completed scan as of now. It returns an
+// error if the policy is invalid or the scan has not finished.
+func (p *VulnerabilityPolicy) Evaluate(scan *SecurityScan, now time.Time) (*PolicyResult, error) {
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy: %w", err)
+	}
+	if scan == nil {
+		return nil, fmt.Errorf("security scan is required")
+	}
+	if scan.Status != ScanStatusScanned {
+		return nil, fmt.Errorf("security scan is not complete (status %q)", scan.Status)
+	}
+
+	result := &PolicyResult{Counts: make(map[string]int)}
+	expired := make(map[int]bool)
+	bySeverity := make(map[string][]VulnerabilityFinding)
+
+	for _, finding := range scan.Findings() {
+		if p.ignores(finding) {
+			result.Ignored = append(result.Ignored, finding)
+			continue
+		}
+		allowed, expiredIndex := p.allows(finding, now)
+		if expiredIndex >= 0 {
+			expired[expiredIndex] = true
+		}
+		if allowed {
+			result.Allowed = append(result.Allowed, finding)
+			continue
+		}
+		result.Counts[finding.Severity]++
+		bySeverity[finding.Severity] = append(bySeverity[finding.Severity], finding)
+	}
+
+	for i, entry := range p.Allowlist {
+		if expired[i] {
+			result.ExpiredAllowlist = append(result.ExpiredAllowlist, entry)
+		}
+	}
+
+	result.Violations = p.violations(bySeverity)
+	result.Passed = len(result.Violations) == 0
+	return result, nil
+}
+
+// violations checks grouped findings against max_severity and max_counts,
+// listing the highest severities first.
+func (p *VulnerabilityPolicy) violations(bySeverity map[string][]VulnerabilityFinding) []PolicyViolation {
+	var violations []PolicyViolation
+	for i := len(Severities) - 1; i >= 0; i-- {
+		severity := Severities[i]
+		findings := bySeverity[severity]
+		if len(findings) == 0 {
+			continue
+		}
+		if p.MaxSeverity != "" && i > SeverityRank(p.MaxSeverity) {
+			violations = append(violations, PolicyViolation{
+				Rule:     PolicyRuleMaxSeverity,
+				Severity: severity,
+				Message:  fmt.Sprintf("%d %s finding(s) exceed max severity %s", len(findings), severity, NormalizeSeverity(p.MaxSeverity)),
+				Findings: findings,
+			})
+		}
+		if limit, ok := p.maxCount(severity); ok && len(findings) > limit {
+			violations = append(violations, PolicyViolation{
+				Rule:     PolicyRuleMaxCount,
+				Severity: severity,
+				Message:  fmt.Sprintf("%d %s finding(s) exceed the limit of %d", len(findings), severity, limit),
+				Findings: findings,
+			})
+		}
+	}
+	return violations
+}
+
+// CheckManifestPolicy fetches the security scan of a manifest, including
+// vulnerabilities, and evaluates policy against it.
+func (c *Client) CheckManifestPolicy(ctx context.Context, namespace, repository, manifestRef string, policy *VulnerabilityPolicy) (*PolicyResult, error) {
+	if policy == nil {
+		return nil, fmt.Errorf("policy is required")
+	}
+
+	scan, err := c.GetManifestSecurity(ctx, namespace, repository, manifestRef, true)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := policy.Evaluate(scan, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate policy for %s/%s@%s: %w", namespace, repository, manifestRef, err)
+	}
+	return result, nil
+}
+
+func (p *VulnerabilityPolicy) ignores(finding VulnerabilityFinding) bool {
+	if p.FixableOnly && finding.FixedBy == "" {
+		return true
+	}
+	for _, rule := range p.IgnorePackages {
+		if globMatch(rule.Name, finding.Package) && (rule.Version == "" || globMatch(rule.Version, finding.Version)) {
+			return true
+		}
+	}
+	return false
+}
+
+// allows reports whether an unexpired allowlist entry covers finding, and the
+// index of the first matching entry that has expired, or -1.
+func (p *VulnerabilityPolicy) allows(finding VulnerabilityFinding, now time.Time) (bool, int) {
+	expiredIndex := -1
+	for i, entry := range p.Allowlist {
+		if !strings.EqualFold(entry.ID, finding.ID) {
+			continue
+		}
+		if entry.Package != "" && !globMatch(entry.Package, finding.Package) {
+			continue
+		}
+		if allowlistExpired(entry, now) {
+			if expiredIndex < 0 {
+				expiredIndex = i
+			}
+			continue
+		}
+		return true, -1
+	}
+	return false, expiredIndex
+}
+
+func (p *VulnerabilityPolicy) maxCount(severity string) (int, bool) {
+	for key, limit := range p.MaxCounts {
+		if NormalizeSeverity(key) == severity {
+			return limit, true
+		}
+	}
+	return 0, false
+}
+
+// allowlistExpired reports whether now is after the end of the entry's expiry day.
+func allowlistExpired(entry PolicyAllowlistEntry, now time.Time) bool {
+	if entry.Expires == "" {
+		return false
+	}
+	expires, err := time.Parse(time.DateOnly, entry.Expires)
+	if err != nil {
+		return true
+	}
+	return !now.UTC().Before(expires.AddDate(0, 0, 1))
+}
+
+func isKnownSeverity(severity string) bool {
+	return NormalizeSeverity(severity) != SeverityUnknown || strings.EqualFold(severity, SeverityUnknown)
+}
+
+func globMatch(pattern, name string) bool {
+	matched, err := path.Match(pattern, name)
+	return err == nil && matched
+}
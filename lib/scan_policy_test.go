@@ -0,0 +1,208 @@
+package lib
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestScan builds a completed scan with one feature per package. Each
+// vuln is "package@version:ID:Severity[:FixedBy]".
+func newTestScan(t *testing.T, vulns ...string) *SecurityScan {
+	t.Helper()
+	features := map[string]*SecurityFeature{}
+	var order []string
+	for _, v := range vulns {
+		parts := strings.Split(v, ":")
+		if len(parts) < 3 {
+			t.Fatalf("invalid test vulnerability %q", v)
+		}
+		pkg, version, _ := strings.Cut(parts[0], "@")
+		if features[parts[0]] == nil {
+			features[parts[0]] = &SecurityFeature{Name: pkg, Version: version}
+			order = append(order, parts[0])
+		}
+		vuln := SecurityVulnerability{Name: parts[1], Severity: parts[2]}
+		if len(parts) > 3 {
+			vuln.FixedBy = parts[3]
+		}
+		features[parts[0]].Vulnerabilities = append(features[parts[0]].Vulnerabilities, vuln)
+	}
+
+	layer := &SecurityLayer{Name: testDigestSHA256}
+	for _, key := range order {
+		layer.Features = append(layer.Features, *features[key])
+	}
+	return &SecurityScan{Status: ScanStatusScanned, Data: &SecurityData{Layer: layer}}
+}
+
+func TestEvaluateMaxSeverity(t *testing.T) {
+	scan := newTestScan(t,
+		"openssl@1.1.1k:CVE-2021-3712:High:1.1.1l",
+		"zlib@1.2.11:CVE-2018-25032:Critical",
+		"bash@5.0:CVE-2019-18276:Low",
+	)
+	policy := &VulnerabilityPolicy{MaxSeverity: "medium"}
+
+	result, err := policy.Evaluate(scan, time.Now())
+	if err != nil {
+		t.Fatalf("Evaluate returned error: %v", err)
+	}
+	if result.Passed {
+		t.Fatal("Expected policy to fail")
+	}
+	if len(result.Violations) != 2 || result.Violations[0].Severity != SeverityCritical || result.Violations[1].Severity != SeverityHigh {
+		t.Errorf("Expected Critical then High violations, got %+v", result.Violations)
+	}
+	if result.Counts[SeverityLow] != 1 {
+		t.Errorf("Expected Low finding to be counted, got %v", result.Counts)
+	}
+}
+
+func TestEvaluateMaxCounts(t *testing.T) {
+	scan := newTestScan(t,
+		"openssl@1.1.1k:CVE-2021-3712:High:1.1.1l",
+		"openssl@1.1.1k:CVE-2021-3711:High:1.1.1l",
+		"bash@5.0:CVE-2019-18276:Medium",
+	)
+	policy := &VulnerabilityPolicy{MaxCounts: map[string]int{"High": 1, "medium": 5}}
+
+	result, err := policy.Evaluate(scan, time.Now())
+	if err != nil {
+		t.Fatalf("Evaluate returned error: %v", err)
+	}
+	if result.Passed || len(result.Violations) != 1 {
+		t.Fatalf("Expected one violation, got %+v", result)
+	}
+	violation := result.Violations[0]
+	if violation.Rule != PolicyRuleMaxCount || len(violation.Findings) != 2 || !strings.Contains(violation.Message, "limit of 1") {
+		t.Errorf("Unexpected violation %+v", violation)
+	}
+}
+
+func TestEvaluateFixableOnlyAndIgnoredPackages(t *testing.T) {
+	scan := newTestScan(t,
+		"openssl@1.1.1k:CVE-2021-3712:Critical",
+		"kernel-headers@4.18:CVE-2022-0001:Critical:4.19",
+		"curl@7.61.1:CVE-2023-38545:High:7.61.1-34",
+	)
+	policy := &VulnerabilityPolicy{
+		MaxSeverity:    SeverityMedium,
+		FixableOnly:    true,
+		IgnorePackages: []PolicyPackageRule{{Name: "kernel-*"}, {Name: "curl", Version: "8.*"}},
+	}
+
+	result, err := policy.Evaluate(scan, time.Now())
+	if err != nil {
+		t.Fatalf("Evaluate returned error: %v", err)
+	}
+	if len(result.Ignored) != 2 {
+		t.Errorf("Expected unfixable openssl and kernel-headers to be ignored, got %+v", result.Ignored)
+	}
+	if result.Passed || len(result.Violations) != 1 || result.Violations[0].Findings[0].Package != "curl" {
+		t.Errorf("Expected curl to violate (version rule does not match), got %+v", result.Violations)
+	}
+}
+
+func TestEvaluateAllowlistExpiry(t *testing.T) {
+	scan := newTestScan(t,
+		"openssl@1.1.1k:CVE-2021-3712:High:1.1.1l",
+		"zlib@1.2.11:CVE-2018-25032:High:1.2.12",
+	)
+	policy := &VulnerabilityPolicy{
+		MaxSeverity: SeverityMedium,
+		Allowlist: []PolicyAllowlistEntry{
+			{ID: "cve-2021-3712", Expires: "2025-06-30", Reason: "not reachable"},
+			{ID: "CVE-2018-25032", Package: "zlib", Expires: "2025-01-31"},
+		},
+	}
+	now := time.Date(2025, 6, 30, 23, 0, 0, 0, time.UTC)
+
+	result, err := policy.Evaluate(scan, now)
+	if err != nil {
+		t.Fatalf("Evaluate returned error: %v", err)
+	}
+	if len(result.Allowed) != 1 || result.Allowed[0].ID != "CVE-2021-3712" {
+		t.Errorf("Expected openssl CVE to be allowed through its expiry day, got %+v", result.Allowed)
+	}
+	if len(result.ExpiredAllowlist) != 1 || result.ExpiredAllowlist[0].ID != "CVE-2018-25032" {
+		t.Errorf("Expected zlib entry to be reported as expired, got %+v", result.ExpiredAllowlist)
+	}
+	if result.Passed {
+		t.Error("Expected expired allowlist entry to stop suppressing the finding")
+	}
+
+	result, err = policy.Evaluate(scan, now.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("Evaluate returned error: %v", err)
+	}
+	if len(result.Allowed) != 0 || len(result.ExpiredAllowlist) != 2 {
+		t.Errorf("Expected both entries expired the next day, got %+v", result)
+	}
+}
+
+func TestEvaluatePassesEmptyPolicy(t *testing.T) {
+	scan := newTestScan(t, "openssl@1.1.1k:CVE-2021-3712:Critical")
+	result, err := (&VulnerabilityPolicy{}).Evaluate(scan, time.Now())
+	if err != nil {
+		t.Fatalf("Evaluate returned error: %v", err)
+	}
+	if !result.Passed || result.Counts[SeverityCritical] != 1 {
+		t.Errorf("Expected empty policy to pass and count findings, got %+v", result)
+	}
+}
+
+func TestEvaluateIncompleteScan(t *testing.T) {
+	_, err := (&VulnerabilityPolicy{}).Evaluate(&SecurityScan{Status: ScanStatusQueued}, time.Now())
+	if err == nil || !strings.Contains(err.Error(), "not complete") {
+		t.Errorf("Expected incomplete scan error, got %v", err)
+	}
+}
+
+func TestVulnerabilityPolicyValidate(t *testing.T) {
+	tests := map[string]VulnerabilityPolicy{
+		"unknown max severity": {MaxSeverity: "Severe"},
+		"unknown count key":    {MaxCounts: map[string]int{"Urgent": 1}},
+		"negative count":       {MaxCounts: map[string]int{"High": -1}},
+		"duplicate count key":  {MaxCounts: map[string]int{"High": 0, "high": 5}},
+		"missing allowlist id": {Allowlist: []PolicyAllowlistEntry{{Expires: "2025-01-01"}}},
+		"bad expiry":           {Allowlist: []PolicyAllowlistEntry{{ID: "CVE-1", Expires: "01/02/2025"}}},
+		"bad package glob":     {IgnorePackages: []PolicyPackageRule{{Name: "[openssl"}}},
+	}
+	for name, policy := range tests {
+		if err := policy.Validate(); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+
+	valid := VulnerabilityPolicy{MaxSeverity: "unknown", MaxCounts: map[string]int{"Critical": 0}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Expected valid policy, got %v", err)
+	}
+}
+
+func TestCheckManifestPolicy(t *testing.T) {
+	scan := newTestScan(t, "openssl@1.1.1k:CVE-2021-3712:High:1.1.1l")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("vulnerabilities") != testQueryValueTrue {
+			t.Error("Expected vulnerabilities to be requested")
+		}
+		data, _ := json.Marshal(scan)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	client, _ := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	result, err := client.CheckManifestPolicy(context.Background(), testNamespace, testRepository, testDigestSHA256, &VulnerabilityPolicy{MaxSeverity: SeverityMedium})
+	if err != nil {
+		t.Fatalf("CheckManifestPolicy returned error: %v", err)
+	}
+	if result.Passed {
+		t.Error("Expected High finding to fail a Medium policy")
+	}
+}
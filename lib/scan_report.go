@@ -0,0 +1,673 @@
+/*
+Package lib provides Quay.io API client functionality.
+
+This file covers SECURITY SCAN REPORT conversion:
+
+Report Formats:
+  - SecurityScanSARIF(scan, info) (*SARIFLog, error)         - SARIF 2.1.0 for code scanning dashboards
+  - SecurityScanVEX(scan, info) (*CycloneDXBOM, error)        - CycloneDX 1.5 BOM with vulnerabilities (VEX)
+  - SecurityScanJUnit(scan, info) (*JUnitTestSuites, error)   - JUnit XML for CI test reporters
+  - WriteSecurityReport(w, format, scan, info) error          - Encode a scan in one of the formats above
+
+Each vulnerability of each package becomes a SARIF result, an affected
+component of a CycloneDX vulnerability, and a failing JUnit test case.
+Severity, fixed version, advisory link and the NVD CVSS score from the
+vulnerability metadata are carried over wherever the format has a place for
+them. Only completed ("scanned") scans can be converted.
+*/
+package lib
+
+import (
+	"cmp"
+	"crypto/rand"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Report formats accepted by WriteSecurityReport.
+const (
+	ReportFormatSARIF     = "sarif"
+	ReportFormatCycloneDX = "cyclonedx"
+	ReportFormatJUnit     = "junit"
+)
+
+const (
+	reportToolName = "go-quay"
+	reportToolURI  = "https://github.com/sebrandon1/go-quay"
+
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+
+	cycloneDXFormat      = "CycloneDX"
+	cycloneDXSpecVersion = "1.5"
+)
+
+// ScanReportInfo identifies the scanned image in converted reports.
+type ScanReportInfo struct {
+	// Image names the scanned repository, e.g. quay.io/myorg/app.
+	Image string
+	// Digest is the scanned manifest digest.
+	Digest string
+	// ToolVersion is reported as the version of the producing tool.
+	ToolVersion string
+	// Timestamp dates the report. Zero means now.
+	Timestamp time.Time
+}
+
+func (i ScanReportInfo) reference() string {
+	if i.Digest == "" {
+		return i.Image
+	}
+	return i.Image + "@" + i.Digest
+}
+
+func (i ScanReportInfo) timestamp() string {
+	if i.Timestamp.IsZero() {
+		return time.Now().UTC().Format(time.RFC3339)
+	}
+	return i.Timestamp.UTC().Format(time.RFC3339)
+}
+
+// SARIF Structures
+
+// SARIFLog is a SARIF 2.1.0 log with a single run.
+type SARIFLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []SARIFRun `json:"runs"`
+}
+
+// SARIFRun is one analysis run.
+type SARIFRun struct {
+	Tool    SARIFTool     `json:"tool"`
+	Results []SARIFResult `json:"results"`
+}
+
+// SARIFTool describes the analysis tool.
+type SARIFTool struct {
+	Driver SARIFDriver `json:"driver"`
+}
+
+// SARIFDriver lists the tool's rules, one per vulnerability ID.
+type SARIFDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []SARIFRule `json:"rules"`
+}
+
+// SARIFRule describes one vulnerability.
+type SARIFRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     SARIFMessage       `json:"shortDescription"`
+	FullDescription      *SARIFMessage      `json:"fullDescription,omitempty"`
+	HelpURI              string             `json:"helpUri,omitempty"`
+	Help                 *SARIFMessage      `json:"help,omitempty"`
+	DefaultConfiguration SARIFConfiguration `json:"defaultConfiguration"`
+	Properties           map[string]any     `json:"properties,omitempty"`
+}
+
+// SARIFMessage is a plain text (and optional Markdown) message.
+type SARIFMessage struct {
+	Text     string `json:"text"`
+	Markdown string `json:"markdown,omitempty"`
+}
+
+// SARIFConfiguration holds a rule's default level.
+type SARIFConfiguration struct {
+	Level string `json:"level"`
+}
+
+// SARIFResult is one vulnerable package.
+type SARIFResult struct {
+	RuleID     string          `json:"ruleId"`
+	RuleIndex  int             `json:"ruleIndex"`
+	Level      string          `json:"level"`
+	Message    SARIFMessage    `json:"message"`
+	Locations  []SARIFLocation `json:"locations"`
+	Properties map[string]any  `json:"properties,omitempty"`
+}
+
+// SARIFLocation points at the scanned image.
+type SARIFLocation struct {
+	PhysicalLocation SARIFPhysicalLocation `json:"physicalLocation"`
+	Message          *SARIFMessage         `json:"message,omitempty"`
+}
+
+// SARIFPhysicalLocation is an artifact and region.
+type SARIFPhysicalLocation struct {
+	ArtifactLocation SARIFArtifactLocation `json:"artifactLocation"`
+	Region           SARIFRegion           `json:"region"`
+}
+
+// SARIFArtifactLocation identifies an artifact by URI.
+type SARIFArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// SARIFRegion is a line range within an artifact.
+type SARIFRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// CycloneDX Structures
+
+// CycloneDXBOM is a CycloneDX 1.5 JSON document.
+type CycloneDXBOM struct {
+	BOMFormat       string                   `json:"bomFormat"`
+	SpecVersion     string                   `json:"specVersion"`
+	SerialNumber    string                   `json:"serialNumber,omitempty"`
+	Version         int                      `json:"version"`
+	Metadata        *CycloneDXMetadata       `json:"metadata,omitempty"`
+	Components      []CycloneDXComponent     `json:"components,omitempty"`
+	Dependencies    []CycloneDXDependency    `json:"dependencies,omitempty"`
+	Vulnerabilities []CycloneDXVulnerability `json:"vulnerabilities,omitempty"`
+}
+
+// CycloneDXMetadata describes the document and its subject.
+type CycloneDXMetadata struct {
+	Timestamp string              `json:"timestamp,omitempty"`
+	Tools     *CycloneDXTools     `json:"tools,omitempty"`
+	Component *CycloneDXComponent `json:"component,omitempty"`
+}
+
+// CycloneDXTools lists the tools that produced the document.
+type CycloneDXTools struct {
+	Components []CycloneDXComponent `json:"components"`
+}
+
+// CycloneDXComponent is a package, container or tool.
+type CycloneDXComponent struct {
+	Type       string              `json:"type"`
+	BOMRef     string              `json:"bom-ref,omitempty"`
+	Name       string              `json:"name"`
+	Version    string              `json:"version,omitempty"`
+	PURL       string              `json:"purl,omitempty"`
+	Properties []CycloneDXProperty `json:"properties,omitempty"`
+}
+
+// CycloneDXProperty is a name/value pair.
+type CycloneDXProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// CycloneDXDependency lists the components a component directly depends on.
+type CycloneDXDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+// CycloneDXVulnerability is one vulnerability and the components it affects.
+type CycloneDXVulnerability struct {
+	BOMRef         string             `json:"bom-ref,omitempty"`
+	ID             string             `json:"id"`
+	Source         *CycloneDXSource   `json:"source,omitempty"`
+	Ratings        []CycloneDXRating  `json:"ratings,omitempty"`
+	Description    string             `json:"description,omitempty"`
+	Recommendation string             `json:"recommendation,omitempty"`
+	Analysis       *CycloneDXAnalysis `json:"analysis,omitempty"`
+	Affects        []CycloneDXAffect  `json:"affects"`
+}
+
+// CycloneDXSource names where a vulnerability or rating comes from.
+type CycloneDXSource struct {
+	Name string `json:"name,omitempty"`
+	URL  string `json:"url,omitempty"`
+}
+
+// CycloneDXRating is a severity and optional CVSS score.
+type CycloneDXRating struct {
+	Source   *CycloneDXSource `json:"source,omitempty"`
+	Score    float64          `json:"score,omitempty"`
+	Severity string           `json:"severity,omitempty"`
+	Method   string           `json:"method,omitempty"`
+	Vector   string           `json:"vector,omitempty"`
+}
+
+// CycloneDXAnalysis is the VEX impact analysis of a vulnerability.
+type CycloneDXAnalysis struct {
+	State    string   `json:"state"`
+	Response []string `json:"response,omitempty"`
+}
+
+// CycloneDXAffect references an affected component by bom-ref.
+type CycloneDXAffect struct {
+	Ref string `json:"ref"`
+}
+
+// JUnit Structures
+
+// JUnitTestSuites is the root of a JUnit XML report.
+type JUnitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr,omitempty"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []JUnitTestSuite `xml:"testsuite"`
+}
+
+// JUnitTestSuite groups the test cases of one image.
+type JUnitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Timestamp  string          `xml:"timestamp,attr,omitempty"`
+	Properties []JUnitProperty `xml:"properties>property,omitempty"`
+	TestCases  []JUnitTestCase `xml:"testcase"`
+}
+
+// JUnitProperty is a suite-level name/value pair.
+type JUnitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// JUnitTestCase is one vulnerability, or one package without any.
+type JUnitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *JUnitFailure `xml:"failure,omitempty"`
+}
+
+// JUnitFailure describes a vulnerability.
+type JUnitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteSecurityReport converts scan to format (sarif, cyclonedx or junit) and
+// writes it to w.
+func WriteSecurityReport(w io.Writer, format string, scan *SecurityScan, info ScanReportInfo) error {
+	var report any
+	var err error
+	switch format {
+	case ReportFormatSARIF:
+		report, err = SecurityScanSARIF(scan, info)
+	case ReportFormatCycloneDX:
+		report, err = SecurityScanVEX(scan, info)
+	case ReportFormatJUnit:
+		suites, err := SecurityScanJUnit(scan, info)
+		if err != nil {
+			return err
+		}
+		return writeReportXML(w, suites)
+	default:
+		return fmt.Errorf("unsupported report format %q (want %s, %s or %s)", format, ReportFormatSARIF, ReportFormatCycloneDX, ReportFormatJUnit)
+	}
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+func writeReportXML(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// SecurityScanSARIF converts a completed scan into a SARIF 2.1.0 log with one
+// rule per vulnerability ID and one result per vulnerable package. Rules
+// carry a security-severity property so GitHub code scanning can rank them.
+func SecurityScanSARIF(scan *SecurityScan, info ScanReportInfo) (*SARIFLog, error) {
+	if err := requireScanned(scan); err != nil {
+		return nil, err
+	}
+
+	driver := SARIFDriver{Name: reportToolName, Version: info.ToolVersion, InformationURI: reportToolURI, Rules: []SARIFRule{}}
+	results := []SARIFResult{}
+	ruleIndex := make(map[string]int)
+	for _, finding := range scan.Findings() {
+		index, ok := ruleIndex[finding.ID]
+		if !ok {
+			index = len(driver.Rules)
+			ruleIndex[finding.ID] = index
+			driver.Rules = append(driver.Rules, sarifRule(finding))
+		}
+		results = append(results, SARIFResult{
+			RuleID:    finding.ID,
+			RuleIndex: index,
+			Level:     sarifLevel(finding.Severity),
+			Message:   SARIFMessage{Text: findingMessage(finding)},
+			Locations: []SARIFLocation{{
+				PhysicalLocation: SARIFPhysicalLocation{
+					ArtifactLocation: SARIFArtifactLocation{URI: cmp.Or(info.Image, "image")},
+					Region:           SARIFRegion{StartLine: 1},
+				},
+				Message: &SARIFMessage{Text: info.reference()},
+			}},
+			Properties: map[string]any{"package": finding.Package, "version": finding.Version, "fixedBy": finding.FixedBy, "addedBy": finding.AddedBy},
+		})
+	}
+
+	return &SARIFLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []SARIFRun{{Tool: SARIFTool{Driver: driver}, Results: results}},
+	}, nil
+}
+
+func sarifRule(finding VulnerabilityFinding) SARIFRule {
+	rule := SARIFRule{
+		ID:                   finding.ID,
+		ShortDescription:     SARIFMessage{Text: fmt.Sprintf("%s (%s)", finding.ID, finding.Severity)},
+		HelpURI:              finding.Link,
+		DefaultConfiguration: SARIFConfiguration{Level: sarifLevel(finding.Severity)},
+		Properties: map[string]any{
+			"security-severity": securitySeverity(finding),
+			"tags":              []string{"security", "vulnerability", strings.ToLower(finding.Severity)},
+		},
+	}
+	if finding.Description != "" {
+		rule.FullDescription = &SARIFMessage{Text: finding.Description}
+		rule.Help = &SARIFMessage{Text: finding.Description}
+	}
+	if finding.Link != "" {
+		rule.Help = &SARIFMessage{
+			Text:     strings.TrimSpace(finding.Description + "\n\n" + finding.Link),
+			Markdown: strings.TrimSpace(fmt.Sprintf("%s\n\n[%s](%s)", finding.Description, finding.ID, finding.Link)),
+		}
+	}
+	return rule
+}
+
+// sarifLevel maps High and Critical to error, Medium to warning and the rest to note.
+func sarifLevel(severity string) string {
+	switch severity {
+	case SeverityCritical, SeverityHigh:
+		return "error"
+	case SeverityMedium:
+		return "warning"
+	}
+	return "note"
+}
+
+// securitySeverity is the CVSS score GitHub uses to rank alerts, estimated
+// from the severity when the scan has none.
+func securitySeverity(finding VulnerabilityFinding) string {
+	if finding.CVSSScore > 0 {
+		return fmt.Sprintf("%.1f", finding.CVSSScore)
+	}
+	switch finding.Severity {
+	case SeverityCritical:
+		return "9.5"
+	case SeverityHigh:
+		return "8.0"
+	case SeverityMedium:
+		return "5.5"
+	case SeverityLow:
+		return "2.0"
+	}
+	return "0.0"
+}
+
+func findingMessage(finding VulnerabilityFinding) string {
+	fix := "No fixed version is available."
+	if finding.FixedBy != "" {
+		fix = "Fixed in " + finding.FixedBy + "."
+	}
+	return fmt.Sprintf("%s %s is affected by %s (%s). %s", finding.Package, finding.Version, finding.ID, finding.Severity, fix)
+}
+
+// SecurityScanVEX converts a completed scan into a CycloneDX 1.5 BOM listing
+// every package as a component and every vulnerability with the components
+// it affects. Each vulnerability's analysis state is in_triage, since the
+// scan cannot tell whether it is exploitable.
+func SecurityScanVEX(scan *SecurityScan, info ScanReportInfo) (*CycloneDXBOM, error) {
+	if err := requireScanned(scan); err != nil {
+		return nil, err
+	}
+
+	bom := newCycloneDXBOM(info)
+	bom.Components = featureComponents(scan.Data.Layer.Features)
+
+	index := make(map[string]int)
+	for _, feature := range scan.Data.Layer.Features {
+		ref := packageURL(feature)
+		for _, vuln := range feature.Vulnerabilities {
+			finding := newFinding(feature, vuln)
+			i, ok := index[finding.ID]
+			if !ok {
+				i = len(bom.Vulnerabilities)
+				index[finding.ID] = i
+				bom.Vulnerabilities = append(bom.Vulnerabilities, cycloneDXVulnerability(finding))
+			}
+			addCycloneDXAffect(&bom.Vulnerabilities[i], ref, finding)
+		}
+	}
+	return bom, nil
+}
+
+func newCycloneDXBOM(info ScanReportInfo) *CycloneDXBOM {
+	subject := &CycloneDXComponent{
+		Type:    "container",
+		BOMRef:  cmp.Or(info.reference(), "image"),
+		Name:    cmp.Or(info.Image, "image"),
+		Version: info.Digest,
+	}
+	return &CycloneDXBOM{
+		BOMFormat:    cycloneDXFormat,
+		SpecVersion:  cycloneDXSpecVersion,
+		SerialNumber: newSerialNumber(),
+		Version:      1,
+		Metadata: &CycloneDXMetadata{
+			Timestamp: info.timestamp(),
+			Tools:     &CycloneDXTools{Components: []CycloneDXComponent{{Type: "application", Name: reportToolName, Version: info.ToolVersion}}},
+			Component: subject,
+		},
+	}
+}
+
+// featureComponents returns one library component per distinct package.
+func featureComponents(features []SecurityFeature) []CycloneDXComponent {
+	var components []CycloneDXComponent
+	seen := make(map[string]bool)
+	for _, feature := range features {
+		purl := packageURL(feature)
+		if seen[purl] {
+			continue
+		}
+		seen[purl] = true
+		component := CycloneDXComponent{Type: "library", BOMRef: purl, Name: feature.Name, Version: feature.Version, PURL: purl}
+		if feature.AddedBy != "" {
+			component.Properties = append(component.Properties, CycloneDXProperty{Name: "quay:layer:addedBy", Value: feature.AddedBy})
+		}
+		if feature.VersionFormat != "" {
+			component.Properties = append(component.Properties, CycloneDXProperty{Name: "quay:package:versionFormat", Value: feature.VersionFormat})
+		}
+		components = append(components, component)
+	}
+	return components
+}
+
+func cycloneDXVulnerability(finding VulnerabilityFinding) CycloneDXVulnerability {
+	rating := CycloneDXRating{Severity: cycloneDXSeverity(finding.Severity), Score: finding.CVSSScore, Vector: finding.CVSSVector}
+	if finding.CVSSScore > 0 || finding.CVSSVector != "" {
+		rating.Source = &CycloneDXSource{Name: "NVD"}
+		rating.Method = cvssMethod(finding.CVSSVector)
+	}
+	vuln := CycloneDXVulnerability{
+		BOMRef:      finding.ID,
+		ID:          finding.ID,
+		Ratings:     []CycloneDXRating{rating},
+		Description: finding.Description,
+		Analysis:    &CycloneDXAnalysis{State: "in_triage"},
+		Affects:     []CycloneDXAffect{},
+	}
+	if finding.Link != "" {
+		vuln.Source = &CycloneDXSource{URL: finding.Link}
+	}
+	return vuln
+}
+
+func addCycloneDXAffect(vuln *CycloneDXVulnerability, ref string, finding VulnerabilityFinding) {
+	for _, affect := range vuln.Affects {
+		if affect.Ref == ref {
+			return
+		}
+	}
+	vuln.Affects = append(vuln.Affects, CycloneDXAffect{Ref: ref})
+	if finding.FixedBy == "" {
+		return
+	}
+	upgrade := fmt.Sprintf("Upgrade %s to %s", finding.Package, finding.FixedBy)
+	if vuln.Recommendation == "" {
+		vuln.Recommendation = upgrade
+	} else {
+		vuln.Recommendation += "; " + upgrade
+	}
+	vuln.Analysis.Response = []string{"update"}
+}
+
+// cycloneDXSeverity maps Quay severities onto the CycloneDX severity enum.
+func cycloneDXSeverity(severity string) string {
+	switch severity {
+	case SeverityNegligible:
+		return "info"
+	case SeverityUnknown:
+		return "unknown"
+	}
+	return strings.ToLower(severity)
+}
+
+func cvssMethod(vector string) string {
+	switch {
+	case strings.HasPrefix(vector, "CVSS:3.1/"):
+		return "CVSSv31"
+	case strings.HasPrefix(vector, "CVSS:3"):
+		return "CVSSv3"
+	case strings.HasPrefix(vector, "CVSS:4"):
+		return "CVSSv4"
+	case vector != "":
+		return "CVSSv2"
+	}
+	return "other"
+}
+
+// packageURL builds a package URL (purl) for a scanned package, typed by its
+// version format and qualified with the distribution Clair detected.
+func packageURL(feature SecurityFeature) string {
+	purl := fmt.Sprintf("pkg:%s/%s", purlType(feature.VersionFormat), purlEscape(feature.Name))
+	if feature.Version != "" {
+		purl += "@" + purlEscape(feature.Version)
+	}
+	if feature.NamespaceName != "" {
+		purl += "?distro=" + url.QueryEscape(strings.ReplaceAll(feature.NamespaceName, ":", "-"))
+	}
+	return purl
+}
+
+// purlEscape percent-encodes a purl name or version; unlike a URL path, purl
+// components must not contain a literal "+".
+func purlEscape(s string) string {
+	return strings.ReplaceAll(url.PathEscape(s), "+", "%2B")
+}
+
+func purlType(versionFormat string) string {
+	switch strings.ToLower(versionFormat) {
+	case "rpm":
+		return "rpm"
+	case "dpkg":
+		return "deb"
+	case "apk":
+		return "apk"
+	case "pypi", "python":
+		return "pypi"
+	case "gem":
+		return "gem"
+	case "npm":
+		return "npm"
+	case "maven", "java":
+		return "maven"
+	case "go", "golang":
+		return "golang"
+	}
+	return "generic"
+}
+
+// newSerialNumber returns a random urn:uuid serial number.
+func newSerialNumber() string {
+	return "urn:uuid:" + newUUID()
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// SecurityScanJUnit converts a completed scan into a JUnit report with one
+// failing test case per vulnerable package and one passing test case per
+// package without known vulnerabilities.
+func SecurityScanJUnit(scan *SecurityScan, info ScanReportInfo) (*JUnitTestSuites, error) {
+	if err := requireScanned(scan); err != nil {
+		return nil, err
+	}
+
+	suite := JUnitTestSuite{
+		Name:      info.reference(),
+		Timestamp: info.timestamp(),
+		TestCases: []JUnitTestCase{},
+	}
+	if info.Digest != "" {
+		suite.Properties = []JUnitProperty{{Name: "digest", Value: info.Digest}}
+	}
+	for _, feature := range scan.Data.Layer.Features {
+		if len(feature.Vulnerabilities) == 0 {
+			suite.TestCases = append(suite.TestCases, JUnitTestCase{Name: strings.TrimSpace(feature.Name + " " + feature.Version), ClassName: feature.Name})
+		}
+	}
+	for _, finding := range scan.Findings() {
+		suite.TestCases = append(suite.TestCases, JUnitTestCase{
+			Name:      fmt.Sprintf("%s (%s %s)", finding.ID, finding.Package, finding.Version),
+			ClassName: finding.Package,
+			Failure: &JUnitFailure{
+				Message: findingMessage(finding),
+				Type:    finding.Severity,
+				Text:    strings.TrimSpace(finding.Description + "\n" + finding.Link),
+			},
+		})
+		suite.Failures++
+	}
+	suite.Tests = len(suite.TestCases)
+
+	return &JUnitTestSuites{
+		Name:     reportToolName,
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Suites:   []JUnitTestSuite{suite},
+	}, nil
+}
+
+// requireScanned returns an error unless scan has completed with results.
+func requireScanned(scan *SecurityScan) error {
+	if scan == nil {
+		return fmt.Errorf("security scan is required")
+	}
+	if scan.Status != ScanStatusScanned {
+		return fmt.Errorf("security scan is not complete (status %q)", scan.Status)
+	}
+	if scan.Data == nil || scan.Data.Layer == nil {
+		return fmt.Errorf("security scan has no data")
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testReportInfo = ScanReportInfo{
+	Image:       "quay.io/testorg/testrepo",
+	Digest:      testDigestSHA256,
+	ToolVersion: "1.2.3",
+	Timestamp:   time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+}
+
+// newTestReportScan returns a scan where CVE-2021-3712 affects two packages
+// and carries NVD CVSS v3 metadata, plus one package without vulnerabilities.
+func newTestReportScan(t *testing.T) *SecurityScan {
+	t.Helper()
+	scan := newTestScan(t,
+		"openssl@1.1.1k:CVE-2021-3712:High:1.1.1l",
+		"openssl-libs@1.1.1k:CVE-2021-3712:High:1.1.1l",
+		"bash@5.0:CVE-2019-18276:Low",
+	)
+	features := scan.Data.Layer.Features
+	for i := range features {
+		features[i].VersionFormat = "rpm"
+		features[i].NamespaceName = "rhel:8"
+		features[i].AddedBy = "sha256:layer1"
+	}
+	features[0].Vulnerabilities[0].Link = "https://access.redhat.com/security/cve/CVE-2021-3712"
+	features[0].Vulnerabilities[0].Description = "Read buffer overruns processing ASN.1 strings"
+	features[0].Vulnerabilities[0].Metadata = map[string]any{
+		"NVD": map[string]any{"CVSSv3": map[string]any{"Score": 7.4, "Vectors": "CVSS:3.1/AV:N/AC:H/PR:N/UI:N/S:U/C:H/I:N/A:H"}},
+	}
+	scan.Data.Layer.Features = append(features, SecurityFeature{Name: "zlib", Version: "1.2.11", VersionFormat: "rpm"})
+	return scan
+}
+
+func TestSecurityScanSARIF(t *testing.T) {
+	log, err := SecurityScanSARIF(newTestReportScan(t), testReportInfo)
+	if err != nil {
+		t.Fatalf("SecurityScanSARIF returned error: %v", err)
+	}
+
+	run := log.Runs[0]
+	if log.Version != sarifVersion || run.Tool.Driver.Version != "1.2.3" {
+		t.Errorf("Unexpected log header: %+v", log)
+	}
+	if len(run.Tool.Driver.Rules) != 2 || len(run.Results) != 3 {
+		t.Fatalf("Expected 2 rules and 3 results, got %d and %d", len(run.Tool.Driver.Rules), len(run.Results))
+	}
+	rule := run.Tool.Driver.Rules[0]
+	if rule.Properties["security-severity"] != "7.4" || rule.HelpURI == "" || rule.DefaultConfiguration.Level != "error" {
+		t.Errorf("Expected CVSS-ranked error rule with help link, got %+v", rule)
+	}
+	if run.Tool.Driver.Rules[1].Properties["security-severity"] != "2.0" {
+		t.Errorf("Expected Low severity to be estimated as 2.0, got %v", run.Tool.Driver.Rules[1].Properties["security-severity"])
+	}
+	if run.Results[1].RuleIndex != 0 || run.Results[2].Level != "note" {
+		t.Errorf("Expected shared rule index and note level, got %+v", run.Results)
+	}
+	location := run.Results[0].Locations[0]
+	if location.PhysicalLocation.ArtifactLocation.URI != testReportInfo.Image || !strings.Contains(location.Message.Text, testDigestSHA256) {
+		t.Errorf("Unexpected location %+v", location)
+	}
+	if !strings.Contains(run.Results[0].Message.Text, "Fixed in 1.1.1l") {
+		t.Errorf("Expected fix in message, got %q", run.Results[0].Message.Text)
+	}
+}
+
+func TestSecurityScanVEX(t *testing.T) {
+	bom, err := SecurityScanVEX(newTestReportScan(t), testReportInfo)
+	if err != nil {
+		t.Fatalf("SecurityScanVEX returned error: %v", err)
+	}
+
+	if bom.BOMFormat != "CycloneDX" || bom.SpecVersion != "1.5" || !strings.HasPrefix(bom.SerialNumber, "urn:uuid:") {
+		t.Errorf("Unexpected BOM header: %+v", bom)
+	}
+	if bom.Metadata.Timestamp != "2025-03-01T12:00:00Z" || bom.Metadata.Component.Version != testDigestSHA256 {
+		t.Errorf("Unexpected metadata: %+v", bom.Metadata)
+	}
+	if len(bom.Components) != 4 || bom.Components[0].PURL != "pkg:rpm/openssl@1.1.1k?distro=rhel-8" {
+		t.Errorf("Expected 4 rpm components, got %+v", bom.Components)
+	}
+	if len(bom.Vulnerabilities) != 2 {
+		t.Fatalf("Expected 2 vulnerabilities, got %d", len(bom.Vulnerabilities))
+	}
+
+	vuln := bom.Vulnerabilities[0]
+	if len(vuln.Affects) != 2 || vuln.Affects[1].Ref != bom.Components[1].BOMRef {
+		t.Errorf("Expected CVE to affect both openssl packages, got %+v", vuln.Affects)
+	}
+	rating := vuln.Ratings[0]
+	if rating.Severity != "high" || rating.Score != 7.4 || rating.Method != "CVSSv31" {
+		t.Errorf("Unexpected rating %+v", rating)
+	}
+	if vuln.Analysis.State != "in_triage" || len(vuln.Analysis.Response) != 1 || !strings.Contains(vuln.Recommendation, "Upgrade openssl to 1.1.1l") {
+		t.Errorf("Unexpected analysis %+v / %q", vuln.Analysis, vuln.Recommendation)
+	}
+	if bom.Vulnerabilities[1].Analysis.Response != nil {
+		t.Error("Expected no update response without a fixed version")
+	}
+}
+
+func TestSecurityScanJUnit(t *testing.T) {
+	suites, err := SecurityScanJUnit(newTestReportScan(t), testReportInfo)
+	if err != nil {
+		t.Fatalf("SecurityScanJUnit returned error: %v", err)
+	}
+
+	if suites.Tests != 4 || suites.Failures != 3 {
+		t.Errorf("Expected 4 tests with 3 failures, got %d/%d", suites.Tests, suites.Failures)
+	}
+	suite := suites.Suites[0]
+	if suite.Name != testReportInfo.Image+"@"+testDigestSHA256 || suite.TestCases[0].Failure != nil {
+		t.Errorf("Expected passing zlib case first in suite %q, got %+v", suite.Name, suite.TestCases[0])
+	}
+	if failure := suite.TestCases[1].Failure; failure == nil || failure.Type != SeverityHigh {
+		t.Errorf("Expected High failure, got %+v", failure)
+	}
+}
+
+func TestWriteSecurityReport(t *testing.T) {
+	scan := newTestReportScan(t)
+
+	var buf bytes.Buffer
+	if err := WriteSecurityReport(&buf, ReportFormatJUnit, scan, testReportInfo); err != nil {
+		t.Fatalf("WriteSecurityReport(junit) returned error: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "<?xml") {
+		t.Errorf("Expected XML header, got %q", buf.String()[:20])
+	}
+	var suites JUnitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &suites); err != nil || suites.Failures != 3 {
+		t.Errorf("Expected JUnit XML to round trip, got %v (%+v)", err, suites)
+	}
+
+	buf.Reset()
+	if err := WriteSecurityReport(&buf, ReportFormatSARIF, scan, testReportInfo); err != nil {
+		t.Fatalf("WriteSecurityReport(sarif) returned error: %v", err)
+	}
+	var log map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil || log["$schema"] != sarifSchema {
+		t.Errorf("Expected SARIF JSON, got %v (%v)", err, log["$schema"])
+	}
+
+	if err := WriteSecurityReport(&buf, "html", scan, testReportInfo); err == nil {
+		t.Error("Expected unsupported format error")
+	}
+	if err := WriteSecurityReport(&buf, ReportFormatSARIF, &SecurityScan{Status: ScanStatusQueued}, testReportInfo); err == nil {
+		t.Error("Expected error for an incomplete scan")
+	}
+}
+
+func TestPackageURL(t *testing.T) {
+	tests := map[string]SecurityFeature{
+		"pkg:deb/libc6@2.31-13%2Bdeb11u5?distro=debian-11": {Name: "libc6", Version: "2.31-13+deb11u5", VersionFormat: "dpkg", NamespaceName: "debian:11"},
+		"pkg:pypi/requests@2.31.0":                         {Name: "requests", Version: "2.31.0", VersionFormat: "pypi"},
+		"pkg:generic/thing":                                {Name: "thing"},
+	}
+	for want, feature := range tests {
+		if got := packageURL(feature); got != want {
+			t.Errorf("packageURL(%+v) = %q, want %q", feature, got, want)
+		}
+	}
+}
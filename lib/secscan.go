@@ -5,6 +5,13 @@ This file covers SECURITY SCAN operations:
 
 Security Scanning:
   - GET /api/v1/repository/{namespace}/{repository}/manifest/{manifestref}/security - GetManifestSecurity()
+  - WaitForSecurityScan(ctx, ns, repo, digest, opts) (*SecurityScan, error)          - Poll until the scan reaches a terminal status
+
+Scan Helpers:
+  - (*SecurityScan).Findings() []VulnerabilityFinding - Flatten features into one finding per package and vulnerability
+  - SeverityRank(severity string) int                  - Order severities from Unknown (0) to Critical
+  - NormalizeSeverity(severity string) string          - Canonical severity name
+  - IsTerminalScanStatus(status string) bool           - Whether a scan status will no longer change
 
 Security scan operations provide access to vulnerability information for
 container images, including CVE details, severity levels, and fix versions.
@@ -12,11 +19,60 @@ container images, including CVE details, severity levels, and fix versions.
 package lib
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Security scan statuses reported by Quay.
+const (
+	ScanStatusScanned     = "scanned"
+	ScanStatusQueued      = "queued"
+	ScanStatusScanning    = "scanning"
+	ScanStatusFailed      = "failed"
+	ScanStatusUnsupported = "unsupported"
+)
+
+// Default polling intervals for WaitForSecurityScan.
+const (
+	DefaultScanPollInterval    = 2 * time.Second
+	DefaultScanMaxPollInterval = 30 * time.Second
 )
 
+// Vulnerability severities reported by Clair, lowest to highest.
+const (
+	SeverityUnknown    = "Unknown"
+	SeverityNegligible = "Negligible"
+	SeverityLow        = "Low"
+	SeverityMedium     = "Medium"
+	SeverityHigh       = "High"
+	SeverityCritical   = "Critical"
+)
+
+// Severities lists every severity from lowest to highest.
+var Severities = []string{SeverityUnknown, SeverityNegligible, SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical}
+
+// VulnerabilityFinding is one vulnerability affecting one package of a scanned image.
+type VulnerabilityFinding struct {
+	ID          string `json:"id"`
+	Severity    string `json:"severity"`
+	Package     string `json:"package"`
+	Version     string `json:"version,omitempty"`
+	FixedBy     string `json:"fixed_by,omitempty"`
+	Namespace   string `json:"namespace,omitempty"`
+	AddedBy     string `json:"added_by,omitempty"`
+	Link        string `json:"link,omitempty"`
+	Description string `json:"description,omitempty"`
+	// CVSSScore and CVSSVector come from the NVD metadata Clair attaches,
+	// preferring CVSS v3 over v2. They are empty when Clair has none.
+	CVSSScore  float64 `json:"cvss_score,omitempty"`
+	CVSSVector string  `json:"cvss_vector,omitempty"`
+}
+
 // GetManifestSecurity retrieves security scan information for a specific manifest
 func (c *Client) GetManifestSecurity(ctx context.Context, namespace, repository, manifestRef string, vulnerabilities bool) (*SecurityScan, error) {
 	if namespace == "" {
@@ -29,7 +85,7 @@ func (c *Client) GetManifestSecurity(ctx context.Context, namespace, repository,
 		return nil, fmt.Errorf("manifestRef is required")
 	}
 
-	req, err := newRequest(ctx, http.MethodGet, c.buildURL("/repository/%s/%s/manifest/%s/security", namespace, repository, manifestRef), nil)
+	req, err := newAPIRequest(ctx, http.MethodGet, c.buildURL("/repository/%s/%s/manifest/%s/security", namespace, repository, manifestRef), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create get manifest security request: %w", err)
 	}
@@ -45,3 +101,137 @@ func (c *Client) GetManifestSecurity(ctx context.Context, namespace, repository,
 
 	return &securityScan, nil
 }
+
+// WaitForScanOptions configures WaitForSecurityScan.
+type WaitForScanOptions struct {
+	// Vulnerabilities requests vulnerability details with the final scan.
+	Vulnerabilities bool
+	// PollInterval is the first delay between polls (default 2s). It doubles
+	// after every poll up to MaxPollInterval (default 30s).
+	PollInterval    time.Duration
+	MaxPollInterval time.Duration
+	// Timeout bounds the total wait. Zero waits until ctx is done.
+	Timeout time.Duration
+	// OnPoll, if set, is called with every non-terminal scan.
+	OnPoll func(scan *SecurityScan)
+}
+
+// WaitForSecurityScan polls the security scan of a freshly pushed manifest
+// until it reaches a terminal status (scanned, failed or unsupported) and
+// returns it. Callers must check the returned Status; only "scanned" carries
+// results. An error is returned if a request fails or the wait times out.
+func (c *Client) WaitForSecurityScan(ctx context.Context, namespace, repository, digest string, opts *WaitForScanOptions) (*SecurityScan, error) {
+	if opts == nil {
+		opts = &WaitForScanOptions{}
+	}
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	interval := cmp.Or(opts.PollInterval, DefaultScanPollInterval)
+	maxInterval := max(cmp.Or(opts.MaxPollInterval, DefaultScanMaxPollInterval), interval)
+
+	status := ScanStatusQueued
+	for {
+		scan, err := c.GetManifestSecurity(ctx, namespace, repository, digest, opts.Vulnerabilities)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("security scan of %s is still %q: %w", digest, status, ctx.Err())
+			}
+			return nil, err
+		}
+		if IsTerminalScanStatus(scan.Status) {
+			return scan, nil
+		}
+		status = scan.Status
+		if opts.OnPoll != nil {
+			opts.OnPoll(scan)
+		}
+
+		t := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, fmt.Errorf("security scan of %s is still %q: %w", digest, status, ctx.Err())
+		case <-t.C:
+		}
+		interval = min(interval*2, maxInterval)
+	}
+}
+
+// IsTerminalScanStatus reports whether a scan with status will no longer change.
+func IsTerminalScanStatus(status string) bool {
+	switch status {
+	case ScanStatusScanned, ScanStatusFailed, ScanStatusUnsupported:
+		return true
+	}
+	return false
+}
+
+// Findings returns one VulnerabilityFinding per package and vulnerability, in
+// scan order. It returns nil when the scan has no vulnerability data.
+func (s *SecurityScan) Findings() []VulnerabilityFinding {
+	if s == nil || s.Data == nil || s.Data.Layer == nil {
+		return nil
+	}
+
+	var findings []VulnerabilityFinding
+	for _, feature := range s.Data.Layer.Features {
+		for _, vuln := range feature.Vulnerabilities {
+			findings = append(findings, newFinding(feature, vuln))
+		}
+	}
+	return findings
+}
+
+func newFinding(feature SecurityFeature, vuln SecurityVulnerability) VulnerabilityFinding {
+	score, vector := vulnerabilityCVSS(vuln.Metadata)
+	return VulnerabilityFinding{
+		ID:          vuln.Name,
+		Severity:    NormalizeSeverity(vuln.Severity),
+		Package:     feature.Name,
+		Version:     feature.Version,
+		FixedBy:     vuln.FixedBy,
+		Namespace:   feature.NamespaceName,
+		AddedBy:     feature.AddedBy,
+		Link:        vuln.Link,
+		Description: vuln.Description,
+		CVSSScore:   score,
+		CVSSVector:  vector,
+	}
+}
+
+// vulnerabilityCVSS extracts the CVSS score and vector from Clair metadata of
+// the form {"NVD": {"CVSSv3": {"Score": 7.5, "Vectors": "CVSS:3.1/..."}}}.
+func vulnerabilityCVSS(metadata map[string]any) (float64, string) {
+	nvd, _ := metadata["NVD"].(map[string]any)
+	for _, key := range []string{"CVSSv3", "CVSSv2"} {
+		cvss, _ := nvd[key].(map[string]any)
+		score, _ := cvss["Score"].(float64)
+		vector, _ := cvss["Vectors"].(string)
+		if score > 0 || vector != "" {
+			return score, vector
+		}
+	}
+	return 0, ""
+}
+
+// NormalizeSeverity returns the canonical spelling of severity, mapping
+// Clair's "Defcon1" to Critical and anything unrecognized to Unknown.
+func NormalizeSeverity(severity string) string {
+	if strings.EqualFold(severity, "Defcon1") {
+		return SeverityCritical
+	}
+	for _, s := range Severities {
+		if strings.EqualFold(severity, s) {
+			return s
+		}
+	}
+	return SeverityUnknown
+}
+
+// SeverityRank orders severities from Unknown (0) to Critical.
+func SeverityRank(severity string) int {
+	return slices.Index(Severities, NormalizeSeverity(severity))
+}
@@ -5,7 +5,10 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 const (
@@ -236,3 +239,112 @@ func TestGetManifestSecurityUnsupported(t *testing.T) {
 		t.Errorf("Expected status 'unsupported', got '%s'", securityScan.Status)
 	}
 }
+
+func TestSecurityScanFindings(t *testing.T) {
+	scan := newTestScan(t,
+		"openssl@1.1.1k:CVE-2021-3712:medium:1.1.1l",
+		"openssl@1.1.1k:CVE-2021-3711:Defcon1",
+		"bash@5.0:CVE-2019-18276:whatever",
+	)
+
+	findings := scan.Findings()
+	if len(findings) != 3 {
+		t.Fatalf("Expected 3 findings, got %d", len(findings))
+	}
+	if findings[0].Package != "openssl" || findings[0].Version != "1.1.1k" || findings[0].Severity != SeverityMedium || findings[0].FixedBy != "1.1.1l" {
+		t.Errorf("Unexpected first finding %+v", findings[0])
+	}
+	if findings[1].Severity != SeverityCritical || findings[2].Severity != SeverityUnknown {
+		t.Errorf("Expected normalized severities, got %s and %s", findings[1].Severity, findings[2].Severity)
+	}
+	if (&SecurityScan{Status: ScanStatusQueued}).Findings() != nil {
+		t.Error("Expected no findings without scan data")
+	}
+}
+
+func TestSeverityRank(t *testing.T) {
+	if SeverityRank("critical") <= SeverityRank(SeverityHigh) || SeverityRank(SeverityLow) <= SeverityRank("Negligible") {
+		t.Error("Expected severities to be ordered")
+	}
+	if SeverityRank("bogus") != 0 {
+		t.Errorf("Expected unknown severities to rank 0, got %d", SeverityRank("bogus"))
+	}
+}
+
+func TestWaitForSecurityScan(t *testing.T) {
+	statuses := []string{ScanStatusQueued, ScanStatusScanning, ScanStatusScanned}
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := statuses[min(int(calls.Add(1))-1, len(statuses)-1)]
+		if status == ScanStatusScanned && r.URL.Query().Get("vulnerabilities") != testQueryValueTrue {
+			t.Error("Expected vulnerabilities=true query parameter")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status": "` + status + `"}`))
+	}))
+	defer server.Close()
+
+	client, _ := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	var polled []string
+	scan, err := client.WaitForSecurityScan(context.Background(), testNamespace, testRepository, testSecScanManifestRef, &WaitForScanOptions{
+		Vulnerabilities: true,
+		PollInterval:    time.Millisecond,
+		OnPoll:          func(s *SecurityScan) { polled = append(polled, s.Status) },
+	})
+	if err != nil {
+		t.Fatalf("WaitForSecurityScan returned error: %v", err)
+	}
+	if scan.Status != ScanStatusScanned || calls.Load() != 3 {
+		t.Errorf("Expected scanned after 3 polls, got %q after %d", scan.Status, calls.Load())
+	}
+	if strings.Join(polled, ",") != "queued,scanning" {
+		t.Errorf("Expected OnPoll for pending statuses, got %v", polled)
+	}
+}
+
+func TestWaitForSecurityScanTerminalFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status": "unsupported"}`))
+	}))
+	defer server.Close()
+
+	client, _ := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	scan, err := client.WaitForSecurityScan(context.Background(), testNamespace, testRepository, testSecScanManifestRef, nil)
+	if err != nil {
+		t.Fatalf("WaitForSecurityScan returned error: %v", err)
+	}
+	if scan.Status != ScanStatusUnsupported {
+		t.Errorf("Expected unsupported status to end the wait, got %q", scan.Status)
+	}
+}
+
+func TestWaitForSecurityScanTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status": "queued"}`))
+	}))
+	defer server.Close()
+
+	client, _ := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	_, err := client.WaitForSecurityScan(context.Background(), testNamespace, testRepository, testSecScanManifestRef, &WaitForScanOptions{
+		PollInterval: 5 * time.Millisecond,
+		Timeout:      30 * time.Millisecond,
+	})
+	if err == nil || !strings.Contains(err.Error(), `still "queued"`) {
+		t.Errorf("Expected timeout error mentioning the pending status, got %v", err)
+	}
+}
+
+func TestIsTerminalScanStatus(t *testing.T) {
+	for _, status := range []string{ScanStatusScanned, ScanStatusFailed, ScanStatusUnsupported} {
+		if !IsTerminalScanStatus(status) {
+			t.Errorf("Expected %q to be terminal", status)
+		}
+	}
+	for _, status := range []string{ScanStatusQueued, ScanStatusScanning, ""} {
+		if IsTerminalScanStatus(status) {
+			t.Errorf("Expected %q not to be terminal", status)
+		}
+	}
+}
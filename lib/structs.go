@@ -50,8 +50,13 @@ User Types:
 Search Types:
   - SearchRepositoryResult, SearchEntity, SearchAllResult - Search results
 
+Registry v2 Types:
+  - RegistryDescriptor, RegistryPlatform, RegistryManifest, ImageManifest - Raw and decoded manifests
+  - RegistryCatalog, RegistryTagList - Catalog and tag listings
+
 Error Types:
   - QuayError - API error responses
+  - RegistryError, RegistryErrorDetail - Registry v2 error responses
 
 Request Types:
   - CreateOrganizationRequest, UpdateOrganizationRequest, CreateTeamRequest, UpdateTeamRequest, CreateRobotRequest, CreateApplicationRequest, CreateQuotaRequest, CreateAutoPruneRequest
@@ -805,6 +810,18 @@ type BuildLogEntry struct {
 	Data    map[string]any `json:"data,omitempty"`
 }
 
+// FileDropRequest represents the request for a build context upload URL
+type FileDropRequest struct {
+	MimeType string `json:"mimeType"`
+}
+
+// FileDrop is an upload URL for a build context and the file ID that
+// identifies the upload in RequestBuildRequest.FileID
+type FileDrop struct {
+	URL    string `json:"url"`
+	FileID string `json:"file_id"`
+}
+
 // RequestBuildRequest represents the request to trigger a build
 type RequestBuildRequest struct {
 	FileID         string   `json:"file_id,omitempty"`
@@ -1048,3 +1065,90 @@ type CreateRepoTokenRequest struct {
 type UpdateRepoTokenRequest struct {
 	Role string `json:"role,omitempty"`
 }
+
+// Registry v2 Structures
+
+// RegistryDescriptor describes content addressed by digest in the registry.
+type RegistryDescriptor struct {
+	MediaType    string            `json:"mediaType,omitempty"`
+	ArtifactType string            `json:"artifactType,omitempty"`
+	Digest       string            `json:"digest"`
+	Size         int64             `json:"size"`
+	URLs         []string          `json:"urls,omitempty"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+	Platform     *RegistryPlatform `json:"platform,omitempty"`
+}
+
+// RegistryPlatform identifies the platform of a manifest in an index
+type RegistryPlatform struct {
+	Architecture string   `json:"architecture"`
+	OS           string   `json:"os"`
+	OSVersion    string   `json:"os.version,omitempty"`
+	OSFeatures   []string `json:"os.features,omitempty"`
+	Variant      string   `json:"variant,omitempty"`
+}
+
+// RegistryManifest is a manifest exactly as stored in the registry. Content
+// holds the raw bytes the digest is computed over.
+type RegistryManifest struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
+	Content   []byte `json:"-"`
+}
+
+// ImageManifest is the decoded form of an image manifest or an index. Config
+// and Layers are set for images; Manifests is set for indexes and manifest lists.
+type ImageManifest struct {
+	SchemaVersion int                  `json:"schemaVersion"`
+	MediaType     string               `json:"mediaType,omitempty"`
+	ArtifactType  string               `json:"artifactType,omitempty"`
+	Config        *RegistryDescriptor  `json:"config,omitempty"`
+	Layers        []RegistryDescriptor `json:"layers,omitempty"`
+	Manifests     []RegistryDescriptor `json:"manifests,omitempty"`
+	Subject       *RegistryDescriptor  `json:"subject,omitempty"`
+	Annotations   map[string]string    `json:"annotations,omitempty"`
+}
+
+// RegistryCatalog represents one page of the registry catalog
+type RegistryCatalog struct {
+	Repositories []string `json:"repositories"`
+	// Next is the cursor for the following page, or "" on the last page.
+	Next string `json:"-"`
+}
+
+// RegistryTagList represents one page of tags for a repository
+type RegistryTagList struct {
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+	// Next is the cursor for the following page, or "" on the last page.
+	Next string `json:"-"`
+}
+
+// RegistryErrorDetail is a single entry of a registry error response
+type RegistryErrorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+	Detail  any    `json:"detail,omitempty"`
+}
+
+// RegistryError represents a registry v2 error response and implements the error interface.
+type RegistryError struct {
+	Status int                   `json:"-"`
+	Errors []RegistryErrorDetail `json:"errors"`
+}
+
+func (e *RegistryError) Error() string {
+	if len(e.Errors) == 0 {
+		return fmt.Sprintf("registry error (status %d)", e.Status)
+	}
+	first := e.Errors[0]
+	if first.Message != "" {
+		return fmt.Sprintf("registry error (status %d): %s: %s", e.Status, first.Code, first.Message)
+	}
+	return fmt.Sprintf("registry error (status %d): %s", e.Status, first.Code)
+}
+
+func (e *RegistryError) StatusCode() int {
+	return e.Status
+}
@@ -33,7 +33,7 @@ func (c *Client) GetTag(ctx context.Context, namespace, repository, tag string)
 		return nil, fmt.Errorf("tag is required")
 	}
 
-	req, err := newRequest(ctx, http.MethodGet, c.buildURL("/repository/%s/%s/tag/%s", namespace, repository, tag), nil)
+	req, err := newAPIRequest(ctx, http.MethodGet, c.buildURL("/repository/%s/%s/tag/%s", namespace, repository, tag), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create get tag request: %w", err)
 	}
@@ -64,7 +64,7 @@ func (c *Client) UpdateTag(ctx context.Context, namespace, repository, tag, expi
 		updateReq.Expiration = expiration
 	}
 
-	req, err := newRequestWithBody(ctx, http.MethodPut, c.buildURL("/repository/%s/%s/tag/%s", namespace, repository, tag), updateReq)
+	req, err := newAPIRequestWithBody(ctx, http.MethodPut, c.buildURL("/repository/%s/%s/tag/%s", namespace, repository, tag), updateReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create update tag request: %w", err)
 	}
@@ -89,7 +89,7 @@ func (c *Client) DeleteTag(ctx context.Context, namespace, repository, tag strin
 		return fmt.Errorf("tag is required")
 	}
 
-	req, err := newRequest(ctx, http.MethodDelete, c.buildURL("/repository/%s/%s/tag/%s", namespace, repository, tag), nil)
+	req, err := newAPIRequest(ctx, http.MethodDelete, c.buildURL("/repository/%s/%s/tag/%s", namespace, repository, tag), nil)
 	if err != nil {
 		return fmt.Errorf("failed to create delete tag request: %w", err)
 	}
@@ -113,7 +113,7 @@ func (c *Client) GetTagHistory(ctx context.Context, namespace, repository, tag s
 		return nil, fmt.Errorf("tag is required")
 	}
 
-	req, err := newRequest(ctx, http.MethodGet, c.buildURL("/repository/%s/%s/tag/%s/history", namespace, repository, tag), nil)
+	req, err := newAPIRequest(ctx, http.MethodGet, c.buildURL("/repository/%s/%s/tag/%s/history", namespace, repository, tag), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create get tag history request: %w", err)
 	}
@@ -141,7 +141,7 @@ func (c *Client) RevertTag(ctx context.Context, namespace, repository, tag, mani
 		return nil, fmt.Errorf("manifestDigest is required")
 	}
 
-	req, err := newRequestWithBody(ctx, http.MethodPost, c.buildURL("/repository/%s/%s/tag/%s/revert", namespace, repository, tag), RevertTagRequest{
+	req, err := newAPIRequestWithBody(ctx, http.MethodPost, c.buildURL("/repository/%s/%s/tag/%s/revert", namespace, repository, tag), RevertTagRequest{
 		ManifestDigest: manifestDigest,
 	})
 	if err != nil {
@@ -176,7 +176,7 @@ func (c *Client) ChangeTag(ctx context.Context, namespace, repository, tag, mani
 	}{
 		ManifestDigest: manifestDigest,
 	}
-	req, err := newRequestWithBody(ctx, http.MethodPut, c.buildURL("/repository/%s/%s/tag/%s", namespace, repository, tag), body)
+	req, err := newAPIRequestWithBody(ctx, http.MethodPut, c.buildURL("/repository/%s/%s/tag/%s", namespace, repository, tag), body)
 	if err != nil {
 		return fmt.Errorf("failed to create change tag request: %w", err)
 	}
@@ -208,7 +208,7 @@ func (c *Client) RestoreTag(ctx context.Context, namespace, repository, tag, man
 	}{
 		ManifestDigest: manifestDigest,
 	}
-	req, err := newRequestWithBody(ctx, http.MethodPost, c.buildURL("/repository/%s/%s/tag/%s/restore", namespace, repository, tag), body)
+	req, err := newAPIRequestWithBody(ctx, http.MethodPost, c.buildURL("/repository/%s/%s/tag/%s/restore", namespace, repository, tag), body)
 	if err != nil {
 		return fmt.Errorf("failed to create restore tag request: %w", err)
 	}
@@ -0,0 +1,127 @@
+/*
+Package lib provides Quay.io API client functionality.
+
+This file covers CLIENT OPTIONS and TELEMETRY:
+
+Client Options (passed to NewClientWithURL):
+  - WithTelemetry(t Telemetry) ClientOption - Observe every API call
+
+Telemetry Hooks:
+  - Telemetry.StartCall(ctx, CallInfo) (ctx, end) - Once per call; end receives CallResult
+
+Route Templates:
+  - RouteFromContext(ctx) string - Low-cardinality route (e.g. /repository/{namespace}/{repository}/tag/)
+
+buildURL keeps the unescaped path template of every request, and newAPIRequest
+stores it in the request context, so telemetry can label calls by route
+instead of by concrete path. The lib/quayotel package provides an
+OpenTelemetry implementation of Telemetry.
+*/
+package lib
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// ClientOption configures optional Client behavior at construction time.
+type ClientOption func(*Client)
+
+// Telemetry observes API calls made by a Client. Implementations must be safe
+// for concurrent use.
+type Telemetry interface {
+	// StartCall is invoked once per API call, before the first attempt. The
+	// returned context is used for every HTTP attempt; end is invoked exactly
+	// once when the call finishes.
+	StartCall(ctx context.Context, info CallInfo) (context.Context, func(CallResult))
+}
+
+// CallInfo describes an API call as it starts.
+type CallInfo struct {
+	Method string
+	Route  string
+}
+
+// CallResult describes a finished API call.
+type CallResult struct {
+	// StatusCode is the HTTP status of the last attempt, or 0 if no response was received.
+	StatusCode int
+	// Attempts is the number of HTTP attempts made, including retries.
+	Attempts int
+	Duration time.Duration
+	Err      error
+}
+
+// WithTelemetry instruments every API call made by the client with t.
+func WithTelemetry(t Telemetry) ClientOption {
+	return func(c *Client) {
+		c.Telemetry = t
+	}
+}
+
+type routeContextKey struct{}
+
+// RouteFromContext returns the route template of the API call that owns ctx,
+// or "" when ctx did not come from a Client request.
+func RouteFromContext(ctx context.Context) string {
+	route, _ := ctx.Value(routeContextKey{}).(string)
+	return route
+}
+
+// routeParams names the placeholder for a verb that follows each known
+// collection segment. Verbs after any other segment become {id}.
+var routeParams = map[string]string{
+	"app":             "client_id",
+	"applications":    "client_id",
+	"autoprunepolicy": "policy_uuid",
+	"build":           "build_uuid",
+	"entities":        "prefix",
+	"error":           "error_type",
+	"invite":          "email",
+	"labels":          "label_id",
+	"manifest":        "manifestref",
+	"marketplace":     "subscription_id",
+	"members":         "member",
+	"notification":    "uuid",
+	"organization":    "organization",
+	"permissions":     "name",
+	"prototypes":      "prototype_id",
+	"robots":          "robot",
+	"tag":             "tag",
+	"team":            "team",
+	"tokens":          "code",
+	"trigger":         "trigger_uuid",
+	"user":            "username",
+	"users":           "username",
+}
+
+// routeTemplate converts a buildURL format string into a route template. A
+// verb becomes the placeholder routeParams gives the segment before it, and
+// literal segments are kept as they are; a pair of verbs after repository is
+// {namespace}/{repository}, matching Quay's repository paths.
+func routeTemplate(pathFmt string) string {
+	segments := strings.Split(pathFmt, "/")
+	for i := 1; i < len(segments); i++ {
+		if !isFormatVerb(segments[i]) {
+			continue
+		}
+		collection := segments[i-1]
+		if collection == "repository" && i+1 < len(segments) && isFormatVerb(segments[i+1]) {
+			segments[i] = "{namespace}"
+			segments[i+1] = "{repository}"
+			i++
+			continue
+		}
+		name, ok := routeParams[collection]
+		if !ok {
+			name = "id"
+		}
+		segments[i] = "{" + name + "}"
+	}
+	return strings.Join(segments, "/")
+}
+
+func isFormatVerb(segment string) bool {
+	return len(segment) == 2 && segment[0] == '%'
+}
@@ -0,0 +1,160 @@
+package lib
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingTelemetry struct {
+	mu      sync.Mutex
+	starts  []CallInfo
+	results []CallResult
+	routes  []string
+}
+
+func (r *recordingTelemetry) StartCall(ctx context.Context, info CallInfo) (context.Context, func(CallResult)) {
+	r.mu.Lock()
+	r.starts = append(r.starts, info)
+	r.routes = append(r.routes, RouteFromContext(ctx))
+	r.mu.Unlock()
+	return ctx, func(res CallResult) {
+		r.mu.Lock()
+		r.results = append(r.results, res)
+		r.mu.Unlock()
+	}
+}
+
+func TestRouteTemplate(t *testing.T) {
+	tests := []struct {
+		pathFmt string
+		want    string
+	}{
+		{"/repository", "/repository"},
+		{"/repository/%s/%s", "/repository/{namespace}/{repository}"},
+		{"/repository/%s/%s/tag/", "/repository/{namespace}/{repository}/tag/"},
+		{"/repository/%s/%s/tag/%s/history", "/repository/{namespace}/{repository}/tag/{tag}/history"},
+		{"/organization/%s/team/%s/members/%s", "/organization/{organization}/team/{team}/members/{member}"},
+		{"/organization/%s/team/%s/invite/%s", "/organization/{organization}/team/{team}/invite/{email}"},
+		{"/user/robots/%s", "/user/robots/{robot}"},
+		{"/repository/%s/%s/permissions/user/%s/transitive", "/repository/{namespace}/{repository}/permissions/user/{username}/transitive"},
+		{"/repository/%s/%s/manifest/%s/labels/%s", "/repository/{namespace}/{repository}/manifest/{manifestref}/labels/{label_id}"},
+		{"/unknown/%s", "/unknown/{id}"},
+	}
+
+	for _, tt := range tests {
+		if got := routeTemplate(tt.pathFmt); got != tt.want {
+			t.Errorf("routeTemplate(%q) = %q, want %q", tt.pathFmt, got, tt.want)
+		}
+	}
+}
+
+func TestBuildURLRouteNotSentOnWire(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/repository/"+testNamespace+"/my repo/tag/" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if r.URL.Fragment != "" {
+			t.Errorf("Expected no fragment on the wire, got %q", r.URL.Fragment)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"tags":[]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithURL(testTokenValue, server.URL+"/api/v1")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	var route string
+	client.Use(Middleware{
+		BeforeRequest: func(req *http.Request) error {
+			route = RouteFromContext(req.Context())
+			return nil
+		},
+	})
+
+	if _, err := client.ListTags(context.Background(), testNamespace, "my repo", 0, false); err != nil {
+		t.Fatalf("ListTags returned error: %v", err)
+	}
+
+	if route != "/repository/{namespace}/{repository}/tag/" {
+		t.Errorf("Expected route template in context, got %q", route)
+	}
+}
+
+func TestWithTelemetryReportsCall(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte(`{"error":"bad gateway"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"name":"latest"}`))
+	}))
+	defer server.Close()
+
+	rec := &recordingTelemetry{}
+	client, err := NewClientWithURL(testTokenValue, server.URL+"/api/v1", WithTelemetry(rec))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.Retry = &RetryConfig{MaxRetries: 2, InitialBackoff: time.Millisecond}
+
+	if _, err := client.GetTag(context.Background(), testNamespace, testRepository, testTagNameLatest); err != nil {
+		t.Fatalf("GetTag returned error: %v", err)
+	}
+
+	if len(rec.starts) != 1 || len(rec.results) != 1 {
+		t.Fatalf("Expected 1 start and 1 result, got %d and %d", len(rec.starts), len(rec.results))
+	}
+
+	info := rec.starts[0]
+	if info.Method != httpMethodGet || info.Route != "/repository/{namespace}/{repository}/tag/{tag}" {
+		t.Errorf("Unexpected call info %+v", info)
+	}
+	if rec.routes[0] != info.Route {
+		t.Errorf("Expected route in StartCall context, got %q", rec.routes[0])
+	}
+
+	res := rec.results[0]
+	if res.Attempts != 2 || res.StatusCode != http.StatusOK || res.Err != nil {
+		t.Errorf("Unexpected call result %+v", res)
+	}
+}
+
+func TestWithTelemetryReportsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"not_found"}`))
+	}))
+	defer server.Close()
+
+	rec := &recordingTelemetry{}
+	client, err := NewClientWithURL(testTokenValue, server.URL+"/api/v1", WithTelemetry(rec))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	if err := client.DeleteRepository(context.Background(), testNamespace, testRepository); err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+
+	res := rec.results[0]
+	if res.StatusCode != http.StatusNotFound || res.Attempts != 1 || res.Err == nil {
+		t.Errorf("Unexpected call result %+v", res)
+	}
+	if rec.starts[0].Method != httpMethodDelete {
+		t.Errorf("Expected DELETE, got %s", rec.starts[0].Method)
+	}
+}